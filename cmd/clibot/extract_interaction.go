@@ -36,6 +36,10 @@ var extractInteractionCmd = &cobra.Command{
 			// Initialize a temporary adapter for extraction
 			adapter, _ := cli.NewOpenCodeAdapter(cli.OpenCodeAdapterConfig{})
 			prompt, response, err = adapter.ExtractLatestInteraction(path)
+		case "codex":
+			// Initialize a temporary adapter for extraction
+			adapter, _ := cli.NewCodexAdapter(cli.CodexAdapterConfig{})
+			prompt, response, err = adapter.ExtractLatestInteraction(path)
 		default:
 			fmt.Printf("Unsupported CLI type: %s\n", cliType)
 			os.Exit(1)
@@ -53,6 +57,6 @@ var extractInteractionCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(extractInteractionCmd)
-	extractInteractionCmd.Flags().StringP("type", "t", "", "CLI type (claude, gemini)")
+	extractInteractionCmd.Flags().StringP("type", "t", "", "CLI type (claude, gemini, opencode, codex)")
 	extractInteractionCmd.Flags().StringP("path", "p", "", "Path to the transcript or session file")
 }
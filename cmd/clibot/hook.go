@@ -3,12 +3,18 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
+	"strings"
 	"time"
 
+	"github.com/keepmind9/clibot/internal/core"
 	"github.com/keepmind9/clibot/internal/logger"
 	"github.com/keepmind9/clibot/pkg/constants"
 	"github.com/sirupsen/logrus"
@@ -18,45 +24,171 @@ import (
 // HookNotifier handles HTTP notifications with timeout and cancellation
 type HookNotifier struct {
 	timeout time.Duration
+	secret  string // Sent in constants.HookSecretHeader
+	socket  string // Unix socket to dial instead of the URL host (optional)
+}
+
+// client returns an HTTP client, dialing the unix socket when configured
+func (h *HookNotifier) client() *http.Client {
+	if h.socket == "" {
+		return http.DefaultClient
+	}
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", h.socket)
+		},
+	}}
 }
 
 // Notify sends hook data to the engine with timeout control
 func (h *HookNotifier) Notify(ctx context.Context, url string, data []byte) error {
+	_, err := h.Decide(ctx, url, data)
+	return err
+}
+
+// Decide sends hook data to the engine and returns the response body, which
+// for a PreToolUse hook is the permission decision JSON for the CLI
+func (h *HookNotifier) Decide(ctx context.Context, url string, data []byte) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(ctx, h.timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/octet-stream")
+	if h.secret != "" {
+		req.Header.Set(constants.HookSecretHeader, h.secret)
+	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := h.client().Do(req)
 	if err != nil {
-		return fmt.Errorf("send request: %w", err)
+		return nil, fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != constants.HTTPSuccessStatusCode {
-		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	return body, nil
+}
+
+// currentTmuxSession returns the name of the tmux session the hook runs in,
+// which is the clibot session name for CLIs started by clibot
+func currentTmuxSession() string {
+	if os.Getenv("TMUX") == "" {
+		return ""
+	}
+	args := []string{"display-message", "-p"}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		args = append(args, "-t", pane)
+	}
+	out, err := exec.Command("tmux", append(args, "#S")...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// hookSessionID extracts the CLI's own session ID from the hook payload, if
+// any: session_id for hooks, thread-id for Codex notify payloads
+func hookSessionID(data []byte) string {
+	var payload struct {
+		SessionID string `json:"session_id"`
+		ThreadID  string `json:"thread-id"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return ""
+	}
+	if payload.SessionID != "" {
+		return payload.SessionID
 	}
+	return payload.ThreadID
+}
 
-	return nil
+// readHookData returns the hook payload: the last argument when one is given
+// (Codex's notify program receives its JSON as an argument), otherwise stdin
+func readHookData(args []string, stdin io.Reader) ([]byte, error) {
+	if len(args) > 0 {
+		return []byte(args[len(args)-1]), nil
+	}
+	return io.ReadAll(stdin)
+}
+
+// isPreToolUseHook reports whether the payload is a PreToolUse hook, whose
+// decision the CLI waits for
+func isPreToolUseHook(data []byte) bool {
+	var payload struct {
+		EventName string `json:"hook_event_name"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return false
+	}
+	return payload.EventName == "PreToolUse"
+}
+
+// hookQuery builds the query string identifying the hook's CLI and session
+func hookQuery(tmuxSession, sessionID string) string {
+	query := url.Values{"cli_type": {cliType}}
+	if tmuxSession != "" {
+		query.Set("tmux_session", tmuxSession)
+	}
+	if sessionID != "" {
+		query.Set("session_id", sessionID)
+	}
+	return query.Encode()
+}
+
+// resolveHookTarget returns the hook server base URL and a notifier configured
+// from the endpoint file published by "clibot serve". An explicit --port
+// overrides the published address but still uses the published secret.
+func resolveHookTarget(endpointPath string, port int, portSet bool) (string, *HookNotifier) {
+	notifier := &HookNotifier{timeout: constants.HookHTTPTimeout}
+	endpoint, err := core.LoadHookEndpoint(endpointPath)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"endpoint": endpointPath,
+			"error":    err,
+		}).Warn("hook-endpoint-unavailable-is-clibot-serve-running")
+	}
+	notifier.secret = endpoint.Secret
+
+	baseURL := endpoint.URL
+	if portSet || baseURL == "" {
+		baseURL = fmt.Sprintf("http://%s:%d", core.DefaultHookHost, port)
+	} else {
+		notifier.socket = endpoint.Socket
+	}
+	return baseURL, notifier
 }
 
 var (
-	cliType  string
-	hookPort int
+	cliType      string
+	hookPort     int
+	hookEndpoint string
 
 	hookCmd = &cobra.Command{
-		Use:   "hook --cli-type <type>",
+		Use:   "hook --cli-type <type> [payload]",
 		Short: "Called by CLI hook to notify main process of events",
 		Long: `Receives hook data from stdin and forwards it to the main process.
 
-The CLI should pass event data as JSON via stdin. Different CLI types may
-have different JSON structures - this command just forwards the data.
+The CLI should pass event data as JSON via stdin, or as the last argument
+for CLIs that pass it that way (Codex's notify program). Different CLI types
+may have different JSON structures - this command just forwards the data.
+
+The hook server address and its shared secret are read from the endpoint
+file written by "clibot serve" (default: ~/.clibot/hook.json). Use --port
+to target a different TCP port on 127.0.0.1.
 
-This command uses an asynchronous notification strategy:
+PreToolUse hooks are sent synchronously: the command waits for the engine
+and prints its permission decision JSON, so chat users can allow or deny
+risky tool calls. All other events use an asynchronous notification strategy:
 - Sends HTTP request in background (non-blocking)
 - Returns quickly after a short delay (300ms)
 - Allows Claude Code to continue execution without UI freeze
@@ -64,18 +196,20 @@ This command uses an asynchronous notification strategy:
 Examples:
   echo '{"session":"my-session","event":"completed"}' | clibot hook --cli-type claude
   cat hook-data.json | clibot hook --cli-type gemini
-  cat hook-data.json | clibot hook --cli-type claude --port 9000`,
+  cat hook-data.json | clibot hook --cli-type claude --port 9000
+  clibot hook --cli-type codex '{"type":"agent-turn-complete","cwd":"/repo"}'`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			// Read raw data from stdin (forward as-is, no parsing)
-			stdinData, err := io.ReadAll(os.Stdin)
+			// Read raw data from the argument or stdin (forward as-is, no parsing)
+			stdinData, err := readHookData(args, os.Stdin)
 			if err != nil {
-				logger.WithField("error", err).Error("failed-to-read-stdin")
+				logger.WithField("error", err).Error("failed-to-read-hook-data")
 				// Exit gracefully to avoid affecting CLI behavior
 				return
 			}
 
 			if len(stdinData) == 0 {
-				logger.Warn("no-data-received-from-stdin")
+				logger.Warn("no-hook-data-received")
 				// Exit gracefully
 				return
 			}
@@ -91,21 +225,41 @@ Examples:
 			fmt.Fprintf(os.Stderr, "stdin (%d bytes):\n%s\n", len(stdinData), string(stdinData))
 			fmt.Fprintf(os.Stderr, "==================\n")
 
+			// The tmux session name and CLI session ID let the engine match the
+			// hook to a session even when several share a working directory
+			baseURL, notifier := resolveHookTarget(hookEndpoint, hookPort, cmd.Flags().Changed("port"))
+			hookURL := baseURL + "/hook?" + hookQuery(currentTmuxSession(), hookSessionID(stdinData))
+
+			// PreToolUse hooks block until the engine decides (possibly after asking
+			// in chat) and print the decision JSON for the CLI to read from stdout.
+			// On failure nothing is printed and the CLI applies its own permissions.
+			if isPreToolUseHook(stdinData) {
+				notifier.timeout = constants.PreToolUseHookTimeout
+				decision, err := notifier.Decide(context.Background(), hookURL, stdinData)
+				if err != nil {
+					logger.WithFields(logrus.Fields{
+						"cli_type": cliType,
+						"error":    err,
+					}).Error("hook-decision-request-failed")
+					return
+				}
+				fmt.Print(string(decision))
+				return
+			}
+
 			// Forward raw data to Engine asynchronously (non-blocking)
 			// This allows Claude Code to continue without waiting for engine response
 			go func() {
 				// Create a context that outlives the main process
 				ctx := context.Background()
-				url := fmt.Sprintf("http://localhost:%d/hook?cli_type=%s", hookPort, cliType)
 
 				logger.WithFields(logrus.Fields{
 					"cli_type": cliType,
-					"url":      url,
+					"url":      hookURL,
 					"size":     len(stdinData),
 				}).Debug("forwarding-hook-data-to-engine-async")
 
-				notifier := &HookNotifier{timeout: constants.HookHTTPTimeout}
-				if err := notifier.Notify(ctx, url, stdinData); err != nil {
+				if err := notifier.Notify(ctx, hookURL, stdinData); err != nil {
 					logger.WithFields(logrus.Fields{
 						"cli_type": cliType,
 						"error":    err,
@@ -134,7 +288,8 @@ Examples:
 )
 
 func init() {
-	hookCmd.Flags().StringVar(&cliType, "cli-type", "", "CLI type (claude/gemini/opencode)")
+	hookCmd.Flags().StringVar(&cliType, "cli-type", "", "CLI type (claude/gemini/opencode/codex)")
 	hookCmd.MarkFlagRequired("cli-type")
-	hookCmd.Flags().IntVarP(&hookPort, "port", "p", 8080, "Hook server port")
+	hookCmd.Flags().IntVarP(&hookPort, "port", "p", core.DefaultHookPort, "Hook server port (overrides the endpoint file)")
+	hookCmd.Flags().StringVar(&hookEndpoint, "endpoint", core.DefaultHookEndpointPath(), "Hook endpoint file written by clibot serve")
 }
@@ -4,10 +4,15 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/keepmind9/clibot/pkg/constants"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestHookNotifier_Notify_Success tests successful hook notification
@@ -94,3 +99,79 @@ func TestHookNotifier_Notify_ServerNotResponding(t *testing.T) {
 	err := notifier.Notify(context.Background(), "http://localhost:9999", data)
 	assert.Error(t, err)
 }
+
+// TestHookNotifier_Notify_SendsSecret tests that the shared secret header is sent
+func TestHookNotifier_Notify_SendsSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "s3cret", r.Header.Get(constants.HookSecretHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := &HookNotifier{timeout: 5 * time.Second, secret: "s3cret"}
+	assert.NoError(t, notifier.Notify(context.Background(), server.URL, []byte(`{}`)))
+}
+
+// TestResolveHookTarget tests reading the endpoint file written by clibot serve
+func TestResolveHookTarget(t *testing.T) {
+	cliType = "claude"
+	path := filepath.Join(t.TempDir(), "hook.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"url":"http://127.0.0.1:9001","socket":"/tmp/clibot.sock","secret":"abc"}`), 0600))
+
+	url, notifier := resolveHookTarget(path, 8080, false)
+	assert.Equal(t, "http://127.0.0.1:9001", url)
+	assert.Equal(t, "abc", notifier.secret)
+	assert.Equal(t, "/tmp/clibot.sock", notifier.socket)
+
+	// An explicit --port overrides the published address but keeps the secret
+	url, notifier = resolveHookTarget(path, 9100, true)
+	assert.Equal(t, "http://127.0.0.1:9100", url)
+	assert.Equal(t, "abc", notifier.secret)
+	assert.Empty(t, notifier.socket)
+
+	// Without an endpoint file the default port is used
+	url, notifier = resolveHookTarget(filepath.Join(t.TempDir(), "missing.json"), 8080, false)
+	assert.Equal(t, "http://127.0.0.1:8080", url)
+	assert.Empty(t, notifier.secret)
+}
+
+func TestHookQuery(t *testing.T) {
+	cliType = "claude"
+	assert.Equal(t, "cli_type=claude", hookQuery("", ""))
+	assert.Equal(t, "cli_type=claude&session_id=abc&tmux_session=my+proj", hookQuery("my proj", "abc"))
+
+	assert.Equal(t, "abc", hookSessionID([]byte(`{"cwd":"/tmp","session_id":"abc"}`)))
+	assert.Equal(t, "t-1", hookSessionID([]byte(`{"type":"agent-turn-complete","thread-id":"t-1"}`)))
+	assert.Empty(t, hookSessionID([]byte(`not json`)))
+}
+
+// TestHookNotifier_Decide tests returning the engine's decision body
+func TestHookNotifier_Decide(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"hookSpecificOutput":{"permissionDecision":"deny"}}`))
+	}))
+	defer server.Close()
+
+	notifier := &HookNotifier{timeout: 5 * time.Second}
+	body, err := notifier.Decide(context.Background(), server.URL, []byte(`{"hook_event_name":"PreToolUse"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"hookSpecificOutput":{"permissionDecision":"deny"}}`, string(body))
+}
+
+func TestIsPreToolUseHook(t *testing.T) {
+	assert.True(t, isPreToolUseHook([]byte(`{"hook_event_name":"PreToolUse","tool_name":"Bash"}`)))
+	assert.False(t, isPreToolUseHook([]byte(`{"hook_event_name":"PostToolUse"}`)))
+	assert.False(t, isPreToolUseHook([]byte(`not json`)))
+}
+
+// TestReadHookData tests taking the payload from an argument (Codex notify) or stdin
+func TestReadHookData(t *testing.T) {
+	data, err := readHookData([]string{`{"type":"agent-turn-complete"}`}, strings.NewReader("ignored"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"agent-turn-complete"}`, string(data))
+
+	data, err = readHookData(nil, strings.NewReader(`{"cwd":"/tmp"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"cwd":"/tmp"}`, string(data))
+}
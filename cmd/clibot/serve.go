@@ -32,7 +32,11 @@ var (
 			}
 
 			fmt.Printf("Running clibot service with config: %s\n", configFile)
-			fmt.Printf("Hook server port: %d\n", config.HookServer.Port)
+			if config.HookServer.Socket != "" {
+				fmt.Printf("Hook server socket: %s\n", config.HookServer.Socket)
+			} else {
+				fmt.Printf("Hook server address: %s:%d\n", config.HookServer.Host, config.HookServer.Port)
+			}
 			fmt.Printf("Whitelist enabled: %v\n", config.Security.WhitelistEnabled)
 
 			// Initialize logger
@@ -174,19 +178,57 @@ func registerCLIAdapters(engine *core.Engine, config *core.Config) error {
 			continue
 		}
 
-		switch cliType {
-		case "claude":
+		switch {
+		case cliConfig.Type == core.CLIAdapterTypeCustom:
+			adapter, err = cli.NewCustomAdapter(cli.CustomAdapterConfig{
+				Name:         cliType,
+				StartCmd:     cliConfig.StartCmd,
+				Env:          cliConfig.Env,
+				InputMethod:  cliConfig.Input.Method,
+				EnterDelayMs: cliConfig.Input.EnterDelayMs,
+				Keys:         cliConfig.Keys,
+				Response: cli.CustomResponseConfig{
+					Source:          cliConfig.Response.Source,
+					CWDField:        cliConfig.Response.CWDField,
+					PromptField:     cliConfig.Response.PromptField,
+					ResponseField:   cliConfig.Response.ResponseField,
+					TranscriptGlob:  cliConfig.Response.TranscriptGlob,
+					TranscriptField: cliConfig.Response.TranscriptField,
+					MessagesPath:    cliConfig.Response.MessagesPath,
+					RolePath:        cliConfig.Response.RolePath,
+					TextPath:        cliConfig.Response.TextPath,
+					AssistantRole:   cliConfig.Response.AssistantRole,
+					UserRole:        cliConfig.Response.UserRole,
+					Regex:           cliConfig.Response.Regex,
+				},
+			})
+		case cliType == "claude":
 			adapter, err = cli.NewClaudeAdapter(cli.ClaudeAdapterConfig{
 				Env: cliConfig.Env,
 			})
-		case "gemini":
+		case cliType == "gemini":
 			adapter, err = cli.NewGeminiAdapter(cli.GeminiAdapterConfig{
 				Env: cliConfig.Env,
 			})
-		case "opencode":
+		case cliType == "opencode":
 			adapter, err = cli.NewOpenCodeAdapter(cli.OpenCodeAdapterConfig{
 				Env: cliConfig.Env,
 			})
+		case cliType == "codex":
+			adapter, err = cli.NewCodexAdapter(cli.CodexAdapterConfig{
+				Env: cliConfig.Env,
+			})
+		case cliType == "aider":
+			var aiderAdapter *cli.AiderAdapter
+			aiderAdapter, err = cli.NewAiderAdapter(cli.AiderAdapterConfig{
+				Env:         cliConfig.Env,
+				HistoryFile: cliConfig.HistoryFile,
+			})
+			if err == nil {
+				// Aider replies are read from its chat history and sent via the engine
+				aiderAdapter.SetEngine(engine)
+				adapter = aiderAdapter
+			}
 		default:
 			log.Printf("Warning: CLI adapter type '%s' not implemented yet", cliType)
 			continue
@@ -197,7 +239,14 @@ func registerCLIAdapters(engine *core.Engine, config *core.Config) error {
 		}
 
 		engine.RegisterCLIAdapter(cliType, adapter)
-		log.Printf("Registered %s CLI adapter (mode: hook)", cliType)
+		switch {
+		case cliConfig.Type == core.CLIAdapterTypeCustom:
+			log.Printf("Registered %s CLI adapter (mode: custom)", cliType)
+		case cliType == "aider":
+			log.Printf("Registered %s CLI adapter (mode: chat history)", cliType)
+		default:
+			log.Printf("Registered %s CLI adapter (mode: hook)", cliType)
+		}
 	}
 
 	return nil
@@ -216,6 +265,8 @@ func registerBotAdapters(engine *core.Engine, config *core.Config) error {
 		switch botType {
 		case "discord":
 			discordBot := bot.NewDiscordBot(botConfig.Token, botConfig.ChannelID)
+			discordBot.SetAllowedChannels(botConfig.ChannelIDs)
+			discordBot.SetThreadPerSession(botConfig.ThreadPerSession)
 			discordBot.SetProxyManager(engine.GetProxyManager())
 			botAdapter = discordBot
 			log.Printf("Registered %s bot adapter", botType)
@@ -235,6 +286,10 @@ func registerBotAdapters(engine *core.Engine, config *core.Config) error {
 		case "dingtalk":
 			dingtalkBot := bot.NewDingTalkBot(botConfig.AppID, botConfig.AppSecret)
 			dingtalkBot.SetProxyManager(engine.GetProxyManager())
+			dingtalkBot.SetMessageFormat(botConfig.Markdown, botConfig.ActionCard)
+			if botConfig.StatePath != "" {
+				dingtalkBot.SetStatePath(botConfig.StatePath)
+			}
 			botAdapter = dingtalkBot
 			log.Printf("Registered %s bot adapter (WebSocket long connection)", botType)
 
@@ -247,6 +302,7 @@ func registerBotAdapters(engine *core.Engine, config *core.Config) error {
 		case "qq":
 			qqBot := bot.NewQQBot(botConfig.AppID, botConfig.AppSecret)
 			qqBot.SetProxyManager(engine.GetProxyManager())
+			qqBot.SetMessageFormat(botConfig.Markdown, botConfig.KeyboardID)
 			botAdapter = qqBot
 			log.Printf("Registered %s bot adapter (WebSocket long connection)", botType)
 
@@ -264,6 +320,56 @@ func registerBotAdapters(engine *core.Engine, config *core.Config) error {
 			botAdapter = weixinBot
 			log.Printf("Registered %s bot adapter (QR login + long polling)", botType)
 
+		case "webhook":
+			integrations := make([]bot.WebhookIntegration, 0, len(botConfig.Integrations))
+			for _, ic := range botConfig.Integrations {
+				integrations = append(integrations, bot.WebhookIntegration{
+					Name:              ic.Name,
+					Path:              ic.Path,
+					Secret:            ic.Secret,
+					SecretEncoding:    ic.SecretEncoding,
+					SignatureHeader:   ic.SignatureHeader,
+					SignaturePrefix:   ic.SignaturePrefix,
+					SignatureEncoding: ic.SignatureEncoding,
+					CallbackURL:       ic.CallbackURL,
+					CallbackTemplate:  ic.CallbackTemplate,
+					CallbackHeaders:   ic.CallbackHeaders,
+					UserField:         ic.UserField,
+					TextField:         ic.TextField,
+					ChannelField:      ic.ChannelField,
+					MessageIDField:    ic.MessageIDField,
+					AckBody:           ic.AckBody,
+					UserMap:           ic.UserMap,
+				})
+			}
+			webhookBot, err := bot.NewWebhookBot(botConfig.Listen, integrations)
+			if err != nil {
+				return fmt.Errorf("failed to create webhook bot: %w", err)
+			}
+			webhookBot.SetProxyManager(engine.GetProxyManager())
+			botAdapter = webhookBot
+			log.Printf("Registered %s bot adapter (%d integrations)", botType, len(integrations))
+
+		case "web":
+			webBot := bot.NewWebBot(botConfig.Listen, botConfig.Tokens)
+			webBot.SetProxyManager(engine.GetProxyManager())
+			botAdapter = webBot
+			log.Printf("Registered %s bot adapter (local web chat UI)", botType)
+
+		case "email":
+			emailBot := bot.NewEmailBot(bot.EmailConfig{
+				IMAPServer:   botConfig.IMAPServer,
+				SMTPServer:   botConfig.SMTPServer,
+				Username:     botConfig.Username,
+				Password:     botConfig.Password,
+				FromAddress:  botConfig.FromAddress,
+				Mailbox:      botConfig.Mailbox,
+				SubjectToken: botConfig.SubjectToken,
+			})
+			emailBot.SetProxyManager(engine.GetProxyManager())
+			botAdapter = emailBot
+			log.Printf("Registered %s bot adapter (IMAP IDLE + SMTP)", botType)
+
 		default:
 			log.Printf("Warning: Bot type '%s' not implemented yet", botType)
 			continue
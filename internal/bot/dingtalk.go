@@ -1,8 +1,18 @@
 package bot
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,31 +24,111 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// DingTalk OpenAPI used when a conversation's session webhook has expired
+const (
+	DingTalkAPIBase = "https://api.dingtalk.com"
+
+	dingtalkAPIRequestTimeout     = 10 * time.Second
+	dingtalkTokenExpirationBuffer = 60 * time.Second
+	dingtalkMaxDownloadSize       = 20 << 20 // Maximum inbound image/voice download size (bytes)
+	dingtalkMarkdownTitleLength   = 30       // Title (notification preview) length in runes
+
+	// Conversation type of group chats in callbacks ("1" is a single chat)
+	dingtalkConversationGroup = "2"
+)
+
+// dingtalkSafeName keeps downloaded file names filesystem-safe
+var dingtalkSafeName = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// dingtalkConversation is what is needed to reach a conversation again
+// Type and StaffID are persisted so proactive sends survive restarts;
+// session webhooks are short-lived and kept in memory only
+type dingtalkConversation struct {
+	Type             string    `json:"type"`               // "1" single chat, "2" group
+	StaffID          string    `json:"staff_id,omitempty"` // Sender staff ID, the OpenAPI target for single chats
+	Webhook          string    `json:"-"`
+	WebhookExpiresAt time.Time `json:"-"` // Zero if the callback did not report an expiry
+}
+
+// dingtalkRichContent is the content of picture, richText and audio messages
+type dingtalkRichContent struct {
+	DownloadCode string `json:"downloadCode"`
+	Recognition  string `json:"recognition"` // Audio: DingTalk's own speech recognition result
+	RichText     []struct {
+		Text         string `json:"text"`
+		Type         string `json:"type"`
+		DownloadCode string `json:"downloadCode"`
+	} `json:"richText"`
+}
+
 // DingTalkBot implements BotAdapter interface for DingTalk using WebSocket long connection
+// Replies go through the conversation's session webhook while it is valid, and
+// through the robot OpenAPI (1:1 batch send / group send) after it expires
 type DingTalkBot struct {
 	DefaultTypingIndicator
-	mu              sync.RWMutex
-	clientID        string
-	clientSecret    string
-	streamClient    *client.StreamClient
-	replier         *chatbot.ChatbotReplier
-	messageHandler  func(BotMessage)
-	sessionWebhooks map[string]string // conversationID -> sessionWebhook
-	ctx             context.Context
-	cancel          context.CancelFunc
-	proxyMgr        proxy.Manager
+	disconnectSignal
+	mu             sync.RWMutex
+	clientID       string
+	clientSecret   string
+	streamClient   *client.StreamClient
+	replier        *chatbot.ChatbotReplier
+	messageHandler func(BotMessage)
+	conversations  map[string]*dingtalkConversation // conversationID -> reply targets
+	statePath      string                           // File persisting conversations
+	apiBase        string                           // OpenAPI base URL, defaults to DingTalkAPIBase
+	accessToken    string
+	tokenExpiresAt time.Time
+	markdown       bool // Send markdown messages
+	actionCard     bool // Send action cards with control-key buttons
+	ctx            context.Context
+	cancel         context.CancelFunc
+	proxyMgr       proxy.Manager
+	transcriber    Transcriber
 }
 
 // NewDingTalkBot creates a new DingTalk bot instance
 func NewDingTalkBot(clientID, clientSecret string) *DingTalkBot {
 	return &DingTalkBot{
-		clientID:        clientID,
-		clientSecret:    clientSecret,
-		sessionWebhooks: make(map[string]string),
-		replier:         chatbot.NewChatbotReplier(),
+		clientID:      clientID,
+		clientSecret:  clientSecret,
+		conversations: make(map[string]*dingtalkConversation),
+		statePath:     DefaultDingTalkStatePath(),
+		apiBase:       DingTalkAPIBase,
+		replier:       chatbot.NewChatbotReplier(),
 	}
 }
 
+// DefaultDingTalkStatePath returns the default conversation state file
+func DefaultDingTalkStatePath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".clibot", "dingtalk", "conversations.json")
+}
+
+// SetStatePath sets the file persisting known conversations (empty disables persistence)
+func (d *DingTalkBot) SetStatePath(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statePath = expandHomePath(path)
+}
+
+// SetMessageFormat selects markdown messages and/or action cards with control-key buttons
+// Buttons send "esc", "tab", "stab" and "ctrlc" back as the user, which only reaches
+// the bot in single chats (group messages must @ the bot)
+func (d *DingTalkBot) SetMessageFormat(markdown, actionCard bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.markdown = markdown
+	d.actionCard = actionCard
+}
+
+// SetTranscriber sets the transcriber used for voice messages
+// DingTalk's own recognition result is preferred when the callback carries one
+func (d *DingTalkBot) SetTranscriber(transcriber Transcriber) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.transcriber = transcriber
+}
+
 // SetProxyManager sets the proxy manager for the DingTalk bot
 func (d *DingTalkBot) SetProxyManager(mgr proxy.Manager) {
 	d.mu.Lock()
@@ -58,6 +148,8 @@ func (d *DingTalkBot) Start(messageHandler func(BotMessage)) error {
 	// Create stream client with credentials
 	credential := client.NewAppCredentialConfig(d.clientID, d.clientSecret)
 
+	d.loadConversations()
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -76,18 +168,23 @@ func (d *DingTalkBot) Start(messageHandler func(BotMessage)) error {
 	// Register chatbot message callback
 	streamClient.RegisterChatBotCallbackRouter(d.handleMessageReceive)
 
-	// Start long connection
+	// Start long connection; the SDK reconnects by itself once connected
+	ctx := d.ctx
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
 				logger.WithField("panic", r).Error("dingtalk-stream-client-panic")
+				d.signalDisconnect(fmt.Errorf("stream client panic: %v", r))
 			}
 		}()
-		if err := streamClient.Start(d.ctx); err != nil {
+		if err := streamClient.Start(ctx); err != nil {
 			logger.WithFields(logrus.Fields{
 				"client_id": d.clientID,
 				"error":     err,
 			}).Error("dingtalk-websocket-connection-failed")
+			if ctx.Err() == nil {
+				d.signalDisconnect(fmt.Errorf("websocket: %w", err))
+			}
 		}
 	}()
 
@@ -122,19 +219,18 @@ func (d *DingTalkBot) handleMessageReceive(ctx context.Context, data *chatbot.Bo
 
 	// Extract message content based on message type
 	content := ""
+	transcribed := false
 	switch data.Msgtype {
 	case "text":
-		content = data.Text.Content
-	case "image":
-		content = "[image]"
-	case "voice":
-		content = "[voice]"
+		content = strings.TrimSpace(data.Text.Content)
+	case "picture", "image", "richText":
+		content = d.parseRichContent(data)
+	case "audio", "voice":
+		content, transcribed = d.parseVoice(data)
 	case "file":
 		content = "[file]"
 	case "video":
 		content = "[video]"
-	case "richText":
-		content = "[rich text]"
 	default:
 		// For unknown types, try to use text content if available
 		if data.Msgtype == "" && data.Text.Content != "" {
@@ -147,12 +243,8 @@ func (d *DingTalkBot) handleMessageReceive(ctx context.Context, data *chatbot.Bo
 		return []byte(""), nil
 	}
 
-	// Store session webhook for sending replies later
-	if data.SessionWebhook != "" {
-		d.mu.Lock()
-		d.sessionWebhooks[data.ConversationId] = data.SessionWebhook
-		d.mu.Unlock()
-	}
+	// Remember how to reach this conversation for replies
+	d.rememberConversation(data)
 
 	// Convert CreateAt (Unix milliseconds) to time.Time
 	var msgTimestamp time.Time
@@ -166,12 +258,13 @@ func (d *DingTalkBot) handleMessageReceive(ctx context.Context, data *chatbot.Bo
 	handler := d.GetMessageHandler()
 	if handler != nil {
 		handler(BotMessage{
-			Platform:  "dingtalk",
-			UserID:    data.SenderStaffId,
-			Channel:   data.ConversationId,
-			MessageID: data.MsgId,
-			Content:   content,
-			Timestamp: msgTimestamp,
+			Platform:    "dingtalk",
+			UserID:      data.SenderStaffId,
+			Channel:     data.ConversationId,
+			MessageID:   data.MsgId,
+			Content:     content,
+			Timestamp:   msgTimestamp,
+			Transcribed: transcribed,
 		})
 	}
 
@@ -179,37 +272,264 @@ func (d *DingTalkBot) handleMessageReceive(ctx context.Context, data *chatbot.Bo
 	return []byte(""), nil
 }
 
+// parseRichContent converts picture and richText messages into text
+// Images are downloaded to a temp file and referenced by path so the CLI can read them
+func (d *DingTalkBot) parseRichContent(data *chatbot.BotCallbackDataModel) string {
+	raw, err := json.Marshal(data.Content)
+	if err != nil {
+		return "[" + data.Msgtype + "]"
+	}
+	var rich dingtalkRichContent
+	if err := json.Unmarshal(raw, &rich); err != nil {
+		return "[" + data.Msgtype + "]"
+	}
+
+	if data.Msgtype != "richText" {
+		return d.imageReference(rich.DownloadCode, data.MsgId, 0)
+	}
+
+	var parts []string
+	for i, item := range rich.RichText {
+		switch {
+		case item.Text != "":
+			parts = append(parts, item.Text)
+		case item.DownloadCode != "":
+			parts = append(parts, d.imageReference(item.DownloadCode, data.MsgId, i))
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+// parseVoice converts an audio message into text, preferring DingTalk's recognition
+// result, then the configured Transcriber, then a "[voice: <path>]" reference
+func (d *DingTalkBot) parseVoice(data *chatbot.BotCallbackDataModel) (string, bool) {
+	var audio dingtalkRichContent
+	if raw, err := json.Marshal(data.Content); err == nil {
+		json.Unmarshal(raw, &audio)
+	}
+	if text := strings.TrimSpace(audio.Recognition); text != "" {
+		return text, true
+	}
+	if audio.DownloadCode == "" {
+		return "[voice]", false
+	}
+
+	path, err := d.downloadFile(audio.DownloadCode, "voice-"+dingtalkSafeName.ReplaceAllString(data.MsgId, "_"), ".amr")
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"msg_id": data.MsgId,
+			"error":  err,
+		}).Warn("failed-to-download-dingtalk-voice")
+		return "[voice]", false
+	}
+
+	d.mu.RLock()
+	transcriber, ctx := d.transcriber, d.ctx
+	d.mu.RUnlock()
+	if transcriber == nil {
+		return fmt.Sprintf("[voice: %s]", path), false
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	text, err := transcribeAudio(ctx, transcriber, path)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"msg_id": data.MsgId,
+			"error":  err,
+		}).Warn("dingtalk-voice-transcription-failed")
+		return fmt.Sprintf("[voice: %s]", path), false
+	}
+	return text, true
+}
+
+// imageReference downloads an inbound image and returns "[image: <path>]"
+func (d *DingTalkBot) imageReference(downloadCode, msgID string, index int) string {
+	if downloadCode == "" {
+		return "[image]"
+	}
+	path, err := d.downloadFile(downloadCode, fmt.Sprintf("%s-%d", dingtalkSafeName.ReplaceAllString(msgID, "_"), index), ".jpg")
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"msg_id": msgID,
+			"error":  err,
+		}).Warn("failed-to-download-dingtalk-image")
+		return "[image]"
+	}
+	return fmt.Sprintf("[image: %s]", path)
+}
+
+// downloadFile resolves a download code via the OpenAPI and saves the file,
+// using defaultExt when the content type has no known extension
+func (d *DingTalkBot) downloadFile(downloadCode, name, defaultExt string) (string, error) {
+	token, err := d.getAccessToken()
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		DownloadURL string `json:"downloadUrl"`
+	}
+	body := map[string]string{"downloadCode": downloadCode, "robotCode": d.clientID}
+	if err := d.postOpenAPI("/v1.0/robot/messageFiles/download", token, body, &result); err != nil {
+		return "", err
+	}
+	if result.DownloadURL == "" {
+		return "", fmt.Errorf("empty download URL")
+	}
+
+	resp, err := d.httpClient().Get(result.DownloadURL)
+	if err != nil {
+		return "", fmt.Errorf("download file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download file failed: %s", resp.Status)
+	}
+
+	ext := defaultExt
+	if exts, _ := mime.ExtensionsByType(resp.Header.Get("Content-Type")); len(exts) > 0 {
+		ext = exts[0]
+	}
+	dir := filepath.Join(os.TempDir(), "clibot-dingtalk")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("create download directory: %w", err)
+	}
+	path := filepath.Join(dir, name+ext)
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, dingtalkMaxDownloadSize))
+	if err != nil {
+		return "", fmt.Errorf("read file: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("write file: %w", err)
+	}
+	return path, nil
+}
+
+// rememberConversation records the reply webhook and OpenAPI target of a conversation
+func (d *DingTalkBot) rememberConversation(data *chatbot.BotCallbackDataModel) {
+	if data.ConversationId == "" {
+		return
+	}
+
+	var expiresAt time.Time
+	if data.SessionWebhookExpiredTime > 0 {
+		expiresAt = time.UnixMilli(data.SessionWebhookExpiredTime)
+	}
+
+	d.mu.Lock()
+	if d.conversations == nil {
+		d.conversations = make(map[string]*dingtalkConversation)
+	}
+	conv, exists := d.conversations[data.ConversationId]
+	if !exists {
+		conv = &dingtalkConversation{}
+		d.conversations[data.ConversationId] = conv
+	}
+	changed := conv.Type != data.ConversationType || conv.StaffID != data.SenderStaffId
+	conv.Type = data.ConversationType
+	conv.StaffID = data.SenderStaffId
+	if data.SessionWebhook != "" {
+		conv.Webhook = data.SessionWebhook
+		conv.WebhookExpiresAt = expiresAt
+	}
+	d.mu.Unlock()
+
+	if changed {
+		d.saveConversations()
+	}
+}
+
+// loadConversations restores persisted conversations
+func (d *DingTalkBot) loadConversations() {
+	d.mu.RLock()
+	path := d.statePath
+	d.mu.RUnlock()
+	if path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.WithField("error", err).Warn("failed-to-read-dingtalk-conversations")
+		}
+		return
+	}
+	var stored map[string]*dingtalkConversation
+	if err := json.Unmarshal(data, &stored); err != nil {
+		logger.WithField("error", err).Warn("failed-to-parse-dingtalk-conversations")
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conversations == nil {
+		d.conversations = make(map[string]*dingtalkConversation)
+	}
+	for id, conv := range stored {
+		if _, exists := d.conversations[id]; !exists && conv != nil {
+			d.conversations[id] = conv
+		}
+	}
+}
+
+// saveConversations persists conversation targets
+func (d *DingTalkBot) saveConversations() {
+	d.mu.RLock()
+	path := d.statePath
+	data, err := json.MarshalIndent(d.conversations, "", "  ")
+	d.mu.RUnlock()
+	if path == "" {
+		return
+	}
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+			err = os.WriteFile(path, data, 0600)
+		}
+	}
+	if err != nil {
+		logger.WithField("error", err).Warn("failed-to-save-dingtalk-conversations")
+	}
+}
+
 // SendMessage sends a message to a DingTalk conversation
+// The session webhook is used while valid; afterwards, or if it fails, the robot OpenAPI
 func (d *DingTalkBot) SendMessage(conversationID, message string) error {
 	if conversationID == "" {
 		return fmt.Errorf("conversation ID is required for DingTalk")
 	}
 
-	// Get session webhook for this conversation
 	d.mu.RLock()
-	sessionWebhook, ok := d.sessionWebhooks[conversationID]
+	var conv dingtalkConversation
+	stored, ok := d.conversations[conversationID]
+	if ok {
+		conv = *stored
+	}
 	d.mu.RUnlock()
 
-	if sessionWebhook == "" || !ok {
-		return fmt.Errorf("no session webhook found for conversation %s, please send a message first", conversationID)
+	if !ok {
+		return fmt.Errorf("unknown conversation %s, please send a message first", conversationID)
 	}
 
-	// DingTalk message limit
-	const maxDingTalkLength = constants.MaxDingTalkMessageLength
-	if len(message) > maxDingTalkLength {
+	webhookBody, msgKey, msgParam := d.buildMessage(truncateDingTalkMessage(message))
+
+	if conv.Webhook != "" && (conv.WebhookExpiresAt.IsZero() || time.Now().Before(conv.WebhookExpiresAt)) {
+		ctx, cancel := context.WithTimeout(context.Background(), constants.DingTalkMessageSendTimeout)
+		err := d.replier.ReplyMessage(ctx, conv.Webhook, webhookBody)
+		cancel()
+		if err == nil {
+			logger.WithField("conversation_id", conversationID).Info("message-sent-to-dingtalk")
+			return nil
+		}
 		logger.WithFields(logrus.Fields{
-			"original_length": len(message),
-			"max_length":      maxDingTalkLength,
-		}).Info("truncating-message-for-dingtalk-limit")
-		message = message[:maxDingTalkLength]
+			"conversation_id": conversationID,
+			"error":           err,
+		}).Warn("dingtalk-session-webhook-failed-falling-back-to-openapi")
 	}
 
-	// Send message using ChatbotReplier
-	ctx, cancel := context.WithTimeout(context.Background(), constants.DingTalkMessageSendTimeout)
-	defer cancel()
-
-	err := d.replier.SimpleReplyText(ctx, sessionWebhook, []byte(message))
-	if err != nil {
+	if err := d.sendViaOpenAPI(conversationID, conv, msgKey, msgParam); err != nil {
 		logger.WithFields(logrus.Fields{
 			"conversation_id": conversationID,
 			"error":           err,
@@ -217,10 +537,223 @@ func (d *DingTalkBot) SendMessage(conversationID, message string) error {
 		return fmt.Errorf("failed to send message to DingTalk: %w", err)
 	}
 
-	logger.WithField("conversation_id", conversationID).Info("message-sent-to-dingtalk")
+	logger.WithField("conversation_id", conversationID).Info("message-sent-to-dingtalk-via-openapi")
+	return nil
+}
+
+// SendDirectMessage sends a message to a user's single chat via the robot OpenAPI
+// userID is the staff ID, so no earlier conversation with the user is needed
+func (d *DingTalkBot) SendDirectMessage(userID, message string) error {
+	if userID == "" {
+		return fmt.Errorf("user ID is required for DingTalk")
+	}
+
+	_, msgKey, msgParam := d.buildMessage(truncateDingTalkMessage(message))
+	if err := d.sendViaOpenAPI(userID, dingtalkConversation{StaffID: userID}, msgKey, msgParam); err != nil {
+		logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err,
+		}).Error("failed-to-send-direct-message-to-dingtalk")
+		return fmt.Errorf("failed to send direct message to DingTalk: %w", err)
+	}
+
+	logger.WithField("user_id", userID).Info("direct-message-sent-to-dingtalk")
 	return nil
 }
 
+// truncateDingTalkMessage cuts a message to the DingTalk length limit
+func truncateDingTalkMessage(message string) string {
+	const maxDingTalkLength = constants.MaxDingTalkMessageLength
+	if len(message) <= maxDingTalkLength {
+		return message
+	}
+	logger.WithFields(logrus.Fields{
+		"original_length": len(message),
+		"max_length":      maxDingTalkLength,
+	}).Info("truncating-message-for-dingtalk-limit")
+	return message[:maxDingTalkLength]
+}
+
+// buildMessage returns the session webhook body and the OpenAPI msgKey/msgParam
+// for the configured message format
+func (d *DingTalkBot) buildMessage(message string) (map[string]interface{}, string, map[string]string) {
+	d.mu.RLock()
+	markdown, actionCard := d.markdown, d.actionCard
+	d.mu.RUnlock()
+
+	title := dingtalkTitle(message)
+	switch {
+	case actionCard:
+		var btns []map[string]string
+		param := map[string]string{"title": title, "text": message}
+		for i, key := range dingtalkControlKeys {
+			actionURL := "dtmd://dingtalkclient/sendMessage?content=" + url.QueryEscape(key.data)
+			btns = append(btns, map[string]string{"title": key.label, "actionURL": actionURL})
+			param[fmt.Sprintf("actionTitle%d", i+1)] = key.label
+			param[fmt.Sprintf("actionURL%d", i+1)] = actionURL
+		}
+		body := map[string]interface{}{
+			"msgtype": "actionCard",
+			"actionCard": map[string]interface{}{
+				"title":          title,
+				"text":           message,
+				"btnOrientation": "1",
+				"btns":           btns,
+			},
+		}
+		return body, fmt.Sprintf("sampleActionCard%d", len(dingtalkControlKeys)), param
+	case markdown:
+		body := map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"title": title, "text": message},
+		}
+		return body, "sampleMarkdown", map[string]string{"title": title, "text": message}
+	default:
+		body := map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": message},
+		}
+		return body, "sampleText", map[string]string{"content": message}
+	}
+}
+
+// dingtalkControlKeys are the action card buttons; data are engine key words
+var dingtalkControlKeys = []struct {
+	label string
+	data  string
+}{
+	{"Esc", "esc"},
+	{"Tab", "tab"},
+	{"Shift-Tab", "stab"},
+	{"Ctrl-C", "ctrlc"},
+}
+
+// dingtalkTitle derives the notification title from the first non-empty line
+func dingtalkTitle(message string) string {
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "#>*- "))
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > dingtalkMarkdownTitleLength {
+			line = string(runes[:dingtalkMarkdownTitleLength])
+		}
+		return line
+	}
+	return "clibot"
+}
+
+// sendViaOpenAPI sends a proactive robot message to a single chat or group
+func (d *DingTalkBot) sendViaOpenAPI(conversationID string, conv dingtalkConversation, msgKey string, msgParam map[string]string) error {
+	token, err := d.getAccessToken()
+	if err != nil {
+		return err
+	}
+
+	param, err := json.Marshal(msgParam)
+	if err != nil {
+		return fmt.Errorf("marshal msgParam: %w", err)
+	}
+	body := map[string]interface{}{
+		"robotCode": d.clientID,
+		"msgKey":    msgKey,
+		"msgParam":  string(param),
+	}
+
+	if conv.Type == dingtalkConversationGroup {
+		body["openConversationId"] = conversationID
+		return d.postOpenAPI("/v1.0/robot/groupMessages/send", token, body, nil)
+	}
+
+	if conv.StaffID == "" {
+		return fmt.Errorf("no staff ID known for conversation %s", conversationID)
+	}
+	body["userIds"] = []string{conv.StaffID}
+	return d.postOpenAPI("/v1.0/robot/oToMessages/batchSend", token, body, nil)
+}
+
+// getAccessToken returns a cached OpenAPI access token, refreshing it before expiry
+func (d *DingTalkBot) getAccessToken() (string, error) {
+	d.mu.RLock()
+	token, expiresAt := d.accessToken, d.tokenExpiresAt
+	d.mu.RUnlock()
+	if token != "" && time.Now().Before(expiresAt) {
+		return token, nil
+	}
+
+	var result struct {
+		AccessToken string `json:"accessToken"`
+		ExpireIn    int64  `json:"expireIn"`
+	}
+	body := map[string]string{"appKey": d.clientID, "appSecret": d.clientSecret}
+	if err := d.postOpenAPI("/v1.0/oauth2/accessToken", "", body, &result); err != nil {
+		return "", fmt.Errorf("get access token: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("get access token: empty token")
+	}
+
+	d.mu.Lock()
+	d.accessToken = result.AccessToken
+	d.tokenExpiresAt = time.Now().Add(time.Duration(result.ExpireIn)*time.Second - dingtalkTokenExpirationBuffer)
+	d.mu.Unlock()
+	return result.AccessToken, nil
+}
+
+// postOpenAPI posts JSON to an OpenAPI path and decodes the response into out (if non-nil)
+func (d *DingTalkBot) postOpenAPI(path, token string, body interface{}, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	d.mu.RLock()
+	base := d.apiBase
+	d.mu.RUnlock()
+	if base == "" {
+		base = DingTalkAPIBase
+	}
+
+	req, err := http.NewRequest(http.MethodPost, base+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("x-acs-dingtalk-access-token", token)
+	}
+
+	resp, err := d.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("request %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s failed: %s: %s", path, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("decode %s response: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// httpClient returns the proxy-aware HTTP client for OpenAPI calls
+func (d *DingTalkBot) httpClient() *http.Client {
+	d.mu.RLock()
+	proxyMgr := d.proxyMgr
+	d.mu.RUnlock()
+	if proxyMgr != nil {
+		if client, err := proxyMgr.GetHTTPClient("dingtalk"); err == nil {
+			return client
+		}
+	}
+	return &http.Client{Timeout: dingtalkAPIRequestTimeout}
+}
+
 // Stop closes the DingTalk WebSocket connection and cleans up resources
 func (d *DingTalkBot) Stop() error {
 	if d.cancel != nil {
@@ -230,7 +763,6 @@ func (d *DingTalkBot) Stop() error {
 	d.mu.Lock()
 	streamClient := d.streamClient
 	d.streamClient = nil
-	d.sessionWebhooks = make(map[string]string)
 	d.mu.Unlock()
 
 	if streamClient != nil {
@@ -1,9 +1,20 @@
 package bot
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/open-dingtalk/dingtalk-stream-sdk-go/chatbot"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestDingTalkBot_SetMessageHandler tests the SetMessageHandler method
@@ -87,3 +98,228 @@ func TestDingTalkBot_NewDingTalkBot(t *testing.T) {
 		assert.Equal(t, "", bot.clientSecret)
 	})
 }
+
+// fakeDingTalkAPI is a fake DingTalk OpenAPI and session webhook endpoint
+type fakeDingTalkAPI struct {
+	mu            sync.Mutex
+	requests      map[string][]map[string]interface{} // path -> JSON bodies
+	tokenRequests int
+	webhookStatus int
+}
+
+func (f *fakeDingTalkAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/img" {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG fake"))
+		return
+	}
+
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests[r.URL.Path] = append(f.requests[r.URL.Path], body)
+
+	switch r.URL.Path {
+	case "/v1.0/oauth2/accessToken":
+		f.tokenRequests++
+		json.NewEncoder(w).Encode(map[string]interface{}{"accessToken": "at-1", "expireIn": 7200})
+	case "/v1.0/robot/messageFiles/download":
+		json.NewEncoder(w).Encode(map[string]string{"downloadUrl": "http://" + r.Host + "/img"})
+	case "/webhook":
+		w.WriteHeader(f.webhookStatus)
+	default:
+		if r.Header.Get("x-acs-dingtalk-access-token") != "at-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}
+}
+
+// newTestDingTalkBot returns a DingTalkBot using a fake OpenAPI and a temp state file
+func newTestDingTalkBot(t *testing.T) (*DingTalkBot, *fakeDingTalkAPI, *httptest.Server) {
+	fake := &fakeDingTalkAPI{requests: make(map[string][]map[string]interface{}), webhookStatus: http.StatusOK}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	bot := NewDingTalkBot("ding-app", "secret")
+	bot.apiBase = server.URL
+	bot.SetStatePath(filepath.Join(t.TempDir(), "conversations.json"))
+	return bot, fake, server
+}
+
+// TestDingTalkBot_SendMessage_UsesValidWebhook tests replies through an unexpired session webhook
+func TestDingTalkBot_SendMessage_UsesValidWebhook(t *testing.T) {
+	bot, fake, server := newTestDingTalkBot(t)
+	bot.handleMessageReceive(context.Background(), &chatbot.BotCallbackDataModel{
+		ConversationId:            "cid-1",
+		ConversationType:          "1",
+		SenderStaffId:             "staff-1",
+		SessionWebhook:            server.URL + "/webhook",
+		SessionWebhookExpiredTime: time.Now().Add(time.Hour).UnixMilli(),
+		Msgtype:                   "text",
+		Text:                      chatbot.BotCallbackDataTextModel{Content: " hi"},
+	})
+
+	require.NoError(t, bot.SendMessage("cid-1", "hello"))
+	require.Len(t, fake.requests["/webhook"], 1)
+	assert.Equal(t, "text", fake.requests["/webhook"][0]["msgtype"])
+	assert.Empty(t, fake.requests["/v1.0/robot/oToMessages/batchSend"])
+}
+
+// TestDingTalkBot_SendMessage_ExpiredWebhookFallsBackToOpenAPI tests proactive 1:1 and group sends
+func TestDingTalkBot_SendMessage_ExpiredWebhookFallsBackToOpenAPI(t *testing.T) {
+	bot, fake, server := newTestDingTalkBot(t)
+	expired := time.Now().Add(-time.Minute).UnixMilli()
+	for _, data := range []*chatbot.BotCallbackDataModel{
+		{ConversationId: "cid-1", ConversationType: "1", SenderStaffId: "staff-1", SessionWebhook: server.URL + "/webhook", SessionWebhookExpiredTime: expired},
+		{ConversationId: "cid-g", ConversationType: "2", SenderStaffId: "staff-2", SessionWebhook: server.URL + "/webhook", SessionWebhookExpiredTime: expired},
+	} {
+		data.Msgtype = "text"
+		data.Text.Content = "hi"
+		bot.handleMessageReceive(context.Background(), data)
+	}
+
+	require.NoError(t, bot.SendMessage("cid-1", "done"))
+	require.NoError(t, bot.SendMessage("cid-g", "done"))
+
+	assert.Empty(t, fake.requests["/webhook"])
+	oto := fake.requests["/v1.0/robot/oToMessages/batchSend"]
+	require.Len(t, oto, 1)
+	assert.Equal(t, "ding-app", oto[0]["robotCode"])
+	assert.Equal(t, []interface{}{"staff-1"}, oto[0]["userIds"])
+	assert.Equal(t, "sampleText", oto[0]["msgKey"])
+	assert.JSONEq(t, `{"content":"done"}`, oto[0]["msgParam"].(string))
+
+	group := fake.requests["/v1.0/robot/groupMessages/send"]
+	require.Len(t, group, 1)
+	assert.Equal(t, "cid-g", group[0]["openConversationId"])
+
+	// The access token is cached
+	assert.Equal(t, 1, fake.tokenRequests)
+}
+
+// TestDingTalkBot_SendMessage_WebhookFailureFallsBack tests the OpenAPI fallback after a webhook error
+func TestDingTalkBot_SendMessage_WebhookFailureFallsBack(t *testing.T) {
+	bot, fake, server := newTestDingTalkBot(t)
+	fake.webhookStatus = http.StatusBadRequest
+	bot.handleMessageReceive(context.Background(), &chatbot.BotCallbackDataModel{
+		ConversationId: "cid-1", ConversationType: "1", SenderStaffId: "staff-1",
+		SessionWebhook: server.URL + "/webhook", Msgtype: "text",
+		Text: chatbot.BotCallbackDataTextModel{Content: "hi"},
+	})
+
+	require.NoError(t, bot.SendMessage("cid-1", "done"))
+	assert.Len(t, fake.requests["/webhook"], 1)
+	assert.Len(t, fake.requests["/v1.0/robot/oToMessages/batchSend"], 1)
+}
+
+// TestDingTalkBot_SendDirectMessage tests 1:1 sends to a staff ID without a prior conversation
+func TestDingTalkBot_SendDirectMessage(t *testing.T) {
+	bot, fake, _ := newTestDingTalkBot(t)
+
+	require.NoError(t, bot.SendDirectMessage("staff-admin", "bot down"))
+	oto := fake.requests["/v1.0/robot/oToMessages/batchSend"]
+	require.Len(t, oto, 1)
+	assert.Equal(t, []interface{}{"staff-admin"}, oto[0]["userIds"])
+	assert.Error(t, bot.SendDirectMessage("", "x"))
+	var _ DirectMessager = bot
+}
+
+// TestDingTalkBot_ConversationsPersisted tests that conversations survive a restart
+func TestDingTalkBot_ConversationsPersisted(t *testing.T) {
+	bot, fake, _ := newTestDingTalkBot(t)
+	bot.handleMessageReceive(context.Background(), &chatbot.BotCallbackDataModel{
+		ConversationId: "cid-1", ConversationType: "1", SenderStaffId: "staff-1",
+		SessionWebhook: "http://unused", Msgtype: "text",
+		Text: chatbot.BotCallbackDataTextModel{Content: "hi"},
+	})
+
+	restarted := NewDingTalkBot("ding-app", "secret")
+	restarted.apiBase = bot.apiBase
+	restarted.SetStatePath(bot.statePath)
+	restarted.loadConversations()
+
+	require.NoError(t, restarted.SendMessage("cid-1", "after restart"))
+	assert.Len(t, fake.requests["/v1.0/robot/oToMessages/batchSend"], 1)
+	assert.Error(t, restarted.SendMessage("cid-unknown", "x"))
+}
+
+// TestDingTalkBot_MessageFormats tests markdown and action card payloads
+func TestDingTalkBot_MessageFormats(t *testing.T) {
+	bot := NewDingTalkBot("ding-app", "secret")
+
+	bot.SetMessageFormat(true, false)
+	body, key, param := bot.buildMessage("## Result\nall good")
+	assert.Equal(t, "markdown", body["msgtype"])
+	assert.Equal(t, "sampleMarkdown", key)
+	assert.Equal(t, "Result", param["title"])
+
+	bot.SetMessageFormat(false, true)
+	body, key, param = bot.buildMessage("done")
+	assert.Equal(t, "actionCard", body["msgtype"])
+	assert.Equal(t, "sampleActionCard4", key)
+	assert.Equal(t, "Ctrl-C", param["actionTitle4"])
+	assert.Equal(t, "dtmd://dingtalkclient/sendMessage?content=ctrlc", param["actionURL4"])
+}
+
+// TestDingTalkBot_RichTextAndImage tests inbound richText and picture parsing
+func TestDingTalkBot_RichTextAndImage(t *testing.T) {
+	bot, _, _ := newTestDingTalkBot(t)
+	var received []BotMessage
+	bot.SetMessageHandler(func(msg BotMessage) { received = append(received, msg) })
+
+	bot.handleMessageReceive(context.Background(), &chatbot.BotCallbackDataModel{
+		ConversationId: "cid-1", MsgId: "msg/1", Msgtype: "richText",
+		Content: map[string]interface{}{"richText": []interface{}{
+			map[string]interface{}{"text": "what is wrong here?"},
+			map[string]interface{}{"type": "picture", "downloadCode": "code-1"},
+		}},
+	})
+	bot.handleMessageReceive(context.Background(), &chatbot.BotCallbackDataModel{
+		ConversationId: "cid-1", MsgId: "msg2", Msgtype: "picture",
+		Content: map[string]interface{}{"downloadCode": "code-2"},
+	})
+
+	require.Len(t, received, 2)
+	lines := strings.Split(received[0].Content, "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "what is wrong here?", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "[image: "), lines[1])
+
+	path := strings.TrimSuffix(strings.TrimPrefix(lines[1], "[image: "), "]")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "\x89PNG fake", string(data))
+	assert.Equal(t, ".png", filepath.Ext(path))
+	os.Remove(path)
+
+	assert.Contains(t, received[1].Content, "[image: ")
+	os.Remove(strings.TrimSuffix(strings.TrimPrefix(received[1].Content, "[image: "), "]"))
+}
+
+// TestDingTalkBot_Voice tests audio messages with and without DingTalk recognition
+func TestDingTalkBot_Voice(t *testing.T) {
+	bot, _, _ := newTestDingTalkBot(t)
+	var received []BotMessage
+	bot.SetMessageHandler(func(msg BotMessage) { received = append(received, msg) })
+
+	bot.handleMessageReceive(context.Background(), &chatbot.BotCallbackDataModel{
+		ConversationId: "cid-1", MsgId: "msg1", Msgtype: "audio",
+		Content: map[string]interface{}{"downloadCode": "code-1", "recognition": "run the tests"},
+	})
+	bot.SetTranscriber(stubTranscriber{text: "show the diff"})
+	t.Cleanup(func() { os.Remove(filepath.Join(os.TempDir(), "clibot-dingtalk", "voice-msg2.png")) })
+	bot.handleMessageReceive(context.Background(), &chatbot.BotCallbackDataModel{
+		ConversationId: "cid-1", MsgId: "msg2", Msgtype: "audio",
+		Content: map[string]interface{}{"downloadCode": "code-2"},
+	})
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "run the tests", received[0].Content)
+	assert.True(t, received[0].Transcribed)
+	assert.Equal(t, "show the diff", received[1].Content)
+	assert.True(t, received[1].Transcribed)
+}
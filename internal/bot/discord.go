@@ -2,6 +2,9 @@ package bot
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,6 +15,94 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Discord adapter constants
+const (
+	// discordTypingEmoji is the reaction used as typing indicator
+	discordTypingEmoji = "⏳"
+	// discordMessageIDSep separates channel and message IDs in BotMessage.MessageID
+	discordMessageIDSep = ":"
+	// discordThreadArchiveMinutes is the auto-archive duration for session threads (1 day)
+	discordThreadArchiveMinutes = 1440
+	// discordMaxAutocompleteChoices is Discord's limit for autocomplete choices
+	discordMaxAutocompleteChoices = 25
+)
+
+// discordSlashCommands are the application commands registered on startup
+// Each command is translated into the equivalent text command for the engine
+var discordSlashCommands = []*discordgo.ApplicationCommand{
+	{Name: "help", Description: "Show clibot help"},
+	{Name: "slist", Description: "List available sessions"},
+	{
+		Name:        "suse",
+		Description: "Switch to a session",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "session", Description: "Session name", Required: true, Autocomplete: true},
+		},
+	},
+	{
+		Name:        "snew",
+		Description: "Create a dynamic session (admin only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Session name", Required: true},
+			{
+				// Choices are filled in from the registered CLI adapters on startup
+				Type: discordgo.ApplicationCommandOptionString, Name: "cli_type", Description: "CLI type", Required: true,
+			},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "work_dir", Description: "Working directory", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "start_cmd", Description: "Custom start command"},
+		},
+	},
+	{
+		Name:        "sstatus",
+		Description: "Show session status",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "session", Description: "Session name", Autocomplete: true},
+		},
+	},
+	{
+		Name:        "shistory",
+		Description: "Show recent exchanges of the current session",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "count", Description: "Number of exchanges"},
+			{
+				Type: discordgo.ApplicationCommandOptionString, Name: "export", Description: "Send as a markdown file",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{{Name: "export", Value: "export"}},
+			},
+		},
+	},
+	{
+		Name:        "ssearch",
+		Description: "Search the current session's conversation",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "text", Description: "Text to search for", Required: true},
+		},
+	},
+	{
+		Name:        "sshot",
+		Description: "Send a screenshot of a session's terminal",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "session", Description: "Session name (default: current session)", Autocomplete: true},
+		},
+	},
+	{
+		Name:        "keys",
+		Description: "Send keys to the current session",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "keys", Description: `Key sequence, e.g. Down Down Enter or C-r "search" Enter`, Required: true},
+		},
+	},
+	{
+		Name:        "raw",
+		Description: "Toggle raw mode: messages are typed without pressing Enter",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionString, Name: "mode", Description: "Turn raw mode on or off",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{{Name: "on", Value: "on"}, {Name: "off", Value: "off"}},
+			},
+		},
+	},
+}
+
 // DiscordMessage represents a Discord message for our interface
 type DiscordMessage interface {
 	ID() string
@@ -24,26 +115,70 @@ type DiscordSessionInterface interface {
 	Open() error
 	Close() error
 	ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error
+	MessageReactionRemove(channelID, messageID, emojiID, userID string, options ...discordgo.RequestOption) error
+	ThreadStart(channelID, name string, typ discordgo.ChannelType, archiveDuration int, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error
+	ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
 }
 
 // DiscordBot implements BotAdapter interface for Discord
 type DiscordBot struct {
-	DefaultTypingIndicator
-	mu             sync.RWMutex
-	token          string
-	channelID      string
-	session        DiscordSessionInterface
-	messageHandler func(BotMessage)
-	proxyMgr       proxy.Manager
+	disconnectSignal
+	mu               sync.RWMutex
+	token            string
+	channelID        string
+	allowedChannels  map[string]struct{} // Guild channels accepting messages (empty = all)
+	threadPerSession bool
+	botUserID        string
+	session          DiscordSessionInterface
+	messageHandler   func(BotMessage)
+	sessionProvider  SessionProvider
+	threadSessions   map[string]string // thread channel ID -> session name
+	sessionThreads   map[string]string // "<parent channel>/<session>" -> thread channel ID
+	threadParents    map[string]string // channel ID -> parent channel ID ("" when not a thread)
+	pendingThreads   map[string]string // "<channel>/<user>" -> session of a /suse awaiting the engine
+	proxyMgr         proxy.Manager
 }
 
 // NewDiscordBot creates a new Discord bot instance
+// channelID, when set, restricts guild messages to that channel (DMs are always accepted)
 func NewDiscordBot(token, channelID string) *DiscordBot {
-	return &DiscordBot{
-		token:     token,
-		channelID: channelID,
-		session:   nil, // Will be created in Start()
+	d := &DiscordBot{
+		token:           token,
+		channelID:       channelID,
+		session:         nil, // Will be created in Start()
+		allowedChannels: make(map[string]struct{}),
+		threadSessions:  make(map[string]string),
+		sessionThreads:  make(map[string]string),
+		threadParents:   make(map[string]string),
+		pendingThreads:  make(map[string]string),
 	}
+	if channelID != "" {
+		d.allowedChannels[channelID] = struct{}{}
+	}
+	return d
+}
+
+// SetAllowedChannels adds guild channels that accept messages in addition to channelID
+func (d *DiscordBot) SetAllowedChannels(channelIDs []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, id := range channelIDs {
+		if id != "" {
+			d.allowedChannels[id] = struct{}{}
+		}
+	}
+}
+
+// SetThreadPerSession enables creating a dedicated thread per session on /suse
+func (d *DiscordBot) SetThreadPerSession(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.threadPerSession = enabled
 }
 
 // SetProxyManager sets the proxy manager for the Discord bot
@@ -53,6 +188,13 @@ func (d *DiscordBot) SetProxyManager(mgr proxy.Manager) {
 	d.proxyMgr = mgr
 }
 
+// SetSessionProvider sets the provider used for session name autocomplete
+func (d *DiscordBot) SetSessionProvider(provider SessionProvider) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sessionProvider = provider
+}
+
 // Start establishes connection to Discord and begins listening for messages
 func (d *DiscordBot) Start(messageHandler func(BotMessage)) error {
 	d.SetMessageHandler(messageHandler)
@@ -91,47 +233,414 @@ func (d *DiscordBot) Start(messageHandler func(BotMessage)) error {
 
 	session := d.session
 
-	// Register message handler
+	// Register handlers
+	session.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
+		d.handleReady(r)
+	})
 	session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
-		// Ignore messages from bots
-		if m.Author.Bot {
-			return
+		d.handleMessageCreate(m)
+	})
+	session.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		d.handleInteraction(i)
+	})
+	// Stop clears d.session before closing, so only real drops are reported
+	session.AddHandler(func(s *discordgo.Session, e *discordgo.Disconnect) {
+		d.mu.RLock()
+		current := d.session == session
+		d.mu.RUnlock()
+		if current {
+			logger.Warn("discord-gateway-disconnected")
+			d.signalDisconnect(fmt.Errorf("gateway disconnected"))
+		}
+	})
+
+	// Open connection
+	if err := session.Open(); err != nil {
+		return fmt.Errorf("failed to open discord connection: %w", err)
+	}
+
+	return nil
+}
+
+// handleReady records the bot identity and registers slash commands
+func (d *DiscordBot) handleReady(r *discordgo.Ready) {
+	if r == nil || r.User == nil {
+		return
+	}
+
+	d.mu.Lock()
+	d.botUserID = r.User.ID
+	session := d.session
+	d.mu.Unlock()
+
+	appID := r.User.ID
+	if r.Application != nil && r.Application.ID != "" {
+		appID = r.Application.ID
+	}
+
+	if session == nil {
+		return
+	}
+	commands := d.slashCommands()
+	if _, err := session.ApplicationCommandBulkOverwrite(appID, "", commands); err != nil {
+		logger.WithField("error", err).Warn("failed-to-register-discord-slash-commands")
+		return
+	}
+	logger.WithField("count", len(commands)).Info("discord-slash-commands-registered")
+}
+
+// slashCommands returns discordSlashCommands with the snew cli_type choices
+// set to the CLI types registered in the engine
+func (d *DiscordBot) slashCommands() []*discordgo.ApplicationCommand {
+	d.mu.RLock()
+	provider := d.sessionProvider
+	d.mu.RUnlock()
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	if provider != nil {
+		for _, cliType := range provider.CLITypes() {
+			if len(choices) == discordMaxAutocompleteChoices {
+				break
+			}
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: cliType, Value: cliType})
 		}
+	}
+
+	commands := make([]*discordgo.ApplicationCommand, 0, len(discordSlashCommands))
+	for _, cmd := range discordSlashCommands {
+		if cmd.Name == "snew" {
+			snew := *cmd
+			snew.Options = make([]*discordgo.ApplicationCommandOption, 0, len(cmd.Options))
+			for _, opt := range cmd.Options {
+				if opt.Name == "cli_type" {
+					withChoices := *opt
+					withChoices.Choices = choices
+					opt = &withChoices
+				}
+				snew.Options = append(snew.Options, opt)
+			}
+			cmd = &snew
+		}
+		commands = append(commands, cmd)
+	}
+	return commands
+}
+
+// handleMessageCreate filters and dispatches an incoming message
+func (d *DiscordBot) handleMessageCreate(m *discordgo.MessageCreate) {
+	// Ignore messages from bots
+	if m == nil || m.Author == nil || m.Author.Bot {
+		return
+	}
+
+	isDM := m.GuildID == ""
+	if !isDM && !d.isAllowedChannel(m.ChannelID) {
+		logger.WithFields(logrus.Fields{
+			"channel": m.ChannelID,
+			"guild":   m.GuildID,
+		}).Debug("ignoring-discord-message-from-unconfigured-channel")
+		return
+	}
+
+	content := d.stripBotMention(m.Content)
+
+	// Log received message
+	logger.WithFields(logrus.Fields{
+		"platform": "discord",
+		"user_id":  m.Author.ID,
+		"username": m.Author.Username,
+		"channel":  m.ChannelID,
+		"dm":       isDM,
+		"content":  content,
+	}).Debug("received-discord-message")
+
+	// Call the handler with BotMessage
+	handler := d.GetMessageHandler()
+	if handler != nil {
+		handler(BotMessage{
+			Platform:  "discord",
+			UserID:    m.Author.ID,
+			Channel:   m.ChannelID,
+			MessageID: m.ChannelID + discordMessageIDSep + m.ID,
+			Content:   content,
+			Session:   d.threadSession(m.ChannelID),
+			Timestamp: time.Now(),
+		})
 
-		// Log received message
 		logger.WithFields(logrus.Fields{
 			"platform": "discord",
-			"user_id":  m.Author.ID,
-			"username": m.Author.Username,
+			"user":     m.Author.ID,
 			"channel":  m.ChannelID,
-			"content":  m.Content,
-		}).Debug("received-discord-message")
-
-		// Call the handler with BotMessage
-		handler := d.GetMessageHandler()
-		if handler != nil {
-			handler(BotMessage{
-				Platform:  "discord",
-				UserID:    m.Author.ID,
-				Channel:   m.ChannelID,
-				Content:   m.Content,
-				Timestamp: time.Now(),
-			})
+			"dm":       isDM,
+		}).Info("message-received-from-discord")
+	}
+}
 
-			logger.WithFields(logrus.Fields{
-				"platform": "discord",
-				"user":     m.Author.ID,
-				"channel":  m.ChannelID,
-			}).Info("message-received-from-discord")
+// isAllowedChannel reports whether a guild channel (or thread under it) accepts messages
+func (d *DiscordBot) isAllowedChannel(channelID string) bool {
+	d.mu.RLock()
+	allowed := d.allowedChannels
+	_, ok := allowed[channelID]
+	_, isSessionThread := d.threadSessions[channelID]
+	parent, parentKnown := d.threadParents[channelID]
+	session := d.session
+	d.mu.RUnlock()
+
+	if len(allowed) == 0 || ok || isSessionThread {
+		return true
+	}
+
+	// Threads inherit the permission of their parent channel
+	if !parentKnown && session != nil {
+		if ch, err := session.Channel(channelID); err == nil && ch.IsThread() {
+			parent = ch.ParentID
 		}
-	})
+		d.mu.Lock()
+		d.threadParents[channelID] = parent
+		d.mu.Unlock()
+	}
 
-	// Open connection
-	if err := session.Open(); err != nil {
-		return fmt.Errorf("failed to open discord connection: %w", err)
+	_, ok = allowed[parent]
+	return parent != "" && ok
+}
+
+// stripBotMention removes a leading mention of the bot from guild messages
+func (d *DiscordBot) stripBotMention(content string) string {
+	d.mu.RLock()
+	botUserID := d.botUserID
+	d.mu.RUnlock()
+
+	if botUserID == "" {
+		return content
+	}
+	for _, mention := range []string{"<@" + botUserID + ">", "<@!" + botUserID + ">"} {
+		if strings.HasPrefix(content, mention) {
+			return strings.TrimSpace(strings.TrimPrefix(content, mention))
+		}
 	}
+	return content
+}
 
-	return nil
+// threadSession returns the session bound to a session thread, if any
+func (d *DiscordBot) threadSession(channelID string) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.threadSessions[channelID]
+}
+
+// handleInteraction handles slash commands and autocomplete requests
+func (d *DiscordBot) handleInteraction(i *discordgo.InteractionCreate) {
+	if i == nil || i.Interaction == nil {
+		return
+	}
+
+	switch i.Type {
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		d.respondAutocomplete(i.Interaction)
+	case discordgo.InteractionApplicationCommand:
+		d.handleSlashCommand(i.Interaction)
+	}
+}
+
+// interactionUserID returns the invoking user for guild and DM interactions
+func interactionUserID(i *discordgo.Interaction) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// slashCommandText converts a slash command into the equivalent text command
+func slashCommandText(data discordgo.ApplicationCommandInteractionData) string {
+	parts := []string{data.Name}
+
+	// Keep the declared option order so positional arguments line up
+	for _, cmd := range discordSlashCommands {
+		if cmd.Name != data.Name {
+			continue
+		}
+		for _, declared := range cmd.Options {
+			for _, opt := range data.Options {
+				if opt.Name == declared.Name {
+					if value, ok := opt.Value.(string); ok && value != "" {
+						parts = append(parts, value)
+					}
+				}
+			}
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// handleSlashCommand acknowledges a slash command and forwards it to the engine
+func (d *DiscordBot) handleSlashCommand(i *discordgo.Interaction) {
+	d.mu.RLock()
+	session := d.session
+	threadPerSession := d.threadPerSession
+	d.mu.RUnlock()
+	if session == nil {
+		return
+	}
+
+	channel := i.ChannelID
+	if i.GuildID != "" && !d.isAllowedChannel(channel) {
+		logger.WithFields(logrus.Fields{
+			"channel": channel,
+			"guild":   i.GuildID,
+		}).Debug("ignoring-discord-slash-command-from-unconfigured-channel")
+		if err := session.InteractionRespond(i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ clibot does not accept commands in this channel",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		}); err != nil {
+			logger.WithField("error", err).Warn("failed-to-acknowledge-discord-interaction")
+		}
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	command := slashCommandText(data)
+	userID := interactionUserID(i)
+
+	logger.WithFields(logrus.Fields{
+		"platform": "discord",
+		"user":     userID,
+		"channel":  channel,
+		"command":  command,
+	}).Info("discord-slash-command-received")
+
+	ack := "> " + command
+	boundSession := d.threadSession(channel)
+
+	// In thread mode, /suse from a guild channel opens (or reuses) the session's
+	// thread once the engine has authorized the user and switched the session
+	if data.Name == "suse" && threadPerSession && i.GuildID != "" && boundSession == "" && userID != "" {
+		d.mu.Lock()
+		d.pendingThreads[channel+"/"+userID] = strings.TrimPrefix(command, "suse ")
+		d.mu.Unlock()
+	}
+
+	if err := session.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: ack},
+	}); err != nil {
+		logger.WithField("error", err).Warn("failed-to-acknowledge-discord-interaction")
+	}
+
+	if handler := d.GetMessageHandler(); handler != nil && userID != "" {
+		handler(BotMessage{
+			Platform:  "discord",
+			UserID:    userID,
+			Channel:   channel,
+			Content:   command,
+			Session:   boundSession,
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// SessionSwitched opens the session thread requested by a /suse slash command
+// Implements SessionSwitchListener; a rejected switch (empty sessionName) only
+// clears the pending request
+func (d *DiscordBot) SessionSwitched(channel, userID, sessionName string) {
+	key := channel + "/" + userID
+	d.mu.Lock()
+	requested, pending := d.pendingThreads[key]
+	delete(d.pendingThreads, key)
+	d.mu.Unlock()
+	if !pending || requested != sessionName {
+		return
+	}
+
+	threadID, err := d.ensureSessionThread(channel, sessionName)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"session": sessionName,
+			"error":   err,
+		}).Warn("failed-to-create-discord-session-thread")
+		return
+	}
+	if err := d.SendMessage(channel, fmt.Sprintf("🧵 Continue in <#%s>", threadID)); err != nil {
+		logger.WithField("error", err).Warn("failed-to-send-discord-session-thread-link")
+	}
+}
+
+// ensureSessionThread returns the thread for a session under parent, creating it if needed
+func (d *DiscordBot) ensureSessionThread(parent, sessionName string) (string, error) {
+	key := parent + "/" + sessionName
+
+	d.mu.RLock()
+	threadID, exists := d.sessionThreads[key]
+	session := d.session
+	d.mu.RUnlock()
+	if exists {
+		return threadID, nil
+	}
+
+	thread, err := session.ThreadStart(parent, sessionName, discordgo.ChannelTypeGuildPublicThread, discordThreadArchiveMinutes)
+	if err != nil {
+		return "", err
+	}
+
+	d.mu.Lock()
+	d.sessionThreads[key] = thread.ID
+	d.threadSessions[thread.ID] = sessionName
+	d.threadParents[thread.ID] = parent
+	d.mu.Unlock()
+
+	logger.WithFields(logrus.Fields{
+		"session": sessionName,
+		"thread":  thread.ID,
+	}).Info("discord-session-thread-created")
+	return thread.ID, nil
+}
+
+// respondAutocomplete suggests session names matching the focused option
+func (d *DiscordBot) respondAutocomplete(i *discordgo.Interaction) {
+	d.mu.RLock()
+	session := d.session
+	provider := d.sessionProvider
+	d.mu.RUnlock()
+	if session == nil {
+		return
+	}
+
+	prefix := ""
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Focused {
+			prefix, _ = opt.Value.(string)
+		}
+	}
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	if provider != nil {
+		sessions, _ := provider.ListSessions("discord", interactionUserID(i))
+		for _, s := range sessions {
+			if !strings.HasPrefix(strings.ToLower(s.Name), strings.ToLower(prefix)) {
+				continue
+			}
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  fmt.Sprintf("%s (%s, %s)", s.Name, s.CLIType, s.State),
+				Value: s.Name,
+			})
+			if len(choices) == discordMaxAutocompleteChoices {
+				break
+			}
+		}
+	}
+
+	if err := session.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	}); err != nil {
+		logger.WithField("error", err).Warn("failed-to-respond-discord-autocomplete")
+	}
 }
 
 // SendMessage sends a message to a Discord channel
@@ -175,6 +684,118 @@ func (d *DiscordBot) SendMessage(channel, message string) error {
 	return nil
 }
 
+// SendDirectMessage opens (or reuses) the DM channel with a user and sends a message to it
+func (d *DiscordBot) SendDirectMessage(userID, message string) error {
+	d.mu.RLock()
+	session := d.session
+	d.mu.RUnlock()
+
+	if session == nil {
+		return fmt.Errorf("discord session not initialized")
+	}
+
+	channel, err := session.UserChannelCreate(userID)
+	if err != nil {
+		return fmt.Errorf("failed to open DM channel with user %s: %w", userID, err)
+	}
+	return d.SendMessage(channel.ID, message)
+}
+
+// SendFile uploads a local file to a Discord channel as an attachment
+func (d *DiscordBot) SendFile(channel, filePath string) error {
+	d.mu.RLock()
+	session := d.session
+	channelID := d.channelID
+	d.mu.RUnlock()
+
+	if session == nil {
+		return fmt.Errorf("discord session not initialized")
+	}
+	targetChannel := channel
+	if targetChannel == "" {
+		targetChannel = channelID
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := session.ChannelMessageSendComplex(targetChannel, &discordgo.MessageSend{
+		Files: []*discordgo.File{{Name: filepath.Base(filePath), Reader: file}},
+	}); err != nil {
+		logger.WithFields(logrus.Fields{
+			"channel": targetChannel,
+			"error":   err,
+		}).Error("failed-to-send-file-to-discord")
+		return fmt.Errorf("failed to send file to channel %s: %w", targetChannel, err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"channel": targetChannel,
+		"file":    filepath.Base(filePath),
+	}).Info("file-sent-to-discord")
+	return nil
+}
+
+// SendImage sends a local image; Discord shows image attachments inline
+func (d *DiscordBot) SendImage(channel, imagePath string) error {
+	return d.SendFile(channel, imagePath)
+}
+
+// splitDiscordMessageID splits "<channel>:<message>" into its parts
+func splitDiscordMessageID(messageID string) (string, string, bool) {
+	channel, msg, ok := strings.Cut(messageID, discordMessageIDSep)
+	if !ok || channel == "" || msg == "" {
+		return "", "", false
+	}
+	return channel, msg, true
+}
+
+// SupportsTypingIndicator returns true; Discord uses a reaction as typing indicator
+func (d *DiscordBot) SupportsTypingIndicator() bool {
+	return true
+}
+
+// AddTypingIndicator adds the typing reaction to the user's message
+func (d *DiscordBot) AddTypingIndicator(messageID string) bool {
+	d.mu.RLock()
+	session := d.session
+	d.mu.RUnlock()
+
+	channel, msg, ok := splitDiscordMessageID(messageID)
+	if session == nil || !ok {
+		return false
+	}
+
+	if err := session.MessageReactionAdd(channel, msg, discordTypingEmoji); err != nil {
+		logger.WithFields(logrus.Fields{
+			"message_id": messageID,
+			"error":      err,
+		}).Warn("failed-to-add-discord-typing-reaction")
+		return false
+	}
+	return true
+}
+
+// RemoveTypingIndicator removes the bot's typing reaction from the user's message
+func (d *DiscordBot) RemoveTypingIndicator(messageID string) error {
+	d.mu.RLock()
+	session := d.session
+	d.mu.RUnlock()
+
+	channel, msg, ok := splitDiscordMessageID(messageID)
+	if session == nil || !ok {
+		return nil
+	}
+
+	if err := session.MessageReactionRemove(channel, msg, discordTypingEmoji, "@me"); err != nil {
+		return fmt.Errorf("failed to remove discord typing reaction: %w", err)
+	}
+	return nil
+}
+
 // Stop closes the Discord connection and cleans up resources
 func (d *DiscordBot) Stop() error {
 	d.mu.Lock()
@@ -1,9 +1,13 @@
 package bot
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestDiscordBot_SetMessageHandler tests the SetMessageHandler method
@@ -88,3 +92,284 @@ func TestDiscordBot_NewDiscordBot(t *testing.T) {
 		assert.Equal(t, "", bot.channelID)
 	})
 }
+
+// newMockedDiscordBot returns a bot wired to a mock session that records dispatched messages
+func newMockedDiscordBot(channelID string) (*DiscordBot, *MockDiscordSession, *[]BotMessage) {
+	mock := &MockDiscordSession{channels: make(map[string]*discordgo.Channel)}
+	bot := NewDiscordBot("token", channelID)
+	bot.session = mock
+
+	var received []BotMessage
+	bot.SetMessageHandler(func(msg BotMessage) {
+		received = append(received, msg)
+	})
+	return bot, mock, &received
+}
+
+// discordMessage builds a MessageCreate event
+func discordMessage(guildID, channelID, content string) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "m1",
+		GuildID:   guildID,
+		ChannelID: channelID,
+		Content:   content,
+		Author:    &discordgo.User{ID: "u1", Username: "alice"},
+	}}
+}
+
+// TestDiscordBot_HandleMessageCreate_ChannelRestriction tests inbound channel and DM filtering
+func TestDiscordBot_HandleMessageCreate_ChannelRestriction(t *testing.T) {
+	bot, mock, received := newMockedDiscordBot("allowed")
+	bot.SetAllowedChannels([]string{"extra"})
+	mock.channels["thread-in-allowed"] = &discordgo.Channel{ID: "thread-in-allowed", ParentID: "allowed", Type: discordgo.ChannelTypeGuildPublicThread}
+	mock.channels["thread-elsewhere"] = &discordgo.Channel{ID: "thread-elsewhere", ParentID: "other", Type: discordgo.ChannelTypeGuildPublicThread}
+
+	bot.handleMessageCreate(discordMessage("g1", "allowed", "a"))
+	bot.handleMessageCreate(discordMessage("g1", "extra", "b"))
+	bot.handleMessageCreate(discordMessage("g1", "other", "c"))
+	bot.handleMessageCreate(discordMessage("", "dm-channel", "d"))
+	bot.handleMessageCreate(discordMessage("g1", "thread-in-allowed", "e"))
+	bot.handleMessageCreate(discordMessage("g1", "thread-elsewhere", "f"))
+
+	var contents []string
+	for _, msg := range *received {
+		contents = append(contents, msg.Content)
+	}
+	assert.Equal(t, []string{"a", "b", "d", "e"}, contents)
+	assert.Equal(t, "allowed:m1", (*received)[0].MessageID)
+}
+
+// TestDiscordBot_HandleMessageCreate_NoRestriction tests that all channels are accepted without configuration
+func TestDiscordBot_HandleMessageCreate_NoRestriction(t *testing.T) {
+	bot, _, received := newMockedDiscordBot("")
+
+	bot.handleMessageCreate(discordMessage("g1", "any", "hello"))
+	assert.Len(t, *received, 1)
+
+	// Bot authors are ignored
+	msg := discordMessage("g1", "any", "hello")
+	msg.Author.Bot = true
+	bot.handleMessageCreate(msg)
+	assert.Len(t, *received, 1)
+}
+
+// TestDiscordBot_HandleMessageCreate_StripsMention tests removal of a leading bot mention
+func TestDiscordBot_HandleMessageCreate_StripsMention(t *testing.T) {
+	bot, _, received := newMockedDiscordBot("")
+	bot.handleReady(&discordgo.Ready{User: &discordgo.User{ID: "bot1"}})
+
+	bot.handleMessageCreate(discordMessage("g1", "c", "<@bot1> slist"))
+	bot.handleMessageCreate(discordMessage("g1", "c", "<@!bot1>  help"))
+
+	assert.Equal(t, "slist", (*received)[0].Content)
+	assert.Equal(t, "help", (*received)[1].Content)
+}
+
+// TestDiscordBot_HandleReady_RegistersCommands tests slash command registration
+func TestDiscordBot_HandleReady_RegistersCommands(t *testing.T) {
+	bot, mock, _ := newMockedDiscordBot("")
+
+	bot.handleReady(&discordgo.Ready{
+		User:        &discordgo.User{ID: "bot1"},
+		Application: &discordgo.Application{ID: "app1"},
+	})
+
+	assert.Equal(t, "app1", mock.registeredAppID)
+	var names []string
+	for _, cmd := range mock.commands {
+		names = append(names, cmd.Name)
+	}
+	assert.Equal(t, []string{"help", "slist", "suse", "snew", "sstatus", "shistory", "ssearch", "sshot", "keys", "raw"}, names)
+}
+
+// TestDiscordBot_SlashCommands_CLITypeChoices tests that snew offers the registered CLI types
+func TestDiscordBot_SlashCommands_CLITypeChoices(t *testing.T) {
+	bot, _, _ := newMockedDiscordBot("")
+	bot.SetSessionProvider(&stubSessionProvider{cliTypes: []string{"claude", "mycli"}})
+
+	var values []interface{}
+	for _, cmd := range bot.slashCommands() {
+		if cmd.Name != "snew" {
+			continue
+		}
+		for _, choice := range cmd.Options[1].Choices {
+			values = append(values, choice.Value)
+		}
+	}
+	assert.Equal(t, []interface{}{"claude", "mycli"}, values)
+
+	// The shared command definitions are left untouched
+	assert.Empty(t, discordSlashCommands[3].Options[1].Choices)
+}
+
+// TestDiscordBot_TypingIndicator tests reaction-based typing indicators
+func TestDiscordBot_TypingIndicator(t *testing.T) {
+	bot, mock, _ := newMockedDiscordBot("")
+
+	assert.True(t, bot.SupportsTypingIndicator())
+	assert.True(t, bot.AddTypingIndicator("c1:m1"))
+	assert.NoError(t, bot.RemoveTypingIndicator("c1:m1"))
+	assert.Equal(t, []string{"+c1/m1/" + discordTypingEmoji, "-c1/m1/" + discordTypingEmoji + "/@me"}, mock.reactions)
+
+	// Malformed IDs are ignored
+	assert.False(t, bot.AddTypingIndicator("no-separator"))
+	assert.NoError(t, bot.RemoveTypingIndicator(""))
+}
+
+// slashInteraction builds an application command interaction
+func slashInteraction(guildID, channelID, name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	i := &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		GuildID:   guildID,
+		ChannelID: channelID,
+		Data:      discordgo.ApplicationCommandInteractionData{Name: name, Options: options},
+	}
+	if guildID != "" {
+		i.Member = &discordgo.Member{User: &discordgo.User{ID: "u1"}}
+	} else {
+		i.User = &discordgo.User{ID: "u1"}
+	}
+	return &discordgo.InteractionCreate{Interaction: i}
+}
+
+// stringOption builds a string option
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+// TestSlashCommandText tests conversion of slash commands to text commands
+func TestSlashCommandText(t *testing.T) {
+	data := discordgo.ApplicationCommandInteractionData{
+		Name: "snew",
+		// Options arrive in arbitrary order
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			stringOption("work_dir", "~/src"),
+			stringOption("name", "dev"),
+			stringOption("cli_type", "claude"),
+		},
+	}
+	assert.Equal(t, "snew dev claude ~/src", slashCommandText(data))
+	assert.Equal(t, "slist", slashCommandText(discordgo.ApplicationCommandInteractionData{Name: "slist"}))
+	assert.Equal(t, "shistory 10 export", slashCommandText(discordgo.ApplicationCommandInteractionData{
+		Name:    "shistory",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("export", "export"), stringOption("count", "10")},
+	}))
+}
+
+// TestDiscordBot_SendFile tests uploading a local file as an attachment
+func TestDiscordBot_SendFile(t *testing.T) {
+	bot, mock, _ := newMockedDiscordBot("")
+	path := filepath.Join(t.TempDir(), "history.md")
+	require.NoError(t, os.WriteFile(path, []byte("# history"), 0o644))
+
+	require.NoError(t, bot.SendFile("c1", path))
+	assert.Equal(t, []string{"history.md"}, mock.sentFiles)
+
+	assert.Error(t, bot.SendFile("c1", filepath.Join(t.TempDir(), "missing.md")))
+	var _ FileSender = bot
+}
+
+// TestDiscordBot_SendDirectMessage tests that direct messages go to the user's DM channel
+func TestDiscordBot_SendDirectMessage(t *testing.T) {
+	bot, mock, _ := newMockedDiscordBot("")
+
+	require.NoError(t, bot.SendDirectMessage("u1", "bot down"))
+	assert.Equal(t, []SentMessage{{Channel: "dm-u1", Message: "bot down"}}, mock.sentMessages)
+	var _ DirectMessager = bot
+}
+
+func TestDiscordBot_SendImage(t *testing.T) {
+	bot, mock, _ := newMockedDiscordBot("")
+	path := filepath.Join(t.TempDir(), "screen.png")
+	require.NoError(t, os.WriteFile(path, []byte("\x89PNG fake"), 0o644))
+
+	require.NoError(t, bot.SendImage("c1", path))
+	assert.Equal(t, []string{"screen.png"}, mock.sentFiles)
+	var _ ImageSender = bot
+}
+
+// TestDiscordBot_HandleSlashCommand tests dispatching slash commands to the engine
+func TestDiscordBot_HandleSlashCommand(t *testing.T) {
+	bot, mock, received := newMockedDiscordBot("")
+
+	bot.handleInteraction(slashInteraction("", "dm", "suse", stringOption("session", "dev")))
+
+	assert.Len(t, mock.responses, 1)
+	assert.Equal(t, discordgo.InteractionResponseChannelMessageWithSource, mock.responses[0].Type)
+	assert.Len(t, *received, 1)
+	assert.Equal(t, "u1", (*received)[0].UserID)
+	assert.Equal(t, "suse dev", (*received)[0].Content)
+	assert.Equal(t, 0, mock.threadsStarted)
+}
+
+// TestDiscordBot_HandleSlashCommand_UnconfiguredChannel tests that slash commands
+// follow the same channel restriction as messages
+func TestDiscordBot_HandleSlashCommand_UnconfiguredChannel(t *testing.T) {
+	bot, mock, received := newMockedDiscordBot("general")
+
+	bot.handleInteraction(slashInteraction("g1", "random", "suse", stringOption("session", "dev")))
+	require.Len(t, mock.responses, 1)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, mock.responses[0].Data.Flags)
+	assert.Empty(t, *received)
+	assert.Empty(t, bot.pendingThreads)
+
+	bot.handleInteraction(slashInteraction("g1", "general", "slist"))
+	assert.Len(t, *received, 1)
+}
+
+// TestDiscordBot_ThreadPerSession tests that /suse opens a session thread bound to the session
+func TestDiscordBot_ThreadPerSession(t *testing.T) {
+	bot, mock, received := newMockedDiscordBot("general")
+	bot.SetThreadPerSession(true)
+
+	// No thread until the engine has accepted the switch
+	bot.handleInteraction(slashInteraction("g1", "general", "suse", stringOption("session", "dev")))
+	assert.Equal(t, 0, mock.threadsStarted)
+	bot.SessionSwitched("general", "u1", "dev")
+	assert.Equal(t, 1, mock.threadsStarted)
+	assert.Contains(t, mock.sentMessages[0].Message, "<#thread-dev>")
+
+	bot.handleInteraction(slashInteraction("g1", "general", "suse", stringOption("session", "dev")))
+	bot.SessionSwitched("general", "u1", "dev")
+	assert.Equal(t, 1, mock.threadsStarted, "thread is reused")
+
+	// Switches without a pending /suse (e.g. the text command) open no thread
+	bot.SessionSwitched("general", "u1", "dev")
+	assert.Len(t, mock.sentMessages, 2)
+
+	// A rejected /suse clears its pending thread
+	bot.handleInteraction(slashInteraction("g1", "general", "suse", stringOption("session", "ops")))
+	bot.SessionSwitched("general", "u1", "")
+	assert.Empty(t, bot.pendingThreads)
+
+	// Messages in the thread are accepted and bound to the session
+	bot.handleMessageCreate(discordMessage("g1", "thread-dev", "run tests"))
+	last := (*received)[len(*received)-1]
+	assert.Equal(t, "thread-dev", last.Channel)
+	assert.Equal(t, "dev", last.Session)
+}
+
+// TestDiscordBot_Autocomplete tests session name autocomplete
+func TestDiscordBot_Autocomplete(t *testing.T) {
+	bot, mock, _ := newMockedDiscordBot("")
+	bot.SetSessionProvider(&stubSessionProvider{sessions: []SessionInfo{
+		{Name: "backend", CLIType: "claude", State: "idle"},
+		{Name: "Bench", CLIType: "gemini", State: "idle"},
+		{Name: "frontend", CLIType: "opencode", State: "idle"},
+	}})
+
+	focused := stringOption("session", "b")
+	focused.Focused = true
+	i := slashInteraction("", "dm", "suse", focused)
+	i.Type = discordgo.InteractionApplicationCommandAutocomplete
+	bot.handleInteraction(i)
+
+	assert.Len(t, mock.responses, 1)
+	resp := mock.responses[0]
+	assert.Equal(t, discordgo.InteractionApplicationCommandAutocompleteResult, resp.Type)
+	var values []interface{}
+	for _, c := range resp.Data.Choices {
+		values = append(values, c.Value)
+	}
+	assert.Equal(t, []interface{}{"backend", "Bench"}, values)
+}
@@ -14,7 +14,14 @@ type MockDiscordSession struct {
 	openCalled       bool
 	closed           bool
 	sentMessages     []SentMessage
+	sentFiles        []string
 	handler          interface{}
+	channels         map[string]*discordgo.Channel
+	reactions        []string
+	responses        []*discordgo.InteractionResponse
+	commands         []*discordgo.ApplicationCommand
+	registeredAppID  string
+	threadsStarted   int
 }
 
 type SentMessage struct {
@@ -51,6 +58,53 @@ func (m *MockDiscordSession) ChannelMessageSend(channel, message string, options
 	return &discordgo.Message{ID: "msg-id"}, nil
 }
 
+func (m *MockDiscordSession) ChannelMessageSendComplex(channel string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if m.shouldFailOnSend {
+		return nil, errors.New("failed to send message")
+	}
+	for _, file := range data.Files {
+		m.sentFiles = append(m.sentFiles, file.Name)
+	}
+	return &discordgo.Message{ID: "msg-id"}, nil
+}
+
+func (m *MockDiscordSession) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	if ch, ok := m.channels[channelID]; ok {
+		return ch, nil
+	}
+	return nil, errors.New("unknown channel")
+}
+
+func (m *MockDiscordSession) UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	return &discordgo.Channel{ID: "dm-" + recipientID, Type: discordgo.ChannelTypeDM}, nil
+}
+
+func (m *MockDiscordSession) MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error {
+	m.reactions = append(m.reactions, "+"+channelID+"/"+messageID+"/"+emojiID)
+	return nil
+}
+
+func (m *MockDiscordSession) MessageReactionRemove(channelID, messageID, emojiID, userID string, options ...discordgo.RequestOption) error {
+	m.reactions = append(m.reactions, "-"+channelID+"/"+messageID+"/"+emojiID+"/"+userID)
+	return nil
+}
+
+func (m *MockDiscordSession) ThreadStart(channelID, name string, typ discordgo.ChannelType, archiveDuration int, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	m.threadsStarted++
+	return &discordgo.Channel{ID: "thread-" + name, ParentID: channelID, Type: typ}, nil
+}
+
+func (m *MockDiscordSession) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error {
+	m.responses = append(m.responses, resp)
+	return nil
+}
+
+func (m *MockDiscordSession) ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	m.registeredAppID = appID
+	m.commands = commands
+	return commands, nil
+}
+
 // Helper to simulate receiving a message through the mock session
 func (m *MockDiscordSession) SimulateMessage(s *discordgo.Session, msg *discordgo.MessageCreate) {
 	if m.handler == nil {
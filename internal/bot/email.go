@@ -0,0 +1,864 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/proxy"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// Email adapter defaults
+const (
+	// DefaultEmailMailbox is the mailbox watched for incoming prompts
+	DefaultEmailMailbox = "INBOX"
+
+	emailIdleRefresh      = 25 * time.Minute // RFC 2177 recommends re-issuing IDLE before 29 minutes
+	emailAttachmentName   = "response.txt"
+	emailInlinePreviewLen = 2000
+	emailDirectSubject    = "clibot notification"
+	maxEmailThreads       = 500
+)
+
+var (
+	// emailQuoteHeaderPattern matches reply headers such as "On Mon, Alice wrote:"
+	emailQuoteHeaderPattern = regexp.MustCompile(`(?i)^on .+wrote:\s*$`)
+	// emailHTMLTagPattern matches HTML tags for html-only messages
+	emailHTMLTagPattern = regexp.MustCompile(`<[^>]*>`)
+)
+
+// EmailConfig holds the IMAP/SMTP settings for the email bot
+type EmailConfig struct {
+	IMAPServer   string // IMAP host:port (993 implicit TLS, 143 STARTTLS)
+	SMTPServer   string // SMTP host:port (465 implicit TLS, otherwise STARTTLS)
+	Username     string // Login for both IMAP and SMTP
+	Password     string // Password or app password
+	FromAddress  string // Sender address for replies (default: Username)
+	Mailbox      string // Mailbox to watch (default: INBOX)
+	SubjectToken string // Shared secret required in the subject; when empty DKIM/SPF must pass
+}
+
+// inboundEmail is a parsed incoming message
+type inboundEmail struct {
+	From        string
+	Subject     string
+	MessageID   string
+	InReplyTo   string
+	References  []string
+	AuthResults string
+	Body        string
+}
+
+// emailThread tracks reply state for one thread (channel)
+type emailThread struct {
+	To         string
+	Subject    string
+	References []string
+	Sent       map[string]bool // Message-IDs of our replies in this thread
+	LastUsed   time.Time       // Last message in or out, for evicting idle threads
+}
+
+// sendMailFunc matches smtp.SendMail so delivery can be replaced in tests
+type sendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailBot implements BotAdapter using IMAP IDLE for inbound and SMTP for outbound mail
+type EmailBot struct {
+	DefaultTypingIndicator
+	disconnectSignal
+	mu             sync.RWMutex
+	config         EmailConfig
+	threads        map[string]*emailThread // channel (sender and thread root Message-ID) -> thread state
+	messageHandler func(BotMessage)
+	sendMail       sendMailFunc
+	ctx            context.Context
+	cancel         context.CancelFunc
+	proxyMgr       proxy.Manager
+}
+
+// NewEmailBot creates a new email bot instance
+func NewEmailBot(config EmailConfig) *EmailBot {
+	if config.Mailbox == "" {
+		config.Mailbox = DefaultEmailMailbox
+	}
+	if config.FromAddress == "" {
+		config.FromAddress = config.Username
+	}
+
+	return &EmailBot{
+		config:   config,
+		threads:  make(map[string]*emailThread),
+		sendMail: sendMailTLS,
+	}
+}
+
+// SetProxyManager sets the proxy manager
+// IMAP and SMTP are not proxied; the manager is kept for interface parity
+func (e *EmailBot) SetProxyManager(mgr proxy.Manager) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.proxyMgr = mgr
+}
+
+// Start connects to the IMAP server and begins watching the mailbox
+func (e *EmailBot) Start(messageHandler func(BotMessage)) error {
+	e.SetMessageHandler(messageHandler)
+
+	if e.config.IMAPServer == "" || e.config.SMTPServer == "" {
+		return fmt.Errorf("email bot requires imap_server and smtp_server")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"imap_server": e.config.IMAPServer,
+		"smtp_server": e.config.SMTPServer,
+		"username":    e.config.Username,
+		"mailbox":     e.config.Mailbox,
+		"auth_mode":   e.authMode(),
+	}).Info("starting-email-bot")
+
+	// Connect once synchronously so configuration errors surface at startup
+	c, err := e.connectIMAP()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	ctx := e.ctx
+	e.mu.Unlock()
+
+	go e.watchLoop(ctx, c)
+
+	logger.Info("email-bot-started")
+	return nil
+}
+
+// authMode describes how senders are authenticated
+func (e *EmailBot) authMode() string {
+	if e.config.SubjectToken != "" {
+		return "subject-token"
+	}
+	return "dkim-spf"
+}
+
+// connectIMAP dials, logs in and selects the watched mailbox
+func (e *EmailBot) connectIMAP() (*client.Client, error) {
+	var c *client.Client
+	var err error
+
+	if strings.HasSuffix(e.config.IMAPServer, ":143") {
+		c, err = client.Dial(e.config.IMAPServer)
+		if err == nil {
+			host, _, _ := net.SplitHostPort(e.config.IMAPServer)
+			err = c.StartTLS(&tls.Config{ServerName: host})
+		}
+	} else {
+		c, err = client.DialTLS(e.config.IMAPServer, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to IMAP server: %w", err)
+	}
+
+	if err := c.Login(e.config.Username, e.config.Password); err != nil {
+		c.Logout()
+		return nil, fmt.Errorf("IMAP login failed: %w", err)
+	}
+
+	if _, err := c.Select(e.config.Mailbox, false); err != nil {
+		c.Logout()
+		return nil, fmt.Errorf("failed to select mailbox %s: %w", e.config.Mailbox, err)
+	}
+
+	return c, nil
+}
+
+// watchLoop processes unseen mail until ctx ends and reports a dropped IMAP
+// session, so the supervisor reconnects with backoff
+func (e *EmailBot) watchLoop(ctx context.Context, c *client.Client) {
+	updates := make(chan client.Update, 16)
+	c.Updates = updates
+	err := e.watchMailbox(ctx, c, updates)
+	c.Logout()
+	close(updates)
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err == nil {
+		err = fmt.Errorf("connection closed")
+	}
+	logger.WithField("error", err).Warn("email-imap-connection-lost")
+	e.signalDisconnect(fmt.Errorf("imap: %w", err))
+}
+
+// watchMailbox fetches unseen messages, then waits in IDLE for new mail
+func (e *EmailBot) watchMailbox(ctx context.Context, c *client.Client, updates <-chan client.Update) error {
+	wake := make(chan struct{}, 1)
+
+	// Drain updates continuously; the client blocks if nobody reads them
+	go func() {
+		for update := range updates {
+			if _, ok := update.(*client.MailboxUpdate); ok {
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	for {
+		if err := e.fetchUnseen(c); err != nil {
+			return err
+		}
+
+		stop := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			done <- c.Idle(stop, nil)
+		}()
+
+		select {
+		case <-ctx.Done():
+			close(stop)
+			<-done
+			return ctx.Err()
+		case <-wake:
+			close(stop)
+			if err := <-done; err != nil {
+				return err
+			}
+		case <-time.After(emailIdleRefresh):
+			close(stop)
+			if err := <-done; err != nil {
+				return err
+			}
+		case err := <-done:
+			if err == nil {
+				err = fmt.Errorf("IDLE ended unexpectedly")
+			}
+			return err
+		}
+	}
+}
+
+// fetchUnseen fetches all unseen messages and dispatches them
+// Fetching BODY[] without PEEK marks messages as \Seen on the server
+func (e *EmailBot) fetchUnseen(c *client.Client) error {
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return fmt.Errorf("IMAP search failed: %w", err)
+	}
+	if len(uids) == 0 {
+		return nil
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uids...)
+	section := &imap.BodySectionName{}
+	items := []imap.FetchItem{section.FetchItem(), imap.FetchUid}
+
+	messages := make(chan *imap.Message, len(uids))
+	if err := c.UidFetch(seqset, items, messages); err != nil {
+		return fmt.Errorf("IMAP fetch failed: %w", err)
+	}
+
+	for msg := range messages {
+		literal := msg.GetBody(section)
+		if literal == nil {
+			continue
+		}
+		raw, err := io.ReadAll(literal)
+		if err != nil {
+			logger.WithField("error", err).Warn("failed-to-read-email-body")
+			continue
+		}
+		e.handleRawEmail(raw)
+	}
+
+	return nil
+}
+
+// handleRawEmail parses, authenticates and dispatches one raw RFC 5322 message
+func (e *EmailBot) handleRawEmail(raw []byte) {
+	email, err := parseInboundEmail(raw)
+	if err != nil {
+		logger.WithField("error", err).Warn("failed-to-parse-email")
+		return
+	}
+
+	// Never react to our own replies (e.g. when sending to the watched mailbox)
+	if strings.EqualFold(email.From, e.config.FromAddress) {
+		return
+	}
+
+	if !e.authenticateSender(email) {
+		logger.WithFields(logrus.Fields{
+			"from":      email.From,
+			"subject":   email.Subject,
+			"auth_mode": e.authMode(),
+		}).Warn("email-sender-authentication-failed")
+		return
+	}
+
+	channel := emailThreadKey(email.From, emailThreadRoot(email))
+	subject := strings.TrimSpace(email.Subject)
+	if e.config.SubjectToken != "" {
+		// Keep the secret out of the reply subject
+		subject = strings.TrimSpace(strings.ReplaceAll(subject, e.config.SubjectToken, ""))
+	}
+
+	references := append([]string{}, email.References...)
+	if email.MessageID != "" {
+		references = append(references, email.MessageID)
+	}
+
+	// Threads are keyed by sender, so a message can only continue the sender's
+	// own threads and never redirects replies to someone else
+	e.mu.Lock()
+	if thread, ok := e.threads[channel]; ok {
+		thread.References = references
+		thread.LastUsed = time.Now()
+	} else {
+		if len(e.threads) >= maxEmailThreads {
+			// Drop the least recently used thread to bound memory; replies there start a new thread
+			e.evictOldestThread()
+		}
+		e.threads[channel] = &emailThread{
+			To:         email.From,
+			Subject:    subject,
+			References: references,
+			LastUsed:   time.Now(),
+		}
+	}
+	e.mu.Unlock()
+
+	logger.WithFields(logrus.Fields{
+		"platform": "email",
+		"from":     email.From,
+		"channel":  channel,
+		"subject":  subject,
+	}).Info("received-email-message")
+
+	if email.Body == "" {
+		return
+	}
+
+	if handler := e.GetMessageHandler(); handler != nil {
+		handler(BotMessage{
+			Platform:  "email",
+			UserID:    email.From,
+			Channel:   channel,
+			MessageID: email.MessageID,
+			Content:   email.Body,
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// evictOldestThread removes the least recently used thread; callers hold e.mu
+func (e *EmailBot) evictOldestThread() {
+	var oldest string
+	var oldestTime time.Time
+	for k, thread := range e.threads {
+		if oldest == "" || thread.LastUsed.Before(oldestTime) {
+			oldest, oldestTime = k, thread.LastUsed
+		}
+	}
+	delete(e.threads, oldest)
+}
+
+// authenticateSender enforces the subject token, or DKIM/SPF when no token is configured
+// Replies to our own messages carry no token, since it is kept out of reply subjects
+func (e *EmailBot) authenticateSender(email *inboundEmail) bool {
+	if e.config.SubjectToken != "" {
+		return strings.Contains(email.Subject, e.config.SubjectToken) || e.repliesToBot(email)
+	}
+	return authResultsPass(email.AuthResults, email.From)
+}
+
+// repliesToBot reports whether a message answers one of our replies in the
+// sender's own thread; only the recipient of that reply knows its Message-ID
+func (e *EmailBot) repliesToBot(email *inboundEmail) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	thread, ok := e.threads[emailThreadKey(email.From, emailThreadRoot(email))]
+	if !ok {
+		return false
+	}
+	if thread.Sent[email.InReplyTo] {
+		return true
+	}
+	for _, id := range email.References {
+		if thread.Sent[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// authResultsPass reports whether an Authentication-Results header shows a passing
+// DKIM signature or SPF check aligned with the sender's domain
+func authResultsPass(header, from string) bool {
+	at := strings.LastIndex(from, "@")
+	if header == "" || at < 0 {
+		return false
+	}
+	domain := strings.ToLower(from[at+1:])
+
+	for _, part := range strings.Split(header, ";") {
+		fields := strings.Fields(strings.ToLower(strings.TrimSpace(part)))
+		if len(fields) == 0 {
+			continue
+		}
+
+		var key string
+		switch fields[0] {
+		case "dkim=pass":
+			key = "header.d="
+		case "spf=pass":
+			key = "smtp.mailfrom="
+		default:
+			continue
+		}
+
+		for _, prop := range fields[1:] {
+			if prop == "header.i=@"+domain {
+				return true
+			}
+			if !strings.HasPrefix(prop, key) {
+				continue
+			}
+			value := strings.TrimPrefix(prop, key)
+			if i := strings.LastIndex(value, "@"); i >= 0 {
+				value = value[i+1:]
+			}
+			if value == domain {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// emailThreadRoot returns the root Message-ID of the thread a message belongs to
+func emailThreadRoot(email *inboundEmail) string {
+	if len(email.References) > 0 {
+		return email.References[0]
+	}
+	if email.InReplyTo != "" {
+		return email.InReplyTo
+	}
+	return email.MessageID
+}
+
+// emailThreadKey returns the channel of a sender's thread
+func emailThreadKey(from, root string) string {
+	return strings.ToLower(from) + " " + root
+}
+
+// parseInboundEmail parses headers and the plain-text body of a raw message
+func parseInboundEmail(raw []byte) (*inboundEmail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid From header: %w", err)
+	}
+
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	body, err := extractEmailText(textproto.MIMEHeader(msg.Header), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the topmost Authentication-Results header is trusted: it is added by
+	// our own receiving server, anything below may come from the sender
+	authResults := ""
+	if values := msg.Header["Authentication-Results"]; len(values) > 0 {
+		authResults = values[0]
+	}
+
+	return &inboundEmail{
+		From:        strings.ToLower(from.Address),
+		Subject:     subject,
+		MessageID:   strings.TrimSpace(msg.Header.Get("Message-Id")),
+		InReplyTo:   strings.TrimSpace(msg.Header.Get("In-Reply-To")),
+		References:  strings.Fields(msg.Header.Get("References")),
+		AuthResults: authResults,
+		Body:        stripQuotedReply(body),
+	}, nil
+}
+
+// extractEmailText returns the text/plain content of a message, falling back to
+// tag-stripped text/html
+func extractEmailText(header textproto.MIMEHeader, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		var htmlFallback string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			if strings.HasPrefix(partType, "multipart/") {
+				text, err := extractEmailText(part.Header, part)
+				if err == nil && text != "" {
+					return text, nil
+				}
+				continue
+			}
+
+			disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+			if disposition == "attachment" {
+				continue
+			}
+
+			data, err := decodeTransferEncoding(part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", err
+			}
+			switch partType {
+			case "text/plain", "":
+				return string(data), nil
+			case "text/html":
+				if htmlFallback == "" {
+					htmlFallback = emailHTMLTagPattern.ReplaceAllString(string(data), "")
+				}
+			}
+		}
+		return htmlFallback, nil
+	}
+
+	data, err := decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+	if err != nil {
+		return "", err
+	}
+	if mediaType == "text/html" {
+		return emailHTMLTagPattern.ReplaceAllString(string(data), ""), nil
+	}
+	return string(data), nil
+}
+
+// decodeTransferEncoding decodes quoted-printable and base64 bodies
+func decodeTransferEncoding(encoding string, r io.Reader) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return io.ReadAll(quotedprintable.NewReader(r))
+	case "base64":
+		return io.ReadAll(base64.NewDecoder(base64.StdEncoding, r))
+	default:
+		return io.ReadAll(r)
+	}
+}
+
+// stripQuotedReply removes quoted history and signatures from a reply body
+func stripQuotedReply(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	var kept []string
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if line == "-- " || emailQuoteHeaderPattern.MatchString(trimmed) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// SendMessage replies to the thread identified by channel
+// Long responses are attached as a text file with a preview in the body
+func (e *EmailBot) SendMessage(channel, message string) error {
+	e.mu.RLock()
+	thread, exists := e.threads[channel]
+	var snapshot emailThread
+	if exists {
+		// Copy under the lock: concurrent sends append to References
+		snapshot = emailThread{
+			To:         thread.To,
+			Subject:    thread.Subject,
+			References: append([]string(nil), thread.References...),
+		}
+	}
+	e.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("unknown email thread: %s", channel)
+	}
+
+	msg, messageID, err := e.buildReply(&snapshot, message)
+	if err != nil {
+		return err
+	}
+
+	auth := smtp.PlainAuth("", e.config.Username, e.config.Password, smtpHost(e.config.SMTPServer))
+	if err := e.sendMail(e.config.SMTPServer, auth, e.config.FromAddress, []string{snapshot.To}, msg); err != nil {
+		logger.WithFields(logrus.Fields{
+			"to":    snapshot.To,
+			"error": err,
+		}).Error("failed-to-send-email")
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	e.mu.Lock()
+	thread.References = append(thread.References, messageID)
+	if thread.Sent == nil {
+		thread.Sent = make(map[string]bool)
+	}
+	thread.Sent[messageID] = true
+	thread.LastUsed = time.Now()
+	e.mu.Unlock()
+
+	logger.WithFields(logrus.Fields{
+		"to":      snapshot.To,
+		"channel": channel,
+		"length":  len(message),
+	}).Info("message-sent-to-email")
+	return nil
+}
+
+// SendDirectMessage sends a new message, outside any thread, to the user's address
+func (e *EmailBot) SendDirectMessage(userID, message string) error {
+	msg, _, err := e.buildReply(&emailThread{To: userID, Subject: emailDirectSubject}, message)
+	if err != nil {
+		return err
+	}
+
+	auth := smtp.PlainAuth("", e.config.Username, e.config.Password, smtpHost(e.config.SMTPServer))
+	if err := e.sendMail(e.config.SMTPServer, auth, e.config.FromAddress, []string{userID}, msg); err != nil {
+		logger.WithFields(logrus.Fields{
+			"to":    userID,
+			"error": err,
+		}).Error("failed-to-send-email")
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"to":     userID,
+		"length": len(message),
+	}).Info("direct-message-sent-to-email")
+	return nil
+}
+
+// buildReply renders an RFC 5322 reply for a thread and returns it with its Message-ID
+// A thread without references yields a new message rather than a reply
+func (e *EmailBot) buildReply(thread *emailThread, message string) ([]byte, string, error) {
+	messageID := generateMessageID(e.config.FromAddress)
+
+	subject := thread.Subject
+	if len(thread.References) > 0 && !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", e.config.FromAddress)
+	header("To", thread.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	if n := len(thread.References); n > 0 {
+		header("In-Reply-To", thread.References[n-1])
+		header("References", strings.Join(thread.References, " "))
+	}
+	header("MIME-Version", "1.0")
+
+	if len(message) <= constants.MaxEmailInlineLength {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, message); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), messageID, nil
+	}
+
+	writer := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+	buf.WriteString("\r\n")
+
+	previewLen := emailInlinePreviewLen
+	for previewLen > 0 && !utf8.RuneStart(message[previewLen]) {
+		previewLen--
+	}
+	preview := message[:previewLen] +
+		fmt.Sprintf("\n\n... (%d characters, full response attached as %s)", len(message), emailAttachmentName)
+	textPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if err := writeQuotedPrintable(textPart, preview); err != nil {
+		return nil, "", err
+	}
+
+	attachment, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8; name=" + emailAttachmentName},
+		"Content-Disposition":       {"attachment; filename=" + emailAttachmentName},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte(message))
+	for len(encoded) > 76 {
+		io.WriteString(attachment, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	io.WriteString(attachment, encoded+"\r\n")
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), messageID, nil
+}
+
+// writeQuotedPrintable writes text with quoted-printable encoding
+func writeQuotedPrintable(w io.Writer, text string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, text); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// generateMessageID returns a unique Message-ID in the sender's domain
+func generateMessageID(from string) string {
+	domain := "clibot.local"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	random := make([]byte, 8)
+	rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
+
+// smtpHost returns the host part of an SMTP address
+func smtpHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// sendMailTLS sends mail via implicit TLS on port 465, otherwise via smtp.SendMail (STARTTLS)
+func sendMailTLS(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	if !strings.HasSuffix(addr, ":465") {
+		return smtp.SendMail(addr, a, from, to, msg)
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: smtpHost(addr)})
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, smtpHost(addr))
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if err := c.Auth(a); err != nil {
+		return err
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Stop stops watching the mailbox and logs out
+func (e *EmailBot) Stop() error {
+	e.mu.Lock()
+	cancel := e.cancel
+	e.cancel = nil
+	e.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	logger.Info("email-bot-stopped")
+	return nil
+}
+
+// SetMessageHandler sets the message handler in a thread-safe manner
+func (e *EmailBot) SetMessageHandler(handler func(BotMessage)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.messageHandler = handler
+}
+
+// GetMessageHandler gets the message handler in a thread-safe manner
+func (e *EmailBot) GetMessageHandler() func(BotMessage) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.messageHandler
+}
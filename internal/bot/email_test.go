@@ -0,0 +1,381 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+const testEmailFirst = "From: Alice <Alice@Example.com>\r\n" +
+	"To: clibot@bot.example\r\n" +
+	"Subject: [tok123] fix the tests\r\n" +
+	"Message-ID: <root@example.com>\r\n" +
+	"Authentication-Results: mx.bot.example; dkim=pass header.d=example.com; spf=pass smtp.mailfrom=alice@example.com\r\n" +
+	"Authentication-Results: attacker; dkim=pass header.d=evil.com\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Please run go test\r\n"
+
+const testEmailReply = "From: alice@example.com\r\n" +
+	"Subject: Re: fix the tests\r\n" +
+	"Message-ID: <reply2@example.com>\r\n" +
+	"In-Reply-To: <bot1@bot.example>\r\n" +
+	"References: <root@example.com> <bot1@bot.example>\r\n" +
+	"Content-Type: multipart/alternative; boundary=XYZ\r\n" +
+	"\r\n" +
+	"--XYZ\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>ignored html</p>\r\n" +
+	"--XYZ\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"now commit =3D done\r\n" +
+	"\r\n" +
+	"On Mon, clibot wrote:\r\n" +
+	"> previous answer\r\n" +
+	"--XYZ--\r\n"
+
+func TestParseInboundEmail(t *testing.T) {
+	email, err := parseInboundEmail([]byte(testEmailFirst))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", email.From)
+	assert.Equal(t, "[tok123] fix the tests", email.Subject)
+	assert.Equal(t, "<root@example.com>", email.MessageID)
+	assert.Equal(t, "Please run go test", email.Body)
+	// Only the topmost Authentication-Results header is kept
+	assert.Contains(t, email.AuthResults, "mx.bot.example")
+	assert.Equal(t, "<root@example.com>", emailThreadRoot(email))
+}
+
+func TestParseInboundEmail_MultipartReply(t *testing.T) {
+	email, err := parseInboundEmail([]byte(testEmailReply))
+	assert.NoError(t, err)
+	assert.Equal(t, "now commit = done", email.Body)
+	assert.Equal(t, []string{"<root@example.com>", "<bot1@bot.example>"}, email.References)
+	assert.Equal(t, "<root@example.com>", emailThreadRoot(email))
+}
+
+func TestParseInboundEmail_HTMLOnly(t *testing.T) {
+	raw := "From: bob@example.com\r\nContent-Type: text/html\r\n\r\n<div>hello <b>world</b></div>\r\n"
+	email, err := parseInboundEmail([]byte(raw))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", email.Body)
+}
+
+func TestParseInboundEmail_InvalidFrom(t *testing.T) {
+	_, err := parseInboundEmail([]byte("Subject: hi\r\n\r\nbody"))
+	assert.Error(t, err)
+}
+
+func TestAuthResultsPass(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		from   string
+		want   bool
+	}{
+		{"dkim pass aligned", "mx; dkim=pass header.d=example.com", "a@example.com", true},
+		{"dkim pass header.i", "mx; dkim=pass header.i=@example.com", "a@example.com", true},
+		{"spf pass aligned", "mx; spf=pass smtp.mailfrom=a@example.com", "a@example.com", true},
+		{"dkim pass other domain", "mx; dkim=pass header.d=evil.com", "a@example.com", false},
+		{"dkim fail", "mx; dkim=fail header.d=example.com", "a@example.com", false},
+		{"spf softfail", "mx; spf=softfail smtp.mailfrom=a@example.com", "a@example.com", false},
+		{"empty header", "", "a@example.com", false},
+		{"no at sign", "mx; dkim=pass header.d=example.com", "nobody", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, authResultsPass(tt.header, tt.from))
+		})
+	}
+}
+
+func TestStripQuotedReply(t *testing.T) {
+	body := "answer\r\n> quoted\r\nmore\r\n-- \r\nsignature"
+	assert.Equal(t, "answer\nmore", stripQuotedReply(body))
+}
+
+func TestEmailBot_HandleRawEmail_Authentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		raw        string
+		dispatched bool
+	}{
+		{"dkim pass without token", "", testEmailFirst, true},
+		{"token present", "[tok123]", testEmailFirst, true},
+		{"token missing", "[other]", testEmailFirst, false},
+		{"no auth results", "", testEmailReply, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEmailBot(EmailConfig{Username: "clibot@bot.example", SubjectToken: tt.token})
+			var received []BotMessage
+			e.SetMessageHandler(func(msg BotMessage) {
+				received = append(received, msg)
+			})
+
+			e.handleRawEmail([]byte(tt.raw))
+
+			if !tt.dispatched {
+				assert.Empty(t, received)
+				return
+			}
+			assert.Len(t, received, 1)
+			assert.Equal(t, "email", received[0].Platform)
+			assert.Equal(t, "alice@example.com", received[0].UserID)
+			assert.Equal(t, "alice@example.com <root@example.com>", received[0].Channel)
+
+			thread := e.threads["alice@example.com <root@example.com>"]
+			assert.NotNil(t, thread)
+			if tt.token != "" {
+				// The secret token is not echoed back in replies
+				assert.NotContains(t, thread.Subject, tt.token)
+			}
+		})
+	}
+}
+
+func TestEmailBot_HandleRawEmail_IgnoresOwnMail(t *testing.T) {
+	e := NewEmailBot(EmailConfig{Username: "alice@example.com"})
+	called := false
+	e.SetMessageHandler(func(BotMessage) { called = true })
+
+	e.handleRawEmail([]byte(testEmailFirst))
+	assert.False(t, called)
+}
+
+func TestEmailBot_SendMessage_Threading(t *testing.T) {
+	e := NewEmailBot(EmailConfig{
+		SMTPServer: "smtp.bot.example:587",
+		Username:   "clibot@bot.example",
+	})
+	e.SetMessageHandler(func(BotMessage) {})
+	e.handleRawEmail([]byte(testEmailFirst))
+
+	var sent []byte
+	var sentTo []string
+	e.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "smtp.bot.example:587", addr)
+		assert.Equal(t, "clibot@bot.example", from)
+		sentTo = to
+		sent = msg
+		return nil
+	}
+
+	assert.NoError(t, e.SendMessage("alice@example.com <root@example.com>", "all tests pass"))
+	assert.Equal(t, []string{"alice@example.com"}, sentTo)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(sent))
+	assert.NoError(t, err)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.Equal(t, "Re: [tok123] fix the tests", subject)
+	assert.Equal(t, "<root@example.com>", msg.Header.Get("In-Reply-To"))
+	assert.Equal(t, "<root@example.com>", msg.Header.Get("References"))
+	body, _ := io.ReadAll(msg.Body)
+	assert.Contains(t, string(body), "all tests pass")
+
+	// Our reply is appended to the thread references for the next message
+	assert.Len(t, e.threads["alice@example.com <root@example.com>"].References, 2)
+
+	assert.Error(t, e.SendMessage("<unknown@example.com>", "hi"))
+}
+
+func TestEmailBot_SendDirectMessage(t *testing.T) {
+	e := NewEmailBot(EmailConfig{
+		SMTPServer: "smtp.bot.example:587",
+		Username:   "clibot@bot.example",
+	})
+	var sent []byte
+	var sentTo []string
+	e.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sentTo = to
+		sent = msg
+		return nil
+	}
+
+	assert.NoError(t, e.SendDirectMessage("admin@example.com", "bot down"))
+	assert.Equal(t, []string{"admin@example.com"}, sentTo)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(sent))
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@example.com", msg.Header.Get("To"))
+	assert.Equal(t, emailDirectSubject, msg.Header.Get("Subject"))
+	assert.Empty(t, msg.Header.Get("In-Reply-To"))
+	var _ DirectMessager = e
+}
+
+func TestEmailBot_SubjectTokenRoundTrip(t *testing.T) {
+	e := NewEmailBot(EmailConfig{
+		SMTPServer:   "smtp.bot.example:587",
+		Username:     "clibot@bot.example",
+		SubjectToken: "[tok123]",
+	})
+	var received []BotMessage
+	e.SetMessageHandler(func(msg BotMessage) {
+		received = append(received, msg)
+	})
+	var sent []byte
+	e.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = msg
+		return nil
+	}
+
+	e.handleRawEmail([]byte(testEmailFirst))
+	assert.Len(t, received, 1)
+	channel := received[0].Channel
+
+	assert.NoError(t, e.SendMessage(channel, "which package?"))
+	reply, err := mail.ReadMessage(bytes.NewReader(sent))
+	assert.NoError(t, err)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(reply.Header.Get("Subject"))
+	assert.NotContains(t, subject, "[tok123]")
+	botID := reply.Header.Get("Message-ID")
+
+	userReply := func(from, inReplyTo string) string {
+		return "From: " + from + "\r\n" +
+			"Subject: " + subject + "\r\n" +
+			"Message-ID: <reply2@example.com>\r\n" +
+			"In-Reply-To: " + inReplyTo + "\r\n" +
+			"References: <root@example.com> " + inReplyTo + "\r\n" +
+			"\r\n" +
+			"internal/bot\r\n"
+	}
+
+	// Replies without the token are only accepted when they answer our message
+	e.handleRawEmail([]byte(userReply("alice@example.com", "<guessed@bot.example>")))
+	e.handleRawEmail([]byte(userReply("mallory@evil.com", botID)))
+	assert.Len(t, received, 1)
+
+	e.handleRawEmail([]byte(userReply("alice@example.com", botID)))
+	assert.Len(t, received, 2)
+	assert.Equal(t, channel, received[1].Channel)
+	assert.Equal(t, "internal/bot", received[1].Content)
+}
+
+func TestEmailBot_HandleRawEmail_ThreadPerSender(t *testing.T) {
+	e := NewEmailBot(EmailConfig{Username: "clibot@bot.example"})
+	var received []BotMessage
+	e.SetMessageHandler(func(msg BotMessage) {
+		received = append(received, msg)
+	})
+
+	e.handleRawEmail([]byte(testEmailFirst))
+	// Another sender referencing Alice's thread gets a thread of their own
+	e.handleRawEmail([]byte("From: mallory@evil.com\r\n" +
+		"Subject: Re: fix the tests\r\n" +
+		"Message-ID: <m1@evil.com>\r\n" +
+		"References: <root@example.com>\r\n" +
+		"Authentication-Results: mx.bot.example; dkim=pass header.d=evil.com\r\n" +
+		"\r\n" +
+		"rm -rf\r\n"))
+
+	assert.Len(t, received, 2)
+	assert.Equal(t, "mallory@evil.com <root@example.com>", received[1].Channel)
+	assert.Equal(t, "alice@example.com", e.threads["alice@example.com <root@example.com>"].To)
+	assert.Equal(t, "mallory@evil.com", e.threads["mallory@evil.com <root@example.com>"].To)
+}
+
+func TestEmailBot_HandleRawEmail_EvictsLeastRecentlyUsedThread(t *testing.T) {
+	e := NewEmailBot(EmailConfig{Username: "clibot@bot.example"})
+	e.SetMessageHandler(func(BotMessage) {})
+	now := time.Now()
+	for i := 0; i < maxEmailThreads; i++ {
+		e.threads[fmt.Sprintf("t%d", i)] = &emailThread{LastUsed: now.Add(-time.Duration(i+1) * time.Minute)}
+	}
+	e.threads["t1"].LastUsed = now.Add(-24 * time.Hour)
+
+	e.handleRawEmail([]byte(testEmailFirst))
+
+	assert.Len(t, e.threads, maxEmailThreads)
+	assert.NotContains(t, e.threads, "t1")
+	assert.Contains(t, e.threads, "t0")
+	assert.Contains(t, e.threads, fmt.Sprintf("t%d", maxEmailThreads-1))
+	assert.Contains(t, e.threads, "alice@example.com <root@example.com>")
+}
+
+func TestEmailBot_SendMessage_LongOutputAttached(t *testing.T) {
+	e := NewEmailBot(EmailConfig{SMTPServer: "smtp.bot.example:587", Username: "clibot@bot.example"})
+	e.threads["<t@example.com>"] = &emailThread{To: "alice@example.com", Subject: "long"}
+
+	var sent []byte
+	e.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = msg
+		return nil
+	}
+
+	long := strings.Repeat("x", constants.MaxEmailInlineLength+1)
+	assert.NoError(t, e.SendMessage("<t@example.com>", long))
+
+	msg, err := mail.ReadMessage(bytes.NewReader(sent))
+	assert.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var parts []textproto.MIMEHeader
+	var attachment []byte
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		parts = append(parts, part.Header)
+		if part.FileName() == emailAttachmentName {
+			attachment, _ = decodeTransferEncoding(part.Header.Get("Content-Transfer-Encoding"), part)
+		}
+	}
+	assert.Len(t, parts, 2)
+	assert.Equal(t, long, string(attachment))
+}
+
+func TestEmailBot_BuildReply_PreviewOnRuneBoundary(t *testing.T) {
+	e := NewEmailBot(EmailConfig{Username: "clibot@bot.example"})
+	// The preview length falls inside a two-byte rune
+	long := "x" + strings.Repeat("é", constants.MaxEmailInlineLength)
+	raw, _, err := e.buildReply(&emailThread{To: "alice@example.com", Subject: "utf8"}, long)
+	assert.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.NoError(t, err)
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	part, err := multipart.NewReader(msg.Body, params["boundary"]).NextPart()
+	assert.NoError(t, err)
+	preview, err := decodeTransferEncoding(part.Header.Get("Content-Transfer-Encoding"), part)
+	assert.NoError(t, err)
+	assert.True(t, utf8.Valid(preview))
+	assert.True(t, strings.HasPrefix(string(preview), long[:emailInlinePreviewLen-1]))
+	assert.False(t, strings.HasPrefix(string(preview), long[:emailInlinePreviewLen+1]))
+}
+
+func TestEmailBot_StartRequiresServers(t *testing.T) {
+	e := NewEmailBot(EmailConfig{Username: "clibot@bot.example"})
+	assert.Error(t, e.Start(func(BotMessage) {}))
+	assert.NoError(t, e.Stop())
+}
+
+func TestNewEmailBot_Defaults(t *testing.T) {
+	e := NewEmailBot(EmailConfig{Username: "clibot@bot.example"})
+	assert.Equal(t, DefaultEmailMailbox, e.config.Mailbox)
+	assert.Equal(t, "clibot@bot.example", e.config.FromAddress)
+	assert.Equal(t, "dkim-spf", e.authMode())
+	assert.False(t, e.SupportsTypingIndicator())
+}
@@ -4,6 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,13 +18,35 @@ import (
 	lark "github.com/larksuite/oapi-sdk-go/v3"
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
 	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher/callback"
 	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
 	"github.com/larksuite/oapi-sdk-go/v3/ws"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	feishuCollapseLines = 30       // Sections longer than this are collapsed in cards
+	feishuPreviewLines  = 10       // Lines of a collapsed text section kept visible
+	feishuMaxImageSize  = 20 << 20 // Maximum inbound image download size (bytes)
+	feishuCardActionKey = "key"    // Card button value field carrying the engine key word
+)
+
+// feishuControlKeys are the card buttons; keys are engine key words (see watchdog.ProcessKeyWords)
+// Approve selects the highlighted option of a CLI permission prompt, Deny dismisses it
+var feishuControlKeys = []struct {
+	label string
+	key   string
+	style string
+}{
+	{"Stop", "ctrlc", "danger"},
+	{"Esc", "esc", "default"},
+	{"Approve", "enter", "primary"},
+	{"Deny", "esc", "default"},
+}
+
 // FeishuBot implements BotAdapter interface for Feishu (Lark) using WebSocket long connection
 type FeishuBot struct {
+	disconnectSignal
 	mu                sync.RWMutex
 	appID             string
 	appSecret         string
@@ -87,6 +114,9 @@ func (f *FeishuBot) Start(messageHandler func(BotMessage)) error {
 	dispatcher.OnP2MessageReceiveV1(func(ctx context.Context, event *larkim.P2MessageReceiveV1) error {
 		return f.handleMessageReceive(ctx, event)
 	})
+	dispatcher.OnP2CardActionTrigger(func(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+		return f.handleCardAction(ctx, event)
+	})
 
 	// Create WebSocket client with proxy support
 	f.mu.Lock()
@@ -113,13 +143,17 @@ func (f *FeishuBot) Start(messageHandler func(BotMessage)) error {
 	wsClient := f.wsClient
 	f.mu.Unlock()
 
-	// Start long connection (this blocks)
+	// Start long connection (this blocks); it only returns once the SDK gives up
+	ctx := f.ctx
 	go func() {
-		if err := wsClient.Start(f.ctx); err != nil {
+		if err := wsClient.Start(ctx); err != nil {
 			logger.WithFields(logrus.Fields{
 				"app_id": f.appID,
 				"error":  err,
 			}).Error("feishu-websocket-connection-failed")
+			if ctx.Err() == nil {
+				f.signalDisconnect(fmt.Errorf("websocket: %w", err))
+			}
 		}
 	}()
 
@@ -164,12 +198,9 @@ func (f *FeishuBot) handleMessageReceive(ctx context.Context, event *larkim.P2Me
 		if ev.Message.ChatType != nil {
 			chatType = *ev.Message.ChatType
 		}
-		// Extract message content (JSON string format)
+		// Extract message content (JSON string format, shape depends on message type)
 		if ev.Message.Content != nil {
-			content = *ev.Message.Content
-			// For text messages, content is like: {"text":"actual message"}
-			// Parse to extract actual text
-			content = extractTextContent(content)
+			content = f.parseContent(messageType, messageID, *ev.Message.Content)
 		}
 	}
 
@@ -211,8 +242,25 @@ func (f *FeishuBot) handleMessageReceive(ctx context.Context, event *larkim.P2Me
 	return nil
 }
 
-// SendMessage sends a message to a Feishu chat
+// SendMessage sends a message to a Feishu chat as an interactive card
 func (f *FeishuBot) SendMessage(chatID, message string) error {
+	_, err := f.send(larkim.ReceiveIdTypeChatId, chatID, message)
+	return err
+}
+
+// SendDirectMessage sends a card to a user's private chat, addressed by open_id
+func (f *FeishuBot) SendDirectMessage(userID, message string) error {
+	_, err := f.send(larkim.ReceiveIdTypeOpenId, userID, message)
+	return err
+}
+
+// SendEditableMessage sends a card and returns its message ID for later patching
+func (f *FeishuBot) SendEditableMessage(chatID, message string) (string, error) {
+	return f.send(larkim.ReceiveIdTypeChatId, chatID, message)
+}
+
+// SendImage uploads a local image and sends it as an image message
+func (f *FeishuBot) SendImage(chatID, imagePath string) error {
 	f.mu.RLock()
 	larkClient := f.larkClient
 	ctx := f.ctx
@@ -222,32 +270,129 @@ func (f *FeishuBot) SendMessage(chatID, message string) error {
 		return fmt.Errorf("feishu client not initialized")
 	}
 
-	if chatID == "" {
-		return fmt.Errorf("chat ID is required for Feishu")
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to open image: %w", err)
 	}
+	defer file.Close()
 
-	// Feishu limit: text message content length
-	const maxFeishuLength = constants.MaxFeishuMessageLength
-	if len(message) > maxFeishuLength {
+	req := larkim.NewCreateImageReqBuilder().
+		Body(larkim.NewCreateImageReqBodyBuilder().
+			ImageType(larkim.ImageTypeMessage).
+			Image(file).
+			Build()).
+		Build()
+	resp, err := larkClient.Im.Image.Create(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to upload image: %w", err)
+	}
+	if !resp.Success() || resp.Data == nil || resp.Data.ImageKey == nil {
 		logger.WithFields(logrus.Fields{
-			"original_length": len(message),
-			"max_length":      maxFeishuLength,
-		}).Info("truncating-message-for-feishu-limit")
-		message = message[:maxFeishuLength]
+			"chat_id": chatID,
+			"code":    resp.Code,
+			"msg":     resp.Msg,
+		}).Error("failed-to-upload-feishu-image-api-error")
+		return fmt.Errorf("API error: code=%d, msg=%s", resp.Code, resp.Msg)
 	}
 
-	// Create message request body
+	_, err = f.create(larkim.ReceiveIdTypeChatId, chatID, larkim.MsgTypeImage, fmt.Sprintf(`{"image_key":"%s"}`, escapeJSONString(*resp.Data.ImageKey)))
+	return err
+}
+
+// EditMessage patches a previously sent card with new content
+func (f *FeishuBot) EditMessage(chatID, messageID, message string) error {
+	f.mu.RLock()
+	larkClient := f.larkClient
+	ctx := f.ctx
+	f.mu.RUnlock()
+
+	if larkClient == nil {
+		return fmt.Errorf("feishu client not initialized")
+	}
+
+	card, err := buildFeishuCard(truncateFeishuMessage(message))
+	if err != nil {
+		return err
+	}
+
+	req := larkim.NewPatchMessageReqBuilder().
+		MessageId(messageID).
+		Body(larkim.NewPatchMessageReqBodyBuilder().Content(card).Build()).
+		Build()
+
+	resp, err := larkClient.Im.Message.Patch(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to patch message %s in chat %s: %w", messageID, chatID, err)
+	}
+	if !resp.Success() {
+		logger.WithFields(logrus.Fields{
+			"chat_id":    chatID,
+			"message_id": messageID,
+			"code":       resp.Code,
+			"msg":        resp.Msg,
+		}).Error("failed-to-patch-feishu-card-api-error")
+		return fmt.Errorf("API error: code=%d, msg=%s", resp.Code, resp.Msg)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"chat_id":    chatID,
+		"message_id": messageID,
+	}).Debug("feishu-card-patched")
+	return nil
+}
+
+// send delivers a message as a card, falling back to plain text when the card is rejected
+// idType tells Feishu how to interpret chatID (chat_id, or open_id for a user)
+func (f *FeishuBot) send(idType, chatID, message string) (string, error) {
+	f.mu.RLock()
+	initialized := f.larkClient != nil
+	f.mu.RUnlock()
+
+	if !initialized {
+		return "", fmt.Errorf("feishu client not initialized")
+	}
+	if chatID == "" {
+		return "", fmt.Errorf("chat ID is required for Feishu")
+	}
+
+	message = truncateFeishuMessage(message)
+
+	card, err := buildFeishuCard(message)
+	if err == nil {
+		messageID, cardErr := f.create(idType, chatID, larkim.MsgTypeInteractive, card)
+		if cardErr == nil {
+			return messageID, nil
+		}
+		err = cardErr
+	}
+	logger.WithFields(logrus.Fields{
+		"chat_id": chatID,
+		"error":   err,
+	}).Warn("feishu-card-failed-falling-back-to-text")
+
 	// For text messages, content format: {"text":"actual content"}
-	contentJSON := fmt.Sprintf(`{"text":"%s"}`, escapeJSONString(message))
+	return f.create(idType, chatID, larkim.MsgTypeText, fmt.Sprintf(`{"text":"%s"}`, escapeJSONString(message)))
+}
+
+// create sends one message of the given type and returns its message ID
+func (f *FeishuBot) create(idType, chatID, msgType, contentJSON string) (string, error) {
+	f.mu.RLock()
+	larkClient := f.larkClient
+	ctx := f.ctx
+	f.mu.RUnlock()
+
+	if larkClient == nil {
+		return "", fmt.Errorf("feishu client not initialized")
+	}
 
 	body := larkim.NewCreateMessageReqBodyBuilder().
 		ReceiveId(chatID).
-		MsgType(larkim.MsgTypeText).
+		MsgType(msgType).
 		Content(contentJSON).
 		Build()
 
 	req := larkim.NewCreateMessageReqBuilder().
-		ReceiveIdType(larkim.ReceiveIdTypeChatId).
+		ReceiveIdType(idType).
 		Body(body).
 		Build()
 
@@ -258,7 +403,7 @@ func (f *FeishuBot) SendMessage(chatID, message string) error {
 			"chat_id": chatID,
 			"error":   err,
 		}).Error("failed-to-send-message-to-feishu")
-		return fmt.Errorf("failed to send message to chat %s: %w", chatID, err)
+		return "", fmt.Errorf("failed to send message to chat %s: %w", chatID, err)
 	}
 
 	if !resp.Success() {
@@ -267,15 +412,86 @@ func (f *FeishuBot) SendMessage(chatID, message string) error {
 			"code":         resp.Code,
 			"msg":          resp.Msg,
 			"request_id":   resp.RequestId,
-			"message":      message,
-			"message_len":  len(message),
+			"msg_type":     msgType,
+			"content_len":  len(contentJSON),
 			"content_json": contentJSON,
 		}).Error("failed-to-send-message-to-feishu-api-error")
-		return fmt.Errorf("API error: code=%d, msg=%s", resp.Code, resp.Msg)
+		return "", fmt.Errorf("API error: code=%d, msg=%s", resp.Code, resp.Msg)
 	}
 
-	logger.WithField("chat_id", chatID).Info("message-sent-to-feishu")
-	return nil
+	messageID := ""
+	if resp.Data != nil && resp.Data.MessageId != nil {
+		messageID = *resp.Data.MessageId
+	}
+
+	logger.WithFields(logrus.Fields{
+		"chat_id":    chatID,
+		"msg_type":   msgType,
+		"message_id": messageID,
+	}).Info("message-sent-to-feishu")
+	return messageID, nil
+}
+
+// truncateFeishuMessage enforces Feishu's message length limit
+func truncateFeishuMessage(message string) string {
+	const maxFeishuLength = constants.MaxFeishuMessageLength
+	if len(message) > maxFeishuLength {
+		logger.WithFields(logrus.Fields{
+			"original_length": len(message),
+			"max_length":      maxFeishuLength,
+		}).Info("truncating-message-for-feishu-limit")
+		message = message[:maxFeishuLength]
+	}
+	return message
+}
+
+// handleCardAction turns a card button click into a BotMessage carrying the button's key word
+func (f *FeishuBot) handleCardAction(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	if event == nil || event.Event == nil || event.Event.Action == nil {
+		return nil, nil
+	}
+
+	ev := event.Event
+	key, _ := ev.Action.Value[feishuCardActionKey].(string)
+	var userID, chatID, messageID string
+	if ev.Operator != nil {
+		userID = ev.Operator.OpenID
+		if userID == "" && ev.Operator.UserID != nil {
+			userID = *ev.Operator.UserID
+		}
+	}
+	if ev.Context != nil {
+		chatID = ev.Context.OpenChatID
+		messageID = ev.Context.OpenMessageID
+	}
+
+	logger.WithFields(logrus.Fields{
+		"platform":   "feishu",
+		"user_id":    userID,
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"key":        key,
+	}).Info("received-feishu-card-action")
+
+	if key == "" || chatID == "" {
+		return nil, nil
+	}
+
+	handler := f.GetMessageHandler()
+	if handler != nil {
+		handler(BotMessage{
+			Platform:  "feishu",
+			UserID:    userID,
+			Channel:   chatID,
+			MessageID: messageID,
+			Content:   key,
+			Timestamp: time.Now(),
+		})
+	}
+
+	return &callback.CardActionTriggerResponse{
+		Toast: &callback.Toast{Type: "info", Content: "Sent: " + key},
+	}, nil
 }
 
 // Stop closes the Feishu WebSocket connection and cleans up resources
@@ -488,3 +704,279 @@ func escapeJSONString(s string) string {
 	}
 	return result
 }
+
+// feishuSection is a run of prose or a fenced code block in an outgoing message
+type feishuSection struct {
+	text string
+	code bool
+	lang string
+}
+
+// splitFeishuSections splits a markdown message into prose and fenced code sections
+// An unterminated fence (e.g. truncated or in-progress output) is closed
+func splitFeishuSections(message string) []feishuSection {
+	var sections []feishuSection
+	var buf []string
+	var current feishuSection
+
+	flush := func() {
+		text := strings.Join(buf, "\n")
+		if current.code || strings.TrimSpace(text) != "" {
+			current.text = text
+			sections = append(sections, current)
+		}
+		buf = nil
+		current = feishuSection{}
+	}
+
+	for _, line := range strings.Split(message, "\n") {
+		fence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		switch {
+		case fence && !current.code:
+			flush()
+			current = feishuSection{code: true, lang: strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "```"))}
+			buf = append(buf, line)
+		case fence && current.code:
+			buf = append(buf, line)
+			flush()
+		default:
+			buf = append(buf, line)
+		}
+	}
+	if current.code {
+		buf = append(buf, "```")
+	}
+	flush()
+	return sections
+}
+
+// buildFeishuCard renders a message as an interactive card (schema 2.0): markdown
+// sections, long sections collapsed into panels, and a row of control-key buttons
+func buildFeishuCard(message string) (string, error) {
+	var elements []map[string]interface{}
+	for _, section := range splitFeishuSections(message) {
+		lines := strings.Split(section.text, "\n")
+		switch {
+		case len(lines) <= feishuCollapseLines:
+			elements = append(elements, feishuMarkdown(section.text))
+		case section.code:
+			title := fmt.Sprintf("Code (%d lines)", len(lines)-2)
+			if section.lang != "" {
+				title = fmt.Sprintf("%s code (%d lines)", section.lang, len(lines)-2)
+			}
+			elements = append(elements, feishuCollapsible(title, section.text))
+		default:
+			elements = append(elements,
+				feishuMarkdown(strings.Join(lines[:feishuPreviewLines], "\n")),
+				feishuCollapsible(fmt.Sprintf("Show %d more lines", len(lines)-feishuPreviewLines),
+					strings.Join(lines[feishuPreviewLines:], "\n")))
+		}
+	}
+	if len(elements) == 0 {
+		elements = append(elements, feishuMarkdown(" "))
+	}
+
+	columns := make([]map[string]interface{}, 0, len(feishuControlKeys))
+	for _, key := range feishuControlKeys {
+		columns = append(columns, map[string]interface{}{
+			"tag":   "column",
+			"width": "auto",
+			"elements": []map[string]interface{}{{
+				"tag":  "button",
+				"text": map[string]string{"tag": "plain_text", "content": key.label},
+				"type": key.style,
+				"behaviors": []map[string]interface{}{{
+					"type":  "callback",
+					"value": map[string]string{feishuCardActionKey: key.key},
+				}},
+			}},
+		})
+	}
+	elements = append(elements, map[string]interface{}{
+		"tag":       "column_set",
+		"flex_mode": "flow",
+		"columns":   columns,
+	})
+
+	card := map[string]interface{}{
+		"schema": "2.0",
+		"config": map[string]interface{}{"update_multi": true, "width_mode": "fill"},
+		"body":   map[string]interface{}{"elements": elements},
+	}
+	data, err := json.Marshal(card)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal card: %w", err)
+	}
+	return string(data), nil
+}
+
+// feishuMarkdown builds a card markdown element
+func feishuMarkdown(content string) map[string]interface{} {
+	return map[string]interface{}{"tag": "markdown", "content": content}
+}
+
+// feishuCollapsible builds a collapsed panel holding markdown content
+func feishuCollapsible(title, content string) map[string]interface{} {
+	return map[string]interface{}{
+		"tag":      "collapsible_panel",
+		"expanded": false,
+		"header": map[string]interface{}{
+			"title": map[string]string{"tag": "plain_text", "content": title},
+		},
+		"elements": []map[string]interface{}{feishuMarkdown(content)},
+	}
+}
+
+// feishuPostContent is the content of an inbound post (rich text) message
+type feishuPostContent struct {
+	Title   string                `json:"title"`
+	Content [][]feishuPostElement `json:"content"`
+}
+
+// feishuPostElement is one inline element of a post paragraph
+type feishuPostElement struct {
+	Tag      string `json:"tag"`
+	Text     string `json:"text"`
+	Href     string `json:"href"`
+	UserName string `json:"user_name"`
+	ImageKey string `json:"image_key"`
+	Language string `json:"language"`
+}
+
+// parseContent converts inbound message content to plain text by message type
+func (f *FeishuBot) parseContent(messageType, messageID, content string) string {
+	switch messageType {
+	case larkim.MsgTypePost:
+		return f.parsePostContent(messageID, content)
+	case larkim.MsgTypeImage:
+		var image struct {
+			ImageKey string `json:"image_key"`
+		}
+		if err := json.Unmarshal([]byte(content), &image); err != nil {
+			return "[image]"
+		}
+		return f.imageReference(messageID, image.ImageKey)
+	default:
+		// For text messages, content is like: {"text":"actual message"}
+		return extractTextContent(content)
+	}
+}
+
+// parsePostContent flattens a post message into text, one line per paragraph
+// Images are downloaded and referenced as "[image: <path>]"
+func (f *FeishuBot) parsePostContent(messageID, content string) string {
+	var post feishuPostContent
+	if err := json.Unmarshal([]byte(content), &post); err != nil {
+		logger.WithField("error", err).Debug("failed-to-parse-post-content-json")
+		return content
+	}
+	// Posts may be wrapped by locale, e.g. {"zh_cn":{"title":...,"content":...}}
+	if post.Title == "" && len(post.Content) == 0 {
+		var localized map[string]feishuPostContent
+		if err := json.Unmarshal([]byte(content), &localized); err == nil {
+			for _, p := range localized {
+				post = p
+				break
+			}
+		}
+	}
+
+	var lines []string
+	if post.Title != "" {
+		lines = append(lines, post.Title)
+	}
+	for _, paragraph := range post.Content {
+		var line strings.Builder
+		for _, el := range paragraph {
+			switch el.Tag {
+			case "text", "md":
+				line.WriteString(el.Text)
+			case "a":
+				line.WriteString(el.Text)
+				if el.Href != "" && el.Href != el.Text {
+					line.WriteString(" (" + el.Href + ")")
+				}
+			case "at":
+				line.WriteString("@" + el.UserName)
+			case "img":
+				line.WriteString(f.imageReference(messageID, el.ImageKey))
+			case "code_block":
+				line.WriteString("```" + strings.ToLower(el.Language) + "\n" + strings.TrimRight(el.Text, "\n") + "\n```")
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// imageReference downloads an inbound image and returns "[image: <path>]"
+func (f *FeishuBot) imageReference(messageID, imageKey string) string {
+	if imageKey == "" {
+		return "[image]"
+	}
+	path, err := f.downloadImage(messageID, imageKey)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"message_id": messageID,
+			"image_key":  imageKey,
+			"error":      err,
+		}).Warn("failed-to-download-feishu-image")
+		return "[image]"
+	}
+	return fmt.Sprintf("[image: %s]", path)
+}
+
+// downloadImage fetches a message image resource and saves it to a temp file
+func (f *FeishuBot) downloadImage(messageID, imageKey string) (string, error) {
+	f.mu.RLock()
+	larkClient := f.larkClient
+	ctx := f.ctx
+	f.mu.RUnlock()
+
+	if larkClient == nil {
+		return "", fmt.Errorf("feishu client not initialized")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req := larkim.NewGetMessageResourceReqBuilder().
+		MessageId(messageID).
+		FileKey(imageKey).
+		Type("image").
+		Build()
+	resp, err := larkClient.Im.MessageResource.Get(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("download image: %w", err)
+	}
+	if !resp.Success() {
+		return "", fmt.Errorf("API error: code=%d, msg=%s", resp.Code, resp.Msg)
+	}
+	if resp.File == nil {
+		return "", fmt.Errorf("empty image response")
+	}
+
+	ext := filepath.Ext(resp.FileName)
+	if ext == "" && resp.ApiResp != nil {
+		if exts, _ := mime.ExtensionsByType(resp.ApiResp.Header.Get("Content-Type")); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	if ext == "" {
+		ext = ".png"
+	}
+	dir := filepath.Join(os.TempDir(), "clibot-feishu")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("create image directory: %w", err)
+	}
+	path := filepath.Join(dir, filepath.Base(imageKey)+ext)
+
+	data, err := io.ReadAll(io.LimitReader(resp.File, feishuMaxImageSize))
+	if err != nil {
+		return "", fmt.Errorf("read image: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("write image: %w", err)
+	}
+	return path, nil
+}
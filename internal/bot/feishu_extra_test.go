@@ -1,9 +1,21 @@
 package bot
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	lark "github.com/larksuite/oapi-sdk-go/v3"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher/callback"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestFeishuBot_SetEncryptKey tests the SetEncryptKey method
@@ -133,3 +145,201 @@ func TestEscapeJSONString_AdditionalCases(t *testing.T) {
 		})
 	}
 }
+
+// fakeFeishuAPI is a fake Feishu open platform API
+type fakeFeishuAPI struct {
+	mu         sync.Mutex
+	created    []map[string]string // message create bodies
+	patched    map[string]string   // message ID -> patched content
+	uploads    int                 // image uploads
+	rejectCard bool
+}
+
+func (f *fakeFeishuAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/open-apis/auth/"):
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "tenant_access_token": "t-1", "expire": 7200})
+	case r.URL.Path == "/open-apis/im/v1/messages" && r.Method == http.MethodPost:
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		body["receive_id_type"] = r.URL.Query().Get("receive_id_type")
+		f.created = append(f.created, body)
+		if f.rejectCard && body["msg_type"] == "interactive" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 230099, "msg": "card invalid"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]string{"message_id": "om_1"}})
+	case r.URL.Path == "/open-apis/im/v1/images" && r.Method == http.MethodPost:
+		f.uploads++
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]string{"image_key": "img_1"}})
+	case strings.HasPrefix(r.URL.Path, "/open-apis/im/v1/messages/") && strings.Contains(r.URL.Path, "/resources/"):
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG fake"))
+	case strings.HasPrefix(r.URL.Path, "/open-apis/im/v1/messages/") && r.Method == http.MethodPatch:
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		f.patched[strings.TrimPrefix(r.URL.Path, "/open-apis/im/v1/messages/")] = body["content"]
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestFeishuBot(t *testing.T) (*FeishuBot, *fakeFeishuAPI) {
+	fake := &fakeFeishuAPI{patched: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	bot := NewFeishuBot("app", "secret")
+	bot.larkClient = lark.NewClient("app", "secret", lark.WithOpenBaseUrl(server.URL))
+	bot.ctx = context.Background()
+	return bot, fake
+}
+
+func TestBuildFeishuCard_CollapsesLongSections(t *testing.T) {
+	longText := strings.Repeat("line\n", feishuCollapseLines+5)
+	longCode := "```go\n" + strings.Repeat("x := 1\n", feishuCollapseLines) + "```"
+	card, err := buildFeishuCard("intro\n" + longCode + "\n" + longText)
+	require.NoError(t, err)
+
+	var parsed struct {
+		Schema string `json:"schema"`
+		Body   struct {
+			Elements []map[string]interface{} `json:"elements"`
+		} `json:"body"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(card), &parsed))
+	assert.Equal(t, "2.0", parsed.Schema)
+
+	var tags []string
+	for _, el := range parsed.Body.Elements {
+		tags = append(tags, el["tag"].(string))
+	}
+	// intro, collapsed code, text preview, collapsed rest, buttons
+	assert.Equal(t, []string{"markdown", "collapsible_panel", "markdown", "collapsible_panel", "column_set"}, tags)
+	assert.Contains(t, card, "go code (30 lines)")
+	assert.Contains(t, card, `"key":"ctrlc"`)
+	assert.Contains(t, card, `"key":"enter"`)
+}
+
+func TestSplitFeishuSections_ClosesUnterminatedFence(t *testing.T) {
+	sections := splitFeishuSections("text\n```sh\nls")
+	require.Len(t, sections, 2)
+	assert.False(t, sections[0].code)
+	assert.True(t, sections[1].code)
+	assert.Equal(t, "sh", sections[1].lang)
+	assert.Equal(t, "```sh\nls\n```", sections[1].text)
+}
+
+func TestFeishuBot_SendEditableMessageAndPatch(t *testing.T) {
+	bot, fake := newTestFeishuBot(t)
+
+	messageID, err := bot.SendEditableMessage("oc_1", "working...")
+	require.NoError(t, err)
+	assert.Equal(t, "om_1", messageID)
+	require.Len(t, fake.created, 1)
+	assert.Equal(t, "interactive", fake.created[0]["msg_type"])
+	assert.Contains(t, fake.created[0]["content"], "working...")
+
+	require.NoError(t, bot.EditMessage("oc_1", messageID, "done"))
+	assert.Contains(t, fake.patched["om_1"], "done")
+}
+
+func TestFeishuBot_SendMessage_FallsBackToText(t *testing.T) {
+	bot, fake := newTestFeishuBot(t)
+	fake.rejectCard = true
+
+	require.NoError(t, bot.SendMessage("oc_1", "hello"))
+	require.Len(t, fake.created, 2)
+	assert.Equal(t, "text", fake.created[1]["msg_type"])
+	assert.Equal(t, `{"text":"hello"}`, fake.created[1]["content"])
+}
+
+func TestFeishuBot_SendDirectMessage(t *testing.T) {
+	bot, fake := newTestFeishuBot(t)
+
+	require.NoError(t, bot.SendMessage("oc_1", "hello"))
+	require.NoError(t, bot.SendDirectMessage("ou_admin", "bot down"))
+	require.Len(t, fake.created, 2)
+	assert.Equal(t, "chat_id", fake.created[0]["receive_id_type"])
+	assert.Equal(t, "open_id", fake.created[1]["receive_id_type"])
+	assert.Equal(t, "ou_admin", fake.created[1]["receive_id"])
+	var _ DirectMessager = bot
+}
+
+func TestFeishuBot_SendImage(t *testing.T) {
+	bot, fake := newTestFeishuBot(t)
+	path := filepath.Join(t.TempDir(), "screen.png")
+	require.NoError(t, os.WriteFile(path, []byte("\x89PNG fake"), 0o600))
+
+	require.NoError(t, bot.SendImage("oc_1", path))
+	assert.Equal(t, 1, fake.uploads)
+	require.Len(t, fake.created, 1)
+	assert.Equal(t, "image", fake.created[0]["msg_type"])
+	assert.Equal(t, `{"image_key":"img_1"}`, fake.created[0]["content"])
+
+	assert.Error(t, bot.SendImage("oc_1", filepath.Join(t.TempDir(), "missing.png")))
+}
+
+func TestFeishuBot_HandleCardAction(t *testing.T) {
+	bot := NewFeishuBot("app", "secret")
+	var received []BotMessage
+	bot.SetMessageHandler(func(msg BotMessage) { received = append(received, msg) })
+
+	resp, err := bot.handleCardAction(context.Background(), &callback.CardActionTriggerEvent{
+		Event: &callback.CardActionTriggerRequest{
+			Operator: &callback.Operator{OpenID: "ou_1"},
+			Action:   &callback.CallBackAction{Value: map[string]interface{}{"key": "ctrlc"}},
+			Context:  &callback.Context{OpenChatID: "oc_1", OpenMessageID: "om_1"},
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	require.Len(t, received, 1)
+	assert.Equal(t, BotMessage{
+		Platform:  "feishu",
+		UserID:    "ou_1",
+		Channel:   "oc_1",
+		MessageID: "om_1",
+		Content:   "ctrlc",
+		Timestamp: received[0].Timestamp,
+	}, received[0])
+
+	// Actions without a key word are ignored
+	_, err = bot.handleCardAction(context.Background(), &callback.CardActionTriggerEvent{
+		Event: &callback.CardActionTriggerRequest{Action: &callback.CallBackAction{}},
+	})
+	require.NoError(t, err)
+	assert.Len(t, received, 1)
+}
+
+func TestFeishuBot_ParseContent_PostAndImage(t *testing.T) {
+	bot, _ := newTestFeishuBot(t)
+
+	post := `{"title":"Bug","content":[[{"tag":"text","text":"see "},{"tag":"a","text":"docs","href":"https://x.y"},{"tag":"at","user_name":"bot"}],` +
+		`[{"tag":"code_block","language":"GO","text":"fmt.Println()\n"}],[{"tag":"img","image_key":"img_1"}]]}`
+	content := bot.parseContent(larkim.MsgTypePost, "om_1", post)
+
+	lines := strings.Split(content, "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, "Bug", lines[0])
+	assert.Equal(t, "see docs (https://x.y)@bot", lines[1])
+	assert.Equal(t, "```go\nfmt.Println()\n```", strings.Join(lines[2:5], "\n"))
+	require.True(t, strings.HasPrefix(lines[5], "[image: "))
+	path := strings.TrimSuffix(strings.TrimPrefix(lines[5], "[image: "), "]")
+	t.Cleanup(func() { os.Remove(path) })
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "\x89PNG fake", string(data))
+
+	image := bot.parseContent(larkim.MsgTypeImage, "om_2", `{"image_key":"img_2"}`)
+	assert.True(t, strings.HasPrefix(image, "[image: "), image)
+
+	// Localized post wrapper
+	localized := bot.parseContent(larkim.MsgTypePost, "om_3", `{"zh_cn":{"title":"","content":[[{"tag":"text","text":"hi"}]]}}`)
+	assert.Equal(t, "hi", localized)
+}
@@ -1,7 +1,7 @@
 // Package bot provides bot adapters for various IM platforms.
 //
 // This package implements a unified interface for connecting to multiple chat platforms,
-// including Discord, Telegram, Feishu (Lark), and DingTalk. Each adapter handles
+// including Discord, Telegram, Feishu (Lark), DingTalk, email, a local web UI and generic webhooks. Each adapter handles
 // platform-specific connection logic, message formatting, and communication patterns.
 //
 // # Supported Platforms
@@ -10,6 +10,9 @@
 //   - Telegram: Long polling for message updates
 //   - Feishu/Lark: WebSocket long connection for enterprise messaging
 //   - DingTalk: WebSocket long connection for enterprise messaging
+//   - Web: Built-in single-page chat UI over WebSocket
+//   - Email: IMAP IDLE for incoming mail, SMTP for replies
+//   - Webhook: HMAC-signed outgoing webhooks (Teams, Mattermost, ...) with templated callbacks
 //
 // # Usage
 //
@@ -40,6 +43,7 @@
 package bot
 
 import (
+	"context"
 	"time"
 
 	"github.com/keepmind9/clibot/internal/proxy"
@@ -94,12 +98,107 @@ type BotAdapter interface {
 	Stop() error
 }
 
+// SessionInfo describes a CLI session for adapters that render session pickers
+type SessionInfo struct {
+	Name    string `json:"name"`     // Session name
+	CLIType string `json:"cli_type"` // CLI type (claude/gemini/opencode/acp)
+	State   string `json:"state"`    // Current session state
+	Dynamic bool   `json:"dynamic"`  // True for sessions created via snew
+}
+
+// SessionProvider lists sessions on behalf of bot adapters
+// Implemented by the engine; selection still goes through the suse command
+type SessionProvider interface {
+	// ListSessions returns all sessions and the user's current session name (empty if none)
+	ListSessions(platform, userID string) ([]SessionInfo, string)
+
+	// CLITypes returns the registered CLI types, sorted
+	CLITypes() []string
+}
+
+// SessionProviderSetter is implemented by adapters that need a SessionProvider
+// The engine injects itself when such an adapter is registered
+type SessionProviderSetter interface {
+	SetSessionProvider(provider SessionProvider)
+}
+
+// SessionListSender is implemented by adapters that render the slist reply with a session picker
+// The engine sends the session list through it instead of SendMessage
+type SessionListSender interface {
+	// SendSessionList sends the slist text together with a picker for sessions;
+	// current is the user's current session name (empty if none)
+	SendSessionList(channel, message string, sessions []SessionInfo, current string) error
+}
+
+// SessionSwitchListener is implemented by adapters that react to a user switching sessions
+// The engine calls it after every suse: sessionName is set once the user is authorized and
+// the session started, and empty if the switch was rejected
+type SessionSwitchListener interface {
+	SessionSwitched(channel, userID, sessionName string)
+}
+
+// MessageEditor is implemented by adapters that can update a sent message in place
+// The engine uses it to stream progressive output into a single message
+type MessageEditor interface {
+	// SendEditableMessage sends a message and returns its platform message ID
+	SendEditableMessage(channel, message string) (string, error)
+
+	// EditMessage replaces the content of a previously sent message
+	EditMessage(channel, messageID, message string) error
+}
+
+// FileSender is implemented by adapters that can send a local file as an attachment
+// The engine uses it for exports such as shistory export
+type FileSender interface {
+	// SendFile uploads the file at filePath to the channel
+	SendFile(channel, filePath string) error
+}
+
+// ImageSender is implemented by adapters that can send a local image inline
+// The engine uses it for pane screenshots (sshot)
+type ImageSender interface {
+	// SendImage uploads the image at imagePath to the channel
+	SendImage(channel, imagePath string) error
+}
+
+// DirectMessager is implemented by adapters that can message a user privately by user ID
+// The engine uses it for admin notifications, since a user ID is not a channel on every platform
+type DirectMessager interface {
+	// SendDirectMessage sends a message to the user's private chat with the bot
+	SendDirectMessage(userID, message string) error
+}
+
+// Transcriber converts recorded audio to text for adapters that receive voice messages
+type Transcriber interface {
+	// Transcribe returns the text spoken in the audio file at audioPath
+	Transcribe(ctx context.Context, audioPath string) (string, error)
+}
+
+// TranscriberSetter is implemented by adapters that can transcribe voice messages
+// The engine injects the configured Transcriber when such an adapter is registered
+type TranscriberSetter interface {
+	SetTranscriber(t Transcriber)
+}
+
+// QRLoginer is implemented by adapters whose login can expire and be renewed by
+// scanning a QR code, which the engine relays to an admin on another platform
+type QRLoginer interface {
+	// SetLoginRequiredHandler registers a callback fired when the login expires
+	SetLoginRequiredHandler(handler func(reason string))
+
+	// StartQRLogin fetches a login QR code and returns it as a link or local image path
+	// done receives nil once the login is confirmed, or the reason it failed
+	StartQRLogin() (qrCode string, done <-chan error, err error)
+}
+
 // BotMessage represents a bot message structure
 type BotMessage struct {
-	Platform  string // feishu/discord/telegram
-	UserID    string // Unique user identifier (for permission control)
-	Channel   string // Channel/session ID
-	MessageID string // Message ID (for typing indicator)
-	Content   string // Message content
-	Timestamp time.Time
+	Platform    string // feishu/discord/telegram
+	UserID      string // Unique user identifier (for permission control)
+	Channel     string // Channel/session ID
+	MessageID   string // Message ID (for typing indicator)
+	Content     string // Message content
+	Session     string // Optional: session this message is bound to, overrides the user's selection
+	Transcribed bool   // Content was transcribed from a voice message and may need confirmation
+	Timestamp   time.Time
 }
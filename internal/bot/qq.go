@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,6 +20,7 @@ import (
 // Official API: https://bots.qq.com
 type QQBot struct {
 	DefaultTypingIndicator
+	disconnectSignal
 	mu             sync.RWMutex
 	appID          string
 	appSecret      string
@@ -32,6 +34,17 @@ type QQBot struct {
 	proxyMgr       proxy.Manager
 	msgSeqMap      map[string]int // Track message sequences for passive reply
 	lastSequence   *int
+	sessionID      string                   // Gateway session from READY, kept across restarts for RESUME
+	apiBase        string                   // OpenAPI base URL, defaults to QQAPIBase
+	replyTargets   map[string]qqReplyTarget // Channel -> latest inbound message for passive reply
+	markdown       bool                     // Send markdown messages (requires markdown permission)
+	keyboardID     string                   // Approved keyboard template attached to markdown messages
+}
+
+// qqReplyTarget is the inbound message a reply to a channel is passively attached to
+type qqReplyTarget struct {
+	msgID      string
+	receivedAt time.Time
 }
 
 // QQ Bot API endpoints
@@ -55,7 +68,11 @@ const (
 	qqMaxMessageLength = 2000 // Maximum message length for QQ (characters)
 
 	// Message types
-	qqMessageTypeText = 0 // Text message type
+	qqMessageTypeText     = 0 // Text message type
+	qqMessageTypeMarkdown = 2 // Markdown message type
+
+	// Passive replies must reference an inbound message received within this window
+	qqPassiveReplyWindow = 5 * time.Minute
 
 	// Message splitting
 	qqSplitMinNewlineIndex = 2 // Minimum index for newline split (maxLen / 2)
@@ -75,9 +92,23 @@ const (
 
 // Intents for subscribing to events
 const (
-	IntentPublicMessages = 1 << 25 // Public message events (1 << 25)
+	IntentDirectMessage       = 1 << 12 // Guild direct message events (DIRECT_MESSAGE_CREATE)
+	IntentPublicMessages      = 1 << 25 // Group @ and C2C message events (1 << 25)
+	IntentPublicGuildMessages = 1 << 30 // Guild channel @ message events (AT_MESSAGE_CREATE)
+
+	qqIntents = IntentPublicMessages | IntentPublicGuildMessages | IntentDirectMessage
+)
+
+// Channel prefixes for non-C2C targets; C2C channels are the bare user openid
+const (
+	qqChannelGroupPrefix = "group:"   // group:<group_openid>
+	qqChannelGuildPrefix = "channel:" // channel:<guild channel id>
+	qqChannelDMPrefix    = "dms:"     // dms:<guild id> for guild direct messages
 )
 
+// qqMentionPattern matches bot mentions such as <@!1234> in guild messages
+var qqMentionPattern = regexp.MustCompile(`<@!?\w+>`)
+
 // Shard configuration
 const (
 	qqShardID    = 0 // Shard ID (0 = first shard)
@@ -102,6 +133,35 @@ type C2CMessageData struct {
 	Content string `json:"content"`
 }
 
+// GroupMessageData represents a group @ message (GROUP_AT_MESSAGE_CREATE)
+type GroupMessageData struct {
+	ID          string `json:"id"`
+	GroupOpenID string `json:"group_openid"`
+	Author      struct {
+		MemberOpenID string `json:"member_openid"`
+	} `json:"author"`
+	Content string `json:"content"`
+}
+
+// GuildMessageData represents a guild channel or guild direct message
+type GuildMessageData struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	GuildID   string `json:"guild_id"`
+	Author    struct {
+		ID  string `json:"id"`
+		Bot bool   `json:"bot"`
+	} `json:"author"`
+	Content string `json:"content"`
+}
+
+// ResumeData contains the resume payload sent instead of identify after a reconnect
+type ResumeData struct {
+	Token     string `json:"token"`
+	SessionID string `json:"session_id"`
+	Seq       int    `json:"seq"`
+}
+
 // HelloData contains heartbeat_interval from OP Hello
 type HelloData struct {
 	HeartbeatInterval int `json:"heartbeat_interval"`
@@ -127,10 +187,22 @@ type QQGatewayResponse struct {
 
 // SendMessageRequest represents the request payload for sending messages
 type SendMessageRequest struct {
+	Content  string      `json:"content,omitempty"`
+	MsgType  int         `json:"msg_type"`
+	MsgID    string      `json:"msg_id,omitempty"`
+	MsgSeq   int         `json:"msg_seq,omitempty"`
+	Markdown *QQMarkdown `json:"markdown,omitempty"`
+	Keyboard *QQKeyboard `json:"keyboard,omitempty"`
+}
+
+// QQMarkdown is the native markdown payload of a message
+type QQMarkdown struct {
 	Content string `json:"content"`
-	MsgType int    `json:"msg_type"`
-	MsgID   string `json:"msg_id,omitempty"`
-	MsgSeq  int    `json:"msg_seq,omitempty"`
+}
+
+// QQKeyboard references a keyboard template approved on the QQ bot platform
+type QQKeyboard struct {
+	ID string `json:"id"`
 }
 
 // SendMessageResponse represents the response from sending a message
@@ -141,12 +213,23 @@ type SendMessageResponse struct {
 // NewQQBot creates a new QQ bot instance
 func NewQQBot(appID, appSecret string) *QQBot {
 	return &QQBot{
-		appID:     appID,
-		appSecret: appSecret,
-		msgSeqMap: make(map[string]int),
+		appID:        appID,
+		appSecret:    appSecret,
+		msgSeqMap:    make(map[string]int),
+		apiBase:      QQAPIBase,
+		replyTargets: make(map[string]qqReplyTarget),
 	}
 }
 
+// SetMessageFormat enables markdown messages and an optional keyboard template
+// Both need the corresponding permission on the QQ bot platform
+func (q *QQBot) SetMessageFormat(markdown bool, keyboardID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.markdown = markdown
+	q.keyboardID = keyboardID
+}
+
 // SetProxyManager sets the proxy manager for the QQ bot
 func (q *QQBot) SetProxyManager(mgr proxy.Manager) {
 	q.mu.Lock()
@@ -222,14 +305,20 @@ func (q *QQBot) sendGateway(payload GatewayPayload) error {
 // startHeartbeat starts the heartbeat loop
 func (q *QQBot) startHeartbeat(intervalMs int) {
 	ticker := time.NewTicker(time.Duration(intervalMs) * time.Millisecond)
+	q.mu.RLock()
+	ctx := q.ctx
+	q.mu.RUnlock()
 	go func() {
 		defer ticker.Stop()
 		for {
 			select {
-			case <-q.ctx.Done():
+			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				heartbeat := GatewayPayload{OP: OPHeartbeat, D: q.lastSequence}
+				q.mu.RLock()
+				seq := q.lastSequence
+				q.mu.RUnlock()
+				heartbeat := GatewayPayload{OP: OPHeartbeat, D: seq}
 				if err := q.sendGateway(heartbeat); err != nil {
 					logger.Debugf("Heartbeat failed: %v", err)
 				}
@@ -243,7 +332,10 @@ func (q *QQBot) Start(messageHandler func(BotMessage)) error {
 	q.SetMessageHandler(messageHandler)
 
 	logger.Infof("[QQ] Starting...")
+	q.mu.Lock()
+	// Guarded by mu: the supervisor may Stop a previous run concurrently
 	q.ctx, q.cancel = context.WithCancel(context.Background())
+	q.mu.Unlock()
 
 	logger.Debugf("[QQ] Fetching access token...")
 	token, err := q.getAccessToken()
@@ -287,30 +379,30 @@ func (q *QQBot) connectGateway(token string) error {
 	}
 
 	logger.Infof("[QQ] WebSocket connected")
+	q.mu.Lock()
 	q.wsConn = ws
-	go q.handleWebSocketMessages(token)
+	ctx := q.ctx
+	q.mu.Unlock()
+	go q.handleWebSocketMessages(ctx, ws, token)
 	return nil
 }
 
 // handleWebSocketMessages receives and processes WebSocket messages
-func (q *QQBot) handleWebSocketMessages(token string) {
+// ctx and ws belong to one connection so a restart cannot be mistaken for a drop
+func (q *QQBot) handleWebSocketMessages(ctx context.Context, ws *websocket.Conn, token string) {
 	for {
 		select {
-		case <-q.ctx.Done():
+		case <-ctx.Done():
 			return
 		default:
-			q.mu.RLock()
-			ws := q.wsConn
-			q.mu.RUnlock()
-
-			if ws == nil {
-				return
-			}
-
 			_, message, err := ws.ReadMessage()
 			if err != nil {
+				// Closed by Stop, not a lost connection
+				if ctx.Err() != nil {
+					return
+				}
 				logger.Errorf("[QQ] WebSocket error: %v", err)
-				q.scheduleReconnect()
+				q.signalDisconnect(fmt.Errorf("websocket read: %w", err))
 				return
 			}
 
@@ -336,12 +428,33 @@ func (q *QQBot) handleGatewayPayload(payload GatewayPayload, token string) {
 			}
 		}
 
-		// Send identify
+		// Resume the previous gateway session if we have one, otherwise identify
+		q.mu.RLock()
+		sessionID := q.sessionID
+		seq := q.lastSequence
+		q.mu.RUnlock()
+
+		if sessionID != "" && seq != nil {
+			logger.Infof("[QQ] Resuming session %s from seq %d", sessionID, *seq)
+			resume := GatewayPayload{
+				OP: OPResume,
+				D: ResumeData{
+					Token:     fmt.Sprintf("QQBot %s", token),
+					SessionID: sessionID,
+					Seq:       *seq,
+				},
+			}
+			if err := q.sendGateway(resume); err != nil {
+				logger.Errorf("[QQ] Resume failed: %v", err)
+			}
+			return
+		}
+
 		identify := GatewayPayload{
 			OP: OPIdentify,
 			D: IdentifyData{
 				Token:   fmt.Sprintf("QQBot %s", token),
-				Intents: IntentPublicMessages,
+				Intents: qqIntents,
 				Shard:   []int{qqShardID, qqShardTotal},
 			},
 		}
@@ -352,22 +465,47 @@ func (q *QQBot) handleGatewayPayload(payload GatewayPayload, token string) {
 	case OPDispatch:
 		// Update sequence number
 		if payload.S != nil {
+			q.mu.Lock()
 			q.lastSequence = payload.S
+			q.mu.Unlock()
 		}
 
 		// Handle event types
 		switch payload.T {
 		case "READY":
+			if ready, ok := payload.D.(map[string]interface{}); ok {
+				if sessionID, ok := ready["session_id"].(string); ok {
+					q.mu.Lock()
+					q.sessionID = sessionID
+					q.mu.Unlock()
+				}
+			}
 			logger.Infof("[QQ] Gateway READY")
+		case "RESUMED":
+			logger.Infof("[QQ] Gateway session resumed")
 		case "C2C_MESSAGE_CREATE":
 			q.handleC2CMessage(payload.D)
+		case "GROUP_AT_MESSAGE_CREATE":
+			q.handleGroupMessage(payload.D)
+		case "AT_MESSAGE_CREATE":
+			q.handleGuildMessage(payload.D, false)
+		case "DIRECT_MESSAGE_CREATE":
+			q.handleGuildMessage(payload.D, true)
 		}
 
 	case OPHeartbeatAck:
 		// Heartbeat acknowledged, nothing to do
 	case OPReconnect:
 		logger.Infof("[QQ] Server requested reconnection")
-		q.scheduleReconnect()
+		q.signalDisconnect(fmt.Errorf("server requested reconnect"))
+	case OPInvalidSession:
+		// The session cannot be resumed; identify from scratch on the next connect
+		logger.Warnf("[QQ] Invalid session, will re-identify")
+		q.mu.Lock()
+		q.sessionID = ""
+		q.lastSequence = nil
+		q.mu.Unlock()
+		q.signalDisconnect(fmt.Errorf("invalid gateway session"))
 	}
 }
 
@@ -386,25 +524,107 @@ func (q *QQBot) handleC2CMessage(data interface{}) {
 	}
 
 	// Create bot message and call handler
-	botMsg := BotMessage{
+	q.dispatch(BotMessage{
 		Platform:  "qq",
 		UserID:    msg.Author.UserOpenID,
 		Channel:   msg.Author.UserOpenID,
 		Content:   msg.Content,
 		Timestamp: time.Now(),
 		MessageID: msg.ID,
+	})
+}
+
+// handleGroupMessage processes group @ messages; replies go back to the group
+func (q *QQBot) handleGroupMessage(data interface{}) {
+	var msg GroupMessageData
+	if err := decodeQQEvent(data, &msg); err != nil {
+		logger.Errorf("[QQ] Parse group message error: %v", err)
+		return
 	}
 
-	if q.messageHandler != nil {
-		q.messageHandler(botMsg)
+	q.dispatch(BotMessage{
+		Platform:  "qq",
+		UserID:    msg.Author.MemberOpenID,
+		Channel:   qqChannelGroupPrefix + msg.GroupOpenID,
+		Content:   strings.TrimSpace(msg.Content),
+		Timestamp: time.Now(),
+		MessageID: msg.ID,
+	})
+}
+
+// handleGuildMessage processes guild channel @ messages and guild direct messages
+func (q *QQBot) handleGuildMessage(data interface{}, direct bool) {
+	var msg GuildMessageData
+	if err := decodeQQEvent(data, &msg); err != nil {
+		logger.Errorf("[QQ] Parse guild message error: %v", err)
+		return
+	}
+	if msg.Author.Bot {
+		return
+	}
+
+	channel := qqChannelGuildPrefix + msg.ChannelID
+	if direct {
+		channel = qqChannelDMPrefix + msg.GuildID
+	}
+
+	q.dispatch(BotMessage{
+		Platform:  "qq",
+		UserID:    msg.Author.ID,
+		Channel:   channel,
+		Content:   strings.TrimSpace(qqMentionPattern.ReplaceAllString(msg.Content, "")),
+		Timestamp: time.Now(),
+		MessageID: msg.ID,
+	})
+}
+
+// decodeQQEvent converts a generic event payload into a typed struct
+func decodeQQEvent(data interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, out)
+}
+
+// dispatch records the message as the passive reply target for its channel
+// and hands it to the message handler
+func (q *QQBot) dispatch(msg BotMessage) {
+	if msg.Content == "" {
+		return
+	}
+
+	q.mu.Lock()
+	if q.replyTargets == nil {
+		q.replyTargets = make(map[string]qqReplyTarget)
+	}
+	q.replyTargets[msg.Channel] = qqReplyTarget{msgID: msg.MessageID, receivedAt: time.Now()}
+	// Prevent unbounded growth
+	if len(q.replyTargets) > maxMsgSeqMapSize {
+		for key := range q.replyTargets {
+			if key != msg.Channel {
+				delete(q.replyTargets, key)
+				break
+			}
+		}
+	}
+	handler := q.messageHandler
+	q.mu.Unlock()
+
+	if handler != nil {
+		handler(msg)
 	}
 }
 
-// scheduleReconnect schedules a reconnection attempt with exponential backoff
-func (q *QQBot) scheduleReconnect() {
-	// TODO: Implement exponential backoff reconnection
-	// For now, just log and stop
-	logger.Warn("Connection lost, manual restart required")
+// replyTarget returns the inbound message ID to passively reply to, if still valid
+func (q *QQBot) replyTarget(channel string) string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	target, exists := q.replyTargets[channel]
+	if !exists || time.Since(target.receivedAt) > qqPassiveReplyWindow {
+		return ""
+	}
+	return target.msgID
 }
 
 // Stop stops the QQ bot and cleans up resources
@@ -529,7 +749,9 @@ func (q *QQBot) getGatewayURL(token string) (string, error) {
 	return gatewayResp.URL, nil
 }
 
-// SendMessage sends a message to QQ (C2C private message)
+// SendMessage sends a message to a QQ user, group, guild channel or guild DM
+// Replies reference the channel's latest inbound message (msg_id/msg_seq) when
+// still inside the passive reply window, otherwise they are sent as active messages
 func (q *QQBot) SendMessage(channel, message string) error {
 	q.mu.RLock()
 	token := q.accessToken
@@ -540,36 +762,87 @@ func (q *QQBot) SendMessage(channel, message string) error {
 	}
 
 	// Split long messages
-	if len(message) > qqMaxMessageLength {
-		parts := splitMessage(message, qqMaxMessageLength)
-		for _, part := range parts {
-			if err := q.sendSingleMessage(channel, part, token); err != nil {
-				return err
-			}
+	parts := splitMessage(message, qqMaxMessageLength)
+	for i, part := range parts {
+		if err := q.sendSingleMessage(channel, part, token, i == len(parts)-1); err != nil {
+			return err
 		}
-		return nil
+	}
+	return nil
+}
+
+// SendDirectMessage sends a C2C message to a user by their user openid
+func (q *QQBot) SendDirectMessage(userID, message string) error {
+	return q.SendMessage(userID, message)
+}
+
+// messageURL returns the OpenAPI endpoint for a channel
+func (q *QQBot) messageURL(channel string) string {
+	base := q.apiBase
+	if base == "" {
+		base = QQAPIBase
 	}
 
-	return q.sendSingleMessage(channel, message, token)
+	switch {
+	case strings.HasPrefix(channel, qqChannelGroupPrefix):
+		return fmt.Sprintf("%s/v2/groups/%s/messages", base, strings.TrimPrefix(channel, qqChannelGroupPrefix))
+	case strings.HasPrefix(channel, qqChannelGuildPrefix):
+		return fmt.Sprintf("%s/channels/%s/messages", base, strings.TrimPrefix(channel, qqChannelGuildPrefix))
+	case strings.HasPrefix(channel, qqChannelDMPrefix):
+		return fmt.Sprintf("%s/dms/%s/messages", base, strings.TrimPrefix(channel, qqChannelDMPrefix))
+	default:
+		return fmt.Sprintf("%s/v2/users/%s/messages", base, channel)
+	}
 }
 
 // sendSingleMessage sends a single message (without splitting)
-func (q *QQBot) sendSingleMessage(channel, message, token string) error {
-	url := fmt.Sprintf("%s/v2/users/%s/messages", QQAPIBase, channel)
+// The keyboard is only attached to the last part of a split message
+func (q *QQBot) sendSingleMessage(channel, message, token string, last bool) error {
+	q.mu.RLock()
+	markdown := q.markdown
+	keyboardID := q.keyboardID
+	q.mu.RUnlock()
 
 	reqBody := SendMessageRequest{
 		Content: message,
 		MsgType: qqMessageTypeText,
-		// Note: QQ requires msg_id and msg_seq for passive reply
-		// This is a simplified version - production should track these
+	}
+	if msgID := q.replyTarget(channel); msgID != "" {
+		reqBody.MsgID = msgID
+		reqBody.MsgSeq = q.nextMsgSeq(msgID)
+	}
+
+	if markdown {
+		mdBody := reqBody
+		mdBody.Content = ""
+		mdBody.MsgType = qqMessageTypeMarkdown
+		mdBody.Markdown = &QQMarkdown{Content: message}
+		if keyboardID != "" && last {
+			mdBody.Keyboard = &QQKeyboard{ID: keyboardID}
+		}
+
+		err := q.postMessage(channel, token, mdBody)
+		if err == nil {
+			return nil
+		}
+		// Fall back to plain text, e.g. when markdown permission is missing
+		logger.Warnf("[QQ] Markdown send failed, retrying as text: %v", err)
+		if reqBody.MsgID != "" {
+			reqBody.MsgSeq = q.nextMsgSeq(reqBody.MsgID)
+		}
 	}
 
+	return q.postMessage(channel, token, reqBody)
+}
+
+// postMessage posts a message payload to the channel's endpoint
+func (q *QQBot) postMessage(channel, token string, reqBody SendMessageRequest) error {
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(jsonData)))
+	req, err := http.NewRequest("POST", q.messageURL(channel), strings.NewReader(string(jsonData)))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -577,8 +850,11 @@ func (q *QQBot) sendSingleMessage(channel, message, token string) error {
 	req.Header.Set("Authorization", fmt.Sprintf("QQBot %s", token))
 
 	client := &http.Client{Timeout: qqMessageSendTimeout}
-	if q.proxyMgr != nil {
-		if proxyClient, proxyErr := q.proxyMgr.GetHTTPClient("qq"); proxyErr == nil {
+	q.mu.RLock()
+	proxyMgr := q.proxyMgr
+	q.mu.RUnlock()
+	if proxyMgr != nil {
+		if proxyClient, proxyErr := proxyMgr.GetHTTPClient("qq"); proxyErr == nil {
 			client = proxyClient
 		}
 	}
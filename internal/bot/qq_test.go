@@ -1,12 +1,18 @@
 package bot
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewQQBot(t *testing.T) {
@@ -308,3 +314,194 @@ func (m *mockProxyManager) GetHTTPClient(platform string) (*http.Client, error)
 func (m *mockProxyManager) GetProxyURL(platform string) string {
 	return ""
 }
+
+// TestQQBot_GatewayResume tests that READY sessions are resumed and invalid sessions reset
+func TestQQBot_GatewayResume(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	sent := make(chan GatewayPayload, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var payload GatewayPayload
+			if err := conn.ReadJSON(&payload); err != nil {
+				return
+			}
+			sent <- payload
+		}
+	}))
+	defer server.Close()
+
+	bot := NewQQBot("app", "secret")
+	bot.ctx, bot.cancel = context.WithCancel(context.Background())
+	defer bot.Stop()
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	bot.wsConn = ws
+
+	seq := 42
+	bot.handleGatewayPayload(GatewayPayload{OP: OPDispatch, T: "READY", S: &seq, D: map[string]interface{}{"session_id": "sess-1"}}, "tok")
+	assert.Equal(t, "sess-1", bot.sessionID)
+
+	// Hello after a reconnect resumes instead of identifying
+	bot.handleGatewayPayload(GatewayPayload{OP: OPHello, D: map[string]interface{}{"heartbeat_interval": float64(60000)}}, "tok")
+	payload := <-sent
+	assert.Equal(t, OPResume, payload.OP)
+	data := payload.D.(map[string]interface{})
+	assert.Equal(t, "sess-1", data["session_id"])
+	assert.Equal(t, float64(42), data["seq"])
+
+	// An invalid session forgets the resume state and reports a disconnect
+	bot.handleGatewayPayload(GatewayPayload{OP: OPInvalidSession}, "tok")
+	assert.Empty(t, bot.sessionID)
+	assert.Nil(t, bot.lastSequence)
+	select {
+	case err := <-bot.Disconnected():
+		assert.ErrorContains(t, err, "invalid gateway session")
+	default:
+		t.Fatal("expected disconnect signal")
+	}
+
+	bot.handleGatewayPayload(GatewayPayload{OP: OPHello}, "tok")
+	payload = <-sent
+	assert.Equal(t, OPIdentify, payload.OP)
+}
+
+// qqAPIRecorder records message requests sent to a fake QQ OpenAPI
+type qqAPIRecorder struct {
+	mu       sync.Mutex
+	paths    []string
+	bodies   []SendMessageRequest
+	failType int // msg_type rejected with 403, -1 for none
+}
+
+func (r *qqAPIRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body SendMessageRequest
+	json.NewDecoder(req.Body).Decode(&body)
+
+	r.mu.Lock()
+	r.paths = append(r.paths, req.URL.Path)
+	r.bodies = append(r.bodies, body)
+	r.mu.Unlock()
+
+	if body.MsgType == r.failType {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	w.Write([]byte(`{"id":"sent"}`))
+}
+
+// newTestQQBot returns an authenticated QQBot pointed at a fake OpenAPI
+func newTestQQBot(t *testing.T) (*QQBot, *qqAPIRecorder, *[]BotMessage) {
+	recorder := &qqAPIRecorder{failType: -1}
+	server := httptest.NewServer(recorder)
+	t.Cleanup(server.Close)
+
+	bot := NewQQBot("app", "secret")
+	bot.apiBase = server.URL
+	bot.accessToken = "tok"
+
+	var received []BotMessage
+	bot.SetMessageHandler(func(msg BotMessage) { received = append(received, msg) })
+	return bot, recorder, &received
+}
+
+// TestQQBot_GroupMessagePassiveReply tests that group replies go to the group with msg_id/msg_seq
+func TestQQBot_GroupMessagePassiveReply(t *testing.T) {
+	bot, recorder, received := newTestQQBot(t)
+
+	bot.handleGatewayPayload(GatewayPayload{OP: OPDispatch, T: "GROUP_AT_MESSAGE_CREATE", D: map[string]interface{}{
+		"id":           "in-1",
+		"group_openid": "G1",
+		"content":      " slist",
+		"author":       map[string]interface{}{"member_openid": "M1"},
+	}}, "tok")
+
+	require.Len(t, *received, 1)
+	msg := (*received)[0]
+	assert.Equal(t, "M1", msg.UserID)
+	assert.Equal(t, "group:G1", msg.Channel)
+	assert.Equal(t, "slist", msg.Content)
+
+	require.NoError(t, bot.SendMessage(msg.Channel, "first"))
+	require.NoError(t, bot.SendMessage(msg.Channel, "second"))
+
+	assert.Equal(t, []string{"/v2/groups/G1/messages", "/v2/groups/G1/messages"}, recorder.paths)
+	assert.Equal(t, "in-1", recorder.bodies[0].MsgID)
+	assert.Equal(t, 1, recorder.bodies[0].MsgSeq)
+	assert.Equal(t, 2, recorder.bodies[1].MsgSeq)
+}
+
+// TestQQBot_GuildMessages tests guild channel and direct message routing
+func TestQQBot_GuildMessages(t *testing.T) {
+	bot, recorder, received := newTestQQBot(t)
+
+	bot.handleGatewayPayload(GatewayPayload{OP: OPDispatch, T: "AT_MESSAGE_CREATE", D: map[string]interface{}{
+		"id":         "in-2",
+		"channel_id": "C9",
+		"guild_id":   "GD",
+		"content":    "<@!12345> sstatus",
+		"author":     map[string]interface{}{"id": "U7"},
+	}}, "tok")
+	bot.handleGatewayPayload(GatewayPayload{OP: OPDispatch, T: "DIRECT_MESSAGE_CREATE", D: map[string]interface{}{
+		"id":       "in-3",
+		"guild_id": "GD",
+		"content":  "help",
+		"author":   map[string]interface{}{"id": "U7"},
+	}}, "tok")
+	// Messages from bots are ignored
+	bot.handleGatewayPayload(GatewayPayload{OP: OPDispatch, T: "AT_MESSAGE_CREATE", D: map[string]interface{}{
+		"id":      "in-4",
+		"content": "loop",
+		"author":  map[string]interface{}{"id": "B1", "bot": true},
+	}}, "tok")
+
+	require.Len(t, *received, 2)
+	assert.Equal(t, "channel:C9", (*received)[0].Channel)
+	assert.Equal(t, "sstatus", (*received)[0].Content)
+	assert.Equal(t, "dms:GD", (*received)[1].Channel)
+
+	require.NoError(t, bot.SendMessage("channel:C9", "ok"))
+	require.NoError(t, bot.SendMessage("dms:GD", "ok"))
+	require.NoError(t, bot.SendMessage("user-openid", "ok"))
+	assert.Equal(t, []string{"/channels/C9/messages", "/dms/GD/messages", "/v2/users/user-openid/messages"}, recorder.paths)
+	assert.Equal(t, "in-2", recorder.bodies[0].MsgID)
+	// No inbound message from this user: sent as an active message
+	assert.Empty(t, recorder.bodies[2].MsgID)
+}
+
+// TestQQBot_PassiveReplyWindow tests that stale inbound messages are not referenced
+func TestQQBot_PassiveReplyWindow(t *testing.T) {
+	bot, _, _ := newTestQQBot(t)
+	bot.replyTargets["group:G1"] = qqReplyTarget{msgID: "old", receivedAt: time.Now().Add(-qqPassiveReplyWindow - time.Second)}
+	bot.replyTargets["group:G2"] = qqReplyTarget{msgID: "new", receivedAt: time.Now()}
+
+	assert.Empty(t, bot.replyTarget("group:G1"))
+	assert.Equal(t, "new", bot.replyTarget("group:G2"))
+}
+
+// TestQQBot_MarkdownAndKeyboard tests markdown messages with a keyboard and the text fallback
+func TestQQBot_MarkdownAndKeyboard(t *testing.T) {
+	bot, recorder, _ := newTestQQBot(t)
+	bot.SetMessageFormat(true, "kb-1")
+
+	require.NoError(t, bot.SendMessage("user-openid", "**done**"))
+	require.Len(t, recorder.bodies, 1)
+	body := recorder.bodies[0]
+	assert.Equal(t, qqMessageTypeMarkdown, body.MsgType)
+	require.NotNil(t, body.Markdown)
+	assert.Equal(t, "**done**", body.Markdown.Content)
+	require.NotNil(t, body.Keyboard)
+	assert.Equal(t, "kb-1", body.Keyboard.ID)
+
+	// Without markdown permission the message is resent as text
+	recorder.failType = qqMessageTypeMarkdown
+	require.NoError(t, bot.SendMessage("user-openid", "plain"))
+	require.Len(t, recorder.bodies, 3)
+	assert.Equal(t, qqMessageTypeText, recorder.bodies[2].MsgType)
+	assert.Equal(t, "plain", recorder.bodies[2].Content)
+	assert.Nil(t, recorder.bodies[2].Keyboard)
+}
@@ -0,0 +1,275 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// ConnectionState is the connection state of a supervised bot adapter
+type ConnectionState string
+
+const (
+	ConnectionConnecting   ConnectionState = "connecting"
+	ConnectionConnected    ConnectionState = "connected"
+	ConnectionReconnecting ConnectionState = "reconnecting"
+	ConnectionStopped      ConnectionState = "stopped"
+)
+
+// DisconnectNotifier is implemented by adapters that can detect a lost connection
+// after Start has returned. Adapters without it are only restarted when Start fails.
+type DisconnectNotifier interface {
+	// Disconnected receives an error whenever the adapter loses its connection
+	Disconnected() <-chan error
+}
+
+// disconnectSignal implements DisconnectNotifier for embedding in adapters
+type disconnectSignal struct {
+	once sync.Once
+	ch   chan error
+}
+
+func (d *disconnectSignal) init() {
+	d.once.Do(func() { d.ch = make(chan error, 1) })
+}
+
+// Disconnected returns the channel signalled when the connection is lost
+func (d *disconnectSignal) Disconnected() <-chan error {
+	d.init()
+	return d.ch
+}
+
+// signalDisconnect reports a lost connection without blocking
+func (d *disconnectSignal) signalDisconnect(err error) {
+	d.init()
+	select {
+	case d.ch <- err:
+	default:
+	}
+}
+
+// AdapterStatus is a snapshot of a supervised adapter's connection
+type AdapterStatus struct {
+	Platform  string
+	State     ConnectionState
+	Since     time.Time // When the adapter entered State
+	Attempts  int       // Consecutive failed attempts, 0 when connected
+	LastError string
+}
+
+// SupervisorConfig configures reconnect backoff and down alerts
+type SupervisorConfig struct {
+	InitialBackoff time.Duration // First retry delay
+	MaxBackoff     time.Duration // Upper bound for retry delay
+	AlertAfter     time.Duration // How long an adapter may stay down before OnDown fires
+}
+
+// Supervisor starts bot adapters and restarts them with jittered exponential
+// backoff when Start fails or the adapter reports a lost connection
+type Supervisor struct {
+	mu       sync.RWMutex
+	config   SupervisorConfig
+	statuses map[string]*AdapterStatus
+
+	// OnDown is called once when an adapter has been down longer than AlertAfter
+	OnDown func(status AdapterStatus)
+	// OnRecover is called when an adapter that triggered OnDown reconnects
+	OnRecover func(status AdapterStatus, downtime time.Duration)
+
+	wg sync.WaitGroup
+}
+
+// NewSupervisor creates a supervisor, filling zero config values with defaults
+func NewSupervisor(config SupervisorConfig) *Supervisor {
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = constants.BotReconnectInitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = constants.BotReconnectMaxBackoff
+	}
+	if config.AlertAfter <= 0 {
+		config.AlertAfter = constants.BotDownAlertThreshold
+	}
+	return &Supervisor{
+		config:   config,
+		statuses: make(map[string]*AdapterStatus),
+	}
+}
+
+// Supervise runs the adapter in a background goroutine until ctx is cancelled
+func (s *Supervisor) Supervise(ctx context.Context, platform string, adapter BotAdapter, handler func(BotMessage)) {
+	s.setState(platform, ConnectionConnecting, 0, "")
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(ctx, platform, adapter, handler)
+		s.setState(platform, ConnectionStopped, 0, "")
+	}()
+}
+
+// Wait blocks until all supervised adapters have exited
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+// Status returns a snapshot of all supervised adapters sorted by platform
+func (s *Supervisor) Status() []AdapterStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]AdapterStatus, 0, len(s.statuses))
+	for _, status := range s.statuses {
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Platform < result[j].Platform })
+	return result
+}
+
+// run is the per-adapter supervision loop
+func (s *Supervisor) run(ctx context.Context, platform string, adapter BotAdapter, handler func(BotMessage)) {
+	notifier, canNotify := adapter.(DisconnectNotifier)
+
+	var downSince time.Time
+	alerted := false
+	attempts := 0
+
+	for ctx.Err() == nil {
+		// Drop stale signals from a previous connection
+		if canNotify {
+			drainDisconnects(notifier.Disconnected())
+		}
+
+		err := startAdapter(adapter, handler)
+		if err == nil {
+			s.setState(platform, ConnectionConnected, 0, "")
+			if alerted && s.OnRecover != nil {
+				s.OnRecover(s.status(platform), time.Since(downSince))
+			}
+			attempts = 0
+			alerted = false
+			downSince = time.Time{}
+
+			logger.WithField("platform", platform).Info("bot-adapter-connected")
+
+			if !canNotify {
+				<-ctx.Done()
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case err = <-notifier.Disconnected():
+				if err == nil {
+					err = fmt.Errorf("connection lost")
+				}
+			}
+		}
+
+		// Start failed or the connection dropped: clean up and retry
+		if stopErr := adapter.Stop(); stopErr != nil {
+			logger.WithFields(logrus.Fields{
+				"platform": platform,
+				"error":    stopErr,
+			}).Debug("bot-adapter-stop-before-reconnect-failed")
+		}
+
+		attempts++
+		if downSince.IsZero() {
+			downSince = time.Now()
+		}
+		s.setState(platform, ConnectionReconnecting, attempts, err.Error())
+
+		delay := s.backoff(attempts)
+		logger.WithFields(logrus.Fields{
+			"platform": platform,
+			"attempt":  attempts,
+			"delay":    delay,
+			"error":    err,
+		}).Warn("bot-adapter-down-reconnecting")
+
+		if !alerted && time.Since(downSince) >= s.config.AlertAfter {
+			alerted = true
+			if s.OnDown != nil {
+				s.OnDown(s.status(platform))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// startAdapter calls Start, converting panics into errors
+func startAdapter(adapter BotAdapter, handler func(BotMessage)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during start: %v", r)
+		}
+	}()
+	return adapter.Start(handler)
+}
+
+// drainDisconnects empties a disconnect channel without blocking
+func drainDisconnects(ch <-chan error) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
+
+// backoff returns the delay before the given attempt: exponential growth capped
+// at MaxBackoff, jittered uniformly into [delay/2, delay]
+func (s *Supervisor) backoff(attempt int) time.Duration {
+	delay := s.config.InitialBackoff
+	for i := 1; i < attempt && delay < s.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.config.MaxBackoff {
+		delay = s.config.MaxBackoff
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// setState records an adapter's state
+func (s *Supervisor) setState(platform string, state ConnectionState, attempts int, lastErr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, exists := s.statuses[platform]
+	if !exists {
+		status = &AdapterStatus{Platform: platform}
+		s.statuses[platform] = status
+	}
+	if status.State != state {
+		status.Since = time.Now()
+	}
+	status.State = state
+	status.Attempts = attempts
+	if lastErr != "" || state == ConnectionConnected {
+		status.LastError = lastErr
+	}
+}
+
+// status returns a copy of one adapter's status
+func (s *Supervisor) status(platform string) AdapterStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if status, exists := s.statuses[platform]; exists {
+		return *status
+	}
+	return AdapterStatus{Platform: platform}
+}
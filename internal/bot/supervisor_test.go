@@ -0,0 +1,169 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyAdapter fails Start a configured number of times and can drop its connection
+type flakyAdapter struct {
+	DefaultTypingIndicator
+	disconnectSignal
+	mu         sync.Mutex
+	failStarts int
+	starts     int
+	stops      int
+}
+
+func (f *flakyAdapter) Start(handler func(BotMessage)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.starts++
+	if f.starts <= f.failStarts {
+		return errors.New("connect refused")
+	}
+	return nil
+}
+
+func (f *flakyAdapter) Stop() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stops++
+	return nil
+}
+
+func (f *flakyAdapter) SendMessage(channel, message string) error { return nil }
+func (f *flakyAdapter) SetProxyManager(mgr proxy.Manager)         {}
+
+func (f *flakyAdapter) startCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.starts
+}
+
+// waitForState polls until the platform reaches state
+func waitForState(t *testing.T, s *Supervisor, platform string, state ConnectionState) AdapterStatus {
+	var status AdapterStatus
+	require.Eventually(t, func() bool {
+		status = s.status(platform)
+		return status.State == state
+	}, 2*time.Second, 5*time.Millisecond)
+	return status
+}
+
+func TestSupervisor_RetriesFailedStart(t *testing.T) {
+	s := NewSupervisor(SupervisorConfig{InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, AlertAfter: time.Hour})
+	adapter := &flakyAdapter{failStarts: 3}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Supervise(ctx, "qq", adapter, func(BotMessage) {})
+
+	status := waitForState(t, s, "qq", ConnectionConnected)
+	assert.Equal(t, 0, status.Attempts)
+	assert.Empty(t, status.LastError)
+	assert.Equal(t, 4, adapter.startCount())
+
+	cancel()
+	s.Wait()
+	assert.Equal(t, ConnectionStopped, s.status("qq").State)
+}
+
+func TestSupervisor_RestartsOnDisconnect(t *testing.T) {
+	s := NewSupervisor(SupervisorConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, AlertAfter: time.Hour})
+	adapter := &flakyAdapter{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Supervise(ctx, "telegram", adapter, func(BotMessage) {})
+	waitForState(t, s, "telegram", ConnectionConnected)
+
+	adapter.signalDisconnect(errors.New("socket closed"))
+	require.Eventually(t, func() bool { return adapter.startCount() == 2 }, 2*time.Second, 5*time.Millisecond)
+	waitForState(t, s, "telegram", ConnectionConnected)
+
+	adapter.mu.Lock()
+	assert.Equal(t, 1, adapter.stops)
+	adapter.mu.Unlock()
+}
+
+func TestSupervisor_AlertsWhenDownAndRecovered(t *testing.T) {
+	s := NewSupervisor(SupervisorConfig{InitialBackoff: 5 * time.Millisecond, MaxBackoff: 5 * time.Millisecond, AlertAfter: time.Nanosecond})
+	adapter := &flakyAdapter{failStarts: 3}
+
+	var mu sync.Mutex
+	var down []AdapterStatus
+	var recovered int
+	s.OnDown = func(status AdapterStatus) {
+		mu.Lock()
+		defer mu.Unlock()
+		down = append(down, status)
+	}
+	s.OnRecover = func(status AdapterStatus, downtime time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		recovered++
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Supervise(ctx, "discord", adapter, func(BotMessage) {})
+	waitForState(t, s, "discord", ConnectionConnected)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, down, 1, "admins are alerted once per outage")
+	assert.Equal(t, "discord", down[0].Platform)
+	assert.Equal(t, "connect refused", down[0].LastError)
+	assert.Equal(t, 1, recovered)
+}
+
+func TestSupervisor_Backoff(t *testing.T) {
+	s := NewSupervisor(SupervisorConfig{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	for attempt, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: 10 * time.Second} {
+		for i := 0; i < 20; i++ {
+			d := s.backoff(attempt)
+			assert.GreaterOrEqual(t, d, max/2)
+			assert.LessOrEqual(t, d, max)
+		}
+	}
+}
+
+func TestSupervisor_StartPanicIsRetried(t *testing.T) {
+	err := startAdapter(&panicAdapter{}, func(BotMessage) {})
+	assert.ErrorContains(t, err, "panic during start")
+}
+
+// panicAdapter panics in Start
+type panicAdapter struct {
+	flakyAdapter
+}
+
+func (p *panicAdapter) Start(handler func(BotMessage)) error {
+	panic("boom")
+}
+
+func TestAdaptersReportDisconnects(t *testing.T) {
+	adapters := map[string]BotAdapter{
+		"dingtalk": &DingTalkBot{},
+		"discord":  &DiscordBot{},
+		"email":    &EmailBot{},
+		"feishu":   &FeishuBot{},
+		"qq":       &QQBot{},
+		"telegram": &TelegramBot{},
+		"web":      &WebBot{},
+		"webhook":  &WebhookBot{},
+		"weixin":   &WeixinBot{},
+	}
+	for name, adapter := range adapters {
+		_, ok := adapter.(DisconnectNotifier)
+		assert.True(t, ok, "%s adapter should report lost connections to the supervisor", name)
+	}
+}
@@ -3,6 +3,12 @@ package bot
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,25 +19,72 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// telegramMaxCallbackData is Telegram's limit for inline button callback data (bytes)
+	telegramMaxCallbackData = 64
+
+	// telegramMaxVoiceSize caps downloaded voice messages (Bot API download limit is 20MB)
+	telegramMaxVoiceSize = 20 * 1024 * 1024
+)
+
+// telegramCommands is the command menu registered with setMyCommands
+// Menu commands arrive as "/cmd@bot args" and are normalized to engine commands
+var telegramCommands = []tgbotapi.BotCommand{
+	{Command: "help", Description: "Show available commands"},
+	{Command: "slist", Description: "List sessions"},
+	{Command: "suse", Description: "Switch session: /suse <name>"},
+	{Command: "sstatus", Description: "Show session status"},
+	{Command: "shistory", Description: "Show recent exchanges: /shistory [n] [export]"},
+	{Command: "ssearch", Description: "Search the conversation: /ssearch <text>"},
+	{Command: "sshot", Description: "Screenshot a session's terminal: /sshot [name]"},
+	{Command: "keys", Description: "Send keys: /keys Down Down Enter"},
+	{Command: "raw", Description: "Toggle raw mode (no Enter): /raw [on|off]"},
+	{Command: "snew", Description: "Create session: /snew <name> <cli_type> <work_dir>"},
+	{Command: "sdel", Description: "Delete a dynamic session: /sdel <name>"},
+	{Command: "sclose", Description: "Close a session: /sclose <name>"},
+	{Command: "whoami", Description: "Show your user info"},
+	{Command: "status", Description: "Show clibot status"},
+}
+
+// telegramControlKeys are the buttons shown under every response
+// Callback data are engine key words (see watchdog.ProcessKeyWords)
+var telegramControlKeys = []tgbotapi.InlineKeyboardButton{
+	tgbotapi.NewInlineKeyboardButtonData("Esc", "esc"),
+	tgbotapi.NewInlineKeyboardButtonData("Tab", "tab"),
+	tgbotapi.NewInlineKeyboardButtonData("Shift-Tab", "stab"),
+	tgbotapi.NewInlineKeyboardButtonData("Ctrl-C", "ctrlc"),
+}
+
 // TelegramBot implements BotAdapter interface for Telegram using long polling
 type TelegramBot struct {
 	DefaultTypingIndicator
+	disconnectSignal
 	mu             sync.RWMutex
 	token          string
+	apiEndpoint    string // Bot API endpoint format, defaults to tgbotapi.APIEndpoint
 	bot            *tgbotapi.BotAPI
 	messageHandler func(BotMessage)
 	ctx            context.Context
 	cancel         context.CancelFunc
 	proxyMgr       proxy.Manager
+	transcriber    Transcriber
 }
 
 // NewTelegramBot creates a new Telegram bot instance
 func NewTelegramBot(token string) *TelegramBot {
 	return &TelegramBot{
-		token: token,
+		token:       token,
+		apiEndpoint: tgbotapi.APIEndpoint,
 	}
 }
 
+// SetTranscriber sets the transcriber used for voice messages
+func (t *TelegramBot) SetTranscriber(transcriber Transcriber) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.transcriber = transcriber
+}
+
 // SetProxyManager sets the proxy manager for the Telegram bot
 func (t *TelegramBot) SetProxyManager(mgr proxy.Manager) {
 	t.mu.Lock()
@@ -42,7 +95,6 @@ func (t *TelegramBot) SetProxyManager(mgr proxy.Manager) {
 // Start establishes long polling connection to Telegram and begins listening for messages
 func (t *TelegramBot) Start(messageHandler func(BotMessage)) error {
 	t.SetMessageHandler(messageHandler)
-	t.ctx, t.cancel = context.WithCancel(context.Background())
 
 	logger.WithFields(logrus.Fields{
 		"token": maskSecret(t.token),
@@ -51,6 +103,13 @@ func (t *TelegramBot) Start(messageHandler func(BotMessage)) error {
 	var err error
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	// Guarded by mu: the supervisor may Stop a previous run concurrently
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+
+	endpoint := t.apiEndpoint
+	if endpoint == "" {
+		endpoint = tgbotapi.APIEndpoint
+	}
 
 	// Use proxy manager if available
 	if t.proxyMgr != nil {
@@ -59,9 +118,9 @@ func (t *TelegramBot) Start(messageHandler func(BotMessage)) error {
 			logger.WithField("error", clientErr).Error("failed-to-create-proxy-client")
 			return fmt.Errorf("failed to create proxy client: %w", clientErr)
 		}
-		t.bot, err = tgbotapi.NewBotAPIWithClient(t.token, tgbotapi.APIEndpoint, client)
+		t.bot, err = tgbotapi.NewBotAPIWithClient(t.token, endpoint, client)
 	} else {
-		t.bot, err = tgbotapi.NewBotAPI(t.token)
+		t.bot, err = tgbotapi.NewBotAPIWithClient(t.token, endpoint, &http.Client{})
 	}
 
 	if err != nil {
@@ -78,6 +137,11 @@ func (t *TelegramBot) Start(messageHandler func(BotMessage)) error {
 		"bot_id":       bot.Self.ID,
 	}).Info("telegram-bot-initialized-successfully")
 
+	// Register the command menu; failure only affects the client-side menu
+	if _, err := bot.Request(tgbotapi.NewSetMyCommands(telegramCommands...)); err != nil {
+		logger.WithField("error", err).Warn("failed-to-register-telegram-commands")
+	}
+
 	// Set up long polling configuration
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = int(constants.TelegramLongPollTimeout.Seconds())
@@ -86,20 +150,26 @@ func (t *TelegramBot) Start(messageHandler func(BotMessage)) error {
 	updates := bot.GetUpdatesChan(u)
 
 	// Process updates in background
+	// Any exit other than Stop is reported so the supervisor can restart polling
+	ctx := t.ctx
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
 				logger.WithField("panic", r).Error("telegram-message-handler-panic")
+				t.signalDisconnect(fmt.Errorf("update handler panic: %v", r))
 			}
 		}()
 		for {
 			select {
-			case <-t.ctx.Done():
+			case <-ctx.Done():
 				logger.Info("telegram-long-polling-stopped")
 				return
 			case update, ok := <-updates:
 				if !ok {
 					logger.Info("telegram-updates-channel-closed")
+					if ctx.Err() == nil {
+						t.signalDisconnect(fmt.Errorf("updates channel closed"))
+					}
 					return
 				}
 
@@ -171,6 +241,10 @@ func (t *TelegramBot) handleMessage(message *tgbotapi.Message) {
 	if content == "" {
 		content = message.Caption
 	}
+	transcribed := false
+	if content == "" && (message.Voice != nil || message.Audio != nil) {
+		content, transcribed = t.voiceContent(message)
+	}
 
 	// Log parsed message data
 	logger.WithFields(logrus.Fields{
@@ -191,20 +265,114 @@ func (t *TelegramBot) handleMessage(message *tgbotapi.Message) {
 		return
 	}
 
+	content = t.normalizeCommand(content)
+
 	// Call the handler with BotMessage
 	handler := t.GetMessageHandler()
 	if handler != nil {
 		handler(BotMessage{
-			Platform:  "telegram",
-			UserID:    userID,
-			Channel:   chatID,
-			MessageID: fmt.Sprintf("%d", message.MessageID),
-			Content:   content,
-			Timestamp: time.Unix(int64(message.Date), 0),
+			Platform:    "telegram",
+			UserID:      userID,
+			Channel:     chatID,
+			MessageID:   fmt.Sprintf("%d", message.MessageID),
+			Content:     content,
+			Timestamp:   time.Unix(int64(message.Date), 0),
+			Transcribed: transcribed,
 		})
 	}
 }
 
+// voiceContent downloads a voice or audio message and transcribes it. Without a
+// transcriber, or when transcription fails, the downloaded file is referenced as
+// "[voice: <path>]" so the CLI can still read it.
+func (t *TelegramBot) voiceContent(message *tgbotapi.Message) (string, bool) {
+	fileID, name := "", fmt.Sprintf("voice-%d.ogg", message.MessageID)
+	if message.Voice != nil {
+		fileID = message.Voice.FileID
+	} else {
+		fileID = message.Audio.FileID
+		if message.Audio.FileName != "" {
+			name = fmt.Sprintf("voice-%d-%s", message.MessageID, message.Audio.FileName)
+		}
+	}
+
+	path, err := t.downloadFile(fileID, name)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"message_id": message.MessageID,
+			"error":      err,
+		}).Warn("telegram-voice-download-failed")
+		return "[voice]", false
+	}
+
+	t.mu.RLock()
+	transcriber, ctx := t.transcriber, t.ctx
+	t.mu.RUnlock()
+	if transcriber == nil {
+		return fmt.Sprintf("[voice: %s]", path), false
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	text, err := transcribeAudio(ctx, transcriber, path)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"message_id": message.MessageID,
+			"error":      err,
+		}).Warn("telegram-voice-transcription-failed")
+		return fmt.Sprintf("[voice: %s]", path), false
+	}
+	return text, true
+}
+
+// downloadFile fetches a Bot API file into the clibot-telegram temp directory
+func (t *TelegramBot) downloadFile(fileID, name string) (string, error) {
+	t.mu.RLock()
+	bot := t.bot
+	t.mu.RUnlock()
+	if bot == nil {
+		return "", fmt.Errorf("bot not initialized")
+	}
+
+	file, err := bot.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return "", fmt.Errorf("get file: %w", err)
+	}
+	// File downloads live under /file/bot<token>/ on the same (possibly self-hosted) API server
+	fileEndpoint := strings.Replace(t.apiEndpoint, "/bot%s/%s", "/file/bot%s/%s", 1)
+	if fileEndpoint == t.apiEndpoint {
+		fileEndpoint = tgbotapi.FileEndpoint
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(fileEndpoint, t.token, file.FilePath), nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	resp, err := bot.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download file failed: %s", resp.Status)
+	}
+
+	dir := filepath.Join(os.TempDir(), "clibot-telegram")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("create download directory: %w", err)
+	}
+	path := filepath.Join(dir, filepath.Base(name))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("create file: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, io.LimitReader(resp.Body, telegramMaxVoiceSize)); err != nil {
+		return "", fmt.Errorf("write file: %w", err)
+	}
+	return path, nil
+}
+
 // handleCallbackQuery handles inline keyboard callback queries
 func (t *TelegramBot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 	if callback == nil || callback.Message == nil {
@@ -228,6 +396,19 @@ func (t *TelegramBot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 		"data":        callback.Data,
 	}).Info("received-telegram-callback-query")
 
+	// Answer the callback to clear the loading spinner on the button
+	t.mu.RLock()
+	bot := t.bot
+	t.mu.RUnlock()
+	if bot != nil {
+		if _, err := bot.Request(tgbotapi.NewCallback(callback.ID, "")); err != nil {
+			logger.WithFields(logrus.Fields{
+				"callback_id": callback.ID,
+				"error":       err,
+			}).Warn("failed-to-answer-telegram-callback-query")
+		}
+	}
+
 	handler := t.GetMessageHandler()
 	if handler != nil {
 		// Use callback data as content, prefixed to identify it as a callback
@@ -246,21 +427,161 @@ func (t *TelegramBot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 	}
 }
 
-// SendMessage sends a message to a Telegram chat
+// SendMessage sends a message to a Telegram chat with control-key buttons
 func (t *TelegramBot) SendMessage(chatID, message string) error {
+	_, err := t.send(chatID, message, controlKeyboard())
+	return err
+}
+
+// SendSessionList sends the slist reply with one "suse <name>" button per session
+func (t *TelegramBot) SendSessionList(chatID, message string, sessions []SessionInfo, current string) error {
+	keyboard, ok := sessionKeyboard(sessions, current)
+	if !ok {
+		keyboard = controlKeyboard()
+	}
+	_, err := t.send(chatID, message, keyboard)
+	return err
+}
+
+// SendDirectMessage sends a message to a user's private chat, whose chat ID is the user ID
+func (t *TelegramBot) SendDirectMessage(userID, message string) error {
+	return t.SendMessage(userID, message)
+}
+
+// SendEditableMessage sends a message and returns its ID for later edits
+func (t *TelegramBot) SendEditableMessage(chatID, message string) (string, error) {
+	messageID, err := t.send(chatID, message, controlKeyboard())
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(messageID), nil
+}
+
+// EditMessage replaces the text of a previously sent message via editMessageText
+func (t *TelegramBot) EditMessage(chatID, messageID, message string) error {
+	bot, chatIDInt, err := t.prepare(chatID)
+	if err != nil {
+		return err
+	}
+	msgID, err := strconv.Atoi(messageID)
+	if err != nil {
+		return fmt.Errorf("invalid message ID format: %w", err)
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatIDInt, msgID, truncateTelegramMessage(message), controlKeyboard())
+	if _, err := bot.Send(edit); err != nil {
+		// Editing with identical content is rejected but is not a failure
+		if strings.Contains(err.Error(), "message is not modified") {
+			return nil
+		}
+		logger.WithFields(logrus.Fields{
+			"chat_id":    chatID,
+			"message_id": messageID,
+			"error":      err,
+		}).Error("failed-to-edit-telegram-message")
+		return fmt.Errorf("failed to edit message %s in chat %s: %w", messageID, chatID, err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"chat_id":    chatID,
+		"message_id": messageID,
+	}).Debug("telegram-message-edited")
+	return nil
+}
+
+// SendFile uploads a local file to a Telegram chat as a document
+func (t *TelegramBot) SendFile(chatID, filePath string) error {
+	bot, chatIDInt, err := t.prepare(chatID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := bot.Send(tgbotapi.NewDocument(chatIDInt, tgbotapi.FilePath(filePath))); err != nil {
+		logger.WithFields(logrus.Fields{
+			"chat_id": chatID,
+			"error":   err,
+		}).Error("failed-to-send-file-to-telegram")
+		return fmt.Errorf("failed to send file to chat %s: %w", chatID, err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"chat_id": chatID,
+		"file":    filepath.Base(filePath),
+	}).Info("file-sent-to-telegram")
+	return nil
+}
+
+// SendImage sends a local image as a photo
+func (t *TelegramBot) SendImage(chatID, imagePath string) error {
+	bot, chatIDInt, err := t.prepare(chatID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := bot.Send(tgbotapi.NewPhoto(chatIDInt, tgbotapi.FilePath(imagePath))); err != nil {
+		logger.WithFields(logrus.Fields{
+			"chat_id": chatID,
+			"error":   err,
+		}).Error("failed-to-send-image-to-telegram")
+		return fmt.Errorf("failed to send image to chat %s: %w", chatID, err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"chat_id": chatID,
+		"image":   filepath.Base(imagePath),
+	}).Info("image-sent-to-telegram")
+	return nil
+}
+
+// send delivers a message with an inline keyboard and returns its ID
+func (t *TelegramBot) send(chatID, message string, keyboard tgbotapi.InlineKeyboardMarkup) (int, error) {
+	bot, chatIDInt, err := t.prepare(chatID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Create message - use plain text to avoid markdown parsing issues
+	msg := tgbotapi.NewMessage(chatIDInt, truncateTelegramMessage(message))
+	msg.ReplyMarkup = keyboard
+
+	// Send message
+	sent, err := bot.Send(msg)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"chat_id": chatID,
+			"error":   err,
+		}).Error("failed-to-send-message-to-telegram")
+		return 0, fmt.Errorf("failed to send message to chat %s: %w", chatID, err)
+	}
+
+	logger.WithField("chat_id", chatID).Info("message-sent-to-telegram")
+	return sent.MessageID, nil
+}
+
+// prepare validates the bot state and parses the chat ID
+func (t *TelegramBot) prepare(chatID string) (*tgbotapi.BotAPI, int64, error) {
 	t.mu.RLock()
 	bot := t.bot
 	t.mu.RUnlock()
 
 	if bot == nil {
-		return fmt.Errorf("telegram bot not initialized")
+		return nil, 0, fmt.Errorf("telegram bot not initialized")
 	}
 
 	if chatID == "" {
-		return fmt.Errorf("chat ID is required for Telegram")
+		return nil, 0, fmt.Errorf("chat ID is required for Telegram")
 	}
 
-	// Telegram message limit
+	// Parse chat ID (convert string to int64)
+	var chatIDInt int64
+	if _, err := fmt.Sscanf(chatID, "%d", &chatIDInt); err != nil {
+		return nil, 0, fmt.Errorf("invalid chat ID format: %w", err)
+	}
+	return bot, chatIDInt, nil
+}
+
+// truncateTelegramMessage truncates a message to Telegram's limit
+func truncateTelegramMessage(message string) string {
 	const maxTelegramLength = constants.MaxTelegramMessageLength
 	if len(message) > maxTelegramLength {
 		logger.WithFields(logrus.Fields{
@@ -269,41 +590,88 @@ func (t *TelegramBot) SendMessage(chatID, message string) error {
 		}).Info("truncating-message-for-telegram-limit")
 		message = message[:maxTelegramLength]
 	}
+	return message
+}
 
-	// Parse chat ID (convert string to int64)
-	var chatIDInt int64
-	if _, err := fmt.Sscanf(chatID, "%d", &chatIDInt); err != nil {
-		return fmt.Errorf("invalid chat ID format: %w", err)
+// controlKeyboard returns the control-key button row shown under responses
+func controlKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(telegramControlKeys)
+}
+
+// sessionKeyboard builds a keyboard with one "suse <name>" button per session, two per row
+// Sessions whose command exceeds the callback data limit are left out
+func sessionKeyboard(sessions []SessionInfo, current string) (tgbotapi.InlineKeyboardMarkup, bool) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, s := range sessions {
+		data := "suse " + s.Name
+		if len(data) > telegramMaxCallbackData {
+			continue
+		}
+		label := s.Name
+		if s.Name == current {
+			label = "✅ " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, data))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
 	}
+	if len(rows) == 0 {
+		return tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...), true
+}
 
-	// Create message - use plain text to avoid markdown parsing issues
-	msg := tgbotapi.NewMessage(chatIDInt, message)
+// normalizeCommand converts "/cmd@bot args" from the command menu into "cmd args"
+// Unknown slash commands are passed through unchanged for the CLI
+func (t *TelegramBot) normalizeCommand(content string) string {
+	if !strings.HasPrefix(content, "/") {
+		return content
+	}
 
-	// Send message
-	_, err := bot.Send(msg)
-	if err != nil {
-		logger.WithFields(logrus.Fields{
-			"chat_id": chatID,
-			"error":   err,
-		}).Error("failed-to-send-message-to-telegram")
-		return fmt.Errorf("failed to send message to chat %s: %w", chatID, err)
+	fields := strings.SplitN(strings.TrimSpace(content[1:]), " ", 2)
+	cmd := fields[0]
+	if at := strings.Index(cmd, "@"); at >= 0 {
+		t.mu.RLock()
+		var botName string
+		if t.bot != nil {
+			botName = t.bot.Self.UserName
+		}
+		t.mu.RUnlock()
+		if !strings.EqualFold(cmd[at+1:], botName) {
+			return content
+		}
+		cmd = cmd[:at]
 	}
 
-	logger.WithField("chat_id", chatID).Info("message-sent-to-telegram")
-	return nil
+	for _, c := range telegramCommands {
+		if c.Command == cmd {
+			if len(fields) == 2 {
+				return cmd + " " + strings.TrimSpace(fields[1])
+			}
+			return cmd
+		}
+	}
+	return content
 }
 
 // Stop closes the Telegram long polling connection and cleans up resources
 func (t *TelegramBot) Stop() error {
-	if t.cancel != nil {
-		t.cancel()
-	}
-
 	t.mu.Lock()
+	cancel := t.cancel
 	bot := t.bot
 	t.bot = nil
 	t.mu.Unlock()
 
+	if cancel != nil {
+		cancel()
+	}
+
 	if bot != nil {
 		bot.StopReceivingUpdates()
 		logger.Info("telegram-long-polling-stopped")
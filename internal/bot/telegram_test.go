@@ -0,0 +1,247 @@
+package bot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// telegramCall is a Bot API request recorded by the fake server
+type telegramCall struct {
+	method string
+	params url.Values
+}
+
+// fakeTelegramAPI is a minimal Bot API server that records calls
+type fakeTelegramAPI struct {
+	mu    sync.Mutex
+	calls []telegramCall
+}
+
+func (f *fakeTelegramAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if strings.HasPrefix(r.URL.Path, "/file/") {
+		w.Write([]byte("voice-data"))
+		return
+	}
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	var result interface{} = true
+	switch method {
+	case "getMe":
+		result = map[string]interface{}{"id": 1, "is_bot": true, "username": "clibot_test"}
+	case "getUpdates":
+		time.Sleep(50 * time.Millisecond)
+		result = []interface{}{}
+	case "getFile":
+		result = map[string]interface{}{"file_id": r.Form.Get("file_id"), "file_path": "voice/file_1.oga"}
+	case "sendMessage", "editMessageText", "sendPhoto", "sendDocument":
+		result = map[string]interface{}{"message_id": 42, "date": 0, "chat": map[string]interface{}{"id": 100}}
+	}
+
+	if method != "getUpdates" {
+		f.mu.Lock()
+		f.calls = append(f.calls, telegramCall{method: method, params: r.Form})
+		f.mu.Unlock()
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+// callsTo returns recorded calls for a method
+func (f *fakeTelegramAPI) callsTo(method string) []telegramCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []telegramCall
+	for _, c := range f.calls {
+		if c.method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// newTestTelegramBot returns a TelegramBot connected to a fake Bot API
+func newTestTelegramBot(t *testing.T) (*TelegramBot, *fakeTelegramAPI) {
+	fake := &fakeTelegramAPI{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	tb := NewTelegramBot("test-token")
+	tb.apiEndpoint = server.URL + "/bot%s/%s"
+	api, err := tgbotapi.NewBotAPIWithClient(tb.token, tb.apiEndpoint, server.Client())
+	require.NoError(t, err)
+	tb.bot = api
+	return tb, fake
+}
+
+// inlineKeyboard decodes the reply_markup of a recorded call
+func inlineKeyboard(t *testing.T, call telegramCall) tgbotapi.InlineKeyboardMarkup {
+	var markup tgbotapi.InlineKeyboardMarkup
+	require.NoError(t, json.Unmarshal([]byte(call.params.Get("reply_markup")), &markup))
+	return markup
+}
+
+func TestTelegramBot_StartRegistersCommands(t *testing.T) {
+	fake := &fakeTelegramAPI{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	tb := NewTelegramBot("test-token")
+	tb.apiEndpoint = server.URL + "/bot%s/%s"
+	require.NoError(t, tb.Start(func(BotMessage) {}))
+	defer tb.Stop()
+
+	calls := fake.callsTo("setMyCommands")
+	require.Len(t, calls, 1)
+	var commands []tgbotapi.BotCommand
+	require.NoError(t, json.Unmarshal([]byte(calls[0].params.Get("commands")), &commands))
+	assert.Equal(t, telegramCommands, commands)
+}
+
+func TestTelegramBot_SendMessage_ControlKeys(t *testing.T) {
+	tb, fake := newTestTelegramBot(t)
+
+	require.NoError(t, tb.SendMessage("100", "done"))
+
+	calls := fake.callsTo("sendMessage")
+	require.Len(t, calls, 1)
+	assert.Equal(t, "done", calls[0].params.Get("text"))
+	markup := inlineKeyboard(t, calls[0])
+	require.Len(t, markup.InlineKeyboard, 1)
+	var data []string
+	for _, b := range markup.InlineKeyboard[0] {
+		data = append(data, *b.CallbackData)
+	}
+	assert.Equal(t, []string{"esc", "tab", "stab", "ctrlc"}, data)
+}
+
+func TestTelegramBot_SendSessionList(t *testing.T) {
+	tb, fake := newTestTelegramBot(t)
+	sessions := []SessionInfo{{Name: "api"}, {Name: "web"}, {Name: "docs"}, {Name: strings.Repeat("x", 64)}}
+
+	require.NoError(t, tb.SendSessionList("100", "📋 Available Sessions:", sessions, "web"))
+	markup := inlineKeyboard(t, fake.callsTo("sendMessage")[0])
+	require.Len(t, markup.InlineKeyboard, 2)
+	assert.Equal(t, "api", markup.InlineKeyboard[0][0].Text)
+	assert.Equal(t, "✅ web", markup.InlineKeyboard[0][1].Text)
+	assert.Equal(t, "suse web", *markup.InlineKeyboard[0][1].CallbackData)
+	// Names exceeding the callback data limit are skipped
+	assert.Len(t, markup.InlineKeyboard[1], 1)
+
+	// Without sessions the control keys are shown instead
+	require.NoError(t, tb.SendSessionList("100", "📋 Available Sessions:", nil, ""))
+	markup = inlineKeyboard(t, fake.callsTo("sendMessage")[1])
+	assert.Equal(t, "esc", *markup.InlineKeyboard[0][0].CallbackData)
+	var _ SessionListSender = tb
+}
+
+func TestTelegramBot_NormalizeCommand(t *testing.T) {
+	tb, _ := newTestTelegramBot(t)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"/slist", "slist"},
+		{"/suse  dev", "suse dev"},
+		{"/sstatus@clibot_test", "sstatus"},
+		{"/help@other_bot", "/help@other_bot"},
+		{"/add main.go", "/add main.go"},
+		{"hello", "hello"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tb.normalizeCommand(tt.input), tt.input)
+	}
+}
+
+func TestTelegramBot_CallbackQueryIsAnswered(t *testing.T) {
+	tb, fake := newTestTelegramBot(t)
+	var received []BotMessage
+	tb.SetMessageHandler(func(msg BotMessage) { received = append(received, msg) })
+
+	tb.handleCallbackQuery(&tgbotapi.CallbackQuery{
+		ID:      "cb-1",
+		From:    &tgbotapi.User{ID: 5},
+		Message: &tgbotapi.Message{MessageID: 42, Chat: &tgbotapi.Chat{ID: 100}},
+		Data:    "suse web",
+	})
+
+	calls := fake.callsTo("answerCallbackQuery")
+	require.Len(t, calls, 1)
+	assert.Equal(t, "cb-1", calls[0].params.Get("callback_query_id"))
+	require.Len(t, received, 1)
+	assert.Equal(t, "suse web", received[0].Content)
+	assert.Equal(t, "5", received[0].UserID)
+}
+
+func TestTelegramBot_EditableMessage(t *testing.T) {
+	tb, fake := newTestTelegramBot(t)
+
+	id, err := tb.SendEditableMessage("100", "partial")
+	require.NoError(t, err)
+	assert.Equal(t, "42", id)
+
+	require.NoError(t, tb.EditMessage("100", id, "partial and more"))
+	calls := fake.callsTo("editMessageText")
+	require.Len(t, calls, 1)
+	assert.Equal(t, "42", calls[0].params.Get("message_id"))
+	assert.Equal(t, "partial and more", calls[0].params.Get("text"))
+	assert.NotEmpty(t, calls[0].params.Get("reply_markup"))
+
+	assert.Error(t, tb.EditMessage("100", "abc", "x"))
+}
+
+func TestTelegramBot_VoiceMessage(t *testing.T) {
+	tb, _ := newTestTelegramBot(t)
+	var received []BotMessage
+	tb.SetMessageHandler(func(msg BotMessage) { received = append(received, msg) })
+	voice := &tgbotapi.Message{
+		MessageID: 7,
+		From:      &tgbotapi.User{ID: 5},
+		Chat:      &tgbotapi.Chat{ID: 100, Type: "private"},
+		Voice:     &tgbotapi.Voice{FileID: "file-1"},
+	}
+
+	// Without a transcriber the downloaded file is referenced
+	tb.handleMessage(voice)
+	require.Len(t, received, 1)
+	require.True(t, strings.HasPrefix(received[0].Content, "[voice: "), received[0].Content)
+	assert.False(t, received[0].Transcribed)
+	path := strings.TrimSuffix(strings.TrimPrefix(received[0].Content, "[voice: "), "]")
+	t.Cleanup(func() { os.Remove(path) })
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "voice-data", string(data))
+
+	tb.SetTranscriber(stubTranscriber{text: "show the diff"})
+	tb.handleMessage(voice)
+	require.Len(t, received, 2)
+	assert.Equal(t, "show the diff", received[1].Content)
+	assert.True(t, received[1].Transcribed)
+}
+
+func TestTelegramBot_SendImage(t *testing.T) {
+	tb, fake := newTestTelegramBot(t)
+	path := filepath.Join(t.TempDir(), "screen.png")
+	require.NoError(t, os.WriteFile(path, []byte("\x89PNG fake"), 0o600))
+
+	require.NoError(t, tb.SendImage("100", path))
+	assert.Len(t, fake.callsTo("sendPhoto"), 1)
+
+	require.NoError(t, tb.SendFile("100", path))
+	assert.Len(t, fake.callsTo("sendDocument"), 1)
+
+	assert.Error(t, tb.SendImage("not-a-chat", path))
+	var _ ImageSender = tb
+}
@@ -1,6 +1,12 @@
 package bot
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/keepmind9/clibot/pkg/constants"
 )
 
@@ -11,3 +17,30 @@ func maskSecret(s string) string {
 	}
 	return s[:constants.SecretMaskPrefixLength] + "***" + s[len(s)-constants.SecretMaskSuffixLength:]
 }
+
+// expandHomePath expands a leading "~/" to the user's home directory
+func expandHomePath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// transcribeAudio runs a Transcriber with constants.TranscriptionTimeout,
+// treating an empty transcript as an error
+func transcribeAudio(ctx context.Context, t Transcriber, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, constants.TranscriptionTimeout)
+	defer cancel()
+
+	text, err := t.Transcribe(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("empty transcript")
+	}
+	return text, nil
+}
@@ -0,0 +1,561 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/proxy"
+	"github.com/sirupsen/logrus"
+)
+
+// Web adapter defaults
+const (
+	// DefaultWebListenAddr is the default listen address for the web chat UI
+	DefaultWebListenAddr = "127.0.0.1:8091"
+
+	webMaxHistory      = 200 // Messages kept per user
+	webMaxTyping       = 500 // Pending typing indicators before pruning
+	webSendBufferSize  = 64  // Outbound frames buffered per connection
+	webAuthTimeout     = 10 * time.Second
+	webWriteTimeout    = 10 * time.Second
+	webPingInterval    = 30 * time.Second
+	webMaxFrameSize    = 64 * 1024
+	webShutdownTimeout = 5 * time.Second
+)
+
+// webIndexHTML is the single-page chat UI
+//
+//go:embed webui/index.html
+var webIndexHTML []byte
+
+// Web frame types exchanged over the WebSocket
+const (
+	webFrameAuth     = "auth"     // client -> server: {token}
+	webFrameAuthOK   = "auth_ok"  // server -> client: {user}
+	webFrameMessage  = "message"  // both directions: {content} / {role, content, timestamp, id}
+	webFrameEdit     = "edit"     // server -> client: {id, content}, replaces a streamed message
+	webFrameHistory  = "history"  // server -> client: {messages}
+	webFrameSessions = "sessions" // client requests, server replies: {sessions, current}
+	webFrameSelect   = "select"   // client -> server: {session}
+	webFrameTyping   = "typing"   // server -> client: {active}
+	webFrameError    = "error"    // server -> client: {content}
+)
+
+// webFrame is the JSON envelope for WebSocket messages
+type webFrame struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id,omitempty"`
+	Token     string          `json:"token,omitempty"`
+	User      string          `json:"user,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	Session   string          `json:"session,omitempty"`
+	Timestamp int64           `json:"timestamp,omitempty"`
+	Active    bool            `json:"active,omitempty"`
+	Current   string          `json:"current,omitempty"`
+	Sessions  []SessionInfo   `json:"sessions,omitempty"`
+	Messages  []webHistoryMsg `json:"messages,omitempty"`
+}
+
+// webHistoryMsg is one entry in a user's chat history
+type webHistoryMsg struct {
+	ID        string `json:"id,omitempty"` // Set on editable bot messages
+	Role      string `json:"role"`         // "user" or "bot"
+	Content   string `json:"content"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// webClient is one authenticated WebSocket connection
+type webClient struct {
+	userID string
+	send   chan []byte
+	once   sync.Once
+}
+
+// close closes the client's send queue exactly once
+func (c *webClient) close() {
+	c.once.Do(func() { close(c.send) })
+}
+
+// WebBot implements BotAdapter by serving a local web chat UI over WebSocket
+type WebBot struct {
+	disconnectSignal
+	mu              sync.RWMutex
+	listenAddr      string
+	tokens          map[string]string // user ID -> login token
+	server          *http.Server
+	upgrader        websocket.Upgrader
+	clients         map[*webClient]struct{}
+	history         map[string][]webHistoryMsg // user ID -> recent messages
+	typing          map[string]string          // message ID -> user ID
+	messageHandler  func(BotMessage)
+	sessionProvider SessionProvider
+	proxyMgr        proxy.Manager
+	msgCounter      atomic.Uint64
+}
+
+// NewWebBot creates a new web chat bot; tokens maps user IDs to login tokens
+func NewWebBot(listenAddr string, tokens map[string]string) *WebBot {
+	if listenAddr == "" {
+		listenAddr = DefaultWebListenAddr
+	}
+	if tokens == nil {
+		tokens = make(map[string]string)
+	}
+
+	return &WebBot{
+		listenAddr: listenAddr,
+		tokens:     tokens,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			// Default CheckOrigin rejects cross-origin upgrades
+		},
+		clients: make(map[*webClient]struct{}),
+		history: make(map[string][]webHistoryMsg),
+		typing:  make(map[string]string),
+	}
+}
+
+// SetProxyManager sets the proxy manager
+// The web bot makes no outbound calls; the manager is kept for interface parity
+func (w *WebBot) SetProxyManager(mgr proxy.Manager) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.proxyMgr = mgr
+}
+
+// SetSessionProvider sets the provider backing the session picker
+func (w *WebBot) SetSessionProvider(provider SessionProvider) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sessionProvider = provider
+}
+
+// Start starts the HTTP server serving the UI and WebSocket endpoint
+func (w *WebBot) Start(messageHandler func(BotMessage)) error {
+	w.SetMessageHandler(messageHandler)
+
+	if len(w.tokens) == 0 {
+		return fmt.Errorf("web bot requires at least one user token")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", w.handleIndex)
+	mux.HandleFunc("/ws", w.handleWebSocket)
+
+	listener, err := net.Listen("tcp", w.listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", w.listenAddr, err)
+	}
+
+	server := &http.Server{Handler: mux}
+	w.mu.Lock()
+	w.server = server
+	w.mu.Unlock()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.WithField("error", err).Error("web-server-error")
+			w.signalDisconnect(fmt.Errorf("web server: %w", err))
+		}
+	}()
+
+	logger.WithFields(logrus.Fields{
+		"address": listener.Addr().String(),
+		"users":   len(w.tokens),
+	}).Info("web-bot-listening")
+	return nil
+}
+
+// handleIndex serves the single-page UI
+func (w *WebBot) handleIndex(rw http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(rw, r)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Write(webIndexHTML)
+}
+
+// authenticate returns the user ID for a token
+func (w *WebBot) authenticate(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	for userID, expected := range w.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			return userID, true
+		}
+	}
+	return "", false
+}
+
+// handleWebSocket upgrades the connection, authenticates and runs the read loop
+func (w *WebBot) handleWebSocket(rw http.ResponseWriter, r *http.Request) {
+	conn, err := w.upgrader.Upgrade(rw, r, nil)
+	if err != nil {
+		logger.WithField("error", err).Warn("web-websocket-upgrade-failed")
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(webMaxFrameSize)
+
+	// The first frame must authenticate; tokens are not passed in URLs to keep them out of logs
+	conn.SetReadDeadline(time.Now().Add(webAuthTimeout))
+	var auth webFrame
+	if err := conn.ReadJSON(&auth); err != nil || auth.Type != webFrameAuth {
+		return
+	}
+	userID, ok := w.authenticate(auth.Token)
+	if !ok {
+		logger.WithField("remote", r.RemoteAddr).Warn("web-authentication-failed")
+		conn.SetWriteDeadline(time.Now().Add(webWriteTimeout))
+		conn.WriteJSON(webFrame{Type: webFrameError, Content: "invalid token"})
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	client := &webClient{userID: userID, send: make(chan []byte, webSendBufferSize)}
+	w.mu.Lock()
+	w.clients[client] = struct{}{}
+	history := append([]webHistoryMsg(nil), w.history[userID]...)
+	w.mu.Unlock()
+
+	defer w.removeClient(client)
+	go w.writePump(conn, client)
+
+	logger.WithFields(logrus.Fields{
+		"platform": "web",
+		"user_id":  userID,
+		"remote":   r.RemoteAddr,
+	}).Info("web-client-connected")
+
+	w.sendTo(client, webFrame{Type: webFrameAuthOK, User: userID})
+	w.sendTo(client, webFrame{Type: webFrameHistory, Messages: history})
+	w.sendSessions(client)
+
+	for {
+		var frame webFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			return
+		}
+		w.handleFrame(client, frame)
+	}
+}
+
+// handleFrame dispatches one frame from an authenticated client
+func (w *WebBot) handleFrame(client *webClient, frame webFrame) {
+	switch frame.Type {
+	case webFrameMessage:
+		w.dispatch(client.userID, strings.TrimSpace(frame.Content))
+	case webFrameSelect:
+		// Selection reuses the suse command so authorization and validation stay in the engine
+		if frame.Session != "" {
+			w.dispatch(client.userID, "suse "+frame.Session)
+		}
+	case webFrameSessions:
+		w.sendSessions(client)
+	}
+}
+
+// dispatch records a user message and forwards it to the engine
+func (w *WebBot) dispatch(userID, content string) {
+	if content == "" {
+		return
+	}
+
+	now := time.Now()
+	w.appendHistory(userID, webHistoryMsg{Role: "user", Content: content, Timestamp: now.UnixMilli()})
+	// Echo to the user's other tabs
+	w.broadcast(userID, webFrame{Type: webFrameMessage, Role: "user", Content: content, Timestamp: now.UnixMilli()})
+
+	messageID := "web-" + strconv.FormatUint(w.msgCounter.Add(1), 10)
+	w.mu.Lock()
+	if len(w.typing) >= webMaxTyping {
+		// Commands never clear their indicator; drop stale entries
+		w.typing = make(map[string]string)
+	}
+	w.typing[messageID] = userID
+	w.mu.Unlock()
+
+	logger.WithFields(logrus.Fields{
+		"platform":   "web",
+		"user_id":    userID,
+		"message_id": messageID,
+	}).Info("received-web-message")
+
+	if handler := w.GetMessageHandler(); handler != nil {
+		handler(BotMessage{
+			Platform:  "web",
+			UserID:    userID,
+			Channel:   userID,
+			MessageID: messageID,
+			Content:   content,
+			Timestamp: now,
+		})
+	}
+}
+
+// sendSessions sends the session picker data to one client
+func (w *WebBot) sendSessions(client *webClient) {
+	w.mu.RLock()
+	provider := w.sessionProvider
+	w.mu.RUnlock()
+	if provider == nil {
+		return
+	}
+
+	sessions, current := provider.ListSessions("web", client.userID)
+	w.sendTo(client, webFrame{Type: webFrameSessions, Sessions: sessions, Current: current})
+}
+
+// writePump serializes writes to a connection and keeps it alive with pings
+func (w *WebBot) writePump(conn *websocket.Conn, client *webClient) {
+	ticker := time.NewTicker(webPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case data, ok := <-client.send:
+			conn.SetWriteDeadline(time.Now().Add(webWriteTimeout))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				conn.Close()
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(webWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// sendTo queues a frame for one client, dropping the client if it cannot keep up
+func (w *WebBot) sendTo(client *webClient, frame webFrame) {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return
+	}
+
+	w.mu.RLock()
+	_, connected := w.clients[client]
+	if connected {
+		select {
+		case client.send <- data:
+		default:
+			connected = false
+		}
+	}
+	w.mu.RUnlock()
+
+	if !connected {
+		w.removeClient(client)
+	}
+}
+
+// clientsOf returns all open connections of a user
+func (w *WebBot) clientsOf(userID string) []*webClient {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var targets []*webClient
+	for client := range w.clients {
+		if client.userID == userID {
+			targets = append(targets, client)
+		}
+	}
+	return targets
+}
+
+// broadcast sends a frame to every connection of a user and reports whether any exist
+func (w *WebBot) broadcast(userID string, frame webFrame) bool {
+	targets := w.clientsOf(userID)
+	for _, client := range targets {
+		w.sendTo(client, frame)
+	}
+	return len(targets) > 0
+}
+
+// removeClient unregisters a client and closes its send queue
+func (w *WebBot) removeClient(client *webClient) {
+	w.mu.Lock()
+	_, exists := w.clients[client]
+	delete(w.clients, client)
+	w.mu.Unlock()
+
+	if exists {
+		client.close()
+	}
+}
+
+// appendHistory stores a message in the user's bounded history
+func (w *WebBot) appendHistory(userID string, msg webHistoryMsg) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	history := append(w.history[userID], msg)
+	if len(history) > webMaxHistory {
+		history = history[len(history)-webMaxHistory:]
+	}
+	w.history[userID] = history
+}
+
+// SendMessage pushes a response to all open tabs of the user (channel is the user ID)
+// Messages are kept in history so they are shown on the next connect
+func (w *WebBot) SendMessage(channel, message string) error {
+	w.mu.RLock()
+	_, known := w.tokens[channel]
+	w.mu.RUnlock()
+	if !known {
+		return fmt.Errorf("unknown web user: %s", channel)
+	}
+
+	now := time.Now().UnixMilli()
+	w.appendHistory(channel, webHistoryMsg{Role: "bot", Content: message, Timestamp: now})
+	delivered := w.broadcast(channel, webFrame{Type: webFrameMessage, Role: "bot", Content: message, Timestamp: now})
+
+	// Session state may have changed (suse/snew/sdel), refresh pickers
+	for _, client := range w.clientsOf(channel) {
+		w.sendSessions(client)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"user_id":   channel,
+		"length":    len(message),
+		"delivered": delivered,
+	}).Info("message-sent-to-web")
+	return nil
+}
+
+// SendDirectMessage pushes a message to the user; every web chat is already private
+func (w *WebBot) SendDirectMessage(userID, message string) error {
+	return w.SendMessage(userID, message)
+}
+
+// SendEditableMessage pushes a response like SendMessage and returns its ID,
+// so progress updates can replace it in place
+func (w *WebBot) SendEditableMessage(channel, message string) (string, error) {
+	w.mu.RLock()
+	_, known := w.tokens[channel]
+	w.mu.RUnlock()
+	if !known {
+		return "", fmt.Errorf("unknown web user: %s", channel)
+	}
+
+	id := "bot-" + strconv.FormatUint(w.msgCounter.Add(1), 10)
+	now := time.Now().UnixMilli()
+	w.appendHistory(channel, webHistoryMsg{ID: id, Role: "bot", Content: message, Timestamp: now})
+	w.broadcast(channel, webFrame{Type: webFrameMessage, ID: id, Role: "bot", Content: message, Timestamp: now})
+	return id, nil
+}
+
+// EditMessage replaces the content of a message sent by SendEditableMessage
+// in history and in all open tabs of the user
+func (w *WebBot) EditMessage(channel, messageID, message string) error {
+	w.mu.Lock()
+	found := false
+	history := w.history[channel]
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].ID == messageID {
+			history[i].Content = message
+			found = true
+			break
+		}
+	}
+	w.mu.Unlock()
+	if !found {
+		return fmt.Errorf("unknown web message %s for %s", messageID, channel)
+	}
+
+	w.broadcast(channel, webFrame{Type: webFrameEdit, ID: messageID, Content: message})
+	return nil
+}
+
+// SupportsTypingIndicator returns true; the UI shows a thinking indicator
+func (w *WebBot) SupportsTypingIndicator() bool {
+	return true
+}
+
+// AddTypingIndicator shows the thinking indicator for the user who sent messageID
+func (w *WebBot) AddTypingIndicator(messageID string) bool {
+	w.mu.RLock()
+	userID, ok := w.typing[messageID]
+	w.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	return w.broadcast(userID, webFrame{Type: webFrameTyping, Active: true})
+}
+
+// RemoveTypingIndicator hides the thinking indicator for the user who sent messageID
+func (w *WebBot) RemoveTypingIndicator(messageID string) error {
+	w.mu.Lock()
+	userID, ok := w.typing[messageID]
+	delete(w.typing, messageID)
+	w.mu.Unlock()
+	if ok {
+		w.broadcast(userID, webFrame{Type: webFrameTyping, Active: false})
+	}
+	return nil
+}
+
+// Stop closes all connections and shuts down the HTTP server
+func (w *WebBot) Stop() error {
+	w.mu.Lock()
+	server := w.server
+	w.server = nil
+	clients := w.clients
+	w.clients = make(map[*webClient]struct{})
+	w.mu.Unlock()
+
+	for client := range clients {
+		client.close()
+	}
+
+	if server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop web server: %w", err)
+	}
+
+	logger.Info("web-bot-stopped")
+	return nil
+}
+
+// SetMessageHandler sets the message handler in a thread-safe manner
+func (w *WebBot) SetMessageHandler(handler func(BotMessage)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messageHandler = handler
+}
+
+// GetMessageHandler gets the message handler in a thread-safe manner
+func (w *WebBot) GetMessageHandler() func(BotMessage) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.messageHandler
+}
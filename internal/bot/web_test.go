@@ -0,0 +1,250 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSessionProvider is a fixed SessionProvider for tests
+type stubSessionProvider struct {
+	sessions []SessionInfo
+	current  string
+	cliTypes []string
+}
+
+func (s *stubSessionProvider) ListSessions(platform, userID string) ([]SessionInfo, string) {
+	return s.sessions, s.current
+}
+
+func (s *stubSessionProvider) CLITypes() []string {
+	return s.cliTypes
+}
+
+// newTestWebServer starts a WebBot handler on an httptest server
+func newTestWebServer(t *testing.T) (*WebBot, *httptest.Server, *[]BotMessage, *sync.Mutex) {
+	w := NewWebBot("", map[string]string{"alice": "token-a", "bob": "token-b"})
+	w.SetSessionProvider(&stubSessionProvider{
+		sessions: []SessionInfo{{Name: "dev", CLIType: "claude", State: "idle"}},
+		current:  "dev",
+	})
+
+	var mu sync.Mutex
+	var received []BotMessage
+	w.SetMessageHandler(func(msg BotMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, msg)
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", w.handleIndex)
+	mux.HandleFunc("/ws", w.handleWebSocket)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return w, server, &received, &mu
+}
+
+// dialWeb connects and authenticates with token
+func dialWeb(t *testing.T, server *httptest.Server, token string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	require.NoError(t, conn.WriteJSON(webFrame{Type: webFrameAuth, Token: token}))
+	return conn
+}
+
+// readFrame reads the next frame with a deadline
+func readFrame(t *testing.T, conn *websocket.Conn) webFrame {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var frame webFrame
+	require.NoError(t, conn.ReadJSON(&frame))
+	return frame
+}
+
+func TestWebBot_ServesIndex(t *testing.T) {
+	_, server, _, _ := newTestWebServer(t)
+
+	resp, err := http.Get(server.URL + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+
+	resp, err = http.Get(server.URL + "/missing")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestWebBot_InvalidToken(t *testing.T) {
+	_, server, _, _ := newTestWebServer(t)
+
+	conn := dialWeb(t, server, "wrong")
+	frame := readFrame(t, conn)
+	assert.Equal(t, webFrameError, frame.Type)
+}
+
+func TestWebBot_LoginHistoryAndSessions(t *testing.T) {
+	w, server, _, _ := newTestWebServer(t)
+	w.appendHistory("alice", webHistoryMsg{Role: "bot", Content: "earlier", Timestamp: 1})
+
+	conn := dialWeb(t, server, "token-a")
+
+	frame := readFrame(t, conn)
+	assert.Equal(t, webFrameAuthOK, frame.Type)
+	assert.Equal(t, "alice", frame.User)
+
+	frame = readFrame(t, conn)
+	assert.Equal(t, webFrameHistory, frame.Type)
+	require.Len(t, frame.Messages, 1)
+	assert.Equal(t, "earlier", frame.Messages[0].Content)
+
+	frame = readFrame(t, conn)
+	assert.Equal(t, webFrameSessions, frame.Type)
+	assert.Equal(t, "dev", frame.Current)
+	assert.Len(t, frame.Sessions, 1)
+}
+
+func TestWebBot_MessageRoundTrip(t *testing.T) {
+	w, server, received, mu := newTestWebServer(t)
+
+	conn := dialWeb(t, server, "token-a")
+	for i := 0; i < 3; i++ {
+		readFrame(t, conn) // auth_ok, history, sessions
+	}
+
+	require.NoError(t, conn.WriteJSON(webFrame{Type: webFrameMessage, Content: "  hello  "}))
+
+	// The user's message is echoed to their tabs
+	frame := readFrame(t, conn)
+	assert.Equal(t, webFrameMessage, frame.Type)
+	assert.Equal(t, "user", frame.Role)
+	assert.Equal(t, "hello", frame.Content)
+
+	mu.Lock()
+	require.Len(t, *received, 1)
+	msg := (*received)[0]
+	mu.Unlock()
+	assert.Equal(t, "web", msg.Platform)
+	assert.Equal(t, "alice", msg.UserID)
+	assert.Equal(t, "alice", msg.Channel)
+	assert.NotEmpty(t, msg.MessageID)
+
+	// Typing indicator
+	assert.True(t, w.AddTypingIndicator(msg.MessageID))
+	frame = readFrame(t, conn)
+	assert.Equal(t, webFrameTyping, frame.Type)
+	assert.True(t, frame.Active)
+
+	// Response is pushed and followed by a session refresh
+	assert.NoError(t, w.SendMessage("alice", "world"))
+	frame = readFrame(t, conn)
+	assert.Equal(t, "bot", frame.Role)
+	assert.Equal(t, "world", frame.Content)
+	frame = readFrame(t, conn)
+	assert.Equal(t, webFrameSessions, frame.Type)
+
+	assert.NoError(t, w.RemoveTypingIndicator(msg.MessageID))
+	frame = readFrame(t, conn)
+	assert.Equal(t, webFrameTyping, frame.Type)
+	assert.False(t, frame.Active)
+
+	// Both directions are kept in history
+	w.mu.RLock()
+	assert.Len(t, w.history["alice"], 2)
+	w.mu.RUnlock()
+}
+
+func TestWebBot_EditableMessage(t *testing.T) {
+	w, server, _, _ := newTestWebServer(t)
+	var _ MessageEditor = w
+
+	conn := dialWeb(t, server, "token-a")
+	for i := 0; i < 3; i++ {
+		readFrame(t, conn)
+	}
+
+	id, err := w.SendEditableMessage("alice", "Working")
+	require.NoError(t, err)
+	frame := readFrame(t, conn)
+	assert.Equal(t, webFrameMessage, frame.Type)
+	assert.Equal(t, id, frame.ID)
+	assert.Equal(t, "Working", frame.Content)
+
+	require.NoError(t, w.EditMessage("alice", id, "Done"))
+	frame = readFrame(t, conn)
+	assert.Equal(t, webFrameEdit, frame.Type)
+	assert.Equal(t, id, frame.ID)
+	assert.Equal(t, "Done", frame.Content)
+
+	// History keeps one entry with the latest content for the next connect
+	w.mu.RLock()
+	assert.Equal(t, []webHistoryMsg{{ID: id, Role: "bot", Content: "Done", Timestamp: w.history["alice"][0].Timestamp}}, w.history["alice"])
+	w.mu.RUnlock()
+
+	assert.Error(t, w.EditMessage("alice", "bot-999", "x"))
+	_, err = w.SendEditableMessage("mallory", "x")
+	assert.Error(t, err)
+}
+
+func TestWebBot_SelectSessionUsesSuse(t *testing.T) {
+	_, server, received, mu := newTestWebServer(t)
+
+	conn := dialWeb(t, server, "token-b")
+	for i := 0; i < 3; i++ {
+		readFrame(t, conn)
+	}
+
+	require.NoError(t, conn.WriteJSON(webFrame{Type: webFrameSelect, Session: "dev"}))
+	readFrame(t, conn) // echo
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, *received, 1)
+	assert.Equal(t, "bob", (*received)[0].UserID)
+	assert.Equal(t, "suse dev", (*received)[0].Content)
+}
+
+func TestWebBot_SendMessage_UnknownUser(t *testing.T) {
+	w := NewWebBot("", map[string]string{"alice": "t"})
+	assert.Error(t, w.SendMessage("mallory", "hi"))
+	// Known user without open tabs still gets history
+	assert.NoError(t, w.SendMessage("alice", "queued"))
+	assert.Len(t, w.history["alice"], 1)
+}
+
+func TestWebBot_HistoryIsBounded(t *testing.T) {
+	w := NewWebBot("", map[string]string{"alice": "t"})
+	for i := 0; i < webMaxHistory+10; i++ {
+		w.appendHistory("alice", webHistoryMsg{Role: "bot", Content: "x"})
+	}
+	assert.Len(t, w.history["alice"], webMaxHistory)
+}
+
+func TestWebBot_StartRequiresTokens(t *testing.T) {
+	w := NewWebBot("127.0.0.1:0", nil)
+	assert.Error(t, w.Start(func(BotMessage) {}))
+
+	w = NewWebBot("127.0.0.1:0", map[string]string{"alice": "t"})
+	assert.NoError(t, w.Start(func(BotMessage) {}))
+	assert.NoError(t, w.Stop())
+	assert.NoError(t, w.Stop())
+}
+
+func TestWebBot_UnknownTypingIndicator(t *testing.T) {
+	w := NewWebBot("", map[string]string{"alice": "t"})
+	assert.True(t, w.SupportsTypingIndicator())
+	assert.False(t, w.AddTypingIndicator("nope"))
+	assert.NoError(t, w.RemoveTypingIndicator("nope"))
+}
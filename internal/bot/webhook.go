@@ -0,0 +1,454 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/proxy"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// Webhook adapter defaults
+const (
+	// DefaultWebhookListenAddr is the default listen address for inbound webhooks
+	DefaultWebhookListenAddr = "127.0.0.1:8090"
+	// DefaultWebhookCallbackTemplate is the default JSON body sent to callback URLs
+	DefaultWebhookCallbackTemplate = `{"text": {{json .Message}}}`
+
+	webhookMaxBodySize    = 1 << 20 // 1MB inbound body limit
+	webhookChannelSep     = "|"     // Separates integration name and conversation ID in channels
+	webhookReadTimeout    = 10 * time.Second
+	webhookShutdownPeriod = 5 * time.Second
+)
+
+// webhookMentionPattern matches Teams-style <at>Bot</at> mentions
+var webhookMentionPattern = regexp.MustCompile(`<at>[^<]*</at>`)
+
+// WebhookIntegration describes one outgoing-webhook integration (Teams, Mattermost, ...)
+type WebhookIntegration struct {
+	Name              string            // Integration name, used as user/channel prefix
+	Path              string            // HTTP path for inbound requests (default: /webhook/<name>)
+	Secret            string            // HMAC secret shared with the chat platform
+	SecretEncoding    string            // "raw" (default) or "base64" (Teams)
+	SignatureHeader   string            // Header carrying the signature (default: X-Signature)
+	SignaturePrefix   string            // Prefix stripped from the header value (e.g. "HMAC ", "sha256=")
+	SignatureEncoding string            // "hex" (default) or "base64"
+	CallbackURL       string            // URL receiving replies
+	CallbackTemplate  string            // text/template for the reply JSON body
+	CallbackHeaders   map[string]string // Extra headers for callback requests
+	UserField         string            // Dotted JSON path of the sender ID (e.g. "from.id")
+	TextField         string            // Dotted JSON path of the message text
+	ChannelField      string            // Dotted JSON path of the conversation ID (optional)
+	MessageIDField    string            // Dotted JSON path of the message ID (optional)
+	AckBody           string            // Body returned synchronously to the inbound request (optional)
+	UserMap           map[string]string // Platform user ID -> whitelist user ID
+}
+
+// webhookCallbackData is the data passed to callback templates
+type webhookCallbackData struct {
+	Integration string
+	Channel     string
+	Message     string
+}
+
+// WebhookBot implements BotAdapter for generic HMAC-signed outgoing webhooks
+type WebhookBot struct {
+	DefaultTypingIndicator
+	disconnectSignal
+	mu             sync.RWMutex
+	listenAddr     string
+	integrations   map[string]*WebhookIntegration
+	templates      map[string]*template.Template
+	server         *http.Server
+	messageHandler func(BotMessage)
+	proxyMgr       proxy.Manager
+}
+
+// NewWebhookBot creates a new generic webhook bot instance
+func NewWebhookBot(listenAddr string, integrations []WebhookIntegration) (*WebhookBot, error) {
+	if listenAddr == "" {
+		listenAddr = DefaultWebhookListenAddr
+	}
+
+	w := &WebhookBot{
+		listenAddr:   listenAddr,
+		integrations: make(map[string]*WebhookIntegration),
+		templates:    make(map[string]*template.Template),
+	}
+
+	for i := range integrations {
+		integration := integrations[i]
+		if integration.Name == "" {
+			return nil, fmt.Errorf("webhook integration #%d has no name", i)
+		}
+		if strings.Contains(integration.Name, webhookChannelSep) {
+			return nil, fmt.Errorf("webhook integration name %q must not contain %q", integration.Name, webhookChannelSep)
+		}
+		if _, exists := w.integrations[integration.Name]; exists {
+			return nil, fmt.Errorf("duplicate webhook integration name %q", integration.Name)
+		}
+		if integration.Secret == "" {
+			return nil, fmt.Errorf("webhook integration %q requires a secret", integration.Name)
+		}
+		if integration.UserField == "" {
+			return nil, fmt.Errorf("webhook integration %q requires a user_field", integration.Name)
+		}
+		if integration.Path == "" {
+			integration.Path = "/webhook/" + integration.Name
+		}
+		if integration.SignatureHeader == "" {
+			integration.SignatureHeader = "X-Signature"
+		}
+		if integration.TextField == "" {
+			integration.TextField = "text"
+		}
+		if integration.CallbackTemplate == "" {
+			integration.CallbackTemplate = DefaultWebhookCallbackTemplate
+		}
+
+		tmpl, err := template.New(integration.Name).Funcs(template.FuncMap{
+			"json": webhookJSONString,
+		}).Parse(integration.CallbackTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("webhook integration %q: invalid callback template: %w", integration.Name, err)
+		}
+
+		w.integrations[integration.Name] = &integration
+		w.templates[integration.Name] = tmpl
+	}
+
+	return w, nil
+}
+
+// SetProxyManager sets the proxy manager used for callback requests
+func (w *WebhookBot) SetProxyManager(mgr proxy.Manager) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.proxyMgr = mgr
+}
+
+// Start starts the inbound HTTP server
+func (w *WebhookBot) Start(messageHandler func(BotMessage)) error {
+	w.SetMessageHandler(messageHandler)
+
+	logger.WithFields(logrus.Fields{
+		"listen":       w.listenAddr,
+		"integrations": len(w.integrations),
+	}).Info("starting-webhook-bot")
+
+	mux := http.NewServeMux()
+	for _, integration := range w.integrations {
+		name := integration.Name
+		mux.HandleFunc(integration.Path, func(rw http.ResponseWriter, r *http.Request) {
+			w.handleInbound(name, rw, r)
+		})
+	}
+
+	listener, err := net.Listen("tcp", w.listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", w.listenAddr, err)
+	}
+
+	server := &http.Server{
+		Handler:     mux,
+		ReadTimeout: webhookReadTimeout,
+	}
+
+	w.mu.Lock()
+	w.server = server
+	w.mu.Unlock()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.WithField("error", err).Error("webhook-server-error")
+			w.signalDisconnect(fmt.Errorf("webhook server: %w", err))
+		}
+	}()
+
+	logger.WithField("address", listener.Addr().String()).Info("webhook-bot-listening")
+	return nil
+}
+
+// handleInbound verifies and dispatches one inbound webhook request
+func (w *WebhookBot) handleInbound(name string, rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.mu.RLock()
+	integration := w.integrations[name]
+	w.mu.RUnlock()
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, webhookMaxBodySize))
+	if err != nil {
+		http.Error(rw, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if !verifyWebhookSignature(integration, body, r.Header.Get(integration.SignatureHeader)) {
+		logger.WithFields(logrus.Fields{
+			"integration": name,
+			"remote":      r.RemoteAddr,
+		}).Warn("webhook-signature-verification-failed")
+		http.Error(rw, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	msg, err := parseWebhookMessage(integration, body)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"integration": name,
+			"error":       err,
+		}).Warn("failed-to-parse-webhook-payload")
+		http.Error(rw, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"platform":    "webhook",
+		"integration": name,
+		"user_id":     msg.UserID,
+		"channel":     msg.Channel,
+	}).Info("received-webhook-message")
+
+	if handler := w.GetMessageHandler(); handler != nil && msg.Content != "" {
+		handler(msg)
+	}
+
+	if integration.AckBody != "" {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		io.WriteString(rw, integration.AckBody)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// verifyWebhookSignature checks the HMAC-SHA256 signature of an inbound body
+func verifyWebhookSignature(integration *WebhookIntegration, body []byte, header string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	header = strings.TrimPrefix(header, integration.SignaturePrefix)
+
+	var provided []byte
+	var err error
+	switch integration.SignatureEncoding {
+	case "base64":
+		provided, err = base64.StdEncoding.DecodeString(header)
+	default:
+		provided, err = hex.DecodeString(header)
+	}
+	if err != nil {
+		return false
+	}
+
+	key := []byte(integration.Secret)
+	if integration.SecretEncoding == "base64" {
+		key, err = base64.StdEncoding.DecodeString(integration.Secret)
+		if err != nil {
+			return false
+		}
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), provided)
+}
+
+// parseWebhookMessage extracts a BotMessage from an inbound JSON payload
+func parseWebhookMessage(integration *WebhookIntegration, body []byte) (BotMessage, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return BotMessage{}, fmt.Errorf("decode payload: %w", err)
+	}
+
+	rawUserID := lookupJSONPath(payload, integration.UserField)
+	if rawUserID == "" {
+		return BotMessage{}, fmt.Errorf("missing user field %q", integration.UserField)
+	}
+	userID := rawUserID
+	if mapped, ok := integration.UserMap[rawUserID]; ok {
+		userID = mapped
+	}
+
+	text := webhookMentionPattern.ReplaceAllString(lookupJSONPath(payload, integration.TextField), "")
+
+	channel := integration.Name
+	if conversation := lookupJSONPath(payload, integration.ChannelField); conversation != "" {
+		channel = integration.Name + webhookChannelSep + conversation
+	}
+
+	return BotMessage{
+		Platform:  "webhook",
+		UserID:    integration.Name + ":" + userID,
+		Channel:   channel,
+		MessageID: lookupJSONPath(payload, integration.MessageIDField),
+		Content:   strings.TrimSpace(text),
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// lookupJSONPath resolves a dotted path (e.g. "from.id") in a decoded JSON object
+func lookupJSONPath(data map[string]interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	var current interface{} = data
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		current = obj[key]
+	}
+
+	switch v := current.(type) {
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprintf("%v", v)
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+// webhookJSONString renders a value as a JSON string literal for templates
+func webhookJSONString(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SendMessage posts a reply to the integration's callback URL
+// The channel has the form "<integration>" or "<integration>|<conversation>"
+func (w *WebhookBot) SendMessage(channel, message string) error {
+	name, conversation, _ := strings.Cut(channel, webhookChannelSep)
+
+	w.mu.RLock()
+	integration, exists := w.integrations[name]
+	tmpl := w.templates[name]
+	proxyMgr := w.proxyMgr
+	w.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("unknown webhook integration for channel %q", channel)
+	}
+	if integration.CallbackURL == "" {
+		return fmt.Errorf("webhook integration %q has no callback_url", name)
+	}
+
+	const maxWebhookLength = constants.MaxWebhookMessageLength
+	if len(message) > maxWebhookLength {
+		logger.WithFields(logrus.Fields{
+			"original_length": len(message),
+			"max_length":      maxWebhookLength,
+		}).Info("truncating-message-for-webhook-limit")
+		message = "..." + message[len(message)-maxWebhookLength+3:]
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, webhookCallbackData{
+		Integration: name,
+		Channel:     conversation,
+		Message:     message,
+	}); err != nil {
+		return fmt.Errorf("render callback template: %w", err)
+	}
+
+	client := &http.Client{Timeout: proxy.DefaultHTTPClientTimeout}
+	if proxyMgr != nil {
+		if proxyClient, err := proxyMgr.GetHTTPClient("webhook"); err == nil {
+			client = proxyClient
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), proxy.DefaultHTTPClientTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, integration.CallbackURL, &body)
+	if err != nil {
+		return fmt.Errorf("create callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range integration.CallbackHeaders {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"integration": name,
+			"error":       err,
+		}).Error("failed-to-send-message-to-webhook")
+		return fmt.Errorf("failed to send webhook callback: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook callback failed: %s", resp.Status)
+	}
+
+	logger.WithField("integration", name).Info("message-sent-to-webhook")
+	return nil
+}
+
+// Stop shuts down the inbound HTTP server
+func (w *WebhookBot) Stop() error {
+	w.mu.Lock()
+	server := w.server
+	w.server = nil
+	w.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookShutdownPeriod)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop webhook server: %w", err)
+	}
+
+	logger.Info("webhook-bot-stopped")
+	return nil
+}
+
+// SetMessageHandler sets the message handler in a thread-safe manner
+func (w *WebhookBot) SetMessageHandler(handler func(BotMessage)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messageHandler = handler
+}
+
+// GetMessageHandler gets the message handler in a thread-safe manner
+func (w *WebhookBot) GetMessageHandler() func(BotMessage) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.messageHandler
+}
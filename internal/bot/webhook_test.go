@@ -0,0 +1,241 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func signHex(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestNewWebhookBot_Defaults(t *testing.T) {
+	w, err := NewWebhookBot("", []WebhookIntegration{{Name: "mm", Secret: "s", UserField: "user_id"}})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultWebhookListenAddr, w.listenAddr)
+
+	integration := w.integrations["mm"]
+	assert.Equal(t, "/webhook/mm", integration.Path)
+	assert.Equal(t, "X-Signature", integration.SignatureHeader)
+	assert.Equal(t, "text", integration.TextField)
+	assert.Equal(t, DefaultWebhookCallbackTemplate, integration.CallbackTemplate)
+}
+
+func TestNewWebhookBot_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		integrations []WebhookIntegration
+	}{
+		{"missing name", []WebhookIntegration{{Secret: "s", UserField: "user_id"}}},
+		{"missing secret", []WebhookIntegration{{Name: "a", UserField: "user_id"}}},
+		{"missing user field", []WebhookIntegration{{Name: "a", Secret: "s"}}},
+		{"separator in name", []WebhookIntegration{{Name: "a|b", Secret: "s", UserField: "user_id"}}},
+		{"duplicate name", []WebhookIntegration{{Name: "a", Secret: "s", UserField: "user_id"}, {Name: "a", Secret: "s", UserField: "user_id"}}},
+		{"bad template", []WebhookIntegration{{Name: "a", Secret: "s", UserField: "user_id", CallbackTemplate: "{{"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebhookBot("", tt.integrations)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestVerifyWebhookSignature_Hex(t *testing.T) {
+	integration := &WebhookIntegration{Secret: "topsecret", SignaturePrefix: "sha256="}
+	body := []byte(`{"text":"hi"}`)
+
+	assert.True(t, verifyWebhookSignature(integration, body, "sha256="+signHex("topsecret", body)))
+	assert.False(t, verifyWebhookSignature(integration, body, "sha256="+signHex("wrong", body)))
+	assert.False(t, verifyWebhookSignature(integration, body, ""))
+	assert.False(t, verifyWebhookSignature(integration, body, "sha256=not-hex"))
+}
+
+func TestVerifyWebhookSignature_TeamsStyle(t *testing.T) {
+	key := []byte("teams-key")
+	integration := &WebhookIntegration{
+		Secret:            base64.StdEncoding.EncodeToString(key),
+		SecretEncoding:    "base64",
+		SignaturePrefix:   "HMAC ",
+		SignatureEncoding: "base64",
+	}
+	body := []byte(`{"text":"<at>Bot</at> hello"}`)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	header := "HMAC " + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	assert.True(t, verifyWebhookSignature(integration, body, header))
+	assert.False(t, verifyWebhookSignature(integration, []byte(`{"text":"tampered"}`), header))
+}
+
+func TestParseWebhookMessage(t *testing.T) {
+	integration := &WebhookIntegration{
+		Name:           "teams",
+		UserField:      "from.id",
+		TextField:      "text",
+		ChannelField:   "conversation.id",
+		MessageIDField: "id",
+		UserMap:        map[string]string{"29:abc": "alice"},
+	}
+	body := []byte(`{"id":"m1","text":"<at>clibot</at> slist","from":{"id":"29:abc"},"conversation":{"id":"c42"}}`)
+
+	msg, err := parseWebhookMessage(integration, body)
+	assert.NoError(t, err)
+	assert.Equal(t, "webhook", msg.Platform)
+	assert.Equal(t, "teams:alice", msg.UserID)
+	assert.Equal(t, "teams|c42", msg.Channel)
+	assert.Equal(t, "m1", msg.MessageID)
+	assert.Equal(t, "slist", msg.Content)
+}
+
+func TestParseWebhookMessage_UnmappedUserAndNoChannel(t *testing.T) {
+	integration := &WebhookIntegration{Name: "mm", UserField: "user_id", TextField: "text"}
+
+	msg, err := parseWebhookMessage(integration, []byte(`{"user_id":12345,"text":"hello"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "mm:12345", msg.UserID)
+	assert.Equal(t, "mm", msg.Channel)
+
+	_, err = parseWebhookMessage(integration, []byte(`{"text":"hello"}`))
+	assert.Error(t, err)
+
+	_, err = parseWebhookMessage(integration, []byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	var data map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"a":{"b":{"c":"deep"}},"n":1.5,"flag":true,"list":[1]}`), &data))
+
+	assert.Equal(t, "deep", lookupJSONPath(data, "a.b.c"))
+	assert.Equal(t, "1.5", lookupJSONPath(data, "n"))
+	assert.Equal(t, "true", lookupJSONPath(data, "flag"))
+	assert.Equal(t, "", lookupJSONPath(data, "list"))
+	assert.Equal(t, "", lookupJSONPath(data, "a.missing"))
+	assert.Equal(t, "", lookupJSONPath(data, "a.b.c.d"))
+	assert.Equal(t, "", lookupJSONPath(data, ""))
+}
+
+func TestWebhookBot_HandleInbound(t *testing.T) {
+	w, err := NewWebhookBot("", []WebhookIntegration{{
+		Name:      "mm",
+		Secret:    "s3cret",
+		UserField: "user_id",
+		AckBody:   `{"ok":true}`,
+	}})
+	assert.NoError(t, err)
+
+	var received []BotMessage
+	w.SetMessageHandler(func(msg BotMessage) {
+		received = append(received, msg)
+	})
+
+	body := []byte(`{"user_id":"u1","text":"hello"}`)
+
+	// Valid signature
+	req := httptest.NewRequest(http.MethodPost, "/webhook/mm", strings.NewReader(string(body)))
+	req.Header.Set("X-Signature", signHex("s3cret", body))
+	rec := httptest.NewRecorder()
+	w.handleInbound("mm", rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"ok":true}`, rec.Body.String())
+	assert.Len(t, received, 1)
+	assert.Equal(t, "mm:u1", received[0].UserID)
+	assert.Equal(t, "hello", received[0].Content)
+
+	// Invalid signature is rejected and not dispatched
+	req = httptest.NewRequest(http.MethodPost, "/webhook/mm", strings.NewReader(string(body)))
+	req.Header.Set("X-Signature", signHex("other", body))
+	rec = httptest.NewRecorder()
+	w.handleInbound("mm", rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Len(t, received, 1)
+
+	// Wrong method
+	req = httptest.NewRequest(http.MethodGet, "/webhook/mm", nil)
+	rec = httptest.NewRecorder()
+	w.handleInbound("mm", rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestWebhookBot_SendMessage(t *testing.T) {
+	var gotBody string
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		gotHeader = r.Header.Get("X-Token")
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	w, err := NewWebhookBot("", []WebhookIntegration{{
+		Name:             "teams",
+		Secret:           "s",
+		UserField:        "from.id",
+		CallbackURL:      server.URL,
+		CallbackTemplate: `{"conversation": {{json .Channel}}, "text": {{json .Message}}}`,
+		CallbackHeaders:  map[string]string{"X-Token": "abc"},
+	}})
+	assert.NoError(t, err)
+	w.SetProxyManager(&mockProxyManager{})
+
+	err = w.SendMessage("teams|c42", "line \"one\"\nline two")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", gotHeader)
+
+	var payload map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(gotBody), &payload))
+	assert.Equal(t, "c42", payload["conversation"])
+	assert.Equal(t, "line \"one\"\nline two", payload["text"])
+}
+
+func TestWebhookBot_SendMessage_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	w, err := NewWebhookBot("", []WebhookIntegration{
+		{Name: "down", Secret: "s", UserField: "user_id", CallbackURL: server.URL},
+		{Name: "nocallback", Secret: "s", UserField: "user_id"},
+	})
+	assert.NoError(t, err)
+
+	assert.Error(t, w.SendMessage("unknown", "hi"))
+	assert.Error(t, w.SendMessage("nocallback", "hi"))
+
+	err = w.SendMessage("down", "hi")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "502")
+}
+
+func TestWebhookBot_StartStop(t *testing.T) {
+	w, err := NewWebhookBot("127.0.0.1:0", []WebhookIntegration{{Name: "mm", Secret: "s", UserField: "user_id"}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.Start(func(BotMessage) {}))
+	assert.NoError(t, w.Stop())
+	// Stopping twice is a no-op
+	assert.NoError(t, w.Stop())
+}
+
+func TestWebhookBot_SupportsTypingIndicator(t *testing.T) {
+	w, err := NewWebhookBot("", nil)
+	assert.NoError(t, err)
+	assert.False(t, w.SupportsTypingIndicator())
+}
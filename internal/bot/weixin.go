@@ -10,6 +10,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
@@ -101,8 +103,11 @@ type inboundTextItem struct {
 	Text string `json:"text"`
 }
 
+// inboundMediaItem is an image, voice, file or video; the protocol uses image_url for every media type
 type inboundMediaItem struct {
 	ImageURL string `json:"image_url"`
+	FileName string `json:"file_name,omitempty"` // Original name of file items
+	Text     string `json:"text,omitempty"`      // Server-side transcription of voice items
 }
 
 // GetUpdatesResponse is the response from POST /ilink/bot/getupdates.
@@ -148,6 +153,7 @@ type weixinOutboundItem struct {
 	Type      int                `json:"type"`
 	TextItem  *outboundTextItem  `json:"text_item,omitempty"`
 	ImageItem *outboundImageItem `json:"image_item,omitempty"`
+	FileItem  *outboundFileItem  `json:"file_item,omitempty"`
 }
 
 type outboundTextItem struct {
@@ -158,6 +164,19 @@ type outboundImageItem struct {
 	ImageURL string `json:"image_url"`
 }
 
+type outboundFileItem struct {
+	ImageURL string `json:"image_url"`
+	FileName string `json:"file_name"`
+}
+
+// UploadMediaResponse is the response from POST /ilink/bot/uploadmedia.
+type weixinUploadMediaResponse struct {
+	URL     string `json:"url"`
+	Ret     int    `json:"ret"`
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
 // GetConfigRequest is sent to POST /ilink/bot/getconfig.
 type weixinGetConfigRequest struct {
 	ILinkUserID  string         `json:"ilink_user_id"`
@@ -211,6 +230,11 @@ const (
 	MaxMessageLength      = 2000
 	MaxChunkLength        = 2000
 	SessionExpiredErrCode = -14
+
+	weixinLongOutputLength = 3 * MaxChunkLength // Longer replies are sent as a file with a preview
+	weixinMaxMediaSize     = 50 << 20           // Maximum inbound media download size (bytes)
+	weixinMaxSeenMsgs      = 1000               // Message IDs remembered for deduplication
+	weixinMaxPollFailures  = 6                  // Consecutive failed polls before reporting a disconnect
 )
 
 // ---------------------------------------------------------------------------
@@ -229,6 +253,18 @@ func DefaultCredentialsPath() string {
 	return filepath.Join(home, ".clibot", "weixin", "credentials.json")
 }
 
+// weixinStatePath returns the conversation state file stored next to the credentials
+func weixinStatePath(credentialsPath string) string {
+	return filepath.Join(filepath.Dir(credentialsPath), "state.json")
+}
+
+// weixinState is the persisted conversation state: reply context tokens and
+// recently seen message IDs (oldest first), so replies and deduplication survive restarts
+type weixinState struct {
+	ContextTokens map[string]string `json:"context_tokens"`
+	SeenMsgs      []string          `json:"seen_msgs"`
+}
+
 func loadCredentials(path string) (*Credentials, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -496,6 +532,62 @@ func sendMessage(client *http.Client, baseURL, token string, body weixinSendMess
 	return nil
 }
 
+// uploadMedia uploads a local file and returns the URL to reference in image/file items.
+func uploadMedia(client *http.Client, baseURL, token, path string) (string, error) {
+	u := strings.TrimSuffix(baseURL, "/") + "/ilink/bot/uploadmedia"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	var payload bytes.Buffer
+	writer := multipart.NewWriter(&payload)
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return "", fmt.Errorf("create form file: %w", err)
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return "", fmt.Errorf("read file: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("close form: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u, &payload)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range buildAuthHeaders(token) {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read body: %w", err)
+	}
+
+	var result weixinUploadMediaResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	if result.ErrCode != 0 {
+		return "", &ApiError{Status: resp.StatusCode, Code: result.ErrCode, Message: result.ErrMsg}
+	}
+	if result.URL == "" {
+		return "", errors.New("upload response missing url")
+	}
+	return result.URL, nil
+}
+
 // getConfig retrieves the typing ticket.
 func getConfig(client *http.Client, baseURL, token, userID, contextToken string) (*weixinGetConfigResponse, error) {
 	u := strings.TrimSuffix(baseURL, "/") + "/ilink/bot/getconfig"
@@ -681,13 +773,15 @@ func printASCIIQR(pngData []byte) {
 
 type WeixinBot struct {
 	DefaultTypingIndicator
+	disconnectSignal
 
-	// sessionMu protects contextTokens, clientToUser, seenMsgs accessed by handleMessage.
+	// sessionMu protects credentials, contextTokens, clientToUser, seenMsgs and the login state.
 	// cursor and lastSyncBuf are only accessed by longPollLoop (single goroutine).
 	// httpClient may be accessed by both longPollLoop and SetProxyManager (called before polling).
 	sessionMu       sync.RWMutex
 	baseURL         string
 	credentialsPath string
+	statePath       string
 	credentials     *Credentials
 	cursor          string // last GetUpdatesBuf for next request
 	lastSyncBuf     string // last SyncBuf for acknowledgment
@@ -695,6 +789,11 @@ type WeixinBot struct {
 	contextTokens map[string]string
 	clientToUser  map[string]string
 	seenMsgs      map[string]bool // deduplication by message_id
+	seenOrder     []string        // seenMsgs keys, oldest first
+
+	loggingIn     bool                // a remote QR login is in progress
+	loginRequired func(reason string) // called when the session expires
+	transcriber   Transcriber
 
 	httpClient     *http.Client
 	messageHandler func(BotMessage)
@@ -715,6 +814,7 @@ func NewWeixinBot(baseURL, credentialsPath string) *WeixinBot {
 	return &WeixinBot{
 		baseURL:         baseURL,
 		credentialsPath: credentialsPath,
+		statePath:       weixinStatePath(credentialsPath),
 		contextTokens:   make(map[string]string),
 		clientToUser:    make(map[string]string),
 		seenMsgs:        make(map[string]bool),
@@ -736,16 +836,36 @@ func (b *WeixinBot) SetProxyManager(mgr proxy.Manager) {
 	b.httpClient = buildClient(APITimeout)
 }
 
+// SetTranscriber sets the transcriber used for voice messages without server-side text
+func (b *WeixinBot) SetTranscriber(t Transcriber) {
+	b.sessionMu.Lock()
+	defer b.sessionMu.Unlock()
+	b.transcriber = t
+}
+
+// SetLoginRequiredHandler registers a callback fired when the WeChat session expires
+func (b *WeixinBot) SetLoginRequiredHandler(handler func(reason string)) {
+	b.sessionMu.Lock()
+	defer b.sessionMu.Unlock()
+	b.loginRequired = handler
+}
+
 func (b *WeixinBot) Start(messageHandler func(BotMessage)) error {
 	b.ctx, b.cancel = context.WithCancel(context.Background())
 	b.messageHandler = messageHandler
 
+	if err := b.loadState(); err != nil {
+		fmt.Printf("WeChat: failed to load state: %v\n", err)
+	}
+
 	creds, err := loadCredentials(b.credentialsPath)
 	if err == nil && creds.Token != "" {
+		b.sessionMu.Lock()
 		b.credentials = creds
 		if creds.BaseURL != "" {
 			b.baseURL = creds.BaseURL
 		}
+		b.sessionMu.Unlock()
 	} else {
 		if err := b.doQRLogin(); err != nil {
 			return fmt.Errorf("QR login failed: %w", err)
@@ -756,6 +876,10 @@ func (b *WeixinBot) Start(messageHandler func(BotMessage)) error {
 	return nil
 }
 
+// errQRExpired is returned while waiting on a QR code the server has expired
+var errQRExpired = errors.New("QR code expired")
+
+// doQRLogin logs in interactively, rendering QR codes on the console until one is confirmed
 func (b *WeixinBot) doQRLogin() error {
 	client := buildClient(APITimeout)
 
@@ -765,75 +889,257 @@ func (b *WeixinBot) doQRLogin() error {
 	}
 
 	fmt.Println("QR code UUID:", qrResp.QRCode)
+	printQRCode(qrResp.QRCodeImgContent)
 
-	if qrResp.QRCodeImgContent != "" {
-		// QRCodeImgContent can be either base64 PNG image data or a URL
-		if strings.HasPrefix(qrResp.QRCodeImgContent, "http") {
-			// It's a URL - use qrterminal to render ASCII QR code
-			fmt.Println("Scan the QR code below with WeChat:")
-			qrterminal.GenerateHalfBlock(qrResp.QRCodeImgContent, qrterminal.M, os.Stdout)
-		} else {
-			// It's base64 PNG - decode and render ASCII QR
-			imgData, err := base64.StdEncoding.DecodeString(qrResp.QRCodeImgContent)
-			if err == nil {
-				printASCIIQR(imgData)
+	for {
+		creds, err := b.waitForQRConfirm(qrResp.QRCode, true)
+		if errors.Is(err, errQRExpired) {
+			qrResp, err = fetchQRCode(client, b.baseURL)
+			if err != nil {
+				return fmt.Errorf("fetch new QR code: %w", err)
 			}
+			printQRCode(qrResp.QRCodeImgContent)
+			continue
 		}
+		if err != nil {
+			return err
+		}
+		if err := b.completeLogin(creds); err != nil {
+			return err
+		}
+		fmt.Println("WeChat login successful!")
+		return nil
 	}
+}
+
+// printQRCode renders QR code content (a URL or base64 PNG) on the console
+func printQRCode(content string) {
+	if content == "" {
+		return
+	}
+	// QRCodeImgContent can be either base64 PNG image data or a URL
+	if strings.HasPrefix(content, "http") {
+		// It's a URL - use qrterminal to render ASCII QR code
+		fmt.Println("Scan the QR code below with WeChat:")
+		qrterminal.GenerateHalfBlock(content, qrterminal.M, os.Stdout)
+		return
+	}
+	// It's base64 PNG - decode and render ASCII QR
+	imgData, err := base64.StdEncoding.DecodeString(content)
+	if err == nil {
+		printASCIIQR(imgData)
+	}
+}
 
+// waitForQRConfirm polls a QR code until it is confirmed, expires or the bot stops
+func (b *WeixinBot) waitForQRConfirm(qrUUID string, verbose bool) (*Credentials, error) {
 	pollClient := buildClient(APITimeout)
 	for {
 		select {
 		case <-b.ctx.Done():
-			return errors.New("login cancelled")
+			return nil, errors.New("login cancelled")
 		case <-time.After(QRCodePollInterval):
 		}
 
-		statusResp, err := pollQRStatus(pollClient, b.baseURL, qrResp.QRCode)
+		statusResp, err := pollQRStatus(pollClient, b.baseURL, qrUUID)
 		if err != nil {
 			continue
 		}
 
 		switch statusResp.Status {
 		case QRStatusScaned:
-			fmt.Println("QR code scanned. Confirm the login inside WeChat.")
+			if verbose {
+				fmt.Println("QR code scanned. Confirm the login inside WeChat.")
+			}
 		case QRStatusConfirmed:
-			creds := &Credentials{
+			return &Credentials{
 				Token:     statusResp.BotToken,
 				BaseURL:   statusResp.BaseURL,
 				AccountID: statusResp.ILinkBotID,
 				UserID:    statusResp.ILinkUserID,
-			}
-			if err := saveCredentials(b.credentialsPath, creds); err != nil {
-				return fmt.Errorf("save credentials: %w", err)
-			}
-			b.sessionMu.Lock()
-			b.credentials = creds
-			if creds.BaseURL != "" {
-				b.baseURL = creds.BaseURL
-			}
-			b.sessionMu.Unlock()
-			fmt.Println("WeChat login successful!")
-			return nil
+			}, nil
 		case QRStatusExpired:
-			qrResp, err = fetchQRCode(client, b.baseURL)
-			if err != nil {
-				return fmt.Errorf("fetch new QR code: %w", err)
-			}
-			if qrResp.QRCodeImgContent != "" {
-				imgData, err := base64.StdEncoding.DecodeString(qrResp.QRCodeImgContent)
-				if err == nil {
-					printASCIIQR(imgData)
-				}
-			}
+			return nil, errQRExpired
+		}
+	}
+}
+
+// completeLogin saves confirmed credentials and makes them active for polling
+func (b *WeixinBot) completeLogin(creds *Credentials) error {
+	if err := saveCredentials(b.credentialsPath, creds); err != nil {
+		return fmt.Errorf("save credentials: %w", err)
+	}
+	b.sessionMu.Lock()
+	b.credentials = creds
+	if creds.BaseURL != "" {
+		b.baseURL = creds.BaseURL
+	}
+	b.sessionMu.Unlock()
+	return nil
+}
+
+// StartQRLogin fetches a login QR code for an admin to scan remotely
+// The returned code is the QR link, or the path of a saved PNG when the server sends image data
+func (b *WeixinBot) StartQRLogin() (string, <-chan error, error) {
+	b.sessionMu.Lock()
+	if b.loggingIn {
+		b.sessionMu.Unlock()
+		return "", nil, errors.New("a WeChat login is already in progress")
+	}
+	b.loggingIn = true
+	baseURL := b.baseURL
+	client := b.httpClient
+	b.sessionMu.Unlock()
+
+	finish := func() {
+		b.sessionMu.Lock()
+		b.loggingIn = false
+		b.sessionMu.Unlock()
+	}
+
+	qrResp, err := fetchQRCode(client, baseURL)
+	if err != nil {
+		finish()
+		return "", nil, fmt.Errorf("fetch QR code: %w", err)
+	}
+
+	qrCode := qrResp.QRCodeImgContent
+	if !strings.HasPrefix(qrCode, "http") {
+		imgData, err := base64.StdEncoding.DecodeString(qrCode)
+		if err != nil {
+			finish()
+			return "", nil, fmt.Errorf("decode QR code image: %w", err)
+		}
+		qrCode, err = saveWeixinMedia(imgData, "login-qr.png")
+		if err != nil {
+			finish()
+			return "", nil, err
 		}
 	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer finish()
+		creds, err := b.waitForQRConfirm(qrResp.QRCode, false)
+		if err == nil {
+			err = b.completeLogin(creds)
+		}
+		done <- err
+	}()
+	return qrCode, done, nil
+}
+
+// expireSession drops the expired credentials and reply context, then asks for a new login
+// With no login handler registered, it falls back to an interactive console login
+func (b *WeixinBot) expireSession() {
+	b.sessionMu.Lock()
+	b.contextTokens = make(map[string]string)
+	b.clientToUser = make(map[string]string)
+	b.credentials = nil
+	handler := b.loginRequired
+	b.sessionMu.Unlock()
+	b.cursor = ""
+	b.lastSyncBuf = ""
+
+	if err := clearCredentials(b.credentialsPath); err != nil {
+		fmt.Printf("WeChat: %v\n", err)
+	}
+	b.saveState()
+
+	if handler != nil {
+		fmt.Println("WeChat session expired, waiting for an admin to re-login...")
+		handler("session expired")
+		return
+	}
+
+	fmt.Println("WeChat session expired, re-authenticating...")
+	if err := b.doQRLogin(); err != nil {
+		fmt.Printf("Re-login failed: %v\n", err)
+	}
+}
+
+// loadState restores persisted context tokens and seen message IDs
+func (b *WeixinBot) loadState() error {
+	data, err := os.ReadFile(b.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read state file: %w", err)
+	}
+
+	var state weixinState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("parse state JSON: %w", err)
+	}
+
+	b.sessionMu.Lock()
+	defer b.sessionMu.Unlock()
+	for user, token := range state.ContextTokens {
+		b.contextTokens[user] = token
+	}
+	for _, id := range state.SeenMsgs {
+		b.markSeenLocked(id)
+	}
+	return nil
+}
+
+// saveState persists context tokens and seen message IDs next to the credentials
+func (b *WeixinBot) saveState() {
+	b.sessionMu.RLock()
+	state := weixinState{
+		ContextTokens: make(map[string]string, len(b.contextTokens)),
+		SeenMsgs:      append([]string(nil), b.seenOrder...),
+	}
+	for user, token := range b.contextTokens {
+		state.ContextTokens[user] = token
+	}
+	b.sessionMu.RUnlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		fmt.Printf("WeChat: failed to marshal state: %v\n", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(b.statePath), 0700); err != nil {
+		fmt.Printf("WeChat: failed to create state directory: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(b.statePath, data, 0600); err != nil {
+		fmt.Printf("WeChat: failed to write state: %v\n", err)
+	}
+}
+
+// markSeenLocked records a message ID, evicting the oldest beyond weixinMaxSeenMsgs
+// Returns false if the ID was already seen. Caller must hold sessionMu.
+func (b *WeixinBot) markSeenLocked(id string) bool {
+	if b.seenMsgs[id] {
+		return false
+	}
+	b.seenMsgs[id] = true
+	b.seenOrder = append(b.seenOrder, id)
+	for len(b.seenOrder) > weixinMaxSeenMsgs {
+		delete(b.seenMsgs, b.seenOrder[0])
+		b.seenOrder = b.seenOrder[1:]
+	}
+	return true
+}
+
+// sleepOrDone waits for d, returning false if the bot stopped meanwhile
+func (b *WeixinBot) sleepOrDone(d time.Duration) bool {
+	select {
+	case <-b.ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
 }
 
 func (b *WeixinBot) longPollLoop() {
 	backoff := 1 * time.Second
 	maxBackoff := 10 * time.Second
 	lastHeartbeat := time.Now()
+	failures := 0
 
 	for {
 		select {
@@ -842,40 +1148,45 @@ func (b *WeixinBot) longPollLoop() {
 		default:
 		}
 
-		// Credentials are set once before this goroutine starts; no mutex needed.
-		token := b.credentials.Token
-		botID := b.credentials.AccountID
-		userID := b.credentials.UserID
+		// Credentials change on re-login, so read them under the lock each round.
+		b.sessionMu.RLock()
+		creds := b.credentials
+		baseURL := b.baseURL
+		b.sessionMu.RUnlock()
 		cursor := b.cursor
 		lastSyncBuf := b.lastSyncBuf
 
-		if token == "" {
-			time.Sleep(backoff)
+		if creds == nil || creds.Token == "" {
+			if !b.sleepOrDone(backoff) {
+				return
+			}
 			continue
 		}
+		token := creds.Token
+		botID := creds.AccountID
+		userID := creds.UserID
 
 		client := &http.Client{Timeout: constants.WechatLongPollTimeout}
 
-		result, err := getUpdates(client, b.baseURL, token, botID, userID, cursor, lastSyncBuf)
+		result, err := getUpdates(client, baseURL, token, botID, userID, cursor, lastSyncBuf)
 		if err != nil {
 			var apiErr *ApiError
 			if errors.As(err, &apiErr) && apiErr.IsSessionExpired() {
-				fmt.Println("WeChat session expired, re-authenticating...")
-				b.sessionMu.Lock()
-				b.contextTokens = make(map[string]string)
-				b.clientToUser = make(map[string]string)
-				b.sessionMu.Unlock()
-				b.credentials = nil
-				b.cursor = ""
-				b.lastSyncBuf = ""
-				if err := b.doQRLogin(); err != nil {
-					fmt.Printf("Re-login failed: %v\n", err)
-				}
+				b.expireSession()
 				backoff = 1 * time.Second
 				continue
 			}
 
-			time.Sleep(backoff)
+			// Transient errors are retried here; persistent ones are reported
+			// so the supervisor shows the adapter as down and restarts it
+			failures++
+			if failures >= weixinMaxPollFailures {
+				b.signalDisconnect(fmt.Errorf("getupdates failed %d times: %w", failures, err))
+				return
+			}
+			if !b.sleepOrDone(backoff) {
+				return
+			}
 			backoff = backoff * 2
 			if backoff > maxBackoff {
 				backoff = maxBackoff
@@ -884,6 +1195,7 @@ func (b *WeixinBot) longPollLoop() {
 		}
 
 		backoff = 1 * time.Second
+		failures = 0
 		if len(result.Msgs) == 0 {
 			if time.Since(lastHeartbeat) >= 30*time.Second {
 				fmt.Println("WeChat: polling... (idle, token ready)")
@@ -905,7 +1217,7 @@ func (b *WeixinBot) longPollLoop() {
 				b.lastSyncBuf = result.SyncBuf
 			}
 
-			// Handle messages (with in-process deduplication)
+			// Handle messages (with persisted deduplication)
 			for _, msg := range result.Msgs {
 				b.handleMessage(msg)
 			}
@@ -914,7 +1226,7 @@ func (b *WeixinBot) longPollLoop() {
 			go func(syncBuf, getBuf string) {
 				for i := 0; i < 3; i++ {
 					ackClient := &http.Client{Timeout: 5 * time.Second}
-					if err := ackUpdates(ackClient, b.baseURL, token, botID, userID, syncBuf, getBuf); err == nil {
+					if err := ackUpdates(ackClient, baseURL, token, botID, userID, syncBuf, getBuf); err == nil {
 						return
 					}
 					time.Sleep(200 * time.Millisecond)
@@ -941,26 +1253,20 @@ func (b *WeixinBot) handleMessage(msg inboundMessage) {
 	}
 
 	// Deduplicate by message_id: the server re-delivers messages until explicitly acked.
-	// Since the cursor mechanism doesn't prevent re-delivery, we dedupe in-memory.
+	// Since the cursor mechanism doesn't prevent re-delivery, seen IDs are persisted
+	// together with the context tokens so duplicates are also dropped after a restart.
 	msgKey := msg.MessageID.String()
 	b.sessionMu.Lock()
-	if b.seenMsgs[msgKey] {
+	if !b.markSeenLocked(msgKey) {
 		b.sessionMu.Unlock()
 		return
 	}
-	b.seenMsgs[msgKey] = true
-	// Prune old entries to keep the map bounded.
-	if len(b.seenMsgs) > 1000 {
-		for k := range b.seenMsgs {
-			delete(b.seenMsgs, k)
-			break
-		}
-	}
 	b.contextTokens[msg.FromUserID] = msg.ContextToken
 	b.clientToUser[msg.ClientID] = msg.FromUserID
 	b.sessionMu.Unlock()
+	b.saveState()
 
-	text := extractInboundText(msg.ItemList)
+	text, transcribed := b.parseInboundItems(msgKey, msg.ItemList)
 	if text == "" {
 		return
 	}
@@ -974,12 +1280,13 @@ func (b *WeixinBot) handleMessage(msg inboundMessage) {
 	}
 
 	handler(BotMessage{
-		Platform:  "weixin",
-		UserID:    msg.FromUserID,
-		Channel:   msg.FromUserID,
-		MessageID: msg.ClientID,
-		Content:   text,
-		Timestamp: time.Unix(msg.CreateTimeMs/1000, 0),
+		Platform:    "weixin",
+		UserID:      msg.FromUserID,
+		Channel:     msg.FromUserID,
+		MessageID:   msg.ClientID,
+		Content:     text,
+		Timestamp:   time.Unix(msg.CreateTimeMs/1000, 0),
+		Transcribed: transcribed,
 	})
 }
 
@@ -1012,6 +1319,117 @@ func extractInboundText(items []inboundMessageItem) string {
 	return strings.Join(parts, "")
 }
 
+// parseInboundItems is extractInboundText with media downloaded to local files,
+// referenced as "[image: <path>]" etc. Voice uses the server transcription, then the
+// configured Transcriber. Items that cannot be downloaded keep their placeholder.
+// The second result reports whether any voice item was transcribed.
+func (b *WeixinBot) parseInboundItems(msgID string, items []inboundMessageItem) (string, bool) {
+	var parts []string
+	transcribed := false
+	for i, item := range items {
+		var kind string
+		var media *inboundMediaItem
+		switch item.Type {
+		case MessageItemTypeImage:
+			kind, media = "image", item.ImageItem
+		case MessageItemTypeVoice:
+			kind, media = "voice", item.VoiceItem
+			if media != nil && media.Text != "" {
+				parts = append(parts, media.Text)
+				transcribed = true
+				continue
+			}
+		case MessageItemTypeFile:
+			kind, media = "file", item.FileItem
+		case MessageItemTypeVideo:
+			kind, media = "video", item.VideoItem
+		}
+		if media == nil || media.ImageURL == "" {
+			parts = append(parts, extractInboundText([]inboundMessageItem{item}))
+			continue
+		}
+
+		name := media.FileName
+		if name == "" {
+			name = fmt.Sprintf("%s-%s-%d", kind, msgID, i)
+		}
+		path, err := b.downloadMedia(media.ImageURL, name)
+		if err != nil {
+			fmt.Printf("WeChat: failed to download %s: %v\n", kind, err)
+			parts = append(parts, extractInboundText([]inboundMessageItem{item}))
+			continue
+		}
+
+		if item.Type == MessageItemTypeVoice {
+			if text, ok := b.transcribe(path); ok {
+				parts = append(parts, text)
+				transcribed = true
+				continue
+			}
+		}
+		parts = append(parts, fmt.Sprintf("[%s: %s]", kind, path))
+	}
+	return strings.Join(parts, ""), transcribed
+}
+
+// transcribe converts a downloaded voice message with the configured Transcriber
+func (b *WeixinBot) transcribe(path string) (string, bool) {
+	b.sessionMu.RLock()
+	transcriber := b.transcriber
+	ctx := b.ctx
+	b.sessionMu.RUnlock()
+	if transcriber == nil {
+		return "", false
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	text, err := transcribeAudio(ctx, transcriber, path)
+	if err != nil {
+		fmt.Printf("WeChat: voice transcription failed: %v\n", err)
+		return "", false
+	}
+	return text, true
+}
+
+// downloadMedia fetches an inbound media URL into the clibot-weixin temp directory
+func (b *WeixinBot) downloadMedia(mediaURL, name string) (string, error) {
+	resp, err := b.httpClient.Get(mediaURL)
+	if err != nil {
+		return "", fmt.Errorf("download media: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download media failed: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, weixinMaxMediaSize))
+	if err != nil {
+		return "", fmt.Errorf("read media: %w", err)
+	}
+
+	if filepath.Ext(name) == "" {
+		if exts, _ := mime.ExtensionsByType(resp.Header.Get("Content-Type")); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	return saveWeixinMedia(data, name)
+}
+
+// saveWeixinMedia writes media data into the clibot-weixin temp directory
+func saveWeixinMedia(data []byte, name string) (string, error) {
+	dir := filepath.Join(os.TempDir(), "clibot-weixin")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("create media directory: %w", err)
+	}
+	path := filepath.Join(dir, filepath.Base(name))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("write media: %w", err)
+	}
+	return path, nil
+}
+
 func chunkMessage(msg string, maxLen int) []string {
 	if len(msg) <= maxLen {
 		return []string{msg}
@@ -1027,43 +1445,137 @@ func chunkMessage(msg string, maxLen int) []string {
 	return chunks
 }
 
+// SendMessage sends text in chunks; replies longer than weixinLongOutputLength are
+// sent as a short preview plus the full output as a file
 func (b *WeixinBot) SendMessage(channel, message string) error {
+	if len(message) > weixinLongOutputLength {
+		err := b.sendLongOutput(channel, message)
+		if err == nil {
+			return nil
+		}
+		fmt.Printf("WeChat: sending long output as file failed, falling back to text: %v\n", err)
+	}
+
+	for _, chunk := range chunkMessage(message, MaxChunkLength) {
+		item := weixinOutboundItem{
+			Type:     MessageItemTypeText,
+			TextItem: &outboundTextItem{Text: chunk},
+		}
+		if err := b.sendItem(channel, item); err != nil {
+			return fmt.Errorf("send message chunk: %w", err)
+		}
+	}
+	return nil
+}
+
+// SendDirectMessage sends a message to a user's chat, whose channel is the user ID
+// Like any send it needs a context_token, so the user must have messaged the bot before
+func (b *WeixinBot) SendDirectMessage(userID, message string) error {
+	return b.SendMessage(userID, message)
+}
+
+// SendImage uploads a local image (e.g. a screenshot) and sends it as an image item
+func (b *WeixinBot) SendImage(channel, imagePath string) error {
+	mediaURL, err := b.upload(channel, imagePath)
+	if err != nil {
+		return err
+	}
+	return b.sendItem(channel, weixinOutboundItem{
+		Type:      MessageItemTypeImage,
+		ImageItem: &outboundImageItem{ImageURL: mediaURL},
+	})
+}
+
+// SendFile uploads a local file and sends it as a file item
+func (b *WeixinBot) SendFile(channel, filePath string) error {
+	mediaURL, err := b.upload(channel, filePath)
+	if err != nil {
+		return err
+	}
+	return b.sendItem(channel, weixinOutboundItem{
+		Type:     MessageItemTypeFile,
+		FileItem: &outboundFileItem{ImageURL: mediaURL, FileName: filepath.Base(filePath)},
+	})
+}
+
+// sendLongOutput uploads the full message as a markdown file, then sends a preview and the file
+func (b *WeixinBot) sendLongOutput(channel, message string) error {
+	path, err := saveWeixinMedia([]byte(message), fmt.Sprintf("output-%s.md", time.Now().Format("20060102-150405")))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	mediaURL, err := b.upload(channel, path)
+	if err != nil {
+		return err
+	}
+
+	preview := chunkMessage(message, MaxChunkLength-100)[0]
+	preview += fmt.Sprintf("\n\n… full output (%d chars) attached", len(message))
+	if err := b.sendItem(channel, weixinOutboundItem{
+		Type:     MessageItemTypeText,
+		TextItem: &outboundTextItem{Text: preview},
+	}); err != nil {
+		return err
+	}
+	return b.sendItem(channel, weixinOutboundItem{
+		Type:     MessageItemTypeFile,
+		FileItem: &outboundFileItem{ImageURL: mediaURL, FileName: filepath.Base(path)},
+	})
+}
+
+// upload checks the channel can be replied to and uploads a local file
+func (b *WeixinBot) upload(channel, path string) (string, error) {
+	b.sessionMu.RLock()
+	_, ok := b.contextTokens[channel]
+	creds := b.credentials
+	baseURL := b.baseURL
+	b.sessionMu.RUnlock()
+
+	if !ok {
+		return "", errors.New("no context_token found for user, message may be out of context")
+	}
+	if creds == nil {
+		return "", errors.New("weixin bot not logged in")
+	}
+
+	mediaURL, err := uploadMedia(&http.Client{Timeout: APITimeout}, baseURL, creds.Token, path)
+	if err != nil {
+		return "", fmt.Errorf("upload media: %w", err)
+	}
+	return mediaURL, nil
+}
+
+// sendItem sends one outbound item in the user's reply context
+func (b *WeixinBot) sendItem(channel string, item weixinOutboundItem) error {
 	b.sessionMu.RLock()
 	contextToken, ok := b.contextTokens[channel]
 	if !ok {
 		b.sessionMu.RUnlock()
 		return errors.New("no context_token found for user, message may be out of context")
 	}
-	token := b.credentials.Token
+	creds := b.credentials
 	baseURL := b.baseURL
 	b.sessionMu.RUnlock()
 
-	chunks := chunkMessage(message, MaxChunkLength)
-	client := &http.Client{Timeout: APITimeout}
+	if creds == nil {
+		return errors.New("weixin bot not logged in")
+	}
 
-	for _, chunk := range chunks {
-		body := weixinSendMessageBody{
-			Msg: weixinOutboundMsg{
-				FromUserID:   "",
-				ToUserID:     channel,
-				ClientID:     uuid.New().String(),
-				MessageType:  MessageTypeBot,
-				MessageState: MessageStateFinish,
-				ContextToken: contextToken,
-				ItemList: []weixinOutboundItem{
-					{
-						Type:     MessageItemTypeText,
-						TextItem: &outboundTextItem{Text: chunk},
-					},
-				},
-			},
-			BaseInfo: weixinBaseInfo{ChannelVersion: DefaultBaseVersion},
-		}
-		if err := sendMessage(client, baseURL, token, body); err != nil {
-			return fmt.Errorf("send message chunk: %w", err)
-		}
+	body := weixinSendMessageBody{
+		Msg: weixinOutboundMsg{
+			FromUserID:   "",
+			ToUserID:     channel,
+			ClientID:     uuid.New().String(),
+			MessageType:  MessageTypeBot,
+			MessageState: MessageStateFinish,
+			ContextToken: contextToken,
+			ItemList:     []weixinOutboundItem{item},
+		},
+		BaseInfo: weixinBaseInfo{ChannelVersion: DefaultBaseVersion},
 	}
-	return nil
+	return sendMessage(&http.Client{Timeout: APITimeout}, baseURL, creds.Token, body)
 }
 
 func (b *WeixinBot) AddTypingIndicator(messageID string) bool {
@@ -1078,9 +1590,13 @@ func (b *WeixinBot) AddTypingIndicator(messageID string) bool {
 		b.sessionMu.RUnlock()
 		return false
 	}
-	token := b.credentials.Token
+	creds := b.credentials
 	baseURL := b.baseURL
 	b.sessionMu.RUnlock()
+	if creds == nil {
+		return false
+	}
+	token := creds.Token
 
 	client := &http.Client{Timeout: APITimeout}
 	cfg, err := getConfig(client, baseURL, token, userID, contextToken)
@@ -1108,9 +1624,13 @@ func (b *WeixinBot) RemoveTypingIndicator(messageID string) error {
 		b.sessionMu.RUnlock()
 		return nil
 	}
-	token := b.credentials.Token
+	creds := b.credentials
 	baseURL := b.baseURL
 	b.sessionMu.RUnlock()
+	if creds == nil {
+		return nil
+	}
+	token := creds.Token
 
 	client := &http.Client{Timeout: APITimeout}
 	cfg, err := getConfig(client, baseURL, token, userID, contextToken)
@@ -1129,6 +1649,7 @@ func (b *WeixinBot) Stop() error {
 	b.contextTokens = make(map[string]string)
 	b.clientToUser = make(map[string]string)
 	b.seenMsgs = make(map[string]bool)
+	b.seenOrder = nil
 	b.credentials = nil
 	b.cursor = ""
 	b.lastSyncBuf = ""
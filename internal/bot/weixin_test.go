@@ -1,11 +1,17 @@
 package bot
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -311,3 +317,201 @@ func TestSendMessageBodyJSON(t *testing.T) {
 	assert.Contains(t, string(data), `"text":"hello"`)
 	assert.Contains(t, string(data), `"base_info"`)
 }
+
+// fakeWeixinAPI is a fake iLink API recording sent messages
+type fakeWeixinAPI struct {
+	mu       sync.Mutex
+	sent     []weixinOutboundMsg
+	uploads  []string // uploaded file names
+	qrStatus string
+}
+
+func (f *fakeWeixinAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/media/photo":
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG fake"))
+	case "/media/voice":
+		w.Write([]byte("voice data"))
+	case "/ilink/bot/sendmessage":
+		var body weixinSendMessageBody
+		json.NewDecoder(r.Body).Decode(&body)
+		f.sent = append(f.sent, body.Msg)
+		w.Write([]byte(`{"ret":0}`))
+	case "/ilink/bot/uploadmedia":
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.Write([]byte(`{"errcode":-1,"errmsg":"no file"}`))
+			return
+		}
+		file.Close()
+		f.uploads = append(f.uploads, header.Filename)
+		fmt.Fprintf(w, `{"ret":0,"url":"https://cdn.example.com/%s"}`, header.Filename)
+	case "/ilink/bot/get_bot_qrcode":
+		w.Write([]byte(`{"qrcode":"qr-1","qrcode_img_content":"https://login.example.com/qr-1"}`))
+	case "/ilink/bot/get_qrcode_status":
+		fmt.Fprintf(w, `{"status":%q,"bot_token":"new-token","ilink_bot_id":"bot-1"}`, f.qrStatus)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestWeixinBot(t *testing.T) (*WeixinBot, *fakeWeixinAPI, *httptest.Server) {
+	fake := &fakeWeixinAPI{qrStatus: QRStatusWait}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	b := NewWeixinBot(server.URL, filepath.Join(t.TempDir(), "credentials.json"))
+	b.credentials = &Credentials{Token: "token"}
+	return b, fake, server
+}
+
+type stubTranscriber struct{ text string }
+
+func (s stubTranscriber) Transcribe(ctx context.Context, audioPath string) (string, error) {
+	return s.text, nil
+}
+
+func TestWeixinBot_StatePersistsAcrossRestart(t *testing.T) {
+	b, _, _ := newTestWeixinBot(t)
+	msg := inboundMessage{
+		MessageID:    "42",
+		FromUserID:   "user-1",
+		ClientID:     "client-1",
+		MessageType:  MessageTypeUser,
+		ContextToken: "ctx-1",
+		ItemList:     []inboundMessageItem{{Type: MessageItemTypeText, TextItem: &inboundTextItem{Text: "hi"}}},
+	}
+	b.handleMessage(msg)
+
+	restarted := NewWeixinBot(b.baseURL, b.credentialsPath)
+	require.NoError(t, restarted.loadState())
+	assert.Equal(t, "ctx-1", restarted.contextTokens["user-1"])
+
+	var received []BotMessage
+	restarted.messageHandler = func(m BotMessage) { received = append(received, m) }
+	restarted.handleMessage(msg)
+	assert.Empty(t, received, "re-delivered message should be deduplicated after restart")
+}
+
+func TestWeixinBot_SeenMessagesEvictOldest(t *testing.T) {
+	b := NewWeixinBot("", filepath.Join(t.TempDir(), "credentials.json"))
+	for i := 0; i < weixinMaxSeenMsgs+5; i++ {
+		assert.True(t, b.markSeenLocked(fmt.Sprintf("m%d", i)))
+	}
+	assert.Len(t, b.seenOrder, weixinMaxSeenMsgs)
+	assert.True(t, b.markSeenLocked("m0"), "oldest IDs are evicted first")
+	assert.False(t, b.markSeenLocked(fmt.Sprintf("m%d", weixinMaxSeenMsgs+4)))
+}
+
+func TestWeixinBot_ParseInboundItems_DownloadsMedia(t *testing.T) {
+	b, _, server := newTestWeixinBot(t)
+
+	text, transcribed := b.parseInboundItems("7", []inboundMessageItem{
+		{Type: MessageItemTypeText, TextItem: &inboundTextItem{Text: "look "}},
+		{Type: MessageItemTypeImage, ImageItem: &inboundMediaItem{ImageURL: server.URL + "/media/photo"}},
+	})
+	assert.False(t, transcribed)
+	require.True(t, strings.HasPrefix(text, "look [image: "), text)
+	path := strings.TrimSuffix(strings.TrimPrefix(text, "look [image: "), "]")
+	t.Cleanup(func() { os.Remove(path) })
+	assert.Equal(t, ".png", filepath.Ext(path))
+
+	// Download failures keep the placeholder
+	text, _ = b.parseInboundItems("8", []inboundMessageItem{
+		{Type: MessageItemTypeFile, FileItem: &inboundMediaItem{ImageURL: server.URL + "/missing"}},
+	})
+	assert.Equal(t, "[file]", text)
+}
+
+func TestWeixinBot_ParseInboundItems_Voice(t *testing.T) {
+	b, _, server := newTestWeixinBot(t)
+	voice := []inboundMessageItem{{Type: MessageItemTypeVoice, VoiceItem: &inboundMediaItem{ImageURL: server.URL + "/media/voice"}}}
+
+	// Server-side transcription wins
+	withText := []inboundMessageItem{{Type: MessageItemTypeVoice, VoiceItem: &inboundMediaItem{ImageURL: server.URL + "/media/voice", Text: "from server"}}}
+	text, transcribed := b.parseInboundItems("1", withText)
+	assert.Equal(t, "from server", text)
+	assert.True(t, transcribed)
+
+	// Without a transcriber the audio file is referenced
+	text, transcribed = b.parseInboundItems("2", voice)
+	assert.True(t, strings.HasPrefix(text, "[voice: "))
+	assert.False(t, transcribed)
+
+	b.SetTranscriber(stubTranscriber{text: " run the tests "})
+	text, transcribed = b.parseInboundItems("3", voice)
+	assert.Equal(t, "run the tests", text)
+	assert.True(t, transcribed)
+}
+
+func TestWeixinBot_SendMessage_LongOutputAsFile(t *testing.T) {
+	b, fake, _ := newTestWeixinBot(t)
+	b.contextTokens["user-1"] = "ctx-1"
+
+	require.NoError(t, b.SendMessage("user-1", strings.Repeat("x", weixinLongOutputLength+1)))
+	require.Len(t, fake.uploads, 1)
+	require.Len(t, fake.sent, 2)
+	assert.Equal(t, MessageItemTypeText, fake.sent[0].ItemList[0].Type)
+	assert.Contains(t, fake.sent[0].ItemList[0].TextItem.Text, "full output")
+	assert.Equal(t, MessageItemTypeFile, fake.sent[1].ItemList[0].Type)
+	assert.Equal(t, "ctx-1", fake.sent[1].ContextToken)
+	assert.Equal(t, "https://cdn.example.com/"+fake.uploads[0], fake.sent[1].ItemList[0].FileItem.ImageURL)
+}
+
+func TestWeixinBot_SendImage(t *testing.T) {
+	b, fake, _ := newTestWeixinBot(t)
+	b.contextTokens["user-1"] = "ctx-1"
+	path := filepath.Join(t.TempDir(), "shot.png")
+	require.NoError(t, os.WriteFile(path, []byte("png"), 0600))
+
+	require.NoError(t, b.SendImage("user-1", path))
+	require.Len(t, fake.sent, 1)
+	assert.Equal(t, MessageItemTypeImage, fake.sent[0].ItemList[0].Type)
+	assert.Equal(t, "https://cdn.example.com/shot.png", fake.sent[0].ItemList[0].ImageItem.ImageURL)
+
+	assert.Error(t, b.SendImage("unknown-user", path))
+}
+
+func TestWeixinBot_ExpireSessionAndRemoteLogin(t *testing.T) {
+	b, fake, _ := newTestWeixinBot(t)
+	b.contextTokens["user-1"] = "ctx-1"
+	require.NoError(t, saveCredentials(b.credentialsPath, b.credentials))
+
+	reasons := make(chan string, 1)
+	b.SetLoginRequiredHandler(func(reason string) { reasons <- reason })
+	b.expireSession()
+
+	assert.Equal(t, "session expired", <-reasons)
+	assert.Nil(t, b.credentials)
+	assert.Empty(t, b.contextTokens)
+	_, err := os.Stat(b.credentialsPath)
+	assert.True(t, os.IsNotExist(err))
+
+	qrCode, done, err := b.StartQRLogin()
+	require.NoError(t, err)
+	assert.Equal(t, "https://login.example.com/qr-1", qrCode)
+
+	_, _, err = b.StartQRLogin()
+	assert.Error(t, err, "concurrent logins are rejected")
+
+	fake.mu.Lock()
+	fake.qrStatus = QRStatusConfirmed
+	fake.mu.Unlock()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(3 * QRCodePollInterval):
+		t.Fatal("login did not complete")
+	}
+	b.sessionMu.RLock()
+	assert.Equal(t, "new-token", b.credentials.Token)
+	b.sessionMu.RUnlock()
+	creds, err := loadCredentials(b.credentialsPath)
+	require.NoError(t, err)
+	assert.Equal(t, "bot-1", creds.AccountID)
+}
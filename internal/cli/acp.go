@@ -47,9 +47,10 @@ type ACPAdapter struct {
 	cmd           *exec.Cmd
 	mu            sync.Mutex
 	sessions      map[string]*acpSession
-	isRemote      bool       // Tracks if connection is remote (tcp/unix) vs local (stdio)
-	currentEngine Engine     // Engine reference for sending responses
-	currentClient *acpClient // Reference to current client for response buffer access
+	isRemote      bool                     // Tracks if connection is remote (tcp/unix) vs local (stdio)
+	currentEngine Engine                   // Engine reference for sending responses
+	currentClient *acpClient               // Reference to current client for response buffer access
+	history       map[string][]HistoryTurn // Session name -> completed turns, oldest first
 }
 
 type acpSession struct {
@@ -98,6 +99,7 @@ func NewACPAdapter(config ACPAdapterConfig) (*ACPAdapter, error) {
 	return &ACPAdapter{
 		config:   config,
 		sessions: make(map[string]*acpSession),
+		history:  make(map[string][]HistoryTurn),
 	}, nil
 }
 
@@ -300,19 +302,24 @@ func (a *ACPAdapter) SendInput(sessionName, input string) error {
 	// Start activity monitor goroutine
 	monitorDone := make(chan struct{})
 	monitorStopped := make(chan struct{})
+	stopMonitor := func() {}
 	if clientImpl != nil {
 		go func() {
 			a.monitorActivity(sessionName, ctx, cancel, clientImpl, monitorDone)
 			close(monitorStopped)
 		}()
-		defer func() {
-			close(monitorDone) // Signal monitor to stop
-			select {
-			case <-monitorStopped: // Wait for monitor to exit
-			case <-time.After(5 * time.Second):
-				logger.WithField("session", sessionName).Warn("acp-monitor-goroutine-did-not-exit-in-time")
-			}
-		}()
+		var stopOnce sync.Once
+		stopMonitor = func() {
+			stopOnce.Do(func() {
+				close(monitorDone) // Signal monitor to stop
+				select {
+				case <-monitorStopped: // Wait for monitor to exit
+				case <-time.After(5 * time.Second):
+					logger.WithField("session", sessionName).Warn("acp-monitor-goroutine-did-not-exit-in-time")
+				}
+			})
+		}
+		defer stopMonitor()
 	}
 
 	// Send prompt using ACP Prompt method
@@ -349,6 +356,9 @@ func (a *ACPAdapter) SendInput(sessionName, input string) error {
 	// After Prompt completes, send buffered response to user
 	// Prompt is synchronous, so when it returns, all response chunks
 	// should have been received via SessionUpdate callback
+	// Stop the monitor first so no progress update races the final response
+	stopMonitor()
+
 	if clientImpl != nil && clientImpl.responseBuf.Len() > 0 {
 		clientImpl.mu.Lock()
 		response := clientImpl.responseBuf.String()
@@ -363,6 +373,7 @@ func (a *ACPAdapter) SendInput(sessionName, input string) error {
 		// Send response to user via engine
 		a.mu.Lock()
 		engine := a.currentEngine
+		a.recordTurn(sessionName, input, response)
 		a.mu.Unlock()
 
 		if engine != nil && sessionName != "" {
@@ -373,6 +384,24 @@ func (a *ACPAdapter) SendInput(sessionName, input string) error {
 	return nil
 }
 
+// recordTurn keeps a completed turn for History, dropping the oldest beyond
+// acpMaxHistoryTurns. Caller must hold a.mu.
+func (a *ACPAdapter) recordTurn(sessionName, prompt, response string) {
+	turns := append(a.history[sessionName], HistoryTurn{Prompt: prompt, Response: response})
+	if len(turns) > acpMaxHistoryTurns {
+		turns = turns[len(turns)-acpMaxHistoryTurns:]
+	}
+	a.history[sessionName] = turns
+}
+
+// History returns the turns completed in this session since clibot started
+// it, oldest first
+func (a *ACPAdapter) History(sessionName, workDir string) ([]HistoryTurn, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]HistoryTurn(nil), a.history[sessionName]...), nil
+}
+
 // monitorActivity monitors activity from the agent and cancels the context if idle
 // This allows long-running requests to complete as long as they're actively working,
 // while cancelling truly hung requests that don't produce any output.
@@ -392,6 +421,11 @@ func (a *ACPAdapter) monitorActivity(sessionName string, baseCtx context.Context
 	ticker := time.NewTicker(acpActivityCheckInterval)
 	defer ticker.Stop()
 
+	// Progress ticker streams partial output to adapters that can edit messages
+	progressTicker := time.NewTicker(acpProgressInterval)
+	defer progressTicker.Stop()
+	lastProgressLen := 0
+
 	// Track start time for max total timeout
 	startTime := time.Now()
 
@@ -416,6 +450,23 @@ func (a *ACPAdapter) monitorActivity(sessionName string, baseCtx context.Context
 			logger.WithField("session", sessionName).
 				Trace("acp-activity-received")
 
+		case <-progressTicker.C:
+			client.mu.Lock()
+			partial := client.responseBuf.String()
+			client.mu.Unlock()
+
+			if len(partial) == lastProgressLen {
+				continue
+			}
+			lastProgressLen = len(partial)
+
+			a.mu.Lock()
+			engine := a.currentEngine
+			a.mu.Unlock()
+			if engine != nil && sessionName != "" {
+				engine.SendProgressToSession(sessionName, partial)
+			}
+
 		case <-ticker.C:
 			// Periodic check for timeout
 			client.lastActivityLock.RLock()
@@ -474,6 +525,7 @@ func (a *ACPAdapter) DeleteSession(sessionName string) error {
 
 	// Remove from sessions map
 	delete(a.sessions, sessionName)
+	delete(a.history, sessionName)
 
 	// Debug logging
 	logger.WithFields(logrus.Fields{
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -135,6 +136,31 @@ func TestACPAdapter_SetEngine(t *testing.T) {
 	assert.NotNil(t, adapter)
 }
 
+// TestACPAdapter_History tests the in-memory turn history
+func TestACPAdapter_History(t *testing.T) {
+	adapter, err := NewACPAdapter(ACPAdapterConfig{})
+	require.NoError(t, err)
+	var _ HistoryReader = adapter
+
+	turns, err := adapter.History("dev", "")
+	require.NoError(t, err)
+	assert.Empty(t, turns)
+
+	for i := 0; i < acpMaxHistoryTurns+2; i++ {
+		adapter.recordTurn("dev", fmt.Sprintf("q%d", i), fmt.Sprintf("a%d", i))
+	}
+	turns, err = adapter.History("dev", "")
+	require.NoError(t, err)
+	assert.Len(t, turns, acpMaxHistoryTurns)
+	assert.Equal(t, HistoryTurn{Prompt: "q2", Response: "a2"}, turns[0])
+	assert.Equal(t, "q201", turns[len(turns)-1].Prompt)
+
+	// Returned turns are a copy
+	turns[0].Prompt = "changed"
+	turns, _ = adapter.History("dev", "")
+	assert.Equal(t, "q2", turns[0].Prompt)
+}
+
 // mockEngine is a mock implementation of Engine for testing
 type mockEngine struct{}
 
@@ -165,3 +191,6 @@ func (m *mockEngine) SendToBot(platform, channel, message string) {
 
 func (m *mockEngine) SendResponseToSession(sessionName, message string) {
 }
+
+func (m *mockEngine) SendProgressToSession(sessionName, message string) {
+}
@@ -22,6 +22,9 @@ const (
 	// Connection ready timeout (30 seconds)
 	acpConnectionReadyTimeout = 30 * time.Second
 
+	// Completed turns kept per session for shistory/ssearch (ACP agents keep no transcript clibot can read)
+	acpMaxHistoryTurns = 200
+
 	// NewSession configuration
 	acpNewSessionTimeout    = 10 * time.Second // per attempt
 	acpNewSessionMaxRetries = 3                // maximum attempts
@@ -38,6 +41,9 @@ const (
 
 	// Activity check interval - how often to check for idle timeout (30 seconds)
 	acpActivityCheckInterval = 30 * time.Second
+
+	// Progress interval - how often partial output is streamed to the user (3 seconds)
+	acpProgressInterval = 3 * time.Second
 )
 
 // ACPAdapterConfig configuration for ACP adapter
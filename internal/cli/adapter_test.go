@@ -43,3 +43,27 @@ func TestBaseAdapter_InputDelayMs(t *testing.T) {
 		assert.Equal(t, 0, adapter.inputDelayMs)
 	})
 }
+
+// TestBaseAdapter_ParseNotification tests recognizing Notification hook events
+func TestBaseAdapter_ParseNotification(t *testing.T) {
+	adapter := NewBaseAdapter("claude", "claude", 0)
+
+	message, ok := adapter.ParseNotification([]byte(`{"hook_event_name":"Notification","message":"Claude needs your permission to use Bash"}`))
+	assert.True(t, ok)
+	assert.Equal(t, "Claude needs your permission to use Bash", message)
+
+	message, ok = adapter.ParseNotification([]byte(`{"hook_event_name":"notification"}`))
+	assert.True(t, ok)
+	assert.Equal(t, "claude is waiting for your input", message)
+
+	_, ok = adapter.ParseNotification([]byte(`{"hook_event_name":"Stop"}`))
+	assert.False(t, ok)
+	_, ok = adapter.ParseNotification([]byte(`not json`))
+	assert.False(t, ok)
+
+	// Hook-based adapters expose it to the engine
+	var _ NotificationParser = &ClaudeAdapter{}
+	var _ NotificationParser = &GeminiAdapter{}
+	var _ NotificationParser = &OpenCodeAdapter{}
+	var _ NotificationParser = &CodexAdapter{}
+}
@@ -0,0 +1,408 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/watchdog"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultAiderHistoryFile is where Aider writes its chat history, relative to
+// the git repository root (Aider's --chat-history-file default)
+const DefaultAiderHistoryFile = ".aider.chat.history.md"
+
+// defaultAiderSettleDelay is how long the history file must stay unchanged
+// before the newest reply is delivered. Aider appends the reply, then token
+// usage, applied edits and the commit, in separate writes.
+const defaultAiderSettleDelay = 3 * time.Second
+
+// aiderChatCommands are Aider commands passed through from chat whose reply is
+// Aider's own output (e.g. "Added main.go to the chat") rather than the model's
+var aiderChatCommands = []string{"/add", "/drop", "/undo"}
+
+// AiderAdapterConfig configuration for Aider adapter
+type AiderAdapterConfig struct {
+	Env         map[string]string // Environment variables to set for the CLI process
+	HistoryFile string            // Chat history file, relative to the repo root or absolute (default: .aider.chat.history.md)
+	SettleDelay time.Duration     // Quiet period after the last history write (default: 3s)
+}
+
+// AiderAdapter implements CLIAdapter for Aider. Aider has no hooks: replies
+// are read from its markdown chat history, which is watched for changes and
+// delivered through the engine once writes settle. The engine decides which
+// sessions are watched (see ReplyWatcher); sessions in poll mode are not.
+type AiderAdapter struct {
+	BaseAdapter
+	historyFile string
+	settleDelay time.Duration
+
+	mu       sync.Mutex
+	engine   Engine                   // Engine reference for sending responses
+	workDirs map[string]string        // Session name -> work dir given to CreateSession
+	sessions map[string]*aiderHistory // Session name -> watched history file
+}
+
+// aiderHistory tracks a session's history file and the prompt awaiting a reply
+type aiderHistory struct {
+	path    string
+	watcher *fsnotify.Watcher
+	offset  int64 // History size when the pending prompt was sent
+	pending bool  // A prompt is waiting for its reply
+}
+
+// NewAiderAdapter creates a new Aider adapter
+func NewAiderAdapter(config AiderAdapterConfig) (*AiderAdapter, error) {
+	if config.HistoryFile == "" {
+		config.HistoryFile = DefaultAiderHistoryFile
+	}
+	if config.SettleDelay <= 0 {
+		config.SettleDelay = defaultAiderSettleDelay
+	}
+	return &AiderAdapter{
+		BaseAdapter: NewBaseAdapter("aider", "aider", 0),
+		historyFile: config.HistoryFile,
+		settleDelay: config.SettleDelay,
+		workDirs:    make(map[string]string),
+		sessions:    make(map[string]*aiderHistory),
+	}, nil
+}
+
+// SetEngine sets the engine reference for sending responses
+func (a *AiderAdapter) SetEngine(engine Engine) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.engine = engine
+}
+
+// CreateSession starts Aider in tmux and remembers the work dir its history
+// file is found in
+func (a *AiderAdapter) CreateSession(sessionName, workDir, startCmd, transportURL string, env map[string]string) error {
+	if err := a.BaseAdapter.CreateSession(sessionName, workDir, startCmd, transportURL, env); err != nil {
+		return err
+	}
+	if dir, err := expandHome(workDir); err == nil && dir != "" {
+		a.mu.Lock()
+		a.workDirs[sessionName] = dir
+		a.mu.Unlock()
+	}
+	return nil
+}
+
+// SendInput sends input to Aider. Watched sessions wait for the reply in the
+// history file.
+func (a *AiderAdapter) SendInput(sessionName, input string) error {
+	a.expectReply(sessionName)
+	return a.BaseAdapter.SendInput(sessionName, input)
+}
+
+// HandleHookData is not used by Aider, which has no hooks
+func (a *AiderAdapter) HandleHookData(data []byte) (string, string, string, error) {
+	return "", "", "", fmt.Errorf("aider does not send hooks; replies are read from %s", a.historyFile)
+}
+
+// WatchSession starts watching the session's history file if it is not yet
+// watched. Sessions clibot did not start use their tmux pane's directory.
+// Implements ReplyWatcher.
+func (a *AiderAdapter) WatchSession(sessionName string) error {
+	a.mu.Lock()
+	_, watched := a.sessions[sessionName]
+	workDir := a.workDirs[sessionName]
+	a.mu.Unlock()
+	if watched {
+		return nil
+	}
+
+	if workDir == "" {
+		dir, err := watchdog.PaneWorkDir(sessionName)
+		if err != nil {
+			return err
+		}
+		workDir = dir
+	}
+	return a.watch(sessionName, a.historyPath(workDir))
+}
+
+// UnwatchSession stops watching the session's history file. Closing the
+// watcher ends the session's run goroutine. Implements ReplyWatcher.
+func (a *AiderAdapter) UnwatchSession(sessionName string) {
+	a.mu.Lock()
+	history, ok := a.sessions[sessionName]
+	delete(a.sessions, sessionName)
+	delete(a.workDirs, sessionName)
+	a.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	history.watcher.Close()
+	logger.WithField("session", sessionName).Info("stopped-watching-aider-history")
+}
+
+// Close stops watching the history files of all sessions
+func (a *AiderAdapter) Close() error {
+	a.mu.Lock()
+	names := make([]string, 0, len(a.sessions))
+	for name := range a.sessions {
+		names = append(names, name)
+	}
+	a.mu.Unlock()
+
+	for _, name := range names {
+		a.UnwatchSession(name)
+	}
+	return nil
+}
+
+// historyPath returns the history file Aider uses when started in workDir
+func (a *AiderAdapter) historyPath(workDir string) string {
+	if filepath.IsAbs(a.historyFile) {
+		return a.historyFile
+	}
+	return filepath.Join(repoRoot(workDir), a.historyFile)
+}
+
+// repoRoot returns the git repository root containing dir, or dir itself
+func repoRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// watch starts watching the history file at path for the session. The
+// directory is watched, since Aider creates the file on its first write.
+func (a *AiderAdapter) watch(sessionName, path string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch %s: %w", filepath.Dir(path), err)
+	}
+
+	history := &aiderHistory{path: path, watcher: watcher}
+	a.mu.Lock()
+	if _, exists := a.sessions[sessionName]; exists {
+		a.mu.Unlock()
+		watcher.Close()
+		return nil
+	}
+	a.sessions[sessionName] = history
+	a.mu.Unlock()
+
+	logger.WithFields(logrus.Fields{
+		"session": sessionName,
+		"history": path,
+	}).Info("watching-aider-history")
+
+	go a.run(sessionName, history)
+	return nil
+}
+
+// expectReply marks the session as waiting for a reply written after the
+// current end of its history file
+func (a *AiderAdapter) expectReply(sessionName string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	history, ok := a.sessions[sessionName]
+	if !ok {
+		return
+	}
+	history.offset = 0
+	if info, err := os.Stat(history.path); err == nil {
+		history.offset = info.Size()
+	}
+	history.pending = true
+}
+
+// run delivers the pending reply once the history file stops changing
+func (a *AiderAdapter) run(sessionName string, history *aiderHistory) {
+	var settle <-chan time.Time
+	for {
+		select {
+		case event, ok := <-history.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == history.path && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create)) {
+				settle = time.After(a.settleDelay)
+			}
+		case err, ok := <-history.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.WithFields(logrus.Fields{
+				"session": sessionName,
+				"error":   err,
+			}).Warn("aider-history-watch-error")
+		case <-settle:
+			settle = nil
+			a.deliver(sessionName, history)
+		}
+	}
+}
+
+// deliver sends the reply to the pending prompt, if it has been written
+func (a *AiderAdapter) deliver(sessionName string, history *aiderHistory) {
+	a.mu.Lock()
+	pending, offset, engine := history.pending, history.offset, a.engine
+	a.mu.Unlock()
+	if !pending {
+		return
+	}
+
+	text, err := readFrom(history.path, offset)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"session": sessionName,
+			"error":   err,
+		}).Warn("failed-to-read-aider-history")
+		return
+	}
+	turn := parseAiderHistory(text)
+	reply := turn.reply()
+	if reply == "" {
+		// Only the prompt has been written so far
+		return
+	}
+
+	a.mu.Lock()
+	history.pending = false
+	a.mu.Unlock()
+
+	logger.WithFields(logrus.Fields{
+		"session":      sessionName,
+		"prompt_len":   len(turn.prompt),
+		"response_len": len(reply),
+	}).Info("response-extracted-from-aider-history")
+
+	if engine != nil {
+		engine.SendResponseToSession(sessionName, reply)
+	}
+}
+
+// readFrom reads a file from offset, or from the start when the file has
+// shrunk since (e.g. it was truncated or replaced)
+func readFrom(path string, offset int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil && info.Size() < offset {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(file)
+	return string(data), err
+}
+
+// aiderTurn is one prompt and what Aider wrote in response
+type aiderTurn struct {
+	prompt string   // The user's input ("#### " lines)
+	text   string   // The model's reply
+	output []string // Aider's own output ("> " lines): edits, commits, command results
+}
+
+// parseAiderHistory parses the first turn in a chunk of Aider's chat history:
+//
+//	#### user prompt
+//
+//	model reply, in markdown
+//
+//	> Applied edit to main.go
+//	> Commit 1a2b3c4 feat: Add greeting
+func parseAiderHistory(text string) aiderTurn {
+	var turn aiderTurn
+	var prompt, reply []string
+	started, inPrompt := false, false
+	for _, line := range strings.Split(text, "\n") {
+		if line == "####" || strings.HasPrefix(line, "#### ") {
+			if started && !inPrompt {
+				// The next turn begins
+				break
+			}
+			started, inPrompt = true, true
+			prompt = append(prompt, strings.TrimSpace(strings.TrimPrefix(line, "####")))
+			continue
+		}
+		if !started {
+			continue
+		}
+		inPrompt = false
+		switch {
+		case strings.HasPrefix(line, ">"):
+			if out := strings.TrimSpace(strings.TrimPrefix(line, ">")); out != "" {
+				turn.output = append(turn.output, out)
+			}
+		case strings.HasPrefix(line, "# aider chat started at"):
+			// Session header
+		default:
+			reply = append(reply, strings.TrimRight(line, " "))
+		}
+	}
+	turn.prompt = strings.TrimSpace(strings.Join(prompt, "\n"))
+	turn.text = strings.TrimSpace(strings.Join(reply, "\n"))
+	return turn
+}
+
+// isChatCommand reports whether the prompt is one of the Aider commands whose
+// result is Aider's own output
+func (t aiderTurn) isChatCommand() bool {
+	fields := strings.Fields(t.prompt)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, cmd := range aiderChatCommands {
+		if fields[0] == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+// reply formats the turn for chat: the model's reply followed by the edits and
+// commits Aider made, or Aider's output for /add, /drop and /undo. Returns ""
+// while the reply has not been written yet.
+func (t aiderTurn) reply() string {
+	if t.isChatCommand() {
+		return strings.Join(t.output, "\n")
+	}
+
+	var summary []string
+	for _, out := range t.output {
+		switch {
+		case strings.HasPrefix(out, "Applied edit to "):
+			summary = append(summary, "✏️ "+out)
+		case strings.HasPrefix(out, "Commit "):
+			summary = append(summary, "📦 "+out)
+		}
+	}
+
+	parts := make([]string, 0, 2)
+	if t.text != "" {
+		parts = append(parts, t.text)
+	}
+	if len(summary) > 0 {
+		parts = append(parts, strings.Join(summary, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
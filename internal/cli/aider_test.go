@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// aiderHistoryFixture is a chat history with a session header, an earlier
+// turn and a turn that edited and committed a file
+const aiderHistoryFixture = `
+# aider chat started at 2026-10-01 10:00:00
+
+> /usr/local/bin/aider --model sonnet
+> Aider v0.86.1
+> Git repo: .git with 12 files
+
+#### add a hello function
+
+Here is the function:
+
+hello.py
+` + "```python" + `
+<<<<<<< SEARCH
+=======
+def hello():
+    print("hello")
+>>>>>>> REPLACE
+` + "```" + `
+
+> Tokens: 2.1k sent, 120 received. Cost: $0.0081 message, $0.0081 session.
+> Applied edit to hello.py
+> Commit 1a2b3c4 feat: Add hello function
+> You can use /undo to undo and discard each aider commit.
+
+#### /add main.go
+
+> Added main.go to the chat
+`
+
+func TestParseAiderHistory(t *testing.T) {
+	turn := parseAiderHistory(aiderHistoryFixture)
+	assert.Equal(t, "add a hello function", turn.prompt)
+	assert.Contains(t, turn.text, "Here is the function:")
+	assert.Contains(t, turn.text, "def hello():")
+	assert.NotContains(t, turn.text, "aider chat started")
+	assert.Contains(t, turn.output, "Applied edit to hello.py")
+	assert.Contains(t, turn.output, "Commit 1a2b3c4 feat: Add hello function")
+	assert.NotContains(t, turn.output, "Added main.go to the chat")
+
+	// Multi-line prompts are several "####" lines
+	turn = parseAiderHistory("#### first line  \n####\n#### second line\n\nok\n")
+	assert.Equal(t, "first line\n\nsecond line", turn.prompt)
+	assert.Equal(t, "ok", turn.text)
+
+	assert.Empty(t, parseAiderHistory("no prompt here\n").prompt)
+}
+
+func TestAiderTurn_Reply(t *testing.T) {
+	turn := parseAiderHistory(aiderHistoryFixture)
+	reply := turn.reply()
+	assert.Contains(t, reply, "Here is the function:")
+	assert.Contains(t, reply, "\n\n✏️ Applied edit to hello.py\n📦 Commit 1a2b3c4 feat: Add hello function")
+	assert.NotContains(t, reply, "Tokens:")
+
+	// Passthrough commands reply with Aider's own output
+	turn = parseAiderHistory("#### /undo\n\n> Removed: 1a2b3c4 feat: Add hello function\n> Now at:  9f8e7d6 Initial commit\n")
+	assert.True(t, turn.isChatCommand())
+	assert.Equal(t, "Removed: 1a2b3c4 feat: Add hello function\nNow at:  9f8e7d6 Initial commit", turn.reply())
+
+	// Nothing to deliver while only the prompt has been written
+	assert.Empty(t, parseAiderHistory("#### explain main.go\n\n").reply())
+}
+
+func TestRepoRoot(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o755))
+	sub := filepath.Join(root, "pkg", "util")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+
+	assert.Equal(t, root, repoRoot(sub))
+
+	plain := t.TempDir()
+	assert.Equal(t, plain, repoRoot(plain))
+}
+
+// recordingEngine records the responses sent to sessions
+type recordingEngine struct {
+	mu        sync.Mutex
+	responses map[string][]string
+}
+
+func (r *recordingEngine) SendToBot(platform, channel, message string)       {}
+func (r *recordingEngine) SendProgressToSession(sessionName, message string) {}
+
+func (r *recordingEngine) SendResponseToSession(sessionName, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses[sessionName] = append(r.responses[sessionName], message)
+}
+
+func (r *recordingEngine) get(sessionName string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.responses[sessionName]...)
+}
+
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(text)
+	require.NoError(t, err)
+}
+
+func TestAiderAdapter_DeliversReplyFromHistory(t *testing.T) {
+	dir := t.TempDir()
+	engine := &recordingEngine{responses: make(map[string][]string)}
+	adapter, err := NewAiderAdapter(AiderAdapterConfig{SettleDelay: 50 * time.Millisecond})
+	require.NoError(t, err)
+	adapter.SetEngine(engine)
+	var _ CLIAdapter = adapter
+
+	history := adapter.historyPath(dir)
+	appendFile(t, history, "#### old prompt\n\nold reply\n\n")
+	require.NoError(t, adapter.watch("proj", history))
+
+	// The earlier turn is not delivered, and the prompt alone is not a reply
+	adapter.expectReply("proj")
+	appendFile(t, history, "#### add a hello function  \n\n")
+	time.Sleep(150 * time.Millisecond)
+	assert.Empty(t, engine.get("proj"))
+
+	appendFile(t, history, "Done.\n\n> Applied edit to hello.py\n")
+	require.Eventually(t, func() bool { return len(engine.get("proj")) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "Done.\n\n✏️ Applied edit to hello.py", engine.get("proj")[0])
+
+	// Writes without a pending prompt (e.g. typed in the terminal) are ignored
+	appendFile(t, history, "#### typed locally\n\nlocal reply\n")
+	time.Sleep(150 * time.Millisecond)
+	assert.Len(t, engine.get("proj"), 1)
+}
+
+func TestAiderAdapter_UnwatchSession(t *testing.T) {
+	dir := t.TempDir()
+	engine := &recordingEngine{responses: make(map[string][]string)}
+	adapter, err := NewAiderAdapter(AiderAdapterConfig{SettleDelay: 50 * time.Millisecond})
+	require.NoError(t, err)
+	adapter.SetEngine(engine)
+	var _ ReplyWatcher = adapter
+
+	history := adapter.historyPath(dir)
+	require.NoError(t, adapter.watch("proj", history))
+	adapter.expectReply("proj")
+	adapter.UnwatchSession("proj")
+	assert.Empty(t, adapter.sessions)
+
+	// Replies written after the session was closed are not delivered
+	appendFile(t, history, "#### prompt\n\nreply\n")
+	time.Sleep(150 * time.Millisecond)
+	assert.Empty(t, engine.get("proj"))
+
+	adapter.UnwatchSession("proj")
+	assert.NoError(t, adapter.Close())
+}
+
+func TestAiderAdapter_HandleHookData(t *testing.T) {
+	adapter, err := NewAiderAdapter(AiderAdapterConfig{})
+	require.NoError(t, err)
+	_, _, _, err = adapter.HandleHookData([]byte(`{}`))
+	assert.Error(t, err)
+}
@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os/exec"
+	"strings"
 
 	"github.com/keepmind9/clibot/internal/logger"
 	"github.com/keepmind9/clibot/internal/watchdog"
@@ -25,6 +27,25 @@ func NewBaseAdapter(name, startCmd string, delay int) BaseAdapter {
 	}
 }
 
+// ParseNotification recognizes Notification hook events, which Claude Code,
+// Gemini CLI and the OpenCode plugin send as
+//
+//	{"hook_event_name": "Notification", "message": "...", ...}
+func (b *BaseAdapter) ParseNotification(data []byte) (string, bool) {
+	var hookData struct {
+		EventName string `json:"hook_event_name"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &hookData); err != nil || !strings.EqualFold(hookData.EventName, "Notification") {
+		return "", false
+	}
+	message := strings.TrimSpace(hookData.Message)
+	if message == "" {
+		message = fmt.Sprintf("%s is waiting for your input", b.cliName)
+	}
+	return message, true
+}
+
 func (b *BaseAdapter) IsSessionAlive(sessionName string) bool {
 	return watchdog.IsSessionAlive(sessionName)
 }
@@ -43,7 +64,14 @@ func (b *BaseAdapter) CreateSession(sessionName, workDir, startCmd, transportURL
 	args := []string{"new-session", "-d", "-s", sessionName}
 
 	// Set working directory if specified
-	if workDir != "" {
+	if host := watchdog.SessionHost(sessionName); workDir != "" && host != "" {
+		// Sessions on an SSH host resolve the directory there
+		remoteDir, err := watchdog.RemoteDir(host, workDir)
+		if err != nil {
+			return fmt.Errorf("session %s: %w", sessionName, err)
+		}
+		args = append(args, "-c", remoteDir)
+	} else if workDir != "" {
 		expandedDir, err := expandHome(workDir)
 		if err != nil {
 			return fmt.Errorf("session %s: invalid work_dir: %w", sessionName, err)
@@ -57,14 +85,14 @@ func (b *BaseAdapter) CreateSession(sessionName, workDir, startCmd, transportURL
 		args = append(args, "-c", expandedDir)
 	}
 
-	cmd := exec.Command("tmux", args...)
+	cmd := watchdog.TmuxCommand(sessionName, args...)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("session %s: failed to create tmux session: %w (output: %s)", sessionName, err, string(output))
 	}
 
 	// Set session-level environment variables (inherited by CLI process)
 	for k, v := range env {
-		setEnvCmd := exec.Command("tmux", "set-environment", "-t", sessionName, k, v)
+		setEnvCmd := watchdog.TmuxCommand(sessionName, "set-environment", "-t", sessionName, k, v)
 		if output, err := setEnvCmd.CombinedOutput(); err != nil {
 			return fmt.Errorf("session %s: failed to set env %s: %w (output: %s)", sessionName, k, err, string(output))
 		}
@@ -21,15 +21,30 @@ type ClaudeAdapterConfig struct {
 // ClaudeAdapter implements CLIAdapter for Claude Code
 type ClaudeAdapter struct {
 	BaseAdapter
+	transcriptDirs []string // Directories hook transcript paths must lie under
 }
 
 // NewClaudeAdapter creates a new Claude Code adapter
 func NewClaudeAdapter(config ClaudeAdapterConfig) (*ClaudeAdapter, error) {
 	return &ClaudeAdapter{
-		BaseAdapter: NewBaseAdapter("claude", "claude", 0),
+		BaseAdapter:    NewBaseAdapter("claude", "claude", 0),
+		transcriptDirs: claudeDataDirs(config.Env),
 	}, nil
 }
 
+// claudeDataDirs returns where Claude Code keeps transcripts:
+// ~/.claude and CLAUDE_CONFIG_DIR when set (adapter env or process env)
+func claudeDataDirs(env map[string]string) []string {
+	dirs := []string{"~/.claude"}
+	if dir := env["CLAUDE_CONFIG_DIR"]; dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
 // HandleHookData handles raw hook data from Claude Code
 // Expected data format (JSON):
 //
@@ -65,9 +80,19 @@ func (c *ClaudeAdapter) HandleHookData(data []byte) (string, string, string, err
 	var lastUserPrompt, response string
 	var err error
 
-	// Extract both prompt and response in one pass if possible
-	if hookData.TranscriptPath != "" {
-		lastUserPrompt, response, err = extractLatestInteraction(hookData.TranscriptPath)
+	// Extract both prompt and response in one pass if possible. Tool and
+	// session events carry no new assistant output, so skip the transcript.
+	if hookData.TranscriptPath != "" && !isClaudeToolOrSessionEvent(hookData.EventName) {
+		var transcriptPath string
+		transcriptPath, err = validateTranscriptPath(hookData.TranscriptPath, hookTranscriptDirs(c.transcriptDirs))
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"transcript": hookData.TranscriptPath,
+				"error":      err,
+			}).Warn("rejected-hook-transcript-path")
+			return "", "", "", err
+		}
+		lastUserPrompt, response, err = extractLatestInteraction(transcriptPath)
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"transcript": hookData.TranscriptPath,
@@ -91,6 +116,76 @@ func (c *ClaudeAdapter) HandleHookData(data []byte) (string, string, string, err
 	return hookData.CWD, lastUserPrompt, response, nil
 }
 
+// isClaudeToolOrSessionEvent reports whether a hook event concerns a tool call
+// or the session lifecycle rather than a finished response
+func isClaudeToolOrSessionEvent(eventName string) bool {
+	switch eventName {
+	case "PreToolUse", "PostToolUse", "SessionStart", "SessionEnd":
+		return true
+	}
+	return false
+}
+
+// ParseHookEvent recognizes the SubagentStop, PreToolUse, PostToolUse,
+// SessionStart and SessionEnd hook events. The subagent summary of a
+// SubagentStop event is the response returned by HandleHookData.
+func (c *ClaudeAdapter) ParseHookEvent(data []byte) (HookEvent, bool) {
+	var hookData struct {
+		EventName string          `json:"hook_event_name"`
+		ToolName  string          `json:"tool_name"`
+		ToolInput json.RawMessage `json:"tool_input"`
+		Source    string          `json:"source"`
+		Reason    string          `json:"reason"`
+	}
+	if err := json.Unmarshal(data, &hookData); err != nil {
+		return HookEvent{}, false
+	}
+
+	event := HookEvent{Name: hookData.EventName}
+	switch hookData.EventName {
+	case "SubagentStop":
+	case "PreToolUse", "PostToolUse":
+		event.ToolName = hookData.ToolName
+		event.ToolInput = summarizeToolInput(hookData.ToolInput)
+	case "SessionStart":
+		event.Detail = hookData.Source
+	case "SessionEnd":
+		event.Detail = hookData.Reason
+	default:
+		return HookEvent{}, false
+	}
+	return event, true
+}
+
+// summarizeToolInput reduces a tool input to one line: the command for Bash,
+// the file path for file tools, otherwise the compact JSON
+func summarizeToolInput(raw json.RawMessage) string {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return ""
+	}
+	for _, key := range []string{"command", "file_path", "notebook_path", "url", "pattern"} {
+		if value, ok := fields[key].(string); ok && value != "" {
+			return truncateToolInput(value)
+		}
+	}
+	compact, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return truncateToolInput(string(compact))
+}
+
+// truncateToolInput keeps tool summaries short enough for a chat message
+func truncateToolInput(s string) string {
+	const maxLen = 300
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= maxLen {
+		return string(runes)
+	}
+	return string(runes[:maxLen]) + "..."
+}
+
 // ========== Transcript.jsonl Parsing ==========
 
 // TranscriptMessage represents a single message in Claude Code's transcript.jsonl
@@ -309,6 +404,133 @@ func extractLatestInteraction(transcriptPath string) (string, string, error) {
 	return prompt, strings.Join(responseTexts, "\n\n"), nil
 }
 
+// claudeProjectDirName returns the directory name Claude Code stores a
+// project's transcripts under: the absolute work dir with every character
+// other than letters and digits replaced by "-"
+func claudeProjectDirName(workDir string) string {
+	if abs, err := filepath.Abs(workDir); err == nil {
+		workDir = abs
+	}
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, workDir)
+}
+
+// TranscriptDirs returns the directories holding transcripts of sessions
+// started in workDir: <data dir>/projects/<project dir name>
+func (c *ClaudeAdapter) TranscriptDirs(workDir string) []string {
+	name := claudeProjectDirName(workDir)
+	var dirs []string
+	for _, dir := range c.transcriptDirs {
+		if expanded, err := expandHome(dir); err == nil {
+			dirs = append(dirs, filepath.Join(expanded, "projects", name))
+		}
+	}
+	return dirs
+}
+
+// LatestTurn reads the latest turn of the newest transcript for workDir.
+// The turn is complete once the last message is assistant text that does not
+// call a tool.
+func (c *ClaudeAdapter) LatestTurn(workDir string) (TurnStatus, error) {
+	path, err := c.latestTranscript(workDir)
+	if err != nil {
+		return TurnStatus{}, err
+	}
+	messages, err := parseTranscript(path)
+	if err != nil {
+		return TurnStatus{}, err
+	}
+
+	lastUserIndex := -1
+	for i := len(messages) - 1; i >= 0; i-- {
+		if isRealUserMessage(messages[i]) {
+			lastUserIndex = i
+			break
+		}
+	}
+	if lastUserIndex == -1 {
+		return TurnStatus{}, fmt.Errorf("no user messages found in %s", path)
+	}
+
+	var texts []string
+	for _, msg := range messages[lastUserIndex+1:] {
+		if msg.Type == "assistant" {
+			if text := getMessageText(msg); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+
+	last := messages[len(messages)-1]
+	complete := last.Type == "assistant" && getMessageText(last) != "" &&
+		last.Message.StopReason != "tool_use" && !hasToolUse(last)
+
+	return TurnStatus{
+		ID:       fmt.Sprintf("%s#%d", path, lastUserIndex),
+		Prompt:   getMessageText(messages[lastUserIndex]),
+		Response: strings.Join(texts, "\n\n"),
+		Complete: complete,
+	}, nil
+}
+
+// latestTranscript returns the most recently written transcript for workDir
+func (c *ClaudeAdapter) latestTranscript(workDir string) (string, error) {
+	var patterns []string
+	for _, dir := range c.TranscriptDirs(workDir) {
+		patterns = append(patterns, filepath.Join(dir, "*.jsonl"))
+	}
+	return newestMatch(patterns...)
+}
+
+// History reads every turn of the newest transcript for workDir. Each real
+// user prompt starts a turn; tool results and meta messages do not.
+func (c *ClaudeAdapter) History(sessionName, workDir string) ([]HistoryTurn, error) {
+	path, err := c.latestTranscript(workDir)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := parseTranscript(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var turns []HistoryTurn
+	var texts []string
+	flush := func() {
+		if len(turns) > 0 {
+			turns[len(turns)-1].Response = strings.Join(texts, "\n\n")
+		}
+		texts = nil
+	}
+	for _, msg := range messages {
+		switch {
+		case isRealUserMessage(msg):
+			flush()
+			turns = append(turns, HistoryTurn{Prompt: getMessageText(msg)})
+		case msg.Type == "assistant":
+			if text := getMessageText(msg); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	flush()
+	return turns, nil
+}
+
+// hasToolUse reports whether an assistant message calls a tool
+func hasToolUse(msg TranscriptMessage) bool {
+	for _, block := range msg.Message.Content {
+		if block.Type == "tool_use" {
+			return true
+		}
+	}
+	return false
+}
+
 // ExtractLatestInteraction exports the latest user prompt and assistant response extraction logic
 func ExtractLatestInteraction(transcriptPath string) (string, string, error) {
 	return extractLatestInteraction(transcriptPath)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -176,6 +177,19 @@ func TestClaudeAdapter_HandleHookData(t *testing.T) {
 		_ = response
 	})
 
+	t.Run("transcript path outside Claude data directories", func(t *testing.T) {
+		outside := filepath.Join(t.TempDir(), "secrets.txt")
+		require.NoError(t, os.WriteFile(outside, []byte("secret"), 0644))
+		data, _ := json.Marshal(map[string]interface{}{
+			"cwd":             "/home/user/project",
+			"transcript_path": outside,
+		})
+
+		_, _, response, err := adapter.HandleHookData(data)
+		assert.Error(t, err)
+		assert.Empty(t, response)
+	})
+
 	t.Run("invalid JSON data", func(t *testing.T) {
 		data := []byte("invalid json")
 
@@ -272,3 +286,107 @@ func TestExtractLatestSubagentFile_FileOperations(t *testing.T) {
 		assert.Equal(t, jsonlFile, latestFile)
 	})
 }
+
+// TestClaudeAdapter_ParseHookEvent tests recognizing subagent, tool and session hook events
+func TestClaudeAdapter_ParseHookEvent(t *testing.T) {
+	adapter := &ClaudeAdapter{}
+
+	event, ok := adapter.ParseHookEvent([]byte(`{"hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"go test ./...","description":"Run tests"}}`))
+	assert.True(t, ok)
+	assert.Equal(t, HookEvent{Name: "PreToolUse", ToolName: "Bash", ToolInput: "go test ./..."}, event)
+
+	event, ok = adapter.ParseHookEvent([]byte(`{"hook_event_name":"PostToolUse","tool_name":"Edit","tool_input":{"file_path":"/repo/main.go","old_string":"a"}}`))
+	assert.True(t, ok)
+	assert.Equal(t, "/repo/main.go", event.ToolInput)
+
+	event, ok = adapter.ParseHookEvent([]byte(`{"hook_event_name":"PreToolUse","tool_name":"Task","tool_input":{"prompt":"x"}}`))
+	assert.True(t, ok)
+	assert.Equal(t, `{"prompt":"x"}`, event.ToolInput)
+
+	event, ok = adapter.ParseHookEvent([]byte(`{"hook_event_name":"SessionStart","source":"resume"}`))
+	assert.True(t, ok)
+	assert.Equal(t, "resume", event.Detail)
+
+	event, ok = adapter.ParseHookEvent([]byte(`{"hook_event_name":"SessionEnd","reason":"clear"}`))
+	assert.True(t, ok)
+	assert.Equal(t, "clear", event.Detail)
+
+	_, ok = adapter.ParseHookEvent([]byte(`{"hook_event_name":"SubagentStop"}`))
+	assert.True(t, ok)
+
+	_, ok = adapter.ParseHookEvent([]byte(`{"hook_event_name":"Stop"}`))
+	assert.False(t, ok)
+	_, ok = adapter.ParseHookEvent([]byte(`not json`))
+	assert.False(t, ok)
+
+	var _ HookEventParser = adapter
+}
+
+func TestClaudeProjectDirName(t *testing.T) {
+	assert.Equal(t, "-home-u-my-proj-v1-2", claudeProjectDirName("/home/u/my_proj/v1.2"))
+}
+
+// TestClaudeAdapter_LatestTurn tests detecting turn completion from the transcript
+func TestClaudeAdapter_LatestTurn(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	adapter, err := NewClaudeAdapter(ClaudeAdapterConfig{Env: map[string]string{"CLAUDE_CONFIG_DIR": configDir}})
+	require.NoError(t, err)
+
+	workDir := "/home/u/proj"
+	dirs := adapter.TranscriptDirs(workDir)
+	require.Contains(t, dirs, filepath.Join(configDir, "projects", "-home-u-proj"))
+	projectDir := filepath.Join(configDir, "projects", "-home-u-proj")
+	require.NoError(t, os.MkdirAll(projectDir, 0o755))
+	path := filepath.Join(projectDir, "session.jsonl")
+
+	lines := `{"type":"user","message":{"role":"user","content":"list files"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Let me look."}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","name":"Bash"}],"stop_reason":"tool_use"}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","content":"README.md"}]}}
+`
+	require.NoError(t, os.WriteFile(path, []byte(lines), 0o644))
+	turn, err := adapter.LatestTurn(workDir)
+	require.NoError(t, err)
+	assert.Equal(t, path+"#0", turn.ID)
+	assert.Equal(t, "list files", turn.Prompt)
+	assert.Equal(t, "Let me look.", turn.Response)
+	assert.False(t, turn.Complete)
+
+	lines += `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"README.md"}],"stop_reason":"end_turn"}}
+`
+	require.NoError(t, os.WriteFile(path, []byte(lines), 0o644))
+	turn, err = adapter.LatestTurn(workDir)
+	require.NoError(t, err)
+	assert.Equal(t, "Let me look.\n\nREADME.md", turn.Response)
+	assert.True(t, turn.Complete)
+}
+
+func TestClaudeAdapter_History(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	adapter, err := NewClaudeAdapter(ClaudeAdapterConfig{Env: map[string]string{"CLAUDE_CONFIG_DIR": configDir}})
+	require.NoError(t, err)
+	var _ HistoryReader = adapter
+
+	projectDir := filepath.Join(configDir, "projects", "-home-u-proj")
+	require.NoError(t, os.MkdirAll(projectDir, 0o755))
+	lines := `{"type":"user","message":{"role":"user","content":"list files"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Let me look."},{"type":"tool_use","name":"Bash"}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","content":"README.md"}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"README.md"}]}}
+{"type":"user","isMeta":true,"message":{"role":"user","content":"<local-command-caveat>ignored</local-command-caveat>"}}
+{"type":"user","message":{"role":"user","content":"thanks"}}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "session.jsonl"), []byte(lines), 0o644))
+
+	turns, err := adapter.History("main", "/home/u/proj")
+	require.NoError(t, err)
+	assert.Equal(t, []HistoryTurn{
+		{Prompt: "list files", Response: "Let me look.\n\nREADME.md"},
+		{Prompt: "thanks"},
+	}, turns)
+
+	_, err = adapter.History("main", "/home/u/other")
+	assert.Error(t, err)
+}
@@ -0,0 +1,340 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// codexTurnComplete is the only event Codex passes to its notify program
+const codexTurnComplete = "agent-turn-complete"
+
+// codexMaxLineSize bounds a single rollout line; session_meta lines embed the
+// full base instructions and tool outputs can be large
+const codexMaxLineSize = 16 * 1024 * 1024
+
+// codexContextPrefixes mark user messages Codex injects itself (AGENTS.md
+// instructions, environment details) rather than prompts typed by the user
+var codexContextPrefixes = []string{
+	"<environment_context>",
+	"<user_instructions>",
+	"# AGENTS.md instructions",
+}
+
+// CodexAdapterConfig configuration for Codex CLI adapter
+type CodexAdapterConfig struct {
+	Env map[string]string // Environment variables to set for the CLI process
+}
+
+// CodexAdapter implements CLIAdapter for OpenAI's Codex CLI
+type CodexAdapter struct {
+	BaseAdapter
+	codexHome string // Codex data directory holding sessions/ (CODEX_HOME)
+}
+
+// NewCodexAdapter creates a new Codex CLI adapter
+func NewCodexAdapter(config CodexAdapterConfig) (*CodexAdapter, error) {
+	return &CodexAdapter{
+		// Codex treats fast keystrokes followed by Enter as a paste, so the
+		// Enter key is delayed like Gemini's
+		BaseAdapter: NewBaseAdapter("codex", "codex", 200),
+		codexHome:   codexHomeDir(config.Env),
+	}, nil
+}
+
+// codexHomeDir returns where Codex keeps its data: CODEX_HOME when set
+// (adapter env or process env), otherwise ~/.codex
+func codexHomeDir(env map[string]string) string {
+	if dir := env["CODEX_HOME"]; dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("CODEX_HOME"); dir != "" {
+		return dir
+	}
+	return "~/.codex"
+}
+
+// codexNotifyPayload is the JSON Codex passes as the last argument of its
+// notify program when a turn completes
+type codexNotifyPayload struct {
+	Type                 string   `json:"type"`
+	ThreadID             string   `json:"thread-id"`
+	TurnID               string   `json:"turn-id"`
+	CWD                  string   `json:"cwd"`
+	InputMessages        []string `json:"input-messages"`
+	LastAssistantMessage string   `json:"last-assistant-message"`
+}
+
+// HandleHookData handles raw notify data from Codex CLI
+// Expected data format (JSON):
+//
+//	{"type": "agent-turn-complete", "thread-id": "...", "cwd": "...",
+//	 "input-messages": ["..."], "last-assistant-message": "..."}
+//
+// The prompt and response come from the payload. When the response or cwd is
+// missing (older Codex versions omit cwd), they are read from the session's
+// rollout file: ~/.codex/sessions/YYYY/MM/DD/rollout-*-{thread-id}.jsonl
+//
+// Parameter data: raw hook data (JSON bytes)
+// Returns: (cwd, lastUserPrompt, response, error)
+func (c *CodexAdapter) HandleHookData(data []byte) (string, string, string, error) {
+	var payload codexNotifyPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		logger.WithField("error", err).Error("failed-to-parse-hook-json-data")
+		return "", "", "", fmt.Errorf("failed to parse JSON data: %w", err)
+	}
+	if payload.Type != codexTurnComplete {
+		return "", "", "", fmt.Errorf("unsupported codex notify type: %q", payload.Type)
+	}
+
+	cwd := payload.CWD
+	response := strings.TrimSpace(payload.LastAssistantMessage)
+	var prompt string
+	if n := len(payload.InputMessages); n > 0 {
+		prompt = strings.TrimSpace(payload.InputMessages[n-1])
+	}
+
+	if cwd == "" || response == "" {
+		rollout, err := c.findRollout(payload.ThreadID, cwd)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"thread_id": payload.ThreadID,
+				"error":     err,
+			}).Warn("failed-to-find-codex-rollout")
+		} else {
+			meta, messages, err := parseCodexRollout(rollout)
+			if err != nil {
+				logger.WithFields(logrus.Fields{
+					"rollout": rollout,
+					"error":   err,
+				}).Warn("failed-to-parse-codex-rollout")
+			}
+			if cwd == "" {
+				cwd = meta.CWD
+			}
+			if response == "" {
+				if p, r, err := latestCodexInteraction(messages); err == nil {
+					prompt, response = p, r
+				}
+			}
+		}
+	}
+
+	if cwd == "" {
+		logger.Warn("missing-cwd-in-hook-data")
+		return "", "", "", fmt.Errorf("missing cwd in hook data")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"cwd":          cwd,
+		"thread_id":    payload.ThreadID,
+		"prompt_len":   len(prompt),
+		"response_len": len(response),
+	}).Info("response-extracted-from-codex-notify")
+
+	return cwd, prompt, response, nil
+}
+
+// findRollout returns the newest rollout file of the given thread. Without a
+// thread id, the newest rollout started in cwd is used; with neither there is
+// no way to tell which rollout belongs to the session, so it is an error.
+func (c *CodexAdapter) findRollout(threadID, cwd string) (string, error) {
+	if threadID == "" && cwd == "" {
+		return "", fmt.Errorf("codex notify payload has neither thread-id nor cwd")
+	}
+	home, err := expandHome(c.codexHome)
+	if err != nil {
+		return "", err
+	}
+	sessionsDir := filepath.Join(home, "sessions")
+
+	type rollout struct {
+		path    string
+		modTime time.Time
+	}
+	var rollouts []rollout
+	err = filepath.WalkDir(sessionsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name := d.Name()
+		if !strings.HasPrefix(name, "rollout-") || !strings.HasSuffix(name, ".jsonl") {
+			return nil
+		}
+		if threadID != "" && !strings.HasSuffix(name, "-"+threadID+".jsonl") {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			rollouts = append(rollouts, rollout{path, info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search rollout files: %w", err)
+	}
+	sort.Slice(rollouts, func(i, j int) bool {
+		return rollouts[i].modTime.After(rollouts[j].modTime)
+	})
+
+	for _, r := range rollouts {
+		if threadID != "" {
+			return r.path, nil
+		}
+		if meta, _, err := parseCodexRollout(r.path); err == nil && meta.CWD != "" &&
+			filepath.Clean(meta.CWD) == filepath.Clean(cwd) {
+			return r.path, nil
+		}
+	}
+	if threadID != "" {
+		return "", fmt.Errorf("no rollout file found in %s for thread %q", sessionsDir, threadID)
+	}
+	return "", fmt.Errorf("no rollout file found in %s for cwd %s", sessionsDir, cwd)
+}
+
+// ExtractLatestInteraction extracts the latest user prompt and assistant
+// reply from a Codex rollout file
+func (c *CodexAdapter) ExtractLatestInteraction(rolloutPath string) (string, string, error) {
+	path, err := expandHome(rolloutPath)
+	if err != nil {
+		return "", "", err
+	}
+	_, messages, err := parseCodexRollout(path)
+	if err != nil {
+		return "", "", err
+	}
+	return latestCodexInteraction(messages)
+}
+
+// codexSessionMeta is the payload of a rollout's session_meta line
+type codexSessionMeta struct {
+	ID  string `json:"id"`
+	CWD string `json:"cwd"`
+}
+
+// codexResponseItem is a model input or output item recorded in a rollout.
+// Only "message" items carry conversation text; reasoning and tool calls are
+// other item types.
+type codexResponseItem struct {
+	Type    string `json:"type"`
+	Role    string `json:"role"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// text joins the item's input_text/output_text blocks
+func (item codexResponseItem) text() string {
+	var texts []string
+	for _, block := range item.Content {
+		if block.Text != "" {
+			texts = append(texts, block.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(texts, "\n\n"))
+}
+
+// parseCodexRollout parses a Codex rollout JSONL file into its session
+// metadata and conversation messages in order. Current rollouts wrap each
+// record as {"type": "session_meta"|"response_item"|..., "payload": {...}};
+// older ones store response items at the top level.
+func parseCodexRollout(path string) (codexSessionMeta, []codexResponseItem, error) {
+	var meta codexSessionMeta
+	file, err := os.Open(path)
+	if err != nil {
+		return meta, nil, fmt.Errorf("failed to open rollout file: %w", err)
+	}
+	defer file.Close()
+
+	var messages []codexResponseItem
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), codexMaxLineSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var record struct {
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		if err := json.Unmarshal(line, &record); err != nil {
+			continue
+		}
+
+		raw := line
+		switch record.Type {
+		case "session_meta":
+			_ = json.Unmarshal(record.Payload, &meta)
+			continue
+		case "response_item":
+			raw = record.Payload
+		case "message":
+			// Legacy rollout: the line is the response item
+		default:
+			continue
+		}
+
+		var item codexResponseItem
+		if err := json.Unmarshal(raw, &item); err != nil || item.Type != "message" {
+			continue
+		}
+		if item.Role == "user" || item.Role == "assistant" {
+			messages = append(messages, item)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return meta, nil, fmt.Errorf("error reading rollout file: %w", err)
+	}
+	return meta, messages, nil
+}
+
+// isCodexUserPrompt reports whether a message is a prompt typed by the user
+func isCodexUserPrompt(item codexResponseItem) bool {
+	if item.Role != "user" {
+		return false
+	}
+	text := item.text()
+	if text == "" {
+		return false
+	}
+	for _, prefix := range codexContextPrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// latestCodexInteraction returns the last user prompt and the assistant
+// messages following it, joined with blank lines
+func latestCodexInteraction(messages []codexResponseItem) (string, string, error) {
+	lastUserIndex := -1
+	for i, item := range messages {
+		if isCodexUserPrompt(item) {
+			lastUserIndex = i
+		}
+	}
+	if lastUserIndex == -1 {
+		return "", "", fmt.Errorf("no user message found in rollout")
+	}
+
+	var parts []string
+	for _, item := range messages[lastUserIndex+1:] {
+		if item.Role == "assistant" {
+			if text := item.text(); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return "", "", fmt.Errorf("no assistant messages found after last user message")
+	}
+	return messages[lastUserIndex].text(), strings.Join(parts, "\n\n"), nil
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// codexRollout is a trimmed rollout in the current format, with injected
+// context messages, reasoning, a tool call and event mirrors of the messages
+const codexRollout = `{"timestamp":"2026-10-01T10:00:00.000Z","type":"session_meta","payload":{"id":"0199a1b2-0000-7000-8000-000000000001","cwd":"/home/u/proj","originator":"codex_cli_rs","cli_version":"0.46.0","instructions":"long base instructions"}}
+{"timestamp":"2026-10-01T10:00:00.100Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"<user_instructions>\nUse tabs.\n</user_instructions>"}]}}
+{"timestamp":"2026-10-01T10:00:00.200Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"<environment_context>\n  <cwd>/home/u/proj</cwd>\n</environment_context>"}]}}
+{"timestamp":"2026-10-01T10:00:01.000Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"what is 1+1"}]}}
+{"timestamp":"2026-10-01T10:00:02.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"2"}]}}
+{"timestamp":"2026-10-01T10:00:03.000Z","type":"turn_context","payload":{"cwd":"/home/u/proj","model":"gpt-5-codex"}}
+{"timestamp":"2026-10-01T10:00:03.100Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"list the go files"}]}}
+{"timestamp":"2026-10-01T10:00:03.200Z","type":"event_msg","payload":{"type":"user_message","message":"list the go files","kind":"plain"}}
+{"timestamp":"2026-10-01T10:00:04.000Z","type":"response_item","payload":{"type":"reasoning","summary":[{"type":"summary_text","text":"Listing files"}]}}
+{"timestamp":"2026-10-01T10:00:04.500Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Let me look."}]}}
+{"timestamp":"2026-10-01T10:00:05.000Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"ls\"]}","call_id":"call_1"}}
+{"timestamp":"2026-10-01T10:00:05.500Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_1","output":"main.go\nutil.go"}}
+{"timestamp":"2026-10-01T10:00:06.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"main.go and util.go"}]}}
+{"timestamp":"2026-10-01T10:00:06.100Z","type":"event_msg","payload":{"type":"agent_message","message":"main.go and util.go"}}
+`
+
+// codexLegacyRollout is a rollout from older Codex versions, where response
+// items are stored at the top level
+const codexLegacyRollout = `{"id":"5973b6c0-94b8-487b-a530-2aeb6098ae0e","timestamp":"2025-05-01T10:00:00Z","instructions":null}
+{"record_type":"state"}
+{"type":"message","role":"user","content":[{"type":"input_text","text":"hello"}]}
+{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Hi! How can I help?"}]}
+{"record_type":"state"}
+`
+
+// writeCodexRollout writes a rollout fixture under home's dated sessions directory
+func writeCodexRollout(t *testing.T, home, threadID, content string) string {
+	t.Helper()
+	dir := filepath.Join(home, "sessions", "2026", "10", "01")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	path := filepath.Join(dir, "rollout-2026-10-01T10-00-00-"+threadID+".jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestParseCodexRollout(t *testing.T) {
+	path := writeCodexRollout(t, t.TempDir(), "0199a1b2-0000-7000-8000-000000000001", codexRollout)
+
+	meta, messages, err := parseCodexRollout(path)
+	require.NoError(t, err)
+	assert.Equal(t, "/home/u/proj", meta.CWD)
+	assert.Equal(t, "0199a1b2-0000-7000-8000-000000000001", meta.ID)
+	// Reasoning, tool calls and event mirrors are not messages
+	assert.Len(t, messages, 7)
+
+	_, _, err = parseCodexRollout(filepath.Join(t.TempDir(), "missing.jsonl"))
+	assert.Error(t, err)
+}
+
+func TestCodexAdapter_ExtractLatestInteraction(t *testing.T) {
+	adapter, err := NewCodexAdapter(CodexAdapterConfig{})
+	require.NoError(t, err)
+
+	t.Run("current format", func(t *testing.T) {
+		path := writeCodexRollout(t, t.TempDir(), "t1", codexRollout)
+		prompt, response, err := adapter.ExtractLatestInteraction(path)
+		require.NoError(t, err)
+		assert.Equal(t, "list the go files", prompt)
+		assert.Equal(t, "Let me look.\n\nmain.go and util.go", response)
+	})
+
+	t.Run("legacy format", func(t *testing.T) {
+		path := writeCodexRollout(t, t.TempDir(), "t2", codexLegacyRollout)
+		prompt, response, err := adapter.ExtractLatestInteraction(path)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", prompt)
+		assert.Equal(t, "Hi! How can I help?", response)
+	})
+
+	t.Run("no reply yet", func(t *testing.T) {
+		path := writeCodexRollout(t, t.TempDir(), "t3",
+			`{"type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"hi"}]}}`+"\n")
+		_, _, err := adapter.ExtractLatestInteraction(path)
+		assert.ErrorContains(t, err, "no assistant messages")
+	})
+}
+
+func TestCodexAdapter_HandleHookData(t *testing.T) {
+	home := t.TempDir()
+	adapter, err := NewCodexAdapter(CodexAdapterConfig{Env: map[string]string{"CODEX_HOME": home}})
+	require.NoError(t, err)
+	writeCodexRollout(t, home, "0199a1b2-0000-7000-8000-000000000001", codexRollout)
+
+	t.Run("payload with reply", func(t *testing.T) {
+		cwd, prompt, response, err := adapter.HandleHookData([]byte(`{
+			"type": "agent-turn-complete",
+			"thread-id": "0199a1b2-0000-7000-8000-000000000001",
+			"turn-id": "12345",
+			"cwd": "/work",
+			"input-messages": ["first", "list the go files"],
+			"last-assistant-message": "main.go and util.go"
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t, "/work", cwd)
+		assert.Equal(t, "list the go files", prompt)
+		assert.Equal(t, "main.go and util.go", response)
+	})
+
+	t.Run("falls back to the rollout", func(t *testing.T) {
+		cwd, prompt, response, err := adapter.HandleHookData([]byte(`{
+			"type": "agent-turn-complete",
+			"thread-id": "0199a1b2-0000-7000-8000-000000000001",
+			"input-messages": ["list the go files"],
+			"last-assistant-message": null
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t, "/home/u/proj", cwd)
+		assert.Equal(t, "list the go files", prompt)
+		assert.Equal(t, "Let me look.\n\nmain.go and util.go", response)
+	})
+
+	t.Run("unknown thread without cwd", func(t *testing.T) {
+		_, _, _, err := adapter.HandleHookData([]byte(`{"type": "agent-turn-complete", "thread-id": "nope"}`))
+		assert.ErrorContains(t, err, "missing cwd")
+	})
+
+	t.Run("cwd without thread id", func(t *testing.T) {
+		// Another thread's newer rollout in a different directory is not used
+		other := writeCodexRollout(t, home, "0199a1b2-0000-7000-8000-000000000002",
+			strings.ReplaceAll(codexRollout, "/home/u/proj", "/home/u/other"))
+		future := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(other, future, future))
+
+		cwd, _, response, err := adapter.HandleHookData([]byte(`{"type": "agent-turn-complete", "cwd": "/home/u/proj"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "/home/u/proj", cwd)
+		assert.Equal(t, "Let me look.\n\nmain.go and util.go", response)
+
+		_, err = adapter.findRollout("", "/home/u/elsewhere")
+		assert.ErrorContains(t, err, "no rollout file found")
+	})
+
+	t.Run("neither thread id nor cwd", func(t *testing.T) {
+		_, err := adapter.findRollout("", "")
+		assert.Error(t, err)
+
+		_, _, _, err = adapter.HandleHookData([]byte(`{"type": "agent-turn-complete", "last-assistant-message": "hi"}`))
+		assert.ErrorContains(t, err, "missing cwd")
+	})
+
+	t.Run("other event types", func(t *testing.T) {
+		_, _, _, err := adapter.HandleHookData([]byte(`{"type": "approval-requested", "cwd": "/work"}`))
+		assert.ErrorContains(t, err, "unsupported codex notify type")
+
+		_, _, _, err = adapter.HandleHookData([]byte(`not json`))
+		assert.Error(t, err)
+	})
+}
+
+func TestCodexHomeDir(t *testing.T) {
+	t.Setenv("CODEX_HOME", "")
+	assert.Equal(t, "~/.codex", codexHomeDir(nil))
+	assert.Equal(t, "/opt/codex", codexHomeDir(map[string]string{"CODEX_HOME": "/opt/codex"}))
+
+	t.Setenv("CODEX_HOME", "/srv/codex")
+	assert.Equal(t, "/srv/codex", codexHomeDir(nil))
+}
@@ -0,0 +1,475 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/watchdog"
+	"github.com/sirupsen/logrus"
+)
+
+// Input methods of the custom adapter
+const (
+	CustomInputSendKeys = "send_keys" // tmux send-keys followed by Enter (default)
+	CustomInputPaste    = "paste"     // pipe into a tmux buffer and paste, keeps multi-line input intact
+	CustomInputStdin    = "stdin"     // write to a named pipe the CLI reads as its stdin (local sessions only)
+)
+
+// Response sources of the custom adapter
+const (
+	CustomResponseHook       = "hook"       // Hook payload fields carry the response
+	CustomResponseTranscript = "transcript" // The hook triggers reading a transcript file
+	CustomResponsePoll       = "poll"       // The tmux pane is polled (no hook needed)
+)
+
+// CustomAdapterConfig describes a CLI entirely from configuration
+type CustomAdapterConfig struct {
+	Name         string               // cli_type the adapter is registered as
+	StartCmd     string               // Command starting the CLI in tmux
+	Env          map[string]string    // Environment variables to set for the CLI process
+	InputMethod  string               // CustomInputSendKeys (default), CustomInputPaste or CustomInputStdin
+	EnterDelayMs int                  // Delay before Enter, for CLIs that drop fast input
+	Response     CustomResponseConfig // Where responses come from
+	Keys         map[string]string    // Chat keyword -> space-separated tmux key names
+}
+
+// CustomResponseConfig locates responses of a custom CLI.
+// Paths use a JSONPath subset: "$.message.content", "items[0].text", "messages[-1]".
+type CustomResponseConfig struct {
+	Source string // CustomResponseHook, CustomResponseTranscript or CustomResponsePoll
+
+	// Hook payload fields (all sources)
+	CWDField      string // Working directory used to match the session (default: cwd)
+	PromptField   string // Last user prompt (hook source)
+	ResponseField string // Assistant response (hook source)
+
+	// Transcript source
+	TranscriptGlob  string // Transcript files; the newest match is read, e.g. ~/.mycli/sessions/*.jsonl
+	TranscriptField string // Hook payload field with the transcript path (must lie under the glob's directory)
+	MessagesPath    string // Messages array in a JSON transcript; empty means JSONL, one message per line
+	RolePath        string // Role of a message (default: role)
+	TextPath        string // Text of a message (default: content)
+	AssistantRole   string // Role value of assistant messages (default: assistant)
+	UserRole        string // Role value of user messages (default: user)
+	Regex           string // Alternative to paths: the last match in the transcript text (group 1 if present)
+}
+
+// CustomAdapter implements CLIAdapter for a CLI described in YAML
+type CustomAdapter struct {
+	BaseAdapter
+	config CustomAdapterConfig
+	regex  *regexp.Regexp
+}
+
+// NewCustomAdapter creates a custom adapter, applying defaults to the config
+func NewCustomAdapter(config CustomAdapterConfig) (*CustomAdapter, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("custom adapter needs a name")
+	}
+	if config.StartCmd == "" {
+		return nil, fmt.Errorf("custom adapter %s: start_cmd is required", config.Name)
+	}
+
+	switch config.InputMethod {
+	case "":
+		config.InputMethod = CustomInputSendKeys
+	case CustomInputSendKeys, CustomInputPaste, CustomInputStdin:
+	default:
+		return nil, fmt.Errorf("custom adapter %s: unknown input method %q", config.Name, config.InputMethod)
+	}
+
+	r := &config.Response
+	switch r.Source {
+	case "":
+		r.Source = CustomResponsePoll
+	case CustomResponseHook, CustomResponseTranscript, CustomResponsePoll:
+	default:
+		return nil, fmt.Errorf("custom adapter %s: unknown response source %q", config.Name, r.Source)
+	}
+	if r.Source == CustomResponseTranscript && r.TranscriptGlob == "" {
+		return nil, fmt.Errorf("custom adapter %s: transcript_glob is required for the transcript source", config.Name)
+	}
+	if r.CWDField == "" {
+		r.CWDField = "cwd"
+	}
+	if r.RolePath == "" {
+		r.RolePath = "role"
+	}
+	if r.TextPath == "" {
+		r.TextPath = "content"
+	}
+	if r.AssistantRole == "" {
+		r.AssistantRole = "assistant"
+	}
+	if r.UserRole == "" {
+		r.UserRole = "user"
+	}
+
+	adapter := &CustomAdapter{
+		BaseAdapter: NewBaseAdapter(config.Name, config.StartCmd, config.EnterDelayMs),
+		config:      config,
+	}
+	if r.Regex != "" {
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("custom adapter %s: invalid regex: %w", config.Name, err)
+		}
+		adapter.regex = re
+	}
+	return adapter, nil
+}
+
+// CreateSession starts the CLI in tmux. With the stdin input method the start
+// command is wrapped so the CLI reads its stdin from the session's input pipe.
+func (c *CustomAdapter) CreateSession(sessionName, workDir, startCmd, transportURL string, env map[string]string) error {
+	if c.config.InputMethod == CustomInputStdin {
+		if startCmd == "" {
+			startCmd = c.startCmd
+		}
+		path, err := stdinPipePath(sessionName)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return fmt.Errorf("create input pipe directory: %w", err)
+		}
+		startCmd = stdinPipeCommand(path, startCmd)
+	}
+	return c.BaseAdapter.CreateSession(sessionName, workDir, startCmd, transportURL, env)
+}
+
+// SendInput sends input using the configured method. Input matching a
+// configured keyword is sent as tmux keys instead.
+func (c *CustomAdapter) SendInput(sessionName, input string) error {
+	if keys, ok := c.config.Keys[strings.ToLower(strings.TrimSpace(input))]; ok {
+		logger.WithFields(logrus.Fields{
+			"session": sessionName,
+			"keyword": input,
+			"keys":    keys,
+		}).Debug("sending-custom-keyword-keys")
+		return watchdog.SendKeyNames(sessionName, strings.Fields(keys))
+	}
+
+	if isKeyInput(input) {
+		return c.BaseAdapter.SendInput(sessionName, input)
+	}
+	switch c.config.InputMethod {
+	case CustomInputPaste:
+		return watchdog.PasteText(sessionName, input, c.inputDelayMs)
+	case CustomInputStdin:
+		path, err := stdinPipePath(sessionName)
+		if err != nil {
+			return err
+		}
+		return writeStdinPipe(path, input)
+	}
+	return c.BaseAdapter.SendInput(sessionName, input)
+}
+
+// stdinPipePath returns the input pipe of a session using the stdin input method
+func stdinPipePath(sessionName string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".clibot", "stdin", sessionName+".fifo"), nil
+}
+
+// stdinPipeCommand wraps startCmd so it runs with the named pipe at path as
+// stdin. The shell opens the pipe for reading and writing, so it never sees
+// end-of-file between the messages clibot writes.
+func stdinPipeCommand(path, startCmd string) string {
+	pipe := shellQuote(path)
+	script := fmt.Sprintf("rm -f %s && mkfifo -m 600 %s && exec 0<>%s && %s", pipe, pipe, pipe, startCmd)
+	return "sh -c " + shellQuote(script)
+}
+
+// writeStdinPipe writes input and a newline to a session's input pipe. Opening
+// without blocking fails when the CLI is not running, instead of waiting for it.
+func writeStdinPipe(path, input string) error {
+	pipe, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return fmt.Errorf("CLI is not reading its input pipe: %w", err)
+	}
+	defer pipe.Close()
+
+	if _, err := pipe.WriteString(input + "\n"); err != nil {
+		return fmt.Errorf("failed to write to input pipe %s: %w", path, err)
+	}
+	logger.WithFields(logrus.Fields{
+		"pipe":   path,
+		"length": len(input),
+	}).Debug("input-written-to-stdin-pipe")
+	return nil
+}
+
+// shellQuote quotes s as a single word for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isKeyInput reports whether input is a key produced by watchdog.ProcessKeyWords
+func isKeyInput(input string) bool {
+	return strings.HasPrefix(input, "C-") || strings.HasPrefix(input, "M-") || strings.Contains(input, "\x1b")
+}
+
+// HandleHookData maps the hook payload to (cwd, prompt, response) using the
+// configured fields, reading the transcript for the transcript source
+func (c *CustomAdapter) HandleHookData(data []byte) (string, string, string, error) {
+	var payload interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		logger.WithField("error", err).Error("failed-to-parse-hook-json-data")
+		return "", "", "", fmt.Errorf("failed to parse JSON data: %w", err)
+	}
+
+	r := c.config.Response
+	cwd := lookupText(payload, r.CWDField)
+	if cwd == "" {
+		return "", "", "", fmt.Errorf("missing %s in hook data", r.CWDField)
+	}
+
+	switch r.Source {
+	case CustomResponseHook:
+		return cwd, lookupText(payload, r.PromptField), lookupText(payload, r.ResponseField), nil
+	case CustomResponseTranscript:
+		path, err := c.transcriptPath(payload)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"cli":   c.cliName,
+				"error": err,
+			}).Warn("custom-adapter-transcript-not-found")
+			return cwd, "", "", nil
+		}
+		prompt, response, err := c.extractTranscript(path)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"transcript": path,
+				"error":      err,
+			}).Warn("failed-to-extract-interaction-from-transcript")
+		}
+		return cwd, prompt, response, nil
+	default:
+		// Poll source: the engine reads the pane; hooks only identify the session
+		return cwd, "", "", nil
+	}
+}
+
+// transcriptPath returns the transcript named by the hook payload, or the
+// newest file matching the transcript glob
+func (c *CustomAdapter) transcriptPath(payload interface{}) (string, error) {
+	r := c.config.Response
+	pattern, err := expandHome(r.TranscriptGlob)
+	if err != nil {
+		return "", err
+	}
+
+	if r.TranscriptField != "" {
+		if path := lookupText(payload, r.TranscriptField); path != "" {
+			return validateTranscriptPath(path, []string{globBaseDir(pattern)})
+		}
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid transcript_glob: %w", err)
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no transcript matches %s", r.TranscriptGlob)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return modTime(matches[i]) > modTime(matches[j])
+	})
+	return matches[0], nil
+}
+
+// extractTranscript returns the last user prompt and the assistant messages
+// that follow it, or the last regex match when a regex is configured
+func (c *CustomAdapter) extractTranscript(path string) (string, string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	if c.regex != nil {
+		matches := c.regex.FindAllStringSubmatch(string(content), -1)
+		if len(matches) == 0 {
+			return "", "", fmt.Errorf("regex matched nothing in %s", path)
+		}
+		last := matches[len(matches)-1]
+		if len(last) > 1 {
+			return "", strings.TrimSpace(last[1]), nil
+		}
+		return "", strings.TrimSpace(last[0]), nil
+	}
+
+	messages, err := c.parseMessages(content)
+	if err != nil {
+		return "", "", err
+	}
+
+	r := c.config.Response
+	lastUser := -1
+	for i := len(messages) - 1; i >= 0; i-- {
+		if lookupText(messages[i], r.RolePath) == r.UserRole {
+			lastUser = i
+			break
+		}
+	}
+
+	var prompt string
+	if lastUser >= 0 {
+		prompt = lookupText(messages[lastUser], r.TextPath)
+	}
+	var responses []string
+	for _, message := range messages[lastUser+1:] {
+		if lookupText(message, r.RolePath) != r.AssistantRole {
+			continue
+		}
+		if text := lookupText(message, r.TextPath); text != "" {
+			responses = append(responses, text)
+		}
+	}
+	return prompt, strings.Join(responses, "\n\n"), nil
+}
+
+// parseMessages reads the messages of a JSONL transcript, or of the array at
+// MessagesPath in a JSON transcript
+func (c *CustomAdapter) parseMessages(content []byte) ([]interface{}, error) {
+	if path := c.config.Response.MessagesPath; path != "" {
+		var doc interface{}
+		if err := json.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse transcript: %w", err)
+		}
+		value, ok := lookupPath(doc, path)
+		messages, isArray := value.([]interface{})
+		if !ok || !isArray {
+			return nil, fmt.Errorf("%s is not an array in transcript", path)
+		}
+		return messages, nil
+	}
+
+	var messages []interface{}
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var message interface{}
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			continue // Skip partial or non-JSON lines
+		}
+		messages = append(messages, message)
+	}
+	return messages, scanner.Err()
+}
+
+// lookupPath resolves a JSONPath subset ("$.a.b[0]", "a.b[-1].c") in decoded JSON
+func lookupPath(value interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	if path == "" {
+		return value, true
+	}
+
+	for _, part := range strings.Split(strings.ReplaceAll(path, "[", ".["), ".") {
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") {
+			items, ok := value.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			index, err := strconv.Atoi(part[1 : len(part)-1])
+			if err != nil {
+				return nil, false
+			}
+			if index < 0 {
+				index += len(items)
+			}
+			if index < 0 || index >= len(items) {
+				return nil, false
+			}
+			value = items[index]
+			continue
+		}
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = fields[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// lookupText resolves a path to text. Arrays of content blocks
+// ([{"type":"text","text":"..."}]) are joined; other values are JSON encoded.
+func lookupText(value interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+	found, ok := lookupPath(value, path)
+	if !ok || found == nil {
+		return ""
+	}
+	return valueText(found)
+}
+
+func valueText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []interface{}:
+		var parts []string
+		for _, item := range v {
+			if block, ok := item.(map[string]interface{}); ok {
+				if text, ok := block["text"].(string); ok && text != "" {
+					parts = append(parts, text)
+				}
+				continue
+			}
+			if text := valueText(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.TrimSpace(strings.Join(parts, "\n"))
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(encoded)
+	}
+}
+
+// globBaseDir returns the directory part of a glob before its first wildcard
+func globBaseDir(pattern string) string {
+	if i := strings.IndexAny(pattern, "*?["); i >= 0 {
+		pattern = pattern[:i]
+		if !strings.HasSuffix(pattern, string(filepath.Separator)) {
+			return filepath.Dir(pattern)
+		}
+	}
+	return filepath.Clean(pattern)
+}
+
+// modTime returns a file's modification time in nanoseconds, 0 if unreadable
+func modTime(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
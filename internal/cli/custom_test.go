@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCustomAdapter_Defaults(t *testing.T) {
+	adapter, err := NewCustomAdapter(CustomAdapterConfig{Name: "mycli", StartCmd: "mycli --tui"})
+	require.NoError(t, err)
+	assert.Equal(t, CustomInputSendKeys, adapter.config.InputMethod)
+	_, err = NewCustomAdapter(CustomAdapterConfig{Name: "repl", StartCmd: "repl", InputMethod: CustomInputStdin})
+	assert.NoError(t, err)
+	assert.Equal(t, CustomResponsePoll, adapter.config.Response.Source)
+	assert.Equal(t, "cwd", adapter.config.Response.CWDField)
+	assert.Equal(t, "mycli --tui", adapter.startCmd)
+
+	var _ CLIAdapter = adapter
+}
+
+func TestStdinPipe(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "in.fifo")
+	out := filepath.Join(dir, "out")
+
+	// Nobody reads the pipe yet
+	assert.Error(t, writeStdinPipe(path, "early"))
+
+	cmd := exec.Command("sh", "-c", stdinPipeCommand(path, "head -n 2 > "+shellQuote(out)))
+	require.NoError(t, cmd.Start())
+	defer cmd.Process.Kill()
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Each message arrives as a line, without end-of-file in between
+	require.NoError(t, writeStdinPipe(path, "it's one"))
+	require.NoError(t, writeStdinPipe(path, "two"))
+	require.NoError(t, cmd.Wait())
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "it's one\ntwo\n", string(data))
+}
+
+func TestNewCustomAdapter_Invalid(t *testing.T) {
+	_, err := NewCustomAdapter(CustomAdapterConfig{Name: "mycli"})
+	assert.Error(t, err)
+
+	_, err = NewCustomAdapter(CustomAdapterConfig{Name: "mycli", StartCmd: "mycli", InputMethod: "telepathy"})
+	assert.Error(t, err)
+
+	_, err = NewCustomAdapter(CustomAdapterConfig{Name: "mycli", StartCmd: "mycli",
+		Response: CustomResponseConfig{Source: CustomResponseTranscript}})
+	assert.ErrorContains(t, err, "transcript_glob")
+
+	_, err = NewCustomAdapter(CustomAdapterConfig{Name: "mycli", StartCmd: "mycli",
+		Response: CustomResponseConfig{Source: CustomResponseHook, Regex: "("}})
+	assert.ErrorContains(t, err, "regex")
+}
+
+func TestCustomAdapter_HandleHookData_HookSource(t *testing.T) {
+	adapter, err := NewCustomAdapter(CustomAdapterConfig{Name: "mycli", StartCmd: "mycli",
+		Response: CustomResponseConfig{
+			Source:        CustomResponseHook,
+			CWDField:      "$.workspace.root",
+			PromptField:   "input",
+			ResponseField: "$.output[-1].content",
+		}})
+	require.NoError(t, err)
+
+	cwd, prompt, response, err := adapter.HandleHookData([]byte(`{
+		"workspace": {"root": "/repo"},
+		"input": "fix the test",
+		"output": [{"content": "thinking"}, {"content": [{"type": "text", "text": "Fixed it."}]}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "/repo", cwd)
+	assert.Equal(t, "fix the test", prompt)
+	assert.Equal(t, "Fixed it.", response)
+
+	_, _, _, err = adapter.HandleHookData([]byte(`{"input": "x"}`))
+	assert.ErrorContains(t, err, "$.workspace.root")
+}
+
+// writeTranscript writes a JSONL transcript fixture and returns its path
+func writeTranscript(t *testing.T, dir, name string, lines ...interface{}) string {
+	t.Helper()
+	f, err := os.Create(filepath.Join(dir, name))
+	require.NoError(t, err)
+	defer f.Close()
+	for _, line := range lines {
+		data, err := json.Marshal(line)
+		require.NoError(t, err)
+		_, err = f.Write(append(data, '\n'))
+		require.NoError(t, err)
+	}
+	return f.Name()
+}
+
+func TestCustomAdapter_HandleHookData_TranscriptSource(t *testing.T) {
+	dir := t.TempDir()
+	older := writeTranscript(t, dir, "old.jsonl",
+		map[string]string{"role": "user", "content": "old prompt"},
+		map[string]string{"role": "assistant", "content": "old answer"})
+	require.NoError(t, os.Chtimes(older, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+	writeTranscript(t, dir, "new.jsonl",
+		map[string]string{"role": "user", "content": "first"},
+		map[string]string{"role": "assistant", "content": "one"},
+		map[string]string{"role": "user", "content": "list files"},
+		map[string]string{"role": "tool", "content": "ls"},
+		map[string]string{"role": "assistant", "content": "README.md"},
+		map[string]string{"role": "assistant", "content": "main.go"})
+
+	adapter, err := NewCustomAdapter(CustomAdapterConfig{Name: "mycli", StartCmd: "mycli",
+		Response: CustomResponseConfig{Source: CustomResponseTranscript, TranscriptGlob: filepath.Join(dir, "*.jsonl")}})
+	require.NoError(t, err)
+
+	cwd, prompt, response, err := adapter.HandleHookData([]byte(`{"cwd": "/repo"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "/repo", cwd)
+	assert.Equal(t, "list files", prompt)
+	assert.Equal(t, "README.md\n\nmain.go", response)
+}
+
+func TestCustomAdapter_HandleHookData_TranscriptField(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTranscript(t, dir, "s.jsonl",
+		map[string]string{"role": "user", "content": "hi"},
+		map[string]string{"role": "assistant", "content": "hello"})
+
+	adapter, err := NewCustomAdapter(CustomAdapterConfig{Name: "mycli", StartCmd: "mycli",
+		Response: CustomResponseConfig{Source: CustomResponseTranscript,
+			TranscriptGlob: filepath.Join(dir, "*.jsonl"), TranscriptField: "transcript"}})
+	require.NoError(t, err)
+
+	_, _, response, err := adapter.HandleHookData([]byte(`{"cwd": "/repo", "transcript": "` + path + `"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", response)
+
+	// Paths outside the glob's directory are not read
+	outside := writeTranscript(t, t.TempDir(), "x.jsonl", map[string]string{"role": "assistant", "content": "secret"})
+	_, _, response, err = adapter.HandleHookData([]byte(`{"cwd": "/repo", "transcript": "` + outside + `"}`))
+	require.NoError(t, err)
+	assert.Empty(t, response)
+}
+
+func TestCustomAdapter_ExtractTranscript_JSONAndRegex(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "chat.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"chat": {"turns": [
+		{"who": "me", "msg": {"text": "what time is it"}},
+		{"who": "bot", "msg": {"text": "noon"}}
+	]}}`), 0o644))
+
+	adapter, err := NewCustomAdapter(CustomAdapterConfig{Name: "mycli", StartCmd: "mycli",
+		Response: CustomResponseConfig{Source: CustomResponseTranscript, TranscriptGlob: jsonPath,
+			MessagesPath: "$.chat.turns", RolePath: "who", TextPath: "msg.text", UserRole: "me", AssistantRole: "bot"}})
+	require.NoError(t, err)
+	prompt, response, err := adapter.extractTranscript(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, "what time is it", prompt)
+	assert.Equal(t, "noon", response)
+
+	logPath := filepath.Join(dir, "chat.log")
+	require.NoError(t, os.WriteFile(logPath, []byte("AI: first\nYOU: again\nAI: second\n"), 0o644))
+	adapter, err = NewCustomAdapter(CustomAdapterConfig{Name: "mycli", StartCmd: "mycli",
+		Response: CustomResponseConfig{Source: CustomResponseTranscript, TranscriptGlob: logPath, Regex: `(?m)^AI: (.*)$`}})
+	require.NoError(t, err)
+	_, response, err = adapter.extractTranscript(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "second", response)
+}
+
+func TestLookupPath(t *testing.T) {
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"a": {"b": [1, {"c": "x"}]}}`), &doc))
+
+	value, ok := lookupPath(doc, "$.a.b[1].c")
+	assert.True(t, ok)
+	assert.Equal(t, "x", value)
+
+	value, ok = lookupPath(doc, "a.b[-2]")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), value)
+
+	_, ok = lookupPath(doc, "a.b[5]")
+	assert.False(t, ok)
+	_, ok = lookupPath(doc, "a.missing")
+	assert.False(t, ok)
+}
+
+func TestGlobBaseDir(t *testing.T) {
+	assert.Equal(t, "/home/u/.mycli/sessions", globBaseDir("/home/u/.mycli/sessions/*.jsonl"))
+	assert.Equal(t, "/home/u/.mycli", globBaseDir("/home/u/.mycli/s-*/log.jsonl"))
+	assert.Equal(t, "/home/u/chat.json", globBaseDir("/home/u/chat.json"))
+}
@@ -21,12 +21,14 @@ type GeminiAdapterConfig struct {
 // GeminiAdapter implements CLIAdapter for Gemini CLI
 type GeminiAdapter struct {
 	BaseAdapter
+	transcriptDirs []string // Directories hook transcript paths must lie under
 }
 
 // NewGeminiAdapter creates a new Gemini CLI adapter
 func NewGeminiAdapter(config GeminiAdapterConfig) (*GeminiAdapter, error) {
 	return &GeminiAdapter{
-		BaseAdapter: NewBaseAdapter("gemini", "gemini", 200),
+		BaseAdapter:    NewBaseAdapter("gemini", "gemini", 200),
+		transcriptDirs: []string{"~/.gemini"},
 	}, nil
 }
 
@@ -60,8 +62,16 @@ func (g *GeminiAdapter) HandleHookData(data []byte) (string, string, string, err
 
 	// Extract transcript_path if available
 	transcriptPath := ""
-	if v, ok := hookData["transcript_path"].(string); ok {
-		transcriptPath = v
+	if v, ok := hookData["transcript_path"].(string); ok && v != "" {
+		// An untrusted path falls back to locating the session file from cwd
+		validated, err := validateTranscriptPath(v, hookTranscriptDirs(g.transcriptDirs))
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"transcript_path": v,
+				"error":           err,
+			}).Warn("rejected-hook-transcript-path")
+		}
+		transcriptPath = validated
 	}
 
 	// Extract hook_event_name to check if this is a notification event
@@ -106,10 +116,7 @@ func (g *GeminiAdapter) HandleHookData(data []byte) (string, string, string, err
 
 // Gemini stores history in: ~/.gemini/tmp/{project_hash}/chats/session-*.json
 func (g *GeminiAdapter) lastSessionFile(cwd string) (string, error) {
-	// Build path to chats directory
-	projectHash := computeProjectHash(cwd)
-	homeDir, _ := os.UserHomeDir()
-	chatsDir := filepath.Join(homeDir, ".gemini", "tmp", projectHash, "chats")
+	chatsDir := geminiChatsDir(cwd)
 
 	// Check if directory exists
 	if _, err := os.Stat(chatsDir); os.IsNotExist(err) {
@@ -162,27 +169,9 @@ func (g *GeminiAdapter) extractGeminiResponse(transcriptPath string, cwd string)
 		latestFile = transcriptPath
 	}
 
-	// Parse JSON
-	data, err := os.ReadFile(latestFile)
+	messages, err := readGeminiSession(latestFile)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to read session file: %w", err)
-	}
-
-	var sessionData struct {
-		Messages []struct {
-			Type     string                   `json:"type"`
-			Content  string                   `json:"content"`
-			Thoughts []map[string]interface{} `json:"thoughts,omitempty"`
-		} `json:"messages"`
-	}
-
-	if err := json.Unmarshal(data, &sessionData); err != nil {
-		return "", "", fmt.Errorf("failed to parse session JSON: %w", err)
-	}
-
-	messages := sessionData.Messages
-	if len(messages) == 0 {
-		return "", "", fmt.Errorf("no messages in session file")
+		return "", "", err
 	}
 
 	// Find last user message index
@@ -227,6 +216,115 @@ func (g *GeminiAdapter) extractGeminiResponse(transcriptPath string, cwd string)
 	return userPrompt, response, nil
 }
 
+// geminiMessage is a message in a Gemini CLI session file
+type geminiMessage struct {
+	Type      string                   `json:"type"` // "user", "gemini", "info", "error"
+	Content   string                   `json:"content"`
+	Thoughts  []map[string]interface{} `json:"thoughts,omitempty"`
+	ToolCalls []json.RawMessage        `json:"toolCalls,omitempty"`
+}
+
+// readGeminiSession reads the messages of a Gemini CLI session file
+func readGeminiSession(path string) ([]geminiMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	var sessionData struct {
+		Messages []geminiMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &sessionData); err != nil {
+		return nil, fmt.Errorf("failed to parse session JSON: %w", err)
+	}
+	if len(sessionData.Messages) == 0 {
+		return nil, fmt.Errorf("no messages in session file")
+	}
+	return sessionData.Messages, nil
+}
+
+// geminiChatsDir returns where Gemini CLI keeps the sessions of a project
+func geminiChatsDir(cwd string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".gemini", "tmp", computeProjectHash(cwd), "chats")
+}
+
+// TranscriptDirs returns the directory holding session files for workDir
+func (g *GeminiAdapter) TranscriptDirs(workDir string) []string {
+	return []string{geminiChatsDir(workDir)}
+}
+
+// LatestTurn reads the latest turn of the newest session file for workDir.
+// The turn is complete once the last message is a Gemini reply without tool calls.
+func (g *GeminiAdapter) LatestTurn(workDir string) (TurnStatus, error) {
+	path, err := g.lastSessionFile(workDir)
+	if err != nil {
+		return TurnStatus{}, err
+	}
+	messages, err := readGeminiSession(path)
+	if err != nil {
+		return TurnStatus{}, err
+	}
+
+	lastUserIndex := -1
+	for i, msg := range messages {
+		if msg.Type == "user" {
+			lastUserIndex = i
+		}
+	}
+	if lastUserIndex == -1 {
+		return TurnStatus{}, fmt.Errorf("no user message found in session")
+	}
+
+	var parts []string
+	for _, msg := range messages[lastUserIndex+1:] {
+		if content := strings.TrimSpace(msg.Content); msg.Type == "gemini" && content != "" {
+			parts = append(parts, content)
+		}
+	}
+
+	last := messages[len(messages)-1]
+	return TurnStatus{
+		ID:       fmt.Sprintf("%s#%d", path, lastUserIndex),
+		Prompt:   strings.TrimSpace(messages[lastUserIndex].Content),
+		Response: strings.Join(parts, "\n\n"),
+		Complete: last.Type == "gemini" && strings.TrimSpace(last.Content) != "" && len(last.ToolCalls) == 0,
+	}, nil
+}
+
+// History reads every turn of the newest session file for workDir
+func (g *GeminiAdapter) History(sessionName, workDir string) ([]HistoryTurn, error) {
+	path, err := g.lastSessionFile(workDir)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := readGeminiSession(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var turns []HistoryTurn
+	var parts []string
+	flush := func() {
+		if len(turns) > 0 {
+			turns[len(turns)-1].Response = strings.Join(parts, "\n\n")
+		}
+		parts = nil
+	}
+	for _, msg := range messages {
+		content := strings.TrimSpace(msg.Content)
+		switch {
+		case msg.Type == "user":
+			flush()
+			turns = append(turns, HistoryTurn{Prompt: content})
+		case msg.Type == "gemini" && content != "":
+			parts = append(parts, content)
+		}
+	}
+	flush()
+	return turns, nil
+}
+
 // computeProjectHash computes SHA256 hash of project path
 // This is used by Gemini to organize conversation history by project
 func computeProjectHash(projectPath string) string {
@@ -337,3 +337,60 @@ func TestGeminiAdapter_ExtractLatestInteraction(t *testing.T) {
 		assert.Equal(t, "", response)
 	})
 }
+
+// TestGeminiAdapter_LatestTurn tests detecting turn completion from the session file
+func TestGeminiAdapter_LatestTurn(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	adapter, err := NewGeminiAdapter(GeminiAdapterConfig{})
+	require.NoError(t, err)
+
+	workDir := "/home/u/proj"
+	chatsDir := adapter.TranscriptDirs(workDir)[0]
+	require.NoError(t, os.MkdirAll(chatsDir, 0o755))
+	path := filepath.Join(chatsDir, "session-1.json")
+
+	write := func(messages string) {
+		require.NoError(t, os.WriteFile(path, []byte(`{"messages": [`+messages+`]}`), 0o644))
+	}
+	write(`{"type": "user", "content": "list files"},
+		{"type": "gemini", "content": "Let me look.", "toolCalls": [{"name": "ls", "status": "success"}]}`)
+	turn, err := adapter.LatestTurn(workDir)
+	require.NoError(t, err)
+	assert.Equal(t, path+"#0", turn.ID)
+	assert.Equal(t, "Let me look.", turn.Response)
+	assert.False(t, turn.Complete)
+
+	write(`{"type": "user", "content": "list files"},
+		{"type": "gemini", "content": "Let me look.", "toolCalls": [{"name": "ls", "status": "success"}]},
+		{"type": "gemini", "content": "README.md"}`)
+	turn, err = adapter.LatestTurn(workDir)
+	require.NoError(t, err)
+	assert.Equal(t, "list files", turn.Prompt)
+	assert.Equal(t, "Let me look.\n\nREADME.md", turn.Response)
+	assert.True(t, turn.Complete)
+}
+
+func TestGeminiAdapter_History(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	adapter, err := NewGeminiAdapter(GeminiAdapterConfig{})
+	require.NoError(t, err)
+	var _ HistoryReader = adapter
+
+	chatsDir := adapter.TranscriptDirs("/home/u/proj")[0]
+	require.NoError(t, os.MkdirAll(chatsDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(chatsDir, "session-1.json"), []byte(`{"messages": [
+		{"type": "user", "content": "list files"},
+		{"type": "gemini", "content": "Let me look.", "toolCalls": [{"name": "ls"}]},
+		{"type": "info", "content": "Tool approved"},
+		{"type": "gemini", "content": "README.md"},
+		{"type": "user", "content": "thanks"},
+		{"type": "gemini", "content": "You're welcome"}
+	]}`), 0o644))
+
+	turns, err := adapter.History("main", "/home/u/proj")
+	require.NoError(t, err)
+	assert.Equal(t, []HistoryTurn{
+		{Prompt: "list files", Response: "Let me look.\n\nREADME.md"},
+		{Prompt: "thanks", Response: "You're welcome"},
+	}, turns)
+}
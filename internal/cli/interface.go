@@ -13,6 +13,8 @@
 //   - Claude Code (claude): Anthropic's AI programming assistant
 //   - Gemini (gemini): Google's AI assistant
 //   - OpenCode (opencode): AI programming assistant
+//   - Codex (codex): OpenAI's AI programming assistant
+//   - Aider (aider): AI pair programming in the terminal
 //
 // # Architecture
 //
@@ -37,6 +39,9 @@ package cli
 type Engine interface {
 	SendToBot(platform, channel, message string)
 	SendResponseToSession(sessionName, message string)
+	// SendProgressToSession delivers partial output of a running request.
+	// The final output must still be sent via SendResponseToSession.
+	SendProgressToSession(sessionName, message string)
 }
 
 // CLIAdapter defines the interface for CLI adapters
@@ -72,3 +77,70 @@ type CLIAdapter interface {
 	// The env parameter sets session-level environment variables (merged with adapter-level env)
 	CreateSession(sessionName, workDir, startCmd, transportURL string, env map[string]string) error
 }
+
+// NotificationParser is implemented by adapters whose hooks report notifications,
+// such as a permission prompt waiting for an answer in the terminal
+type NotificationParser interface {
+	// ParseNotification returns the notification message and true when data is
+	// a Notification hook event
+	ParseNotification(data []byte) (message string, ok bool)
+}
+
+// HookEvent is a lifecycle or tool hook event other than Stop and Notification
+type HookEvent struct {
+	Name      string // Hook event name, e.g. "PreToolUse" or "SessionStart"
+	ToolName  string // Tool events: the tool being called
+	ToolInput string // Tool events: one-line summary of the tool input
+	Detail    string // SessionStart source or SessionEnd reason
+}
+
+// HookEventParser is implemented by adapters whose CLI reports subagent, tool
+// and session lifecycle hooks
+type HookEventParser interface {
+	// ParseHookEvent returns the event and true when data is one of the
+	// supported lifecycle or tool hook events
+	ParseHookEvent(data []byte) (event HookEvent, ok bool)
+}
+
+// TurnStatus is the state of the latest turn in a CLI's transcript
+type TurnStatus struct {
+	ID       string // Identifies the turn's user prompt, changing with each new prompt
+	Prompt   string // The user prompt
+	Response string // Assistant text written so far
+	Complete bool   // The CLI has finished responding
+}
+
+// TranscriptWatcher is implemented by adapters whose transcripts show when a
+// turn is complete, so sessions can be watched without hooks (completion: watch)
+type TranscriptWatcher interface {
+	// TranscriptDirs returns the directories the CLI writes transcripts to
+	// when running in workDir
+	TranscriptDirs(workDir string) []string
+
+	// LatestTurn reads the latest turn of the newest transcript for workDir
+	LatestTurn(workDir string) (TurnStatus, error)
+}
+
+// ReplyWatcher is implemented by adapters without hooks that watch the CLI's
+// own files for replies instead (Aider's chat history). The engine watches
+// local sessions in hook mode, and stops watching when a session is stopped.
+type ReplyWatcher interface {
+	// WatchSession starts watching for the session's replies, if not already watching
+	WatchSession(sessionName string) error
+
+	// UnwatchSession stops watching the session and releases its watcher
+	UnwatchSession(sessionName string)
+}
+
+// HistoryTurn is one user prompt and the assistant's reply to it
+type HistoryTurn struct {
+	Prompt   string // The user prompt
+	Response string // The assistant's reply, text blocks joined with blank lines
+}
+
+// HistoryReader is implemented by adapters that can read a session's earlier
+// turns back from the CLI's own transcript (shistory, ssearch)
+type HistoryReader interface {
+	// History returns the turns of the session's current conversation, oldest first
+	History(sessionName, workDir string) ([]HistoryTurn, error)
+}
@@ -90,8 +90,10 @@ func (o *OpenCodeAdapter) HandleHookData(data []byte) (string, string, string, e
 
 // dbMessage represents a parsed message row from OpenCode storage
 type dbMessage struct {
-	ID   string
-	Role string
+	ID        string
+	Role      string
+	Completed bool   // Assistant messages: generation has finished
+	Finish    string // Assistant messages: finish reason, "tool-calls" when tools run next
 }
 
 // OpenCodeSessionInfo represents the structure of an OpenCode session info file
@@ -193,6 +195,22 @@ func (o *OpenCodeAdapter) extractLatestInteractionFromDB(cwd string, sessionID s
 	return prompt, strings.Join(responseParts, "\n\n"), nil
 }
 
+// openReadOnlyDB opens OpenCode's SQLite database read-only
+func openReadOnlyDB() (*sql.DB, error) {
+	dbPath, err := getDBPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("sqlite db not found: %w", err)
+	}
+	db, err := sql.Open("sqlite", dbPath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite db: %w", err)
+	}
+	return db, nil
+}
+
 func getDBPath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -215,7 +233,8 @@ func getLatestSessionIDFromDB(db *sql.DB, projectID string) (string, error) {
 
 func getMessagesFromDB(db *sql.DB, sessionID string) ([]dbMessage, error) {
 	rows, err := db.Query(
-		"SELECT id, json_extract(data, '$.role') as role FROM message WHERE session_id = ? ORDER BY time_created",
+		"SELECT id, json_extract(data, '$.role') as role, json_extract(data, '$.time.completed') IS NOT NULL, "+
+			"COALESCE(json_extract(data, '$.finish'), '') FROM message WHERE session_id = ? ORDER BY time_created",
 		sessionID,
 	)
 	if err != nil {
@@ -226,7 +245,7 @@ func getMessagesFromDB(db *sql.DB, sessionID string) ([]dbMessage, error) {
 	var messages []dbMessage
 	for rows.Next() {
 		var msg dbMessage
-		if err := rows.Scan(&msg.ID, &msg.Role); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.Role, &msg.Completed, &msg.Finish); err != nil {
 			continue
 		}
 		messages = append(messages, msg)
@@ -279,37 +298,9 @@ func (o *OpenCodeAdapter) extractLatestInteractionFromFiles(cwd string, sessionI
 		}
 	}
 
-	// Read all message files for the session
-	messageDir := filepath.Join(storageDir, "message", sessionID)
-	files, err := filepath.Glob(filepath.Join(messageDir, "*.json"))
-	if err != nil || len(files) == 0 {
-		return "", "", fmt.Errorf("no messages found for session %s", sessionID)
-	}
-
-	sort.Strings(files)
-
-	type fileMessage struct {
-		ID   string
-		Role string
-	}
-
-	var messages []fileMessage
-	for _, file := range files {
-		data, err := os.ReadFile(file)
-		if err != nil {
-			continue
-		}
-		var raw struct {
-			ID   string `json:"id"`
-			Role string `json:"role"`
-		}
-		if err := json.Unmarshal(data, &raw); err == nil && raw.Role != "" {
-			messages = append(messages, fileMessage{ID: raw.ID, Role: raw.Role})
-		}
-	}
-
-	if len(messages) == 0 {
-		return "", "", fmt.Errorf("no valid messages parsed for session %s", sessionID)
+	messages, err := readFileMessages(storageDir, sessionID)
+	if err != nil {
+		return "", "", err
 	}
 
 	// Find last user message
@@ -347,6 +338,42 @@ func (o *OpenCodeAdapter) extractLatestInteractionFromFiles(cwd string, sessionI
 	return prompt, strings.Join(responseParts, "\n\n"), nil
 }
 
+// readFileMessages reads the messages of a session from
+// storage/message/<sessionID>/*.json, ordered by ID (creation order)
+func readFileMessages(storageDir, sessionID string) ([]dbMessage, error) {
+	messageDir := filepath.Join(storageDir, "message", sessionID)
+	files, err := filepath.Glob(filepath.Join(messageDir, "*.json"))
+	if err != nil || len(files) == 0 {
+		return nil, fmt.Errorf("no messages found for session %s", sessionID)
+	}
+
+	sort.Strings(files)
+
+	var messages []dbMessage
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var raw struct {
+			ID   string `json:"id"`
+			Role string `json:"role"`
+			Time struct {
+				Completed int64 `json:"completed"`
+			} `json:"time"`
+			Finish string `json:"finish"`
+		}
+		if err := json.Unmarshal(data, &raw); err == nil && raw.Role != "" {
+			messages = append(messages, dbMessage{ID: raw.ID, Role: raw.Role, Completed: raw.Time.Completed != 0, Finish: raw.Finish})
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no valid messages parsed for session %s", sessionID)
+	}
+	return messages, nil
+}
+
 // loadPartsTextFromFiles reads text parts from storage/part/<messageID>/*.json
 func loadPartsTextFromFiles(storageDir, messageID string) (string, error) {
 	partDir := filepath.Join(storageDir, "part", messageID)
@@ -384,6 +411,185 @@ func (o *OpenCodeAdapter) ExtractLatestInteraction(transcriptPath string) (strin
 	return extractLatestInteractionFromFile(transcriptPath)
 }
 
+// ========== Transcript Watching ==========
+
+// TranscriptDirs returns the directories OpenCode writes to: the SQLite
+// database directory and the legacy message storage
+func (o *OpenCodeAdapter) TranscriptDirs(workDir string) []string {
+	var dirs []string
+	if dbPath, err := getDBPath(); err == nil {
+		dirs = append(dirs, filepath.Dir(dbPath))
+	}
+	if storageDir, err := getStorageDir(); err == nil {
+		dirs = append(dirs, filepath.Join(storageDir, "message"))
+	}
+	return dirs
+}
+
+// LatestTurn reads the latest turn of the project's newest session. The turn
+// is complete once the last message is a finished assistant message that
+// does not hand over to tool calls.
+func (o *OpenCodeAdapter) LatestTurn(workDir string) (TurnStatus, error) {
+	sessionID, messages, err := latestSessionMessages(workDir)
+	if err != nil {
+		return TurnStatus{}, err
+	}
+
+	lastUserID := ""
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			lastUserID = messages[i].ID
+			break
+		}
+	}
+	if lastUserID == "" {
+		return TurnStatus{}, fmt.Errorf("no user message found in session %s", sessionID)
+	}
+
+	prompt, response, err := o.extractLatestInteractionFromStorage(workDir, sessionID)
+	if err != nil {
+		return TurnStatus{}, err
+	}
+
+	last := messages[len(messages)-1]
+	return TurnStatus{
+		ID:       sessionID + "#" + lastUserID,
+		Prompt:   prompt,
+		Response: response,
+		Complete: last.Role == "assistant" && last.Completed && last.Finish != "tool-calls",
+	}, nil
+}
+
+// latestSessionMessages returns the newest session of the project in cwd and
+// its messages, from SQLite or else the file storage
+func latestSessionMessages(cwd string) (string, []dbMessage, error) {
+	projectID, err := getProjectID(cwd)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get project ID: %w", err)
+	}
+
+	if sessionID, messages, err := latestSessionMessagesFromDB(projectID); err == nil {
+		return sessionID, messages, nil
+	}
+
+	storageDir, err := getStorageDir()
+	if err != nil {
+		return "", nil, err
+	}
+	sessionID, err := getLatestSessionID(storageDir, projectID)
+	if err != nil {
+		return "", nil, err
+	}
+	messages, err := readFileMessages(storageDir, sessionID)
+	return sessionID, messages, err
+}
+
+// latestSessionMessagesFromDB returns the project's newest session and its
+// messages from the SQLite database
+func latestSessionMessagesFromDB(projectID string) (string, []dbMessage, error) {
+	db, err := openReadOnlyDB()
+	if err != nil {
+		return "", nil, err
+	}
+	defer db.Close()
+
+	sessionID, err := getLatestSessionIDFromDB(db, projectID)
+	if err != nil {
+		return "", nil, err
+	}
+	messages, err := getMessagesFromDB(db, sessionID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(messages) == 0 {
+		return "", nil, fmt.Errorf("no messages found for session %s", sessionID)
+	}
+	return sessionID, messages, nil
+}
+
+// ========== History ==========
+
+// History reads every turn of the project's newest session, from SQLite or
+// else the file storage
+func (o *OpenCodeAdapter) History(sessionName, workDir string) ([]HistoryTurn, error) {
+	projectID, err := getProjectID(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project ID: %w", err)
+	}
+
+	if turns, err := historyFromDB(projectID); err == nil {
+		return turns, nil
+	}
+
+	storageDir, err := getStorageDir()
+	if err != nil {
+		return nil, err
+	}
+	sessionID, err := getLatestSessionID(storageDir, projectID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := readFileMessages(storageDir, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return groupOpenCodeTurns(messages, func(messageID string) string {
+		text, _ := loadPartsTextFromFiles(storageDir, messageID)
+		return text
+	}), nil
+}
+
+// historyFromDB reads every turn of the project's newest session from SQLite
+func historyFromDB(projectID string) ([]HistoryTurn, error) {
+	db, err := openReadOnlyDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	sessionID, err := getLatestSessionIDFromDB(db, projectID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := getMessagesFromDB(db, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no messages found for session %s", sessionID)
+	}
+	return groupOpenCodeTurns(messages, func(messageID string) string {
+		text, _ := getMessageTextFromDB(db, messageID)
+		return text
+	}), nil
+}
+
+// groupOpenCodeTurns groups messages into turns, each starting at a user
+// message; textOf loads a message's text parts
+func groupOpenCodeTurns(messages []dbMessage, textOf func(messageID string) string) []HistoryTurn {
+	var turns []HistoryTurn
+	var parts []string
+	flush := func() {
+		if len(turns) > 0 {
+			turns[len(turns)-1].Response = strings.Join(parts, "\n\n")
+		}
+		parts = nil
+	}
+	for _, msg := range messages {
+		switch msg.Role {
+		case "user":
+			flush()
+			turns = append(turns, HistoryTurn{Prompt: strings.TrimSpace(textOf(msg.ID))})
+		case "assistant":
+			if text := strings.TrimSpace(textOf(msg.ID)); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	flush()
+	return turns
+}
+
 // ========== Shared Helper Functions ==========
 
 func getStorageDir() (string, error) {
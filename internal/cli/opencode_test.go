@@ -333,3 +333,72 @@ func TestGetProjectID(t *testing.T) {
 		assert.Equal(t, "global", projectID)
 	})
 }
+
+// TestOpenCodeAdapter_LatestTurn tests detecting turn completion from file storage
+func TestOpenCodeAdapter_LatestTurn(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workDir := t.TempDir() // Not a git repo: project "global"
+	storageDir, err := getStorageDir()
+	require.NoError(t, err)
+
+	writeJSON := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	writeJSON(filepath.Join(storageDir, "session", "global", "ses_1.json"), `{"id":"ses_1","projectID":"global","time":{"updated":10}}`)
+	writeJSON(filepath.Join(storageDir, "message", "ses_1", "msg_1.json"), `{"id":"msg_1","role":"user"}`)
+	writeJSON(filepath.Join(storageDir, "part", "msg_1", "prt_1.json"), `{"type":"text","text":"list files"}`)
+	writeJSON(filepath.Join(storageDir, "message", "ses_1", "msg_2.json"), `{"id":"msg_2","role":"assistant","time":{"created":1,"completed":2},"finish":"tool-calls"}`)
+	writeJSON(filepath.Join(storageDir, "part", "msg_2", "prt_2.json"), `{"type":"text","text":"Let me look."}`)
+
+	adapter, err := NewOpenCodeAdapter(OpenCodeAdapterConfig{})
+	require.NoError(t, err)
+	assert.Contains(t, adapter.TranscriptDirs(workDir), filepath.Join(storageDir, "message"))
+
+	turn, err := adapter.LatestTurn(workDir)
+	require.NoError(t, err)
+	assert.Equal(t, "ses_1#msg_1", turn.ID)
+	assert.Equal(t, "list files", turn.Prompt)
+	assert.False(t, turn.Complete)
+
+	writeJSON(filepath.Join(storageDir, "message", "ses_1", "msg_3.json"), `{"id":"msg_3","role":"assistant","time":{"created":3,"completed":4},"finish":"stop"}`)
+	writeJSON(filepath.Join(storageDir, "part", "msg_3", "prt_3.json"), `{"type":"text","text":"README.md"}`)
+	turn, err = adapter.LatestTurn(workDir)
+	require.NoError(t, err)
+	assert.Equal(t, "Let me look.\n\nREADME.md", turn.Response)
+	assert.True(t, turn.Complete)
+
+	var _ TranscriptWatcher = adapter
+	var _ TranscriptWatcher = &ClaudeAdapter{}
+	var _ TranscriptWatcher = &GeminiAdapter{}
+}
+
+func TestOpenCodeAdapter_History(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workDir := t.TempDir() // Not a git repo: project "global"
+	storageDir, err := getStorageDir()
+	require.NoError(t, err)
+
+	writeJSON := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	writeJSON(filepath.Join(storageDir, "session", "global", "ses_1.json"), `{"id":"ses_1","projectID":"global","time":{"updated":10}}`)
+	writeJSON(filepath.Join(storageDir, "message", "ses_1", "msg_1.json"), `{"id":"msg_1","role":"user"}`)
+	writeJSON(filepath.Join(storageDir, "part", "msg_1", "prt_1.json"), `{"type":"text","text":"list files"}`)
+	writeJSON(filepath.Join(storageDir, "message", "ses_1", "msg_2.json"), `{"id":"msg_2","role":"assistant"}`)
+	writeJSON(filepath.Join(storageDir, "part", "msg_2", "prt_2.json"), `{"type":"text","text":"README.md"}`)
+	writeJSON(filepath.Join(storageDir, "message", "ses_1", "msg_3.json"), `{"id":"msg_3","role":"user"}`)
+	writeJSON(filepath.Join(storageDir, "part", "msg_3", "prt_3.json"), `{"type":"text","text":"thanks"}`)
+
+	adapter, err := NewOpenCodeAdapter(OpenCodeAdapterConfig{})
+	require.NoError(t, err)
+	var _ HistoryReader = adapter
+
+	turns, err := adapter.History("main", workDir)
+	require.NoError(t, err)
+	assert.Equal(t, []HistoryTurn{
+		{Prompt: "list files", Response: "README.md"},
+		{Prompt: "thanks"},
+	}, turns)
+}
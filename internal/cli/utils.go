@@ -6,10 +6,15 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"syscall"
 )
 
+// RemoteTranscriptDir is where the engine mirrors transcripts of sessions on
+// SSH hosts before handing their hooks to an adapter
+const RemoteTranscriptDir = "~/.clibot/remote"
+
 // expandHome expands ~ to user's home directory
 // This is a shared utility function used across multiple CLI adapters
 // Returns an error if the home directory cannot be determined
@@ -24,6 +29,43 @@ func expandHome(path string) (string, error) {
 	return path, nil
 }
 
+// validateTranscriptPath resolves a transcript path reported by a hook and
+// checks that it lies under one of the CLI's data directories, so a forged
+// hook payload cannot make clibot read and forward arbitrary files
+func validateTranscriptPath(path string, dirs []string) (string, error) {
+	expanded, err := expandHome(path)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(expanded) {
+		return "", fmt.Errorf("transcript path must be absolute: %s", path)
+	}
+	resolved, err := filepath.EvalSymlinks(expanded)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve transcript path: %w", err)
+	}
+
+	for _, dir := range dirs {
+		dir, err := expandHome(dir)
+		if err != nil || dir == "" {
+			continue
+		}
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = real
+		}
+		if rel, err := filepath.Rel(dir, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("transcript path outside CLI data directories: %s", path)
+}
+
+// hookTranscriptDirs returns the directories a hook's transcript path may lie
+// under: the CLI's data directories and the mirror of remote transcripts
+func hookTranscriptDirs(dirs []string) []string {
+	return append(slices.Clip(dirs), RemoteTranscriptDir)
+}
+
 // buildShellCommand creates a cross-platform shell command
 // For Linux/macOS (including WSL2): sh -c "command"
 // For Windows (native, though not officially supported): cmd /c "command"
@@ -48,3 +90,25 @@ func buildShellCommand(command string) *exec.Cmd {
 	cmd.SysProcAttr = attrs
 	return cmd
 }
+
+// newestMatch returns the most recently modified file matching any of the
+// glob patterns
+func newestMatch(patterns ...string) (string, error) {
+	var newest string
+	var newestTime int64
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		for _, match := range matches {
+			if t := modTime(match); newest == "" || t > newestTime {
+				newest, newestTime = match, t
+			}
+		}
+	}
+	if newest == "" {
+		return "", fmt.Errorf("no files match %s", strings.Join(patterns, ", "))
+	}
+	return newest, nil
+}
@@ -103,3 +103,42 @@ func TestBuildShellCommand_EmptyCommand(t *testing.T) {
 		assert.Equal(t, []string{cmd.Args[0], "-c", ""}, cmd.Args)
 	}
 }
+
+func TestValidateTranscriptPath(t *testing.T) {
+	dataDir := t.TempDir()
+	transcript := filepath.Join(dataDir, "projects", "p", "session.jsonl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(transcript), 0755))
+	require.NoError(t, os.WriteFile(transcript, []byte("{}"), 0644))
+	dirs := []string{dataDir}
+
+	resolved, err := validateTranscriptPath(transcript, dirs)
+	assert.NoError(t, err)
+	realTranscript, _ := filepath.EvalSymlinks(transcript)
+	assert.Equal(t, realTranscript, resolved)
+
+	outside := filepath.Join(t.TempDir(), "secrets.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0644))
+	_, err = validateTranscriptPath(outside, dirs)
+	assert.Error(t, err)
+
+	// Traversal and relative paths are rejected
+	_, err = validateTranscriptPath(filepath.Join(dataDir, "..", filepath.Base(filepath.Dir(outside)), "secrets.txt"), dirs)
+	assert.Error(t, err)
+	_, err = validateTranscriptPath("session.jsonl", dirs)
+	assert.Error(t, err)
+
+	if runtime.GOOS != "windows" {
+		// A symlink inside the data directory pointing outside is rejected
+		link := filepath.Join(dataDir, "link.jsonl")
+		require.NoError(t, os.Symlink(outside, link))
+		_, err = validateTranscriptPath(link, dirs)
+		assert.Error(t, err)
+	}
+}
+
+func TestHookTranscriptDirs(t *testing.T) {
+	dirs := make([]string, 1, 4)
+	dirs[0] = "~/.claude"
+	assert.Equal(t, []string{"~/.claude", RemoteTranscriptDir}, hookTranscriptDirs(dirs))
+	assert.Empty(t, dirs[:2][1], "the adapter's directories are not appended to in place")
+}
@@ -46,13 +46,20 @@ package core
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/keepmind9/clibot/internal/sandbox"
+	"github.com/keepmind9/clibot/pkg/constants"
 	"gopkg.in/yaml.v3"
 )
 
 const (
 	DefaultHookPort        = 8080
+	DefaultHookHost        = "127.0.0.1"
 	DefaultLogLevel        = "info"
 	DefaultLogMaxSize      = 100 // MB
 	DefaultLogMaxBackups   = 5
@@ -64,12 +71,46 @@ const (
 	DefaultWatchdogMaxRetries   = 10
 	DefaultWatchdogInitialDelay = "500ms"
 	DefaultWatchdogRetryDelay   = "800ms"
+	DefaultWatchdogTimeout      = "10m"
 	// DefaultTimeout is the default timeout for CLI adapters
 	// - For hook mode: 1 hour (maximum time to wait for response after hook triggers)
 	// - For ACP mode: 5 minutes (idle timeout)
 	DefaultTimeout = "1h"
+
+	// Transcription backends
+	TranscriptionBackendWhisperCPP = "whisper_cpp"
+	TranscriptionBackendOpenAI     = "openai"
+
+	// CLIAdapterTypeCustom marks a CLI adapter defined entirely in YAML
+	CLIAdapterTypeCustom = "custom"
+
+	// Session response modes
+	SessionModeHook = "hook" // Responses arrive via CLI hooks
+	SessionModePoll = "poll" // Responses are read from the tmux pane once it settles
+
+	CompletionHook  = "hook"  // Hook mode turns end with the CLI's Stop hook
+	CompletionWatch = "watch" // Hook mode turns end when the CLI's transcript shows a finished reply
+
+	// PreToolUse hook modes
+	PreToolUseOff     = "off"
+	PreToolUseNotify  = "notify"
+	PreToolUseApprove = "approve"
+	// DefaultApprovalTimeout is how long a PreToolUse approval waits for a chat reply
+	DefaultApprovalTimeout = "5m"
 )
 
+// DefaultRiskyTools are the Claude Code tools that pre_tool_use applies to by default
+var DefaultRiskyTools = []string{"Bash", "Write", "Edit", "MultiEdit", "NotebookEdit"}
+
+// WatchableCLITypes are the CLIs whose transcripts show when a turn is
+// complete, so their sessions can use completion: watch
+var WatchableCLITypes = []string{"claude", "gemini", "opencode"}
+
+// RemoteHookCLITypes are the CLIs whose hooks work from a session host: their
+// hooks name the transcript, which is read over SSH. Other CLIs on a host use
+// poll mode.
+var RemoteHookCLITypes = []string{"claude", "gemini"}
+
 // LoadConfig loads configuration from file and expands environment variables
 func LoadConfig(configPath string) (*Config, error) {
 	// Read configuration file
@@ -133,6 +174,9 @@ func validateConfig(config *Config) error {
 	if err := validateSecuritySettings(config); err != nil {
 		return err
 	}
+	if err := validateTranscription(config); err != nil {
+		return err
+	}
 	return validateBotAndSessionConfig(config)
 }
 
@@ -141,6 +185,12 @@ func setServerDefaults(config *Config) {
 	if config.HookServer.Port == 0 {
 		config.HookServer.Port = DefaultHookPort
 	}
+	if config.HookServer.Host == "" {
+		config.HookServer.Host = DefaultHookHost
+	}
+	if config.HookServer.RemotePort == 0 {
+		config.HookServer.RemotePort = config.HookServer.Port
+	}
 }
 
 // setLoggingDefaults sets default values for logging configuration
@@ -176,11 +226,86 @@ func setWatchdogDefaults(config *Config) {
 	if config.Watchdog.RetryDelay == "" {
 		config.Watchdog.RetryDelay = DefaultWatchdogRetryDelay
 	}
+	if config.Watchdog.Timeout == "" {
+		config.Watchdog.Timeout = DefaultWatchdogTimeout
+	}
 }
 
 // setSessionDefaults sets and validates session configuration
 func setSessionDefaults(config *Config) error {
-	// No defaults to set currently
+	if config.Session.Sandbox != nil {
+		if err := setSandboxDefaults(config.Session.Sandbox, "session.sandbox"); err != nil {
+			return err
+		}
+	}
+
+	for i := range config.Sessions {
+		switch config.Sessions[i].Mode {
+		case "":
+			config.Sessions[i].Mode = SessionModeHook
+			if pollsByDefault(config, config.Sessions[i]) {
+				config.Sessions[i].Mode = SessionModePoll
+			}
+		case SessionModeHook, SessionModePoll:
+		default:
+			return fmt.Errorf("sessions[%s].mode must be %q or %q, got %q",
+				config.Sessions[i].Name, SessionModeHook, SessionModePoll, config.Sessions[i].Mode)
+		}
+
+		switch config.Sessions[i].Completion {
+		case "":
+			config.Sessions[i].Completion = CompletionHook
+		case CompletionHook:
+		case CompletionWatch:
+			if config.Sessions[i].Mode != SessionModeHook {
+				return fmt.Errorf("sessions[%s].completion %q requires mode %q",
+					config.Sessions[i].Name, CompletionWatch, SessionModeHook)
+			}
+			if !slices.Contains(WatchableCLITypes, config.Sessions[i].CLIType) {
+				return fmt.Errorf("sessions[%s].completion %q is supported for %s, not %q",
+					config.Sessions[i].Name, CompletionWatch, strings.Join(WatchableCLITypes, ", "), config.Sessions[i].CLIType)
+			}
+		default:
+			return fmt.Errorf("sessions[%s].completion must be %q or %q, got %q",
+				config.Sessions[i].Name, CompletionHook, CompletionWatch, config.Sessions[i].Completion)
+		}
+
+		if err := validateSessionHost(config.Sessions[i]); err != nil {
+			return err
+		}
+		if err := validateSessionSandbox(config.Sessions[i]); err != nil {
+			return err
+		}
+		if err := validateSessionInput(config, config.Sessions[i]); err != nil {
+			return err
+		}
+
+		events := &config.Sessions[i].HookEvents
+		switch events.PreToolUse {
+		case "":
+			events.PreToolUse = PreToolUseOff
+		case PreToolUseOff, PreToolUseNotify, PreToolUseApprove:
+		default:
+			return fmt.Errorf("sessions[%s].hook_events.pre_tool_use must be %q, %q or %q, got %q",
+				config.Sessions[i].Name, PreToolUseOff, PreToolUseNotify, PreToolUseApprove, events.PreToolUse)
+		}
+		if len(events.RiskyTools) == 0 {
+			events.RiskyTools = append([]string(nil), DefaultRiskyTools...)
+		}
+		if events.ApprovalTimeout == "" {
+			events.ApprovalTimeout = DefaultApprovalTimeout
+		}
+		timeout, err := time.ParseDuration(events.ApprovalTimeout)
+		if err != nil {
+			return fmt.Errorf("sessions[%s].hook_events.approval_timeout is invalid: %w", config.Sessions[i].Name, err)
+		}
+		// "clibot hook" stops waiting after PreToolUseHookTimeout, so a longer
+		// approval would never reach the CLI
+		if timeout >= constants.PreToolUseHookTimeout {
+			return fmt.Errorf("sessions[%s].hook_events.approval_timeout must be less than %s, got %s",
+				config.Sessions[i].Name, constants.PreToolUseHookTimeout, events.ApprovalTimeout)
+		}
+	}
 	return nil
 }
 
@@ -196,11 +321,61 @@ func setCLIAdapterDefaults(config *Config) {
 
 // validateCLIAdapters validates CLI adapter configurations
 func validateCLIAdapters(config *Config) error {
-	// Hook mode is the only supported mode
-	// All CLI adapters must be configured to use hooks
+	for cliType, adapter := range config.CLIAdapters {
+		switch adapter.Type {
+		case "":
+		case CLIAdapterTypeCustom:
+			if cliType == "acp" {
+				return fmt.Errorf("cli_adapters.acp cannot be a custom adapter")
+			}
+			if adapter.StartCmd == "" {
+				return fmt.Errorf("cli_adapters.%s.start_cmd is required for a custom adapter", cliType)
+			}
+			switch adapter.Response.Source {
+			case "", "poll", "hook":
+			case "transcript":
+				if adapter.Response.TranscriptGlob == "" {
+					return fmt.Errorf("cli_adapters.%s.response.transcript_glob is required for the transcript source", cliType)
+				}
+			default:
+				return fmt.Errorf("cli_adapters.%s.response.source must be poll, hook or transcript, got %q", cliType, adapter.Response.Source)
+			}
+			switch adapter.Input.Method {
+			case "", "send_keys", "paste", "stdin":
+			default:
+				return fmt.Errorf("cli_adapters.%s.input.method must be send_keys, paste or stdin, got %q", cliType, adapter.Input.Method)
+			}
+		default:
+			return fmt.Errorf("cli_adapters.%s.type must be empty or %q, got %q", cliType, CLIAdapterTypeCustom, adapter.Type)
+		}
+	}
 	return nil
 }
 
+// DefaultStartCmd returns the command that starts a CLI type when a session
+// sets no start_cmd: the custom adapter's start_cmd, otherwise the type name
+func (c *Config) DefaultStartCmd(cliType string) string {
+	if adapter, ok := c.CLIAdapters[cliType]; ok && adapter.Type == CLIAdapterTypeCustom && adapter.StartCmd != "" {
+		return adapter.StartCmd
+	}
+	return cliType
+}
+
+// pollsByDefault reports whether a session reads the tmux pane unless it sets
+// a mode, which is the case for custom adapters without hooks, for CLIs on
+// hosts whose hooks do not work remotely and for sandboxed CLIs
+func pollsByDefault(config *Config, session SessionConfig) bool {
+	if session.Host != "" && !slices.Contains(RemoteHookCLITypes, session.CLIType) {
+		return true
+	}
+	if session.Sandbox != nil && session.CLIType != "acp" {
+		return true
+	}
+	adapter, ok := config.CLIAdapters[session.CLIType]
+	return ok && adapter.Type == CLIAdapterTypeCustom &&
+		(adapter.Response.Source == "" || adapter.Response.Source == SessionModePoll)
+}
+
 // validateSecuritySettings validates security configuration
 func validateSecuritySettings(config *Config) error {
 	if config.Security.WhitelistEnabled && len(config.Security.AllowedUsers) == 0 {
@@ -209,6 +384,122 @@ func validateSecuritySettings(config *Config) error {
 	return nil
 }
 
+// validateTranscription validates the voice transcription backend settings
+func validateTranscription(config *Config) error {
+	t := config.Transcription
+	if !t.Enabled {
+		return nil
+	}
+	switch t.Backend {
+	case TranscriptionBackendWhisperCPP:
+		if t.ModelPath == "" {
+			return fmt.Errorf("transcription.model_path is required for the whisper_cpp backend")
+		}
+	case TranscriptionBackendOpenAI:
+	default:
+		return fmt.Errorf("transcription.backend must be %q or %q, got %q",
+			TranscriptionBackendWhisperCPP, TranscriptionBackendOpenAI, t.Backend)
+	}
+	return nil
+}
+
+// validateSessionHost checks that a session on an SSH host only uses what
+// works remotely: tmux, polling, and hooks that name their transcript
+func validateSessionHost(session SessionConfig) error {
+	host := session.Host
+	switch {
+	case host == "":
+		return nil
+	case strings.HasPrefix(host, "-") || strings.ContainsAny(host, " \t\n'\""):
+		return fmt.Errorf("sessions[%s].host is not a valid SSH target: %q", session.Name, host)
+	case session.CLIType == "acp":
+		return fmt.Errorf("sessions[%s].host is not supported for acp sessions", session.Name)
+	case session.Completion == CompletionWatch:
+		return fmt.Errorf("sessions[%s].completion %q reads local transcripts and cannot be used with host",
+			session.Name, CompletionWatch)
+	case session.Mode == SessionModeHook && !slices.Contains(RemoteHookCLITypes, session.CLIType):
+		return fmt.Errorf("sessions[%s] on host %s needs mode %q: hook mode on hosts is supported for %s",
+			session.Name, host, SessionModePoll, strings.Join(RemoteHookCLITypes, ", "))
+	}
+	return nil
+}
+
+// validateSessionInput checks that sessions of custom CLIs reading stdin run
+// locally and outside a container: clibot writes their input to a local pipe
+func validateSessionInput(config *Config, session SessionConfig) error {
+	adapter, ok := config.CLIAdapters[session.CLIType]
+	if !ok || adapter.Type != CLIAdapterTypeCustom || adapter.Input.Method != "stdin" {
+		return nil
+	}
+	switch {
+	case session.Host != "":
+		return fmt.Errorf("sessions[%s] cannot use host: cli_adapters.%s reads input from a local pipe (input.method stdin)",
+			session.Name, session.CLIType)
+	case session.Sandbox != nil:
+		return fmt.Errorf("sessions[%s] cannot be sandboxed: cli_adapters.%s reads input from a local pipe (input.method stdin)",
+			session.Name, session.CLIType)
+	}
+	return nil
+}
+
+// sandboxMemoryPattern matches memory limits such as "512m" or "4g"
+var sandboxMemoryPattern = regexp.MustCompile(`^[0-9]+[bkmgBKMG]?$`)
+
+// setSandboxDefaults fills in the runtime and network of a sandbox and checks
+// its settings; field names it in errors
+func setSandboxDefaults(sb *SandboxConfig, field string) error {
+	switch sb.Runtime {
+	case "":
+		sb.Runtime = sandbox.RuntimeDocker
+	case sandbox.RuntimeDocker, sandbox.RuntimePodman:
+	default:
+		return fmt.Errorf("%s.runtime must be %q or %q, got %q",
+			field, sandbox.RuntimeDocker, sandbox.RuntimePodman, sb.Runtime)
+	}
+	if sb.Image == "" {
+		return fmt.Errorf("%s.image is required", field)
+	}
+	if sb.Network == "" {
+		sb.Network = sandbox.NetworkNone
+	}
+	if sb.CPUs != "" {
+		if cpus, err := strconv.ParseFloat(sb.CPUs, 64); err != nil || cpus <= 0 {
+			return fmt.Errorf("%s.cpus must be a positive number, got %q", field, sb.CPUs)
+		}
+	}
+	if sb.Memory != "" && !sandboxMemoryPattern.MatchString(sb.Memory) {
+		return fmt.Errorf("%s.memory must be a size such as \"4g\", got %q", field, sb.Memory)
+	}
+	for _, mount := range sb.Mounts {
+		if parts := strings.Split(mount, ":"); len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("%s.mounts entries must be \"source:target[:ro]\", got %q", field, mount)
+		}
+	}
+	return nil
+}
+
+// validateSessionSandbox checks that a sandboxed session only uses what works
+// inside a container: polling the tmux pane, or ACP over stdio
+func validateSessionSandbox(session SessionConfig) error {
+	if session.Sandbox == nil {
+		return nil
+	}
+	field := fmt.Sprintf("sessions[%s].sandbox", session.Name)
+	if err := setSandboxDefaults(session.Sandbox, field); err != nil {
+		return err
+	}
+	switch {
+	case session.Host != "":
+		return fmt.Errorf("%s cannot be combined with host", field)
+	case session.CLIType == "acp" && session.Transport != "" && session.Transport != "stdio://":
+		return fmt.Errorf("%s requires the stdio transport for acp sessions", field)
+	case session.CLIType != "acp" && session.Mode != SessionModePoll:
+		return fmt.Errorf("sessions[%s] is sandboxed and needs mode %q: hooks cannot reach clibot from the container",
+			session.Name, SessionModePoll)
+	}
+	return nil
+}
+
 // validateBotAndSessionConfig validates bot and session configuration
 func validateBotAndSessionConfig(config *Config) error {
 	if len(config.Bots) == 0 {
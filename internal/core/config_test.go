@@ -476,3 +476,178 @@ func TestExpandHome_AbsolutePath_ReturnsUnchanged(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "/absolute/path/to/file", result)
 }
+
+func TestValidateTranscription(t *testing.T) {
+	assert.NoError(t, validateTranscription(&Config{}))
+	assert.NoError(t, validateTranscription(&Config{Transcription: TranscriptionConfig{
+		Enabled: true, Backend: TranscriptionBackendOpenAI,
+	}}))
+
+	err := validateTranscription(&Config{Transcription: TranscriptionConfig{
+		Enabled: true, Backend: TranscriptionBackendWhisperCPP,
+	}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "model_path")
+
+	err = validateTranscription(&Config{Transcription: TranscriptionConfig{Enabled: true, Backend: "vosk"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "vosk")
+}
+
+func TestSetSessionDefaults_HookEvents(t *testing.T) {
+	config := &Config{Sessions: []SessionConfig{{Name: "main"}}}
+	assert.NoError(t, setSessionDefaults(config))
+	events := config.Sessions[0].HookEvents
+	assert.Equal(t, PreToolUseOff, events.PreToolUse)
+	assert.Equal(t, DefaultRiskyTools, events.RiskyTools)
+	assert.Equal(t, DefaultApprovalTimeout, events.ApprovalTimeout)
+
+	err := setSessionDefaults(&Config{Sessions: []SessionConfig{{Name: "main", HookEvents: HookEventsConfig{PreToolUse: "block"}}}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "block")
+
+	err = setSessionDefaults(&Config{Sessions: []SessionConfig{{Name: "main", HookEvents: HookEventsConfig{ApprovalTimeout: "soon"}}}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "approval_timeout")
+
+	// Approvals must end before "clibot hook" stops waiting
+	err = setSessionDefaults(&Config{Sessions: []SessionConfig{{Name: "main", HookEvents: HookEventsConfig{ApprovalTimeout: "30m"}}}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must be less than 30m0s")
+	assert.NoError(t, setSessionDefaults(&Config{Sessions: []SessionConfig{{Name: "main", HookEvents: HookEventsConfig{ApprovalTimeout: "29m"}}}}))
+}
+
+func TestSetSessionDefaults_Mode(t *testing.T) {
+	config := &Config{Sessions: []SessionConfig{{Name: "main"}, {Name: "tui", Mode: SessionModePoll}}}
+	assert.NoError(t, setSessionDefaults(config))
+	assert.Equal(t, SessionModeHook, config.Sessions[0].Mode)
+	assert.Equal(t, SessionModePoll, config.Sessions[1].Mode)
+
+	err := setSessionDefaults(&Config{Sessions: []SessionConfig{{Name: "main", Mode: "watch"}}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "watch")
+}
+
+func TestValidateCLIAdapters_Custom(t *testing.T) {
+	valid := &Config{CLIAdapters: map[string]CLIAdapterConfig{
+		"mycli":  {Type: CLIAdapterTypeCustom, StartCmd: "mycli"},
+		"claude": {},
+	}}
+	assert.NoError(t, validateCLIAdapters(valid))
+	assert.Equal(t, "mycli", valid.DefaultStartCmd("mycli"))
+	assert.Equal(t, "claude", valid.DefaultStartCmd("claude"))
+
+	for name, adapter := range map[string]CLIAdapterConfig{
+		"start_cmd":       {Type: CLIAdapterTypeCustom},
+		"transcript_glob": {Type: CLIAdapterTypeCustom, StartCmd: "x", Response: CustomResponseConfig{Source: "transcript"}},
+		"source":          {Type: CLIAdapterTypeCustom, StartCmd: "x", Response: CustomResponseConfig{Source: "magic"}},
+		"input.method":    {Type: CLIAdapterTypeCustom, StartCmd: "x", Input: CustomInputConfig{Method: "telepathy"}},
+		"type":            {Type: "plugin"},
+	} {
+		err := validateCLIAdapters(&Config{CLIAdapters: map[string]CLIAdapterConfig{"mycli": adapter}})
+		assert.ErrorContains(t, err, name)
+	}
+}
+
+func TestSetSessionDefaults_StdinInputIsLocal(t *testing.T) {
+	adapters := map[string]CLIAdapterConfig{
+		"repl": {Type: CLIAdapterTypeCustom, StartCmd: "repl", Input: CustomInputConfig{Method: "stdin"}},
+	}
+	assert.NoError(t, validateCLIAdapters(&Config{CLIAdapters: adapters}))
+	assert.NoError(t, setSessionDefaults(&Config{CLIAdapters: adapters,
+		Sessions: []SessionConfig{{Name: "local", CLIType: "repl"}}}))
+
+	err := setSessionDefaults(&Config{CLIAdapters: adapters,
+		Sessions: []SessionConfig{{Name: "remote", CLIType: "repl", Host: "dev@devbox"}}})
+	assert.ErrorContains(t, err, "cannot use host")
+
+	err = setSessionDefaults(&Config{CLIAdapters: adapters,
+		Sessions: []SessionConfig{{Name: "boxed", CLIType: "repl", Sandbox: &SandboxConfig{Image: "img"}}}})
+	assert.ErrorContains(t, err, "cannot be sandboxed")
+}
+
+func TestSetSessionDefaults_CustomPollAdapter(t *testing.T) {
+	config := &Config{
+		CLIAdapters: map[string]CLIAdapterConfig{
+			"tui":    {Type: CLIAdapterTypeCustom, StartCmd: "tui"},
+			"hooked": {Type: CLIAdapterTypeCustom, StartCmd: "hooked", Response: CustomResponseConfig{Source: "hook"}},
+		},
+		Sessions: []SessionConfig{{Name: "a", CLIType: "tui"}, {Name: "b", CLIType: "hooked"}},
+	}
+	assert.NoError(t, setSessionDefaults(config))
+	assert.Equal(t, SessionModePoll, config.Sessions[0].Mode)
+	assert.Equal(t, SessionModeHook, config.Sessions[1].Mode)
+}
+
+func TestSetSessionDefaults_Completion(t *testing.T) {
+	config := &Config{Sessions: []SessionConfig{
+		{Name: "main", CLIType: "claude"},
+		{Name: "watched", CLIType: "gemini", Completion: CompletionWatch},
+	}}
+	assert.NoError(t, setSessionDefaults(config))
+	assert.Equal(t, CompletionHook, config.Sessions[0].Completion)
+	assert.Equal(t, CompletionWatch, config.Sessions[1].Completion)
+
+	for name, session := range map[string]SessionConfig{
+		"must be":  {Name: "s", CLIType: "claude", Completion: "tail"},
+		"requires": {Name: "s", CLIType: "claude", Mode: SessionModePoll, Completion: CompletionWatch},
+		"codex":    {Name: "s", CLIType: "codex", Completion: CompletionWatch},
+	} {
+		err := setSessionDefaults(&Config{Sessions: []SessionConfig{session}})
+		assert.ErrorContains(t, err, name)
+	}
+}
+
+func TestSetSessionDefaults_Host(t *testing.T) {
+	config := &Config{Sessions: []SessionConfig{
+		{Name: "claude", CLIType: "claude", Host: "dev@devbox"},
+		{Name: "codex", CLIType: "codex", Host: "devbox"},
+		{Name: "local", CLIType: "codex"},
+	}}
+	assert.NoError(t, setSessionDefaults(config))
+	assert.Equal(t, SessionModeHook, config.Sessions[0].Mode)
+	assert.Equal(t, SessionModePoll, config.Sessions[1].Mode, "hooks of other CLIs do not work on hosts")
+	assert.Equal(t, SessionModeHook, config.Sessions[2].Mode)
+
+	for name, session := range map[string]SessionConfig{
+		"not a valid SSH target": {Name: "s", CLIType: "claude", Host: "-oProxyCommand=x"},
+		"acp":                    {Name: "s", CLIType: "acp", Host: "devbox", Mode: SessionModePoll},
+		"cannot be used":         {Name: "s", CLIType: "claude", Host: "devbox", Completion: CompletionWatch},
+		"needs mode":             {Name: "s", CLIType: "codex", Host: "devbox", Mode: SessionModeHook},
+	} {
+		err := setSessionDefaults(&Config{Sessions: []SessionConfig{session}})
+		assert.ErrorContains(t, err, name)
+	}
+}
+
+func TestSetSessionDefaults_Sandbox(t *testing.T) {
+	config := &Config{
+		Session: SessionGlobalConfig{Sandbox: &SandboxConfig{Image: "dev"}},
+		Sessions: []SessionConfig{
+			{Name: "boxed", CLIType: "claude", Sandbox: &SandboxConfig{Image: "claude", Runtime: "podman", CPUs: "1.5", Memory: "2g"}},
+			{Name: "agent", CLIType: "acp", Sandbox: &SandboxConfig{Image: "agent"}},
+		},
+	}
+	assert.NoError(t, setSessionDefaults(config))
+	assert.Equal(t, SessionModePoll, config.Sessions[0].Mode, "hooks cannot reach clibot from a container")
+	assert.Equal(t, "none", config.Sessions[0].Sandbox.Network)
+	assert.Equal(t, "podman", config.Sessions[0].Sandbox.Runtime)
+	assert.Equal(t, "docker", config.Session.Sandbox.Runtime)
+
+	for name, session := range map[string]SessionConfig{
+		"image is required":  {Name: "s", CLIType: "claude", Sandbox: &SandboxConfig{}},
+		"runtime":            {Name: "s", CLIType: "claude", Sandbox: &SandboxConfig{Image: "x", Runtime: "lxc"}},
+		"cpus":               {Name: "s", CLIType: "claude", Sandbox: &SandboxConfig{Image: "x", CPUs: "-1"}},
+		"memory":             {Name: "s", CLIType: "claude", Sandbox: &SandboxConfig{Image: "x", Memory: "lots"}},
+		"mounts":             {Name: "s", CLIType: "claude", Sandbox: &SandboxConfig{Image: "x", Mounts: []string{"/data"}}},
+		"combined with host": {Name: "s", CLIType: "claude", Host: "devbox", Mode: SessionModePoll, Sandbox: &SandboxConfig{Image: "x"}},
+		"stdio transport":    {Name: "s", CLIType: "acp", Transport: "tcp://127.0.0.1:9000", Sandbox: &SandboxConfig{Image: "x"}},
+		"needs mode":         {Name: "s", CLIType: "claude", Mode: SessionModeHook, Sandbox: &SandboxConfig{Image: "x"}},
+	} {
+		err := setSessionDefaults(&Config{Sessions: []SessionConfig{session}})
+		assert.ErrorContains(t, err, name)
+	}
+
+	err := setSessionDefaults(&Config{Session: SessionGlobalConfig{Sandbox: &SandboxConfig{}}})
+	assert.ErrorContains(t, err, "session.sandbox.image")
+}
@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,6 +19,7 @@ import (
 	"github.com/keepmind9/clibot/internal/cli"
 	"github.com/keepmind9/clibot/internal/logger"
 	"github.com/keepmind9/clibot/internal/proxy"
+	"github.com/keepmind9/clibot/internal/sandbox"
 	"github.com/keepmind9/clibot/internal/watchdog"
 	"github.com/keepmind9/clibot/pkg/constants"
 	"github.com/sirupsen/logrus"
@@ -34,23 +36,31 @@ const (
 //
 // Performance: O(1) map lookup for exact match commands.
 var specialCommands = map[string]struct{}{
-	"help":    {},
-	"status":  {},
-	"slist":   {},
-	"sstatus": {},
-	"whoami":  {},
-	"echo":    {},
-	"snew":    {},
-	"sdel":    {},
-	"suse":    {},
-	"sclose":  {},
+	"help":     {},
+	"status":   {},
+	"slist":    {},
+	"sstatus":  {},
+	"whoami":   {},
+	"echo":     {},
+	"snew":     {},
+	"sdel":     {},
+	"suse":     {},
+	"sclose":   {},
+	"shistory": {},
+	"ssearch":  {},
+	"sshot":    {},
+	"keys":     {},
+	"raw":      {},
+	"relogin":  {},
 }
 
 // isSpecialCommand checks if input is a special command.
 //
 // Matching strategy (exact match for maximum performance):
 //   - Exact match: "help", "status", "sessions", "whoami", "echo"
-//   - With args: "suse", "snew", "sdel" (with string arguments)
+//   - With args: "suse", "snew", "sdel", "shistory", "ssearch", "sshot", "keys", "raw" (with string arguments)
+//   - "raw" only takes "on" or "off", and "keys" only a valid key sequence, so
+//     prompts that merely start with these words go to the CLI
 //
 // Returns: (commandName, isCommand, remainingArgs)
 //
@@ -70,14 +80,16 @@ func isSpecialCommand(input string) (string, bool, []string) {
 		return input, true, nil
 	}
 
-	// Handle commands with string arguments (suse, snew, sdel, sclose, sstatus)
+	// Handle commands with string arguments (suse, snew, sdel, sclose, sstatus, shistory, ssearch, sshot, keys, raw, relogin)
 	// These commands accept arbitrary string arguments (session names, paths, etc.)
 	fields := strings.Fields(input)
 	if len(fields) > 1 {
 		cmd := fields[0]
 		// Only check known commands that accept string arguments
-		if cmd == "suse" || cmd == "snew" || cmd == "sdel" || cmd == "sclose" || cmd == "sstatus" {
-			if _, exists := specialCommands[cmd]; exists {
+		if cmd == "suse" || cmd == "snew" || cmd == "sdel" || cmd == "sclose" || cmd == "sstatus" ||
+			cmd == "shistory" || cmd == "ssearch" || cmd == "sshot" || cmd == "keys" || cmd == "raw" ||
+			cmd == "relogin" {
+			if _, exists := specialCommands[cmd]; exists && commandArgsValid(cmd, input, fields[1:]) {
 				return cmd, true, fields[1:]
 			}
 		}
@@ -86,22 +98,56 @@ func isSpecialCommand(input string) (string, bool, []string) {
 	return "", false, nil
 }
 
+// commandArgsValid reports whether args fit commands that share their first
+// word with ordinary prompts, e.g. "raw SQL is slow here, why?"
+func commandArgsValid(cmd, input string, args []string) bool {
+	switch cmd {
+	case "raw":
+		return len(args) == 1 && (args[0] == "on" || args[0] == "off")
+	case "keys":
+		_, err := watchdog.ParseKeys(strings.TrimSpace(strings.TrimPrefix(input, cmd)))
+		return err == nil
+	}
+	return true
+}
+
 // Engine is the core scheduling engine that manages CLI sessions and bot connections
 type Engine struct {
-	config          *Config
-	cliAdapters     map[string]cli.CLIAdapter // CLI type -> adapter
-	activeBots      map[string]bot.BotAdapter // Bot type -> adapter
-	sessions        map[string]*Session       // Session name -> Session
-	sessionMu       sync.RWMutex              // Mutex for session access
-	messageChan     chan bot.BotMessage       // Bot message channel
-	hookServer      *http.Server              // HTTP server for hooks
-	sessionChannels map[string]BotChannel     // Session name -> active bot channel (for routing responses)
-	userSessions    map[string]string         // User key (platform:userID) -> current session name
-	cmdLocksMu      sync.RWMutex              // Protects sessionCmdLocks map
-	sessionCmdLocks map[string]*sync.Mutex    // Per-session command locks (prevents concurrent commands on same session)
-	proxyMgr        *proxy.ProxyManager       // Proxy manager for HTTP clients
-	ctx             context.Context           // Context for cancellation
-	cancel          context.CancelFunc        // Cancel function for graceful shutdown
+	config             *Config
+	cliAdapters        map[string]cli.CLIAdapter                   // CLI type -> adapter
+	activeBots         map[string]bot.BotAdapter                   // Bot type -> adapter
+	sessions           map[string]*Session                         // Session name -> Session
+	sessionMu          sync.RWMutex                                // Mutex for session access
+	messageChan        chan bot.BotMessage                         // Bot message channel
+	hookServer         *http.Server                                // HTTP server for hooks
+	hookSecret         string                                      // Shared secret required on hook requests, generated at startup
+	hookEndpointPath   string                                      // File publishing the hook address and secret to "clibot hook"
+	hookSessionIDs     map[string]string                           // CLI session ID (from hooks) -> session name
+	unmatchedAlerts    map[string]time.Time                        // "<cli_type>:<cwd>" of unmatched hooks without session hints -> last admin alert
+	capturePane        func(string, int) (string, error)           // Captures a tmux pane without ANSI codes (replaced in tests)
+	captureScreen      func(string) (string, error)                // Captures a pane's visible screen with ANSI codes (replaced in tests)
+	sendKeys           func(string, []watchdog.KeyStep) error      // Sends a key sequence to a tmux session (replaced in tests)
+	keysCaptureDelay   time.Duration                               // Wait between sending keys and capturing their effect
+	readRemoteFile     func(string, string) ([]byte, error)        // Reads a file on a session host (replaced in tests)
+	resolveRemoteHome  func(string) (string, error)                // Resolves the home directory on a session host (replaced in tests)
+	remoteHomes        map[string]string                           // Session host -> resolved home directory
+	hookTunnel         func(context.Context, string, string) error // Holds the hook tunnel to a session host open until it closes (replaced in tests)
+	removeSandbox      func(string, string) error                  // Force-removes a sandbox container (replaced in tests)
+	sandboxStats       func(string, string) (sandbox.Stats, error) // Reads a sandbox container's resource usage (replaced in tests)
+	sessionChannels    map[string]BotChannel                       // Session name -> active bot channel (for routing responses)
+	progressMsgs       map[string]BotChannel                       // Session name -> editable progress message (MessageID is the bot's message)
+	userSessions       map[string]string                           // User key (platform:userID) -> current session name
+	rawModes           map[string]bool                             // User keys with raw mode on (messages typed without Enter)
+	cmdLocksMu         sync.RWMutex                                // Protects sessionCmdLocks map
+	sessionCmdLocks    map[string]*sync.Mutex                      // Per-session command locks (prevents concurrent commands on same session)
+	proxyMgr           *proxy.ProxyManager                         // Proxy manager for HTTP clients
+	supervisor         *bot.Supervisor                             // Restarts bot adapters and tracks their connection state
+	transcriber        bot.Transcriber                             // Voice message transcription backend (nil when disabled)
+	pendingTranscripts map[string]pendingTranscript                // User key -> voice transcript awaiting confirmation
+	pendingApprovals   map[string][]*pendingApproval               // Session name -> PreToolUse hooks awaiting allow/deny, oldest first
+	toolFeeds          map[string][]string                         // Session name -> recent tool calls shown in the PostToolUse feed
+	ctx                context.Context                             // Context for cancellation
+	cancel             context.CancelFunc                          // Cancel function for graceful shutdown
 }
 
 // BotChannel represents a bot channel for sending responses
@@ -121,21 +167,96 @@ func NewEngine(config *Config) *Engine {
 	}
 
 	engine := &Engine{
-		config:          config,
-		cliAdapters:     make(map[string]cli.CLIAdapter),
-		activeBots:      make(map[string]bot.BotAdapter),
-		sessions:        make(map[string]*Session),
-		messageChan:     make(chan bot.BotMessage, constants.MessageChannelBufferSize),
-		sessionChannels: make(map[string]BotChannel),
-		userSessions:    make(map[string]string),
-		sessionCmdLocks: make(map[string]*sync.Mutex),
-		proxyMgr:        proxy.NewProxyManager(NewCoreConfigAdapter(config)),
-		ctx:             ctx,
-		cancel:          cancel,
-	}
+		config:             config,
+		cliAdapters:        make(map[string]cli.CLIAdapter),
+		activeBots:         make(map[string]bot.BotAdapter),
+		sessions:           make(map[string]*Session),
+		messageChan:        make(chan bot.BotMessage, constants.MessageChannelBufferSize),
+		sessionChannels:    make(map[string]BotChannel),
+		progressMsgs:       make(map[string]BotChannel),
+		pendingTranscripts: make(map[string]pendingTranscript),
+		pendingApprovals:   make(map[string][]*pendingApproval),
+		toolFeeds:          make(map[string][]string),
+		hookEndpointPath:   DefaultHookEndpointPath(),
+		hookSessionIDs:     make(map[string]string),
+		unmatchedAlerts:    make(map[string]time.Time),
+		capturePane:        watchdog.CapturePaneClean,
+		captureScreen:      watchdog.CaptureScreen,
+		sendKeys:           watchdog.SendKeySequence,
+		keysCaptureDelay:   constants.KeysCaptureDelay,
+		readRemoteFile:     readRemoteTranscript,
+		resolveRemoteHome:  resolveRemoteHome,
+		remoteHomes:        make(map[string]string),
+		hookTunnel:         openHookTunnel,
+		removeSandbox:      sandbox.Remove,
+		sandboxStats:       sandbox.ContainerStats,
+		supervisor:         bot.NewSupervisor(bot.SupervisorConfig{}),
+		userSessions:       make(map[string]string),
+		rawModes:           make(map[string]bool),
+		sessionCmdLocks:    make(map[string]*sync.Mutex),
+		proxyMgr:           proxy.NewProxyManager(NewCoreConfigAdapter(config)),
+		ctx:                ctx,
+		cancel:             cancel,
+	}
+	engine.transcriber = newTranscriber(config.Transcription, engine.proxyMgr)
+	engine.supervisor.OnDown = engine.handleBotDown
+	engine.supervisor.OnRecover = engine.handleBotRecovered
 	return engine
 }
 
+// BotStatuses returns the connection state of every supervised bot adapter
+func (e *Engine) BotStatuses() []bot.AdapterStatus {
+	return e.supervisor.Status()
+}
+
+// handleBotDown alerts admins on the other platforms when a bot stays down
+func (e *Engine) handleBotDown(status bot.AdapterStatus) {
+	logger.WithFields(logrus.Fields{
+		"platform": status.Platform,
+		"attempts": status.Attempts,
+		"error":    status.LastError,
+	}).Error("bot-adapter-still-down-notifying-admins")
+
+	e.notifyAdmins(status.Platform, fmt.Sprintf(
+		"⚠️ %s bot has been disconnected since %s (%d reconnect attempts)\nLast error: %s",
+		status.Platform, status.Since.Format("15:04:05"), status.Attempts, status.LastError))
+}
+
+// handleBotRecovered tells admins that a previously reported bot is back
+func (e *Engine) handleBotRecovered(status bot.AdapterStatus, downtime time.Duration) {
+	e.notifyAdmins(status.Platform, fmt.Sprintf(
+		"✅ %s bot reconnected after %s", status.Platform, downtime.Round(time.Second)))
+}
+
+// notifyAdmins sends a direct message to every admin on connected platforms other than exclude
+// Platforms whose adapter cannot send direct messages are skipped
+func (e *Engine) notifyAdmins(exclude, message string) {
+	connected := make(map[string]bool)
+	for _, status := range e.supervisor.Status() {
+		connected[status.Platform] = status.State == bot.ConnectionConnected
+	}
+
+	for platform, admins := range e.config.Security.Admins {
+		if platform == exclude || !connected[platform] {
+			continue
+		}
+		messager, ok := e.activeBots[platform].(bot.DirectMessager)
+		if !ok {
+			logger.WithField("platform", platform).Warn("bot-cannot-send-direct-messages-skipping-admins")
+			continue
+		}
+		for _, adminID := range admins {
+			if err := messager.SendDirectMessage(adminID, message); err != nil {
+				logger.WithFields(logrus.Fields{
+					"platform": platform,
+					"admin":    adminID,
+					"error":    err,
+				}).Error("failed-to-notify-admin")
+			}
+		}
+	}
+}
+
 // RegisterCLIAdapter registers a CLI adapter
 func (e *Engine) RegisterCLIAdapter(cliType string, adapter cli.CLIAdapter) {
 	e.cliAdapters[cliType] = adapter
@@ -144,6 +265,29 @@ func (e *Engine) RegisterCLIAdapter(cliType string, adapter cli.CLIAdapter) {
 // RegisterBotAdapter registers a bot adapter
 func (e *Engine) RegisterBotAdapter(botType string, adapter bot.BotAdapter) {
 	e.activeBots[botType] = adapter
+	if setter, ok := adapter.(bot.SessionProviderSetter); ok {
+		setter.SetSessionProvider(e)
+	}
+	if setter, ok := adapter.(bot.TranscriberSetter); ok && e.transcriber != nil {
+		setter.SetTranscriber(e.transcriber)
+	}
+	if loginer, ok := adapter.(bot.QRLoginer); ok {
+		loginer.SetLoginRequiredHandler(func(reason string) {
+			e.handleLoginRequired(botType, reason)
+		})
+	}
+}
+
+// handleLoginRequired asks admins on the other platforms to renew an expired bot login
+func (e *Engine) handleLoginRequired(platform, reason string) {
+	logger.WithFields(logrus.Fields{
+		"platform": platform,
+		"reason":   reason,
+	}).Warn("bot-login-required-notifying-admins")
+
+	e.notifyAdmins(platform, fmt.Sprintf(
+		"⚠️ %s bot needs a new login (%s)\nSend 'relogin %s' to get a login QR code",
+		platform, reason, platform))
 }
 
 // GetProxyManager returns the proxy manager
@@ -165,19 +309,24 @@ func (e *Engine) initializeSessions() error {
 		// Determine start command: use configured value or default to CLI type
 		startCmd := sessionConfig.StartCmd
 		if startCmd == "" {
-			startCmd = sessionConfig.CLIType
+			startCmd = e.config.DefaultStartCmd(sessionConfig.CLIType)
 		}
 
 		// Create new session
 		session := &Session{
-			Name:      sessionConfig.Name,
-			CLIType:   sessionConfig.CLIType,
-			WorkDir:   sessionConfig.WorkDir,
-			StartCmd:  startCmd,
-			State:     StateIdle,
-			CreatedAt: time.Now().Format(time.RFC3339),
-			IsDynamic: false, // Configured sessions are not dynamic
-			CreatedBy: "",
+			Name:       sessionConfig.Name,
+			CLIType:    sessionConfig.CLIType,
+			WorkDir:    sessionConfig.WorkDir,
+			StartCmd:   startCmd,
+			Mode:       sessionConfig.Mode,
+			Completion: sessionConfig.Completion,
+			Host:       sessionConfig.Host,
+			Sandbox:    sessionConfig.Sandbox,
+			State:      StateIdle,
+			CreatedAt:  time.Now().Format(time.RFC3339),
+			IsDynamic:  false, // Configured sessions are not dynamic
+			CreatedBy:  "",
+			HookEvents: sessionConfig.HookEvents,
 		}
 
 		// Check if CLI adapter exists
@@ -187,6 +336,9 @@ func (e *Engine) initializeSessions() error {
 			continue
 		}
 
+		// Route the session's tmux commands to its host before touching tmux
+		watchdog.SetSessionHost(session.Name, session.Host)
+
 		// Check if session is alive or create if auto_start is enabled
 		if adapter.IsSessionAlive(session.Name) {
 			log.Printf("Session %s is already running", session.Name)
@@ -222,11 +374,21 @@ func (e *Engine) ensureSessionStarted(session *Session, sessionConfig SessionCon
 	// Determine start command
 	startCmd := sessionConfig.StartCmd
 	if startCmd == "" {
-		startCmd = session.CLIType
+		startCmd = e.config.DefaultStartCmd(session.CLIType)
+	}
+
+	// Sandboxed sessions start the CLI in their container
+	env := sessionConfig.Env
+	if session.Sandbox != nil {
+		var err error
+		if startCmd, err = e.sandboxStartCmd(session, session.WorkDir, startCmd, env); err != nil {
+			return false, fmt.Errorf("failed to prepare sandbox: %w", err)
+		}
+		env = nil
 	}
 
 	// Start the session
-	if err := adapter.CreateSession(session.Name, session.WorkDir, startCmd, sessionConfig.Transport, sessionConfig.Env); err != nil {
+	if err := adapter.CreateSession(session.Name, session.WorkDir, startCmd, sessionConfig.Transport, env); err != nil {
 		return false, fmt.Errorf("failed to create session: %w", err)
 	}
 
@@ -250,6 +412,11 @@ func (e *Engine) needsHookServer() bool {
 func (e *Engine) Run(ctx context.Context) error {
 	logger.Info("starting-clibot-engine")
 
+	// Tunnel hooks from session hosts back to the hook server
+	if e.needsHookServer() {
+		e.forwardHooks()
+	}
+
 	// Initialize sessions
 	if err := e.initializeSessions(); err != nil {
 		return fmt.Errorf("failed to initialize sessions: %w", err)
@@ -275,22 +442,7 @@ func (e *Engine) Run(ctx context.Context) error {
 		}
 
 		log.Printf("Starting %s bot...", botType)
-		go func(bt string, ba bot.BotAdapter) {
-			defer func() {
-				if r := recover(); r != nil {
-					logger.WithFields(logrus.Fields{
-						"bot_type": bt,
-						"panic":    r,
-					}).Error("bot-start-panic-recovered")
-				}
-			}()
-			if err := ba.Start(e.HandleBotMessage); err != nil {
-				logger.WithFields(logrus.Fields{
-					"bot_type": bt,
-					"error":    err,
-				}).Error("failed-to-start-bot")
-			}
-		}(botType, botAdapter)
+		e.supervisor.Supervise(e.ctx, botType, botAdapter, e.HandleBotMessage)
 	}
 
 	// Start main event loop
@@ -325,7 +477,8 @@ func (e *Engine) HandleBotMessage(msg bot.BotMessage) {
 	input := strings.TrimSpace(msg.Content)
 	cmd, isSpecialCmd, args := isSpecialCommand(input)
 
-	if isSpecialCmd {
+	// Voice transcripts are queued so they are confirmed before running as commands
+	if isSpecialCmd && !msg.Transcribed {
 		// Special commands are processed asynchronously for immediate response
 		logger.WithFields(logrus.Fields{
 			"command": cmd,
@@ -380,6 +533,9 @@ func (e *Engine) handleSpecialCommandWithAuth(command string, args []string, msg
 			"user":     msg.UserID,
 		}).Warn("unauthorized-special-command")
 		e.SendToBot(msg.Platform, msg.Channel, "❌ Unauthorized: Please contact administrator")
+		if command == "suse" {
+			e.notifySessionSwitch(msg, "")
+		}
 		return
 	}
 
@@ -399,6 +555,9 @@ func (e *Engine) handleSpecialCommandWithAuth(command string, args []string, msg
 
 			e.SendToBot(msg.Platform, msg.Channel,
 				"⚠️  This session is currently processing another command. Please try again later.")
+			if command == "suse" {
+				e.notifySessionSwitch(msg, "")
+			}
 			return
 		}
 		defer lock.Unlock()
@@ -420,7 +579,7 @@ func (e *Engine) HandleUserMessage(msg bot.BotMessage) {
 	input := strings.TrimSpace(msg.Content)
 	cmd, isSpecialCmd, args := isSpecialCommand(input)
 
-	if isSpecialCmd {
+	if isSpecialCmd && !msg.Transcribed {
 		// Only "help" and "echo" bypass whitelist check
 		if cmd == "help" || cmd == "echo" {
 			logger.WithFields(logrus.Fields{
@@ -446,6 +605,13 @@ func (e *Engine) HandleUserMessage(msg bot.BotMessage) {
 
 	logger.WithField("user", msg.UserID).Debug("user-authorized")
 
+	// Step 1.5: Echo voice transcripts for confirmation, or swap in a confirmed one
+	var proceed bool
+	if msg, proceed = e.interceptTranscript(msg); !proceed {
+		return
+	}
+	cmd, isSpecialCmd, args = isSpecialCommand(strings.TrimSpace(msg.Content))
+
 	// Step 2: Handle remaining special commands (status, slist, etc.)
 	if isSpecialCmd {
 		logger.WithFields(logrus.Fields{
@@ -463,6 +629,10 @@ func (e *Engine) HandleUserMessage(msg bot.BotMessage) {
 
 	e.sessionMu.Lock()
 	sessionName, userHasSession := e.userSessions[userKey]
+	if msg.Session != "" {
+		// Message is bound to a session by the adapter (e.g. a Discord session thread)
+		sessionName, userHasSession = msg.Session, true
+	}
 	var session *Session
 	sessionInvalid := false
 
@@ -470,7 +640,9 @@ func (e *Engine) HandleUserMessage(msg bot.BotMessage) {
 		session = e.sessions[sessionName]
 		if session == nil {
 			// User's selected session no longer exists, clean up the stale reference
-			delete(e.userSessions, userKey)
+			if msg.Session == "" {
+				delete(e.userSessions, userKey)
+			}
 			sessionInvalid = true
 			logger.WithFields(logrus.Fields{
 				"user":          userKey,
@@ -522,6 +694,21 @@ func (e *Engine) HandleUserMessage(msg bot.BotMessage) {
 		"cli":     session.CLIType,
 	}).Debug("session-found")
 
+	// Step 3.1: An allow/deny reply answers a tool call waiting for approval
+	if e.answerToolApproval(session.Name, msg) {
+		return
+	}
+
+	// Step 3.2: In raw mode the message is typed without Enter and the pane shown.
+	// Keys are sent in order here; the capture delay runs off the message loop.
+	if e.isRawMode(userKey) {
+		steps := watchdog.RawInputSteps(msg.Content)
+		if e.sendSessionKeys(session, steps, msg) {
+			go e.showPaneAfterKeys(session, steps, msg)
+		}
+		return
+	}
+
 	// Record the session → channel mapping for routing responses
 	e.sessionMu.Lock()
 	e.sessionChannels[session.Name] = BotChannel{
@@ -576,9 +763,35 @@ func (e *Engine) HandleUserMessage(msg bot.BotMessage) {
 		}).Debug("keyword-converted-to-key-sequence")
 	}
 
-	// NOTE: Before snapshot capture removed - only hook mode is supported
 	adapter := e.cliAdapters[session.CLIType]
 
+	// Polling sessions diff the pane against a snapshot taken before sending;
+	// watched sessions skip the transcript turn that was current before sending
+	var beforeCapture string
+	switch {
+	case session.Mode == SessionModePoll:
+		var err error
+		if beforeCapture, err = e.capturePane(session.Name, constants.PollCaptureLines); err != nil {
+			logger.WithFields(logrus.Fields{
+				"session": session.Name,
+				"error":   err,
+			}).Warn("failed-to-capture-pane-before-input")
+		}
+	case session.Completion == CompletionWatch:
+		beforeCapture = e.latestTurnID(session)
+	}
+
+	// Adapters without hooks watch the CLI's files for the reply, which only
+	// works for local sessions in hook mode
+	if watcher, ok := adapter.(cli.ReplyWatcher); ok && session.Mode != SessionModePoll && session.Host == "" {
+		if err := watcher.WatchSession(session.Name); err != nil {
+			logger.WithFields(logrus.Fields{
+				"session": session.Name,
+				"error":   err,
+			}).Warn("failed-to-watch-session-replies")
+		}
+	}
+
 	// Step 5: Send to CLI
 	if err := adapter.SendInput(session.Name, processedContent); err != nil {
 		logger.WithFields(logrus.Fields{
@@ -592,11 +805,11 @@ func (e *Engine) HandleUserMessage(msg bot.BotMessage) {
 	// Step 6: Update session state to processing
 	e.updateSessionState(session.Name, StateProcessing)
 
-	// Hook mode: No polling needed, responses will be received via hooks
-	// Start watchdog for session monitoring
+	// Hook mode waits for hooks or watches the transcript; poll mode watches
+	// the pane until it settles
 	if session.NeedsWatchdog() {
 		ctx, cleanup := e.startNewWatchdogForSession(session.Name)
-		go func(sessionName string, watchdogCtx context.Context) {
+		go func(sessionName, userPrompt, before string, watchdogCtx context.Context) {
 			defer func() {
 				if r := recover(); r != nil {
 					logger.WithFields(logrus.Fields{
@@ -615,13 +828,13 @@ func (e *Engine) HandleUserMessage(msg bot.BotMessage) {
 				return
 			}
 
-			if err := e.startWatchdogWithContext(watchdogCtx, session, "", ""); err != nil {
+			if err := e.startWatchdogWithContext(watchdogCtx, session, userPrompt, before); err != nil {
 				logger.WithFields(logrus.Fields{
 					"session": sessionName,
 					"error":   err,
 				}).Error("watchdog-failed")
 			}
-		}(session.Name, ctx)
+		}(session.Name, msg.Content, beforeCapture, ctx)
 	}
 }
 
@@ -662,16 +875,40 @@ func (e *Engine) HandleSpecialCommandWithArgs(command string, args []string, msg
 		e.handleCloseSession(args, msg)
 	case "sstatus":
 		e.handleSessionStatus(args, msg)
+	case "shistory":
+		e.handleHistory(args, msg)
+	case "ssearch":
+		e.handleSearch(args, msg)
+	case "sshot":
+		e.handleScreenshot(args, msg)
+	case "keys":
+		e.handleKeys(args, msg)
+	case "raw":
+		e.handleRawMode(args, msg)
+	case "relogin":
+		e.handleRelogin(args, msg)
 	default:
 		e.SendToBot(msg.Platform, msg.Channel,
 			fmt.Sprintf("❌ Unknown command: %s\nUse 'help' to see available commands", command))
 	}
 }
 
+// sessionPlacement describes a session's CLI type and where it runs, e.g.
+// "claude @ devbox" or "codex, sandboxed"
+func sessionPlacement(session *Session) string {
+	placement := session.CLIType
+	if session.Host != "" {
+		placement += " @ " + session.Host
+	}
+	if session.Sandbox != nil {
+		placement += ", sandboxed"
+	}
+	return placement
+}
+
 // listSessions lists all available sessions
 func (e *Engine) listSessions(msg bot.BotMessage) {
 	e.sessionMu.RLock()
-	defer e.sessionMu.RUnlock()
 
 	// Get user's current session
 	userKey := getUserKey(msg.Platform, msg.UserID)
@@ -704,7 +941,7 @@ func (e *Engine) listSessions(msg bot.BotMessage) {
 				marker = " ⬅️ **CURRENT**"
 			}
 			response += fmt.Sprintf("  • %s (%s) - %s [static]%s\n",
-				session.Name, session.CLIType, session.State, marker)
+				session.Name, sessionPlacement(session), session.State, marker)
 		}
 		response += "\n"
 	}
@@ -718,17 +955,63 @@ func (e *Engine) listSessions(msg bot.BotMessage) {
 				marker = " ⬅️ **CURRENT**"
 			}
 			response += fmt.Sprintf("  • %s (%s) - %s [dynamic, created by %s]%s\n",
-				session.Name, session.CLIType, session.State, session.CreatedBy, marker)
+				session.Name, sessionPlacement(session), session.State, session.CreatedBy, marker)
 		}
 	}
 
 	if !hasCurrent && len(e.sessions) > 0 {
 		response += "\n💡 Use: suse <session_name> to select a session\n"
 	}
+	e.sessionMu.RUnlock()
 
+	// Adapters with a session picker send it along with the list
+	if sender, ok := e.activeBots[msg.Platform].(bot.SessionListSender); ok {
+		sessions, current := e.ListSessions(msg.Platform, msg.UserID)
+		if err := sender.SendSessionList(msg.Channel, response, sessions, current); err != nil {
+			logger.WithFields(logrus.Fields{
+				"platform": msg.Platform,
+				"channel":  msg.Channel,
+				"error":    err,
+			}).Error("failed-to-send-session-list-to-bot")
+		}
+		return
+	}
 	e.SendToBot(msg.Platform, msg.Channel, response)
 }
 
+// ListSessions returns all sessions sorted by name and the user's current session
+// Implements bot.SessionProvider for adapters with session pickers
+func (e *Engine) ListSessions(platform, userID string) ([]bot.SessionInfo, string) {
+	e.sessionMu.RLock()
+	defer e.sessionMu.RUnlock()
+
+	sessions := make([]bot.SessionInfo, 0, len(e.sessions))
+	for _, session := range e.sessions {
+		sessions = append(sessions, bot.SessionInfo{
+			Name:    session.Name,
+			CLIType: session.CLIType,
+			State:   string(session.State),
+			Dynamic: session.IsDynamic,
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Name < sessions[j].Name
+	})
+
+	return sessions, e.userSessions[getUserKey(platform, userID)]
+}
+
+// CLITypes returns the registered CLI types, sorted
+// Implements bot.SessionProvider for adapters that offer CLI type choices
+func (e *Engine) CLITypes() []string {
+	types := make([]string, 0, len(e.cliAdapters))
+	for cliType := range e.cliAdapters {
+		types = append(types, cliType)
+	}
+	sort.Strings(types)
+	return types
+}
+
 // showStatus shows the status of all sessions
 func (e *Engine) showStatus(msg bot.BotMessage) {
 	e.sessionMu.RLock()
@@ -755,6 +1038,21 @@ func (e *Engine) showStatus(msg bot.BotMessage) {
 		response += fmt.Sprintf("  %s %s (%s) - %s %s\n", status, session.Name, session.CLIType, session.State, origin)
 	}
 
+	if bots := e.supervisor.Status(); len(bots) > 0 {
+		response += "\nBots:\n"
+		for _, b := range bots {
+			status := "❌"
+			if b.State == bot.ConnectionConnected {
+				status = "✅"
+			}
+			line := fmt.Sprintf("  %s %s - %s since %s", status, b.Platform, b.State, b.Since.Format("15:04:05"))
+			if b.State == bot.ConnectionReconnecting {
+				line += fmt.Sprintf(" (attempt %d: %s)", b.Attempts, b.LastError)
+			}
+			response += line + "\n"
+		}
+	}
+
 	e.SendToBot(msg.Platform, msg.Channel, response)
 }
 
@@ -812,11 +1110,17 @@ func (e *Engine) showHelp(msg bot.BotMessage) {
   suse <name>  - Switch current session
   sclose [name] - Close running session (default: current session)
   sstatus [name] - Show session status (default: all sessions)
+  shistory [n] [export] - Show the last n exchanges of the current session (default: 5), or send them as a markdown file
+  ssearch <text> - Search the current session's conversation
+  sshot [name] - Send a screenshot of the session's terminal (default: current session)
+  keys <keys>  - Send keys to the current session, e.g. keys Down Down Enter
+  raw [on|off] - Toggle raw mode: messages are typed without pressing Enter
   status       - Show status of all sessions
   whoami       - Show your current session info
   echo         - Echo your IM user info (for whitelist config)
   snew <name> <cli_type> <work_dir> [cmd] - Create new session (admin only)
   sdel <name>  - Delete dynamic session (admin only)
+  relogin <platform> - Get a login QR code for an expired bot, e.g. weixin (admin only)
 
 **Special Keywords** (exact match, case-insensitive):
   ⚠️ These keywords only work in Hook mode with tmux input
@@ -835,6 +1139,12 @@ func (e *Engine) showHelp(msg bot.BotMessage) {
   sclose backend    → Close session 'backend' (if you're the creator or admin)
   sstatus           → Show status of all sessions
   sstatus backend  → Show detailed status of 'backend' session
+  shistory 10       → Show the last 10 exchanges
+  shistory export   → Export the whole conversation as markdown
+  ssearch migration → Find exchanges mentioning "migration"
+  sshot             → See the current session's screen, menus and colors included
+  keys Down*2 Enter → Pick the third option of a menu
+  keys C-r "test" Enter → Search the shell history for "test" and run it
   status            → Show status
   tab               → Send Tab key to CLI
   ctrl-c            → Interrupt current process
@@ -864,6 +1174,75 @@ func (e *Engine) handleEcho(msg bot.BotMessage) {
 	e.SendToBot(msg.Platform, msg.Channel, response)
 }
 
+// handleRelogin starts a QR login for a bot whose login expired (admin only)
+// Usage: relogin <platform>
+func (e *Engine) handleRelogin(args []string, msg bot.BotMessage) {
+	if !e.config.IsAdmin(msg.Platform, msg.UserID) {
+		e.SendToBot(msg.Platform, msg.Channel, "❌ Permission denied: admin only")
+		return
+	}
+	if len(args) != 1 {
+		e.SendToBot(msg.Platform, msg.Channel, "❌ Usage: relogin <platform>")
+		return
+	}
+
+	platform := args[0]
+	adapter, exists := e.activeBots[platform]
+	if !exists {
+		e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("❌ Bot '%s' is not enabled", platform))
+		return
+	}
+	loginer, ok := adapter.(bot.QRLoginer)
+	if !ok {
+		e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("❌ Bot '%s' does not support QR login", platform))
+		return
+	}
+
+	qrCode, done, err := loginer.StartQRLogin()
+	if err != nil {
+		e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("❌ Failed to start %s login: %v", platform, err))
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"platform": platform,
+		"admin":    msg.UserID,
+	}).Info("bot-qr-login-started")
+	e.sendToRequester(msg, fmt.Sprintf("📱 Scan this QR code to log in the %s bot:\n%s", platform, qrCode))
+
+	go func() {
+		select {
+		case <-e.ctx.Done():
+			return
+		case err := <-done:
+			if err != nil {
+				e.sendToRequester(msg, fmt.Sprintf("❌ %s login failed: %v", platform, err))
+				return
+			}
+			e.sendToRequester(msg, fmt.Sprintf("✅ %s bot logged in", platform))
+		}
+	}()
+}
+
+// sendToRequester sends a direct message to the user who sent msg, so that
+// login QR codes are not posted to shared channels
+// Platforms without direct messages get the message in the original channel
+func (e *Engine) sendToRequester(msg bot.BotMessage, message string) {
+	messager, ok := e.activeBots[msg.Platform].(bot.DirectMessager)
+	if !ok {
+		e.SendToBot(msg.Platform, msg.Channel, message)
+		return
+	}
+	if err := messager.SendDirectMessage(msg.UserID, message); err != nil {
+		logger.WithFields(logrus.Fields{
+			"platform": msg.Platform,
+			"user_id":  msg.UserID,
+			"error":    err,
+		}).Error("failed-to-send-direct-message")
+		e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("❌ Failed to send you a direct message: %v", err))
+	}
+}
+
 // handleNewSession creates a new dynamic session (admin only)
 // Usage: new <name> <cli_type> <work_dir> [start_cmd]
 func (e *Engine) handleNewSession(args []string, msg bot.BotMessage) {
@@ -889,7 +1268,7 @@ func (e *Engine) handleNewSession(args []string, msg bot.BotMessage) {
 	name := args[0]
 	cliType := args[1]
 	workDir := args[2]
-	startCmd := cliType
+	startCmd := e.config.DefaultStartCmd(cliType)
 	if len(args) >= 4 {
 		startCmd = args[3]
 	}
@@ -905,7 +1284,7 @@ func (e *Engine) handleNewSession(args []string, msg bot.BotMessage) {
 	adapter, exists := e.cliAdapters[cliType]
 	if !exists {
 		e.SendToBot(msg.Platform, msg.Channel,
-			fmt.Sprintf("❌ Invalid CLI type: '%s'\nSupported: claude, gemini, opencode", cliType))
+			fmt.Sprintf("❌ Invalid CLI type: '%s'\nSupported: %s", cliType, strings.Join(e.CLITypes(), ", ")))
 		return
 	}
 
@@ -970,10 +1349,30 @@ func (e *Engine) handleNewSession(args []string, msg bot.BotMessage) {
 		IsDynamic: true,
 		CreatedBy: fmt.Sprintf("%s:%s", msg.Platform, msg.UserID),
 	}
+	if sb := e.config.Session.Sandbox; sb != nil {
+		sandboxCopy := *sb
+		session.Sandbox = &sandboxCopy
+	}
+	if pollsByDefault(e.config, SessionConfig{CLIType: cliType, Sandbox: session.Sandbox}) {
+		session.Mode = SessionModePoll
+	}
+	if err := validateSessionInput(e.config, SessionConfig{Name: name, CLIType: cliType, Sandbox: session.Sandbox}); err != nil {
+		e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("❌ Failed to create session: %v", err))
+		return
+	}
 
 	// 9. Create tmux session and start CLI
 	// For dynamic sessions, transport is typically empty (non-ACP adapters)
-	if err := adapter.CreateSession(name, expandedDir, startCmd, "", nil); err != nil {
+	createCmd := startCmd
+	if session.Sandbox != nil {
+		if createCmd, err = e.sandboxStartCmd(session, expandedDir, startCmd, nil); err != nil {
+			logger.WithField("error", err).Error("failed-to-prepare-dynamic-session-sandbox")
+			e.SendToBot(msg.Platform, msg.Channel,
+				fmt.Sprintf("❌ Failed to create session: %v", err))
+			return
+		}
+	}
+	if err := adapter.CreateSession(name, expandedDir, createCmd, "", nil); err != nil {
 		logger.WithField("error", err).Error("failed-to-create-dynamic-session")
 		e.SendToBot(msg.Platform, msg.Channel,
 			fmt.Sprintf("❌ Failed to create session: %v", err))
@@ -1034,6 +1433,10 @@ func (e *Engine) handleUseSession(args []string, msg bot.BotMessage) {
 		"args":     args,
 	}).Info("handle-use-session-command")
 
+	// Runs after the session lock below is released
+	switched := ""
+	defer func() { e.notifySessionSwitch(msg, switched) }()
+
 	// 1. Parameter validation
 	if len(args) < 1 {
 		e.SendToBot(msg.Platform, msg.Channel,
@@ -1108,6 +1511,19 @@ func (e *Engine) handleUseSession(args []string, msg bot.BotMessage) {
 	}
 
 	e.SendToBot(msg.Platform, msg.Channel, response)
+	switched = sessionName
+}
+
+// notifySessionSwitch tells the platform's adapter how a suse ended, with
+// sessionName empty if the switch was rejected
+func (e *Engine) notifySessionSwitch(msg bot.BotMessage, sessionName string) {
+	e.sessionMu.RLock()
+	botAdapter := e.activeBots[msg.Platform]
+	e.sessionMu.RUnlock()
+
+	if listener, ok := botAdapter.(bot.SessionSwitchListener); ok {
+		listener.SessionSwitched(msg.Channel, msg.UserID, sessionName)
+	}
 }
 
 // handleDeleteSession deletes a dynamic session (admin only)
@@ -1251,7 +1667,7 @@ func (e *Engine) handleCloseSession(args []string, msg bot.BotMessage) {
 	}
 
 	// Check if session is alive
-	if session.State != StateProcessing && session.State != StateIdle {
+	if session.State != StateProcessing && session.State != StateIdle && session.State != StateWaitingInput {
 		e.SendToBot(msg.Platform, msg.Channel,
 			fmt.Sprintf("⚠️  Session '%s' is not running (state: %s)", sessionName, session.State))
 		return
@@ -1306,9 +1722,14 @@ func (e *Engine) stopSession(session *Session) error {
 		}
 	} else {
 		// Tmux-based adapters: kill tmux session
-		cmd := exec.Command("tmux", "kill-session", "-t", session.Name)
+		cmd := watchdog.TmuxCommand(session.Name, "kill-session", "-t", session.Name)
 		err = cmd.Run()
 	}
+	// The container outlives the pane or process that started it
+	e.stopSandbox(session)
+	if watcher, ok := adapter.(cli.ReplyWatcher); ok {
+		watcher.UnwatchSession(session.Name)
+	}
 
 	if err != nil {
 		return err
@@ -1336,6 +1757,7 @@ type SessionStatus struct {
 	CreatedBy    string
 	IsAlive      bool
 	ProcessInfo  *ProcessInfo
+	Sandbox      *SandboxStatus // Container of sandboxed sessions (nil otherwise)
 	LastActivity string
 }
 
@@ -1420,6 +1842,9 @@ func (e *Engine) showAllSessionsStatus(msg bot.BotMessage) {
 		if status.IsAlive && status.ProcessInfo != nil {
 			response += fmt.Sprintf(" | PID: %d | Mem: %s", status.ProcessInfo.PID, status.ProcessInfo.Memory)
 		}
+		if status.Sandbox != nil && status.Sandbox.Stats != nil {
+			response += fmt.Sprintf(" | 📦 CPU: %s | Mem: %s", status.Sandbox.Stats.CPU, status.Sandbox.Stats.Memory)
+		}
 
 		response += "\n"
 	}
@@ -1451,6 +1876,7 @@ func (e *Engine) getSessionStatus(session *Session) *SessionStatus {
 		if procInfo := e.getProcessInfo(session); procInfo != nil {
 			status.ProcessInfo = procInfo
 		}
+		status.Sandbox = e.sandboxStatus(session)
 	}
 
 	return status
@@ -1462,6 +1888,12 @@ func (e *Engine) getProcessInfo(session *Session) *ProcessInfo {
 	var pid int
 	var cmd string
 
+	// The pane process of a session on a host is not visible locally, and a
+	// sandboxed session's is only the container client (see sandboxStatus)
+	if session.Host != "" || session.Sandbox != nil {
+		return nil
+	}
+
 	// Get tmux session PID
 	if session.CLIType != "acp" {
 		// For tmux-based sessions, get the pane PID
@@ -1644,6 +2076,21 @@ func (e *Engine) sendSessionStatus(msg bot.BotMessage, status *SessionStatus) {
 		response += fmt.Sprintf("  • Uptime: %s\n", status.ProcessInfo.Uptime)
 	}
 
+	// Container info
+	if sb := status.Sandbox; sb != nil {
+		response += "\n📦 **Sandbox**\n"
+		response += fmt.Sprintf("  • Container: %s\n", sb.Container)
+		response += fmt.Sprintf("  • Image: %s\n", sb.Image)
+		response += fmt.Sprintf("  • Network: %s\n", sb.Network)
+		if sb.Stats != nil {
+			response += fmt.Sprintf("  • CPU: %s\n", sb.Stats.CPU)
+			response += fmt.Sprintf("  • Memory: %s\n", sb.Stats.Memory)
+			response += fmt.Sprintf("  • Processes: %s\n", sb.Stats.PIDs)
+		} else {
+			response += "  • Usage: unavailable\n"
+		}
+	}
+
 	e.SendToBot(msg.Platform, msg.Channel, response)
 }
 
@@ -1807,8 +2254,11 @@ func (e *Engine) SendResponseToSession(sessionName, message string) {
 		"response_length": len(message),
 	}).Info("sending-response-to-user")
 
-	// Send the message
-	e.SendToBot(botChannel.Platform, botChannel.Channel, message)
+	// Replace the progress message with the final response if one was streamed,
+	// otherwise send it as a new message
+	if !e.finishProgress(sessionName, botChannel, message) {
+		e.SendToBot(botChannel.Platform, botChannel.Channel, message)
+	}
 
 	// Remove typing indicator after a short delay if supported
 	if botChannel.MessageID != "" {
@@ -1816,6 +2266,87 @@ func (e *Engine) SendResponseToSession(sessionName, message string) {
 	}
 }
 
+// SendProgressToSession streams partial output of a running request to the session's channel
+// Only adapters implementing bot.MessageEditor receive progress; the first call sends a
+// message and later calls edit it in place. Other platforms just get the final response.
+func (e *Engine) SendProgressToSession(sessionName, message string) {
+	if strings.TrimSpace(message) == "" {
+		return
+	}
+
+	e.sessionMu.RLock()
+	botChannel, exists := e.sessionChannels[sessionName]
+	botAdapter, botExists := e.activeBots[botChannel.Platform]
+	progress, hasProgress := e.progressMsgs[sessionName]
+	e.sessionMu.RUnlock()
+
+	if !exists || !botExists {
+		return
+	}
+	editor, ok := botAdapter.(bot.MessageEditor)
+	if !ok {
+		return
+	}
+
+	// Keep editing the same message unless the session moved to another channel
+	if hasProgress && progress.Platform == botChannel.Platform && progress.Channel == botChannel.Channel {
+		if err := editor.EditMessage(progress.Channel, progress.MessageID, message); err != nil {
+			logger.WithFields(logrus.Fields{
+				"session":  sessionName,
+				"platform": progress.Platform,
+				"error":    err,
+			}).Warn("failed-to-edit-progress-message")
+		}
+		return
+	}
+
+	messageID, err := editor.SendEditableMessage(botChannel.Channel, message)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"session":  sessionName,
+			"platform": botChannel.Platform,
+			"error":    err,
+		}).Warn("failed-to-send-progress-message")
+		return
+	}
+
+	e.sessionMu.Lock()
+	e.progressMsgs[sessionName] = BotChannel{
+		Platform:  botChannel.Platform,
+		Channel:   botChannel.Channel,
+		MessageID: messageID,
+	}
+	e.sessionMu.Unlock()
+}
+
+// finishProgress edits the session's progress message into the final response
+// Returns true if the response was delivered by editing
+func (e *Engine) finishProgress(sessionName string, botChannel BotChannel, message string) bool {
+	e.sessionMu.Lock()
+	progress, exists := e.progressMsgs[sessionName]
+	delete(e.progressMsgs, sessionName)
+	botAdapter := e.activeBots[progress.Platform]
+	e.sessionMu.Unlock()
+
+	if !exists || progress.Platform != botChannel.Platform || progress.Channel != botChannel.Channel {
+		return false
+	}
+	editor, ok := botAdapter.(bot.MessageEditor)
+	if !ok {
+		return false
+	}
+
+	if err := editor.EditMessage(progress.Channel, progress.MessageID, message); err != nil {
+		logger.WithFields(logrus.Fields{
+			"session":  sessionName,
+			"platform": progress.Platform,
+			"error":    err,
+		}).Warn("failed-to-edit-progress-message-sending-new")
+		return false
+	}
+	return true
+}
+
 // SendToAllBots sends a message to all active bots
 func (e *Engine) SendToAllBots(message string) {
 	for platform, botAdapter := range e.activeBots {
@@ -1827,7 +2358,10 @@ func (e *Engine) SendToAllBots(message string) {
 
 // startWatchdogWithContext starts monitoring with a cancellable context
 // This prevents goroutine leaks when multiple messages are sent rapidly
-// Only hook mode is supported now
+// Hook mode returns immediately unless the session watches its transcript
+// (completion: watch); poll mode watches the pane until the response settles.
+// beforeCapture is the pane capture (poll) or transcript turn ID (watch) taken
+// before the prompt was sent.
 func (e *Engine) startWatchdogWithContext(ctx context.Context, session *Session, userPrompt string, beforeCapture string) error {
 	// Check if session needs watchdog monitoring
 	if !session.NeedsWatchdog() {
@@ -1838,15 +2372,17 @@ func (e *Engine) startWatchdogWithContext(ctx context.Context, session *Session,
 		return nil
 	}
 
-	logger.WithField("session", session.Name).Debug("hook-mode-watchdog-started")
+	if session.Mode == SessionModePoll {
+		return e.pollForResponse(ctx, session.Name, userPrompt, beforeCapture)
+	}
+	if session.Completion == CompletionWatch {
+		return e.watchForCompletion(ctx, session, beforeCapture)
+	}
 
 	// Hook mode is event-driven
 	// The engine waits for hook notifications via HTTP
 	// Actual hook handling is done in handleHookRequest
 	logger.WithField("session", session.Name).Debug("hook-mode-watchdog-waiting")
-
-	// In hook mode, we just wait for the hook to trigger
-	// The actual processing happens when the hook is received
 	return nil
 }
 
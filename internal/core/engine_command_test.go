@@ -216,6 +216,34 @@ func TestIsSpecialCommand_WithArgs(t *testing.T) {
 			expectedIsCmd: false,
 			expectedArgs:  nil,
 		},
+		{
+			name:          "raw off",
+			input:         "raw off",
+			expectedCmd:   "raw",
+			expectedIsCmd: true,
+			expectedArgs:  []string{"off"},
+		},
+		{
+			name:          "prompt starting with raw is not a command",
+			input:         "raw SQL is slow here, why?",
+			expectedCmd:   "",
+			expectedIsCmd: false,
+			expectedArgs:  nil,
+		},
+		{
+			name:          "keys with a key sequence",
+			input:         `keys C-r "git log" Enter`,
+			expectedCmd:   "keys",
+			expectedIsCmd: true,
+			expectedArgs:  []string{"C-r", `"git`, `log"`, "Enter"},
+		},
+		{
+			name:          "prompt starting with keys is not a command",
+			input:         "keys in this map are never sorted",
+			expectedCmd:   "",
+			expectedIsCmd: false,
+			expectedArgs:  nil,
+		},
 		{
 			name:          "non-command text",
 			input:         "hello this is a normal message",
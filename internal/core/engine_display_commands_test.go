@@ -145,6 +145,37 @@ func TestEngine_HandleUseSession_NonExistingSession(t *testing.T) {
 	assert.Contains(t, mockBot.lastMessage, "does not exist")
 }
 
+// switchRecordingBot is a mock bot adapter that records session switches
+type switchRecordingBot struct {
+	mockBotAdapter
+	switches []string
+}
+
+func (b *switchRecordingBot) SessionSwitched(channel, userID, sessionName string) {
+	b.switches = append(b.switches, channel+"/"+userID+"/"+sessionName)
+}
+
+// TestEngine_HandleUseSession_NotifiesSwitchListener tests that adapters learn how each switch ended
+func TestEngine_HandleUseSession_NotifiesSwitchListener(t *testing.T) {
+	engine := NewEngine(&Config{})
+	engine.RegisterCLIAdapter("claude", newMockCLIAdapter())
+	engine.sessions["main"] = &Session{Name: "main", CLIType: "claude", State: StateIdle}
+	listener := &switchRecordingBot{}
+	engine.RegisterBotAdapter("discord", listener)
+
+	msg := bot.BotMessage{Platform: "discord", Channel: "general", UserID: "u1"}
+	engine.handleUseSession([]string{"nonexistent"}, msg)
+	assert.Equal(t, []string{"general/u1/"}, listener.switches)
+
+	engine.handleUseSession([]string{"main"}, msg)
+	assert.Equal(t, []string{"general/u1/", "general/u1/main"}, listener.switches)
+
+	// Users outside the whitelist are rejected before the command runs
+	engine.config.Security.WhitelistEnabled = true
+	engine.handleSpecialCommandWithAuth("suse", []string{"main"}, msg)
+	assert.Equal(t, []string{"general/u1/", "general/u1/main", "general/u1/"}, listener.switches)
+}
+
 // TestEngine_HandleNewSession_NoArgs tests handleNewSession with no arguments
 func TestEngine_HandleNewSession_NoArgs(t *testing.T) {
 	config := &Config{
@@ -229,6 +260,35 @@ func TestEngine_HandleDeleteSession_NoArgs(t *testing.T) {
 	assert.Equal(t, 1, mockBot.messageCount)
 }
 
+// TestEngine_HandleNewSession_InvalidCLIType tests that the error lists the registered CLI types
+func TestEngine_HandleNewSession_InvalidCLIType(t *testing.T) {
+	config := &Config{
+		Security: SecurityConfig{
+			Admins: map[string][]string{
+				"testbot": {"user123"},
+			},
+		},
+	}
+	engine := NewEngine(config)
+	engine.RegisterCLIAdapter("gemini", nil)
+	engine.RegisterCLIAdapter("claude", nil)
+
+	mockBot := &mockBotAdapter{}
+	engine.RegisterBotAdapter("testbot", mockBot)
+
+	msg := bot.BotMessage{
+		Platform: "testbot",
+		Channel:  "test-channel",
+		UserID:   "user123",
+	}
+
+	engine.handleNewSession([]string{"newsession", "cursor", "/tmp"}, msg)
+
+	assert.Equal(t, 1, mockBot.messageCount)
+	assert.Contains(t, mockBot.lastMessage, "Invalid CLI type: 'cursor'")
+	assert.Contains(t, mockBot.lastMessage, "Supported: claude, gemini")
+}
+
 // TestEngine_HandleNewSession_NonACPWithoutHookServer tests creating non-ACP session when hook server is not running
 func TestEngine_HandleNewSession_NonACPWithoutHookServer(t *testing.T) {
 	config := &Config{
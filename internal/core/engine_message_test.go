@@ -0,0 +1,114 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockCLIAdapter is a mock implementation of cli.CLIAdapter that records inputs
+type mockCLIAdapter struct {
+	inputs map[string][]string // session name -> inputs
+}
+
+func newMockCLIAdapter() *mockCLIAdapter {
+	return &mockCLIAdapter{inputs: make(map[string][]string)}
+}
+
+func (m *mockCLIAdapter) SendInput(sessionName, input string) error {
+	m.inputs[sessionName] = append(m.inputs[sessionName], input)
+	return nil
+}
+
+func (m *mockCLIAdapter) HandleHookData(data []byte) (string, string, string, error) {
+	return "", "", "", nil
+}
+
+func (m *mockCLIAdapter) IsSessionAlive(sessionName string) bool {
+	return true
+}
+
+func (m *mockCLIAdapter) CreateSession(sessionName, workDir, startCmd, transportURL string, env map[string]string) error {
+	return nil
+}
+
+// newMessageTestEngine creates an engine with two claude sessions and a mock CLI adapter
+func newMessageTestEngine() (*Engine, *mockCLIAdapter, *mockBotAdapter) {
+	engine := NewEngine(&Config{})
+	cliAdapter := newMockCLIAdapter()
+	botAdapter := &mockBotAdapter{}
+	engine.RegisterCLIAdapter("claude", cliAdapter)
+	engine.RegisterBotAdapter("discord", botAdapter)
+	engine.sessions["main"] = &Session{Name: "main", CLIType: "claude", State: StateIdle}
+	engine.sessions["side"] = &Session{Name: "side", CLIType: "claude", State: StateIdle}
+	return engine, cliAdapter, botAdapter
+}
+
+// TestEngine_HandleUserMessage_UsesSelectedSession tests routing to the user's selected session
+func TestEngine_HandleUserMessage_UsesSelectedSession(t *testing.T) {
+	engine, cliAdapter, _ := newMessageTestEngine()
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleUserMessage(bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1", Content: "hello"})
+
+	assert.Equal(t, []string{"hello"}, cliAdapter.inputs["main"])
+	assert.Empty(t, cliAdapter.inputs["side"])
+}
+
+// TestEngine_HandleUserMessage_BoundSession tests that adapter-bound sessions override the selection
+func TestEngine_HandleUserMessage_BoundSession(t *testing.T) {
+	engine, cliAdapter, _ := newMessageTestEngine()
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleUserMessage(bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "thread", Content: "hi", Session: "side"})
+
+	assert.Equal(t, []string{"hi"}, cliAdapter.inputs["side"])
+	assert.Empty(t, cliAdapter.inputs["main"])
+	assert.Equal(t, "thread", engine.sessionChannels["side"].Channel)
+	// The user's own selection is untouched
+	assert.Equal(t, "main", engine.userSessions[getUserKey("discord", "u1")])
+}
+
+// TestEngine_HandleUserMessage_BoundSessionMissing tests a bound session that no longer exists
+func TestEngine_HandleUserMessage_BoundSessionMissing(t *testing.T) {
+	engine, cliAdapter, botAdapter := newMessageTestEngine()
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleUserMessage(bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "thread", Content: "hi", Session: "gone"})
+
+	assert.Empty(t, cliAdapter.inputs)
+	assert.Contains(t, botAdapter.lastMessage, "'gone' no longer exists")
+	assert.Equal(t, "main", engine.userSessions[getUserKey("discord", "u1")])
+}
+
+// replyWatchingCLIAdapter is a mock CLI adapter that records watched sessions
+type replyWatchingCLIAdapter struct {
+	mockCLIAdapter
+	watched []string
+}
+
+func (m *replyWatchingCLIAdapter) WatchSession(sessionName string) error {
+	m.watched = append(m.watched, sessionName)
+	return nil
+}
+
+func (m *replyWatchingCLIAdapter) UnwatchSession(sessionName string) {}
+
+// TestEngine_HandleUserMessage_WatchesReplies tests that only local hook mode sessions are watched for replies
+func TestEngine_HandleUserMessage_WatchesReplies(t *testing.T) {
+	engine := NewEngine(&Config{})
+	adapter := &replyWatchingCLIAdapter{mockCLIAdapter: *newMockCLIAdapter()}
+	engine.RegisterCLIAdapter("aider", adapter)
+	engine.RegisterBotAdapter("discord", &mockBotAdapter{})
+	engine.sessions["hook"] = &Session{Name: "hook", CLIType: "aider", State: StateIdle}
+	engine.sessions["poll"] = &Session{Name: "poll", CLIType: "aider", State: StateIdle, Mode: SessionModePoll}
+	engine.sessions["remote"] = &Session{Name: "remote", CLIType: "aider", State: StateIdle, Host: "dev@devbox"}
+	engine.capturePane = func(sessionName string, lines int) (string, error) { return "", nil }
+
+	for _, name := range []string{"hook", "poll", "remote"} {
+		engine.HandleUserMessage(bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1", Content: "hi", Session: name})
+		assert.Equal(t, []string{"hi"}, adapter.inputs[name])
+	}
+	assert.Equal(t, []string{"hook"}, adapter.watched)
+}
@@ -66,3 +66,39 @@ func TestEngine_OverwriteBotAdapter(t *testing.T) {
 	// Verify the bot was replaced
 	assert.Equal(t, bot2, engine.activeBots["discord"])
 }
+
+// TestEngine_RegisterBotAdapter_InjectsSessionProvider tests that session-picker adapters receive the engine
+func TestEngine_RegisterBotAdapter_InjectsSessionProvider(t *testing.T) {
+	engine := NewEngine(&Config{})
+	webBot := bot.NewWebBot("", map[string]string{"alice": "token"})
+
+	engine.RegisterBotAdapter("web", webBot)
+
+	assert.Equal(t, webBot, engine.activeBots["web"])
+}
+
+// TestEngine_ListSessions tests listing sessions for session pickers
+func TestEngine_ListSessions(t *testing.T) {
+	engine := NewEngine(&Config{})
+	engine.sessions["beta"] = &Session{Name: "beta", CLIType: "gemini", State: StateProcessing, IsDynamic: true}
+	engine.sessions["alpha"] = &Session{Name: "alpha", CLIType: "claude", State: StateIdle}
+	engine.userSessions[getUserKey("web", "alice")] = "beta"
+
+	sessions, current := engine.ListSessions("web", "alice")
+	assert.Equal(t, "beta", current)
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, bot.SessionInfo{Name: "alpha", CLIType: "claude", State: "idle"}, sessions[0])
+	assert.Equal(t, bot.SessionInfo{Name: "beta", CLIType: "gemini", State: "processing", Dynamic: true}, sessions[1])
+
+	_, current = engine.ListSessions("web", "bob")
+	assert.Empty(t, current)
+}
+
+// TestEngine_CLITypes tests listing the registered CLI types
+func TestEngine_CLITypes(t *testing.T) {
+	engine := NewEngine(&Config{})
+	engine.RegisterCLIAdapter("gemini", newMockCLIAdapter())
+	engine.RegisterCLIAdapter("claude", newMockCLIAdapter())
+
+	assert.Equal(t, []string{"claude", "gemini"}, engine.CLITypes())
+}
@@ -1,11 +1,14 @@
 package core
 
 import (
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/keepmind9/clibot/internal/bot"
 	"github.com/keepmind9/clibot/internal/proxy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestEngine_SendToBot_WithRegisteredBot tests SendToBot with registered bot
@@ -57,6 +60,7 @@ func TestEngine_SendToAllBots_WithRegisteredBots(t *testing.T) {
 // mockBotAdapter is a mock implementation of BotAdapter for testing
 type mockBotAdapter struct {
 	bot.DefaultTypingIndicator
+	mu           sync.Mutex // Messages may be sent from engine goroutines
 	messageCount int
 	lastMessage  string
 	lastChannel  string
@@ -71,12 +75,21 @@ func (m *mockBotAdapter) Stop() error {
 }
 
 func (m *mockBotAdapter) SendMessage(channel, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.messageCount++
 	m.lastMessage = message
 	m.lastChannel = channel
 	return nil
 }
 
+// lastSent returns the number of messages sent and the latest one
+func (m *mockBotAdapter) lastSent() (count int, channel, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.messageCount, m.lastChannel, m.lastMessage
+}
+
 func (m *mockBotAdapter) SetMessageHandler(handler func(bot.BotMessage)) {
 	// Nothing to set
 }
@@ -89,3 +102,185 @@ func (m *mockBotAdapter) SetProxyManager(mgr proxy.Manager) {
 	// Mock implementation - do nothing
 	// Store mgr if needed for testing, but for most tests it's not used
 }
+
+// mockDMBot is a mock bot adapter that can send direct messages
+type mockDMBot struct {
+	mockBotAdapter
+	dmCount    int
+	lastDM     string
+	lastDMUser string
+}
+
+func (m *mockDMBot) SendDirectMessage(userID, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dmCount++
+	m.lastDM = message
+	m.lastDMUser = userID
+	return nil
+}
+
+// lastDirect returns the number of direct messages sent and the latest one
+func (m *mockDMBot) lastDirect() (count int, userID, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dmCount, m.lastDMUser, m.lastDM
+}
+
+// mockEditorBot is a mock bot adapter that supports editing messages
+type mockEditorBot struct {
+	mockBotAdapter
+	sentEditable []string
+	edits        map[string]string // message ID -> latest content
+}
+
+func (m *mockEditorBot) SendEditableMessage(channel, message string) (string, error) {
+	m.sentEditable = append(m.sentEditable, message)
+	if m.edits == nil {
+		m.edits = make(map[string]string)
+	}
+	m.edits["p1"] = message
+	return "p1", nil
+}
+
+func (m *mockEditorBot) EditMessage(channel, messageID, message string) error {
+	m.edits[messageID] = message
+	return nil
+}
+
+// TestEngine_SendProgressToSession_EditsInPlace tests that progress and the final response share one message
+func TestEngine_SendProgressToSession_EditsInPlace(t *testing.T) {
+	engine := NewEngine(&Config{})
+	editor := &mockEditorBot{}
+	engine.RegisterBotAdapter("telegram", editor)
+	engine.sessionChannels["dev"] = BotChannel{Platform: "telegram", Channel: "100"}
+
+	engine.SendProgressToSession("dev", "step 1")
+	engine.SendProgressToSession("dev", "step 1\nstep 2")
+	engine.SendResponseToSession("dev", "step 1\nstep 2\ndone")
+
+	assert.Equal(t, []string{"step 1"}, editor.sentEditable)
+	assert.Equal(t, "step 1\nstep 2\ndone", editor.edits["p1"])
+	assert.Equal(t, 0, editor.messageCount, "final response should edit, not send")
+	assert.Empty(t, engine.progressMsgs)
+
+	// The next response starts a fresh message
+	engine.SendResponseToSession("dev", "next")
+	assert.Equal(t, 1, editor.messageCount)
+}
+
+// TestEngine_SendProgressToSession_NonEditor tests that progress is dropped for plain adapters
+func TestEngine_SendProgressToSession_NonEditor(t *testing.T) {
+	engine := NewEngine(&Config{})
+	plain := &mockBotAdapter{}
+	engine.RegisterBotAdapter("discord", plain)
+	engine.sessionChannels["dev"] = BotChannel{Platform: "discord", Channel: "c1"}
+
+	engine.SendProgressToSession("dev", "partial")
+	assert.Equal(t, 0, plain.messageCount)
+
+	engine.SendResponseToSession("dev", "final")
+	assert.Equal(t, 1, plain.messageCount)
+	assert.Equal(t, "final", plain.lastMessage)
+}
+
+// TestEngine_HandleBotDown_NotifiesAdminsElsewhere tests that outage alerts are sent as direct messages
+// to admins on other connected platforms, skipping platforms that cannot send them
+func TestEngine_HandleBotDown_NotifiesAdminsElsewhere(t *testing.T) {
+	engine := NewEngine(&Config{
+		Security: SecurityConfig{Admins: map[string][]string{
+			"telegram": {"1001"},
+			"qq":       {"openid-1"},
+			"webhook":  {"teams:alice"},
+		}},
+	})
+	defer engine.cancel()
+	telegram := &mockDMBot{}
+	qq := &mockDMBot{}
+	webhook := &mockBotAdapter{}
+	engine.RegisterBotAdapter("telegram", telegram)
+	engine.RegisterBotAdapter("qq", qq)
+	engine.RegisterBotAdapter("webhook", webhook)
+	engine.supervisor.Supervise(engine.ctx, "telegram", telegram, engine.HandleBotMessage)
+	engine.supervisor.Supervise(engine.ctx, "webhook", webhook, engine.HandleBotMessage)
+
+	assert.Eventually(t, func() bool {
+		statuses := engine.BotStatuses()
+		return len(statuses) == 2 &&
+			statuses[0].State == bot.ConnectionConnected && statuses[1].State == bot.ConnectionConnected
+	}, time.Second, 5*time.Millisecond)
+
+	engine.handleBotDown(bot.AdapterStatus{Platform: "qq", Attempts: 3, LastError: "dial timeout"})
+
+	assert.Equal(t, 1, telegram.dmCount)
+	assert.Equal(t, "1001", telegram.lastDMUser)
+	assert.Contains(t, telegram.lastDM, "qq bot has been disconnected")
+	assert.Contains(t, telegram.lastDM, "dial timeout")
+	assert.Equal(t, 0, telegram.messageCount)
+	assert.Equal(t, 0, qq.dmCount)
+	assert.Equal(t, 0, webhook.messageCount)
+}
+
+// mockLoginBot is a mock bot adapter whose login can be renewed by QR code
+type mockLoginBot struct {
+	mockBotAdapter
+	loginRequired func(reason string)
+	done          chan error
+}
+
+func (m *mockLoginBot) SetLoginRequiredHandler(handler func(reason string)) {
+	m.loginRequired = handler
+}
+
+func (m *mockLoginBot) StartQRLogin() (string, <-chan error, error) {
+	return "https://login.example.com/qr", m.done, nil
+}
+
+// TestEngine_Relogin tests that expired logins are reported to admins and renewed via relogin
+// Prompts and QR codes are sent to the admin directly, never to the channel the command came from
+func TestEngine_Relogin(t *testing.T) {
+	engine := NewEngine(&Config{
+		Security: SecurityConfig{Admins: map[string][]string{"telegram": {"1001"}}},
+	})
+	defer engine.cancel()
+	telegram := &mockDMBot{}
+	weixin := &mockLoginBot{done: make(chan error, 1)}
+	engine.RegisterBotAdapter("telegram", telegram)
+	engine.RegisterBotAdapter("weixin", weixin)
+	engine.supervisor.Supervise(engine.ctx, "telegram", telegram, engine.HandleBotMessage)
+
+	assert.Eventually(t, func() bool {
+		statuses := engine.BotStatuses()
+		return len(statuses) == 1 && statuses[0].State == bot.ConnectionConnected
+	}, time.Second, 5*time.Millisecond)
+
+	require.NotNil(t, weixin.loginRequired)
+	weixin.loginRequired("session expired")
+	assert.Equal(t, 1, telegram.dmCount)
+	assert.Equal(t, "1001", telegram.lastDMUser)
+	assert.Contains(t, telegram.lastDM, "relogin weixin")
+
+	admin := bot.BotMessage{Platform: "telegram", UserID: "1001", Channel: "-100200"}
+	engine.handleRelogin([]string{"weixin"}, admin)
+	assert.Equal(t, 2, telegram.dmCount)
+	assert.Equal(t, "1001", telegram.lastDMUser)
+	assert.Contains(t, telegram.lastDM, "https://login.example.com/qr")
+	assert.Equal(t, 0, telegram.messageCount)
+
+	weixin.done <- nil
+	assert.Eventually(t, func() bool {
+		count, _, _ := telegram.lastDirect()
+		return count == 3
+	}, time.Second, 5*time.Millisecond)
+	_, userID, message := telegram.lastDirect()
+	assert.Equal(t, "1001", userID)
+	assert.Contains(t, message, "weixin bot logged in")
+	assert.Equal(t, 0, telegram.messageCount)
+
+	// Non-admins and bots without QR login are rejected in the channel
+	engine.handleRelogin([]string{"weixin"}, bot.BotMessage{Platform: "telegram", UserID: "2002", Channel: "2002"})
+	assert.Contains(t, telegram.lastMessage, "admin only")
+	engine.handleRelogin([]string{"telegram"}, admin)
+	assert.Equal(t, "-100200", telegram.lastChannel)
+	assert.Contains(t, telegram.lastMessage, "does not support QR login")
+}
@@ -173,6 +173,38 @@ func TestEngine_ListSessions_WithUserSession(t *testing.T) {
 	assert.Contains(t, mockBot.lastMessage, "session1")
 }
 
+// mockSessionListBot is a mock bot adapter that renders session pickers
+type mockSessionListBot struct {
+	mockBotAdapter
+	listMessage string
+	sessions    []bot.SessionInfo
+	current     string
+}
+
+func (m *mockSessionListBot) SendSessionList(channel, message string, sessions []bot.SessionInfo, current string) error {
+	m.listMessage = message
+	m.sessions = sessions
+	m.current = current
+	return nil
+}
+
+// TestEngine_ListSessions_SessionListSender tests that slist passes the sessions to adapters with pickers
+func TestEngine_ListSessions_SessionListSender(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	defer engine.cancel()
+	picker := &mockSessionListBot{}
+	engine.RegisterBotAdapter("telegram", picker)
+	engine.userSessions[getUserKey("telegram", "u1")] = "side"
+
+	engine.listSessions(bot.BotMessage{Platform: "telegram", Channel: "100", UserID: "u1"})
+
+	assert.Equal(t, 0, picker.messageCount)
+	assert.Contains(t, picker.listMessage, "Your current session: **side**")
+	require.Len(t, picker.sessions, 2)
+	assert.Equal(t, "main", picker.sessions[0].Name)
+	assert.Equal(t, "side", picker.current)
+}
+
 // TestEngine_EnsureSessionStarted_NonExistentSession tests ensureSessionStarted with non-existent session
 func TestEngine_EnsureSessionStarted_NonExistentSession(t *testing.T) {
 	config := &Config{
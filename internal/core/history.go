@@ -0,0 +1,328 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/internal/cli"
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// historyPageLimits are the message limits history replies are paginated to;
+// other platforms use constants.HistoryDefaultPageLength
+var historyPageLimits = map[string]int{
+	"discord":  constants.MaxDiscordMessageLength,
+	"telegram": constants.MaxTelegramMessageLength,
+	"weixin":   constants.MaxWeixinMessageLength,
+}
+
+// historySnippetContext is how much text ssearch shows around a match
+const historySnippetContext = 150
+
+// currentSession returns the session a command from msg applies to: the
+// session the message is bound to, else the user's selected session
+func (e *Engine) currentSession(msg bot.BotMessage) *Session {
+	e.sessionMu.RLock()
+	defer e.sessionMu.RUnlock()
+
+	sessionName := msg.Session
+	if sessionName == "" {
+		sessionName = e.userSessions[getUserKey(msg.Platform, msg.UserID)]
+	}
+	return e.sessions[sessionName]
+}
+
+// readHistory reads the turns of a session's conversation from its CLI
+func (e *Engine) readHistory(session *Session) ([]cli.HistoryTurn, error) {
+	reader, ok := e.cliAdapters[session.CLIType].(cli.HistoryReader)
+	if !ok {
+		return nil, fmt.Errorf("%s sessions do not keep a readable history", session.CLIType)
+	}
+	if session.Host != "" {
+		return nil, fmt.Errorf("session '%s' runs on %s, whose history cannot be read here", session.Name, session.Host)
+	}
+	if session.Sandbox != nil {
+		return nil, fmt.Errorf("session '%s' runs in a container, whose history cannot be read here", session.Name)
+	}
+	workDir, err := expandHome(session.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	return reader.History(session.Name, workDir)
+}
+
+// handleHistory shows the last exchanges of the current session, or exports
+// them as a markdown file
+// Usage: shistory [n] [export]
+func (e *Engine) handleHistory(args []string, msg bot.BotMessage) {
+	count, export := 0, false
+	for _, arg := range args {
+		if arg == "export" {
+			export = true
+			continue
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			e.SendToBot(msg.Platform, msg.Channel, "❌ Usage: shistory [n] [export]")
+			return
+		}
+		count = n
+	}
+
+	session, turns, ok := e.sessionHistory(msg)
+	if !ok {
+		return
+	}
+
+	if export {
+		// Exports hold the whole conversation unless a count is given
+		if count > 0 && count < len(turns) {
+			turns = turns[len(turns)-count:]
+		}
+		e.exportHistory(session, turns, msg)
+		return
+	}
+
+	if count == 0 {
+		count = constants.HistoryDefaultTurns
+	}
+	count = min(count, constants.HistoryMaxTurns, len(turns))
+	start := len(turns) - count
+
+	blocks := make([]string, 0, count)
+	for i, turn := range turns[start:] {
+		blocks = append(blocks, formatHistoryTurn(start+i+1, turn.Prompt,
+			truncateHistoryText(turn.Response, constants.HistoryTurnPreviewLength)))
+	}
+	header := fmt.Sprintf("📜 [%s] Last %d of %d exchanges", session.Name, count, len(turns))
+	e.sendPaged(msg, header, blocks)
+}
+
+// handleSearch lists the current session's turns containing the given text,
+// newest first
+// Usage: ssearch <text>
+func (e *Engine) handleSearch(args []string, msg bot.BotMessage) {
+	query := strings.Join(args, " ")
+	if query == "" {
+		e.SendToBot(msg.Platform, msg.Channel, "❌ Usage: ssearch <text>")
+		return
+	}
+
+	session, turns, ok := e.sessionHistory(msg)
+	if !ok {
+		return
+	}
+
+	var blocks []string
+	matches := 0
+	for i := len(turns) - 1; i >= 0; i-- {
+		turn := turns[i]
+		promptAt := indexFold(turn.Prompt, query)
+		responseAt := indexFold(turn.Response, query)
+		if promptAt < 0 && responseAt < 0 {
+			continue
+		}
+		matches++
+		if len(blocks) < constants.HistorySearchMaxResults {
+			blocks = append(blocks, formatHistoryTurn(i+1,
+				historySnippet(turn.Prompt, promptAt, len(query)),
+				historySnippet(turn.Response, responseAt, len(query))))
+		}
+	}
+
+	if matches == 0 {
+		e.SendToBot(msg.Platform, msg.Channel,
+			fmt.Sprintf("🔎 [%s] No exchanges matching \"%s\"", session.Name, query))
+		return
+	}
+	header := fmt.Sprintf("🔎 [%s] %d exchanges matching \"%s\"", session.Name, matches, query)
+	if matches > len(blocks) {
+		header += fmt.Sprintf(" (newest %d shown)", len(blocks))
+	}
+	e.sendPaged(msg, header, blocks)
+}
+
+// sessionHistory resolves the current session and reads its history,
+// reporting problems to the user. ok is false when nothing should be shown.
+func (e *Engine) sessionHistory(msg bot.BotMessage) (*Session, []cli.HistoryTurn, bool) {
+	session := e.currentSession(msg)
+	if session == nil {
+		e.SendToBot(msg.Platform, msg.Channel,
+			"❌ You don't have an active session\nUse 'suse <name>' to select one")
+		return nil, nil, false
+	}
+
+	turns, err := e.readHistory(session)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"session": session.Name,
+			"error":   err,
+		}).Warn("failed-to-read-session-history")
+		e.SendToBot(msg.Platform, msg.Channel,
+			fmt.Sprintf("❌ Failed to read history of session '%s': %v", session.Name, err))
+		return nil, nil, false
+	}
+	if len(turns) == 0 {
+		e.SendToBot(msg.Platform, msg.Channel,
+			fmt.Sprintf("📭 Session '%s' has no conversation history yet", session.Name))
+		return nil, nil, false
+	}
+	return session, turns, true
+}
+
+// exportHistory writes turns to a markdown file and sends it as an attachment,
+// or tells the user where it was saved when the platform cannot receive files
+func (e *Engine) exportHistory(session *Session, turns []cli.HistoryTurn, msg bot.BotMessage) {
+	path, err := writeHistoryMarkdown(session, turns)
+	if err != nil {
+		e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("❌ Failed to export history: %v", err))
+		return
+	}
+
+	if sender, ok := e.activeBots[msg.Platform].(bot.FileSender); ok {
+		err := sender.SendFile(msg.Channel, path)
+		if err == nil {
+			os.Remove(path)
+			return
+		}
+		logger.WithFields(logrus.Fields{
+			"platform": msg.Platform,
+			"session":  session.Name,
+			"error":    err,
+		}).Warn("failed-to-send-history-export")
+	}
+
+	e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf(
+		"📄 Exported %d exchanges of '%s' to %s on the clibot host (the file could not be sent here)",
+		len(turns), session.Name, path))
+}
+
+// writeHistoryMarkdown writes turns as a markdown document to the temp directory
+func writeHistoryMarkdown(session *Session, turns []cli.HistoryTurn) (string, error) {
+	now := time.Now()
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", session.Name)
+	fmt.Fprintf(&b, "- CLI: %s\n- Work dir: %s\n- Exported: %s\n- Exchanges: %d\n",
+		session.CLIType, session.WorkDir, now.Format("2006-01-02 15:04:05"), len(turns))
+	for i, turn := range turns {
+		fmt.Fprintf(&b, "\n## Exchange %d\n\n**Prompt**\n\n%s\n\n**Reply**\n\n%s\n",
+			i+1, turn.Prompt, turn.Response)
+	}
+
+	name := fmt.Sprintf("clibot-history-%s-%s.md", session.Name, now.Format("20060102-150405"))
+	path := filepath.Join(os.TempDir(), name)
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// formatHistoryTurn formats one exchange for chat, numbered by its position
+// in the conversation
+func formatHistoryTurn(n int, prompt, response string) string {
+	prompt = truncateHistoryText(prompt, constants.HistoryTurnPreviewLength)
+	if response == "" {
+		response = "(no reply)"
+	}
+	return fmt.Sprintf("#%d 👤 %s\n🤖 %s", n, prompt, response)
+}
+
+// truncateHistoryText cuts text longer than maxLen bytes at a rune boundary
+func truncateHistoryText(text string, maxLen int) string {
+	if len(text) <= maxLen {
+		return text
+	}
+	cut := runeBoundary(text, maxLen)
+	return text[:cut] + fmt.Sprintf("… (%d more chars, use 'shistory export' for the full text)", len(text)-cut)
+}
+
+// historySnippet returns the text around a match at index at (of length
+// matchLen), or the start of text when it does not contain the match
+func historySnippet(text string, at, matchLen int) string {
+	if at < 0 {
+		return truncateHistoryText(text, 2*historySnippetContext)
+	}
+	start := runeBoundary(text, max(at-historySnippetContext, 0))
+	end := runeBoundary(text, min(at+matchLen+historySnippetContext, len(text)))
+	snippet := strings.TrimSpace(text[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// indexFold returns the index of the first case-insensitive match of substr
+// in s, or -1
+func indexFold(s, substr string) int {
+	return strings.Index(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// sendPaged sends the header and blocks in as few messages as fit the
+// platform's limit, numbering the pages when there are several
+func (e *Engine) sendPaged(msg bot.BotMessage, header string, blocks []string) {
+	limit, ok := historyPageLimits[msg.Platform]
+	if !ok {
+		limit = constants.HistoryDefaultPageLength
+	}
+	pages := paginate(append([]string{header}, blocks...), limit)
+	for i, page := range pages {
+		if len(pages) > 1 {
+			page = fmt.Sprintf("(%d/%d) %s", i+1, len(pages), page)
+		}
+		e.SendToBot(msg.Platform, msg.Channel, page)
+	}
+}
+
+// paginate joins blocks with blank lines into pages of at most limit bytes,
+// leaving room for a page number. Blocks longer than a page are split.
+func paginate(blocks []string, limit int) []string {
+	limit -= len("(99/99) ")
+	var pages []string
+	var page string
+	for _, block := range blocks {
+		for len(block) > limit {
+			if page != "" {
+				pages = append(pages, page)
+				page = ""
+			}
+			cut := runeBoundary(block, limit)
+			pages = append(pages, block[:cut])
+			block = block[cut:]
+		}
+		switch {
+		case page == "":
+			page = block
+		case len(page)+2+len(block) <= limit:
+			page += "\n\n" + block
+		default:
+			pages = append(pages, page)
+			page = block
+		}
+	}
+	if page != "" {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// runeBoundary returns the largest index <= i that starts a rune in s
+func runeBoundary(s string, i int) int {
+	if i >= len(s) {
+		return len(s)
+	}
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/internal/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// historyCLIAdapter is a mock CLI adapter with a fixed conversation history
+type historyCLIAdapter struct {
+	*mockCLIAdapter
+	turns []cli.HistoryTurn
+}
+
+func (a *historyCLIAdapter) History(sessionName, workDir string) ([]cli.HistoryTurn, error) {
+	return a.turns, nil
+}
+
+// mockFileBot is a mock bot adapter that records every message and file sent
+type mockFileBot struct {
+	mockBotAdapter
+	mu       sync.Mutex
+	messages []string
+	files    []string // Contents of the files sent
+}
+
+func (m *mockFileBot) SendMessage(channel, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	return m.mockBotAdapter.SendMessage(channel, message)
+}
+
+// sent returns a copy of the messages, for replies sent from other goroutines
+func (m *mockFileBot) sent() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.messages...)
+}
+
+func (m *mockFileBot) SendFile(channel, filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	m.files = append(m.files, string(data))
+	return nil
+}
+
+// newHistoryCLIAdapter returns a history adapter with turns numbered exchanges
+func newHistoryCLIAdapter(turns int) *historyCLIAdapter {
+	adapter := &historyCLIAdapter{mockCLIAdapter: newMockCLIAdapter()}
+	for i := 1; i <= turns; i++ {
+		adapter.turns = append(adapter.turns, cli.HistoryTurn{
+			Prompt:   fmt.Sprintf("question %d", i),
+			Response: fmt.Sprintf("answer %d", i),
+		})
+	}
+	return adapter
+}
+
+func historyMessage(content string) bot.BotMessage {
+	return bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1", Content: content}
+}
+
+// TestEngine_HandleHistory tests showing the latest exchanges of the current session
+func TestEngine_HandleHistory(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterCLIAdapter("claude", newHistoryCLIAdapter(8))
+	fileBot := &mockFileBot{}
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleSpecialCommandWithArgs("shistory", nil, historyMessage("shistory"))
+	require.Len(t, fileBot.messages, 1)
+	reply := fileBot.messages[0]
+	assert.Contains(t, reply, "📜 [main] Last 5 of 8 exchanges")
+	assert.Contains(t, reply, "#4 👤 question 4\n🤖 answer 4")
+	assert.Contains(t, reply, "#8 👤 question 8")
+	assert.NotContains(t, reply, "question 3")
+
+	fileBot.messages = nil
+	engine.HandleSpecialCommandWithArgs("shistory", []string{"2"}, historyMessage("shistory 2"))
+	require.Len(t, fileBot.messages, 1)
+	assert.Contains(t, fileBot.messages[0], "Last 2 of 8")
+
+	fileBot.messages = nil
+	engine.HandleSpecialCommandWithArgs("shistory", []string{"abc"}, historyMessage("shistory abc"))
+	assert.Equal(t, []string{"❌ Usage: shistory [n] [export]"}, fileBot.messages)
+}
+
+// TestEngine_HandleHistory_Paginates tests splitting long history to the platform limit
+func TestEngine_HandleHistory_Paginates(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterCLIAdapter("claude", newHistoryCLIAdapter(0))
+	fileBot := &mockFileBot{}
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	adapter := engine.cliAdapters["claude"].(*historyCLIAdapter)
+	for i := 0; i < 5; i++ {
+		adapter.turns = append(adapter.turns, cli.HistoryTurn{Prompt: "q", Response: strings.Repeat("x", 1000)})
+	}
+
+	engine.HandleSpecialCommandWithArgs("shistory", nil, historyMessage("shistory"))
+	require.Greater(t, len(fileBot.messages), 1)
+	for i, page := range fileBot.messages {
+		assert.LessOrEqual(t, len(page), 2000)
+		assert.True(t, strings.HasPrefix(page, fmt.Sprintf("(%d/%d) ", i+1, len(fileBot.messages))), page)
+	}
+}
+
+// TestEngine_HandleHistory_Export tests sending the conversation as a markdown file
+func TestEngine_HandleHistory_Export(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterCLIAdapter("claude", newHistoryCLIAdapter(3))
+	fileBot := &mockFileBot{}
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleSpecialCommandWithArgs("shistory", []string{"export"}, historyMessage("shistory export"))
+	require.Len(t, fileBot.files, 1)
+	assert.Empty(t, fileBot.messages)
+	assert.Contains(t, fileBot.files[0], "# main\n")
+	assert.Contains(t, fileBot.files[0], "## Exchange 1\n\n**Prompt**\n\nquestion 1\n\n**Reply**\n\nanswer 1\n")
+	assert.Contains(t, fileBot.files[0], "- Exchanges: 3")
+
+	engine.HandleSpecialCommandWithArgs("shistory", []string{"1", "export"}, historyMessage("shistory 1 export"))
+	require.Len(t, fileBot.files, 2)
+	assert.Contains(t, fileBot.files[1], "question 3")
+	assert.NotContains(t, fileBot.files[1], "question 2")
+}
+
+// TestEngine_HandleHistory_ExportWithoutFileSupport tests the saved-path fallback
+func TestEngine_HandleHistory_ExportWithoutFileSupport(t *testing.T) {
+	engine, _, plain := newMessageTestEngine()
+	engine.RegisterCLIAdapter("claude", newHistoryCLIAdapter(1))
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleSpecialCommandWithArgs("shistory", []string{"export"}, historyMessage("shistory export"))
+	assert.Contains(t, plain.lastMessage, "📄 Exported 1 exchanges of 'main' to ")
+	path := strings.Fields(strings.TrimPrefix(plain.lastMessage, "📄 Exported 1 exchanges of 'main' to "))[0]
+	defer os.Remove(path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "answer 1")
+}
+
+// TestEngine_HandleHistory_Errors tests sessions without history support or selection
+func TestEngine_HandleHistory_Errors(t *testing.T) {
+	engine, _, botAdapter := newMessageTestEngine()
+
+	engine.HandleSpecialCommandWithArgs("shistory", nil, historyMessage("shistory"))
+	assert.Contains(t, botAdapter.lastMessage, "You don't have an active session")
+
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	engine.HandleSpecialCommandWithArgs("shistory", nil, historyMessage("shistory"))
+	assert.Contains(t, botAdapter.lastMessage, "claude sessions do not keep a readable history")
+
+	engine.RegisterCLIAdapter("claude", newHistoryCLIAdapter(0))
+	engine.HandleSpecialCommandWithArgs("ssearch", []string{"x"}, historyMessage("ssearch x"))
+	assert.Equal(t, "📭 Session 'main' has no conversation history yet", botAdapter.lastMessage)
+}
+
+// TestEngine_HandleSearch tests finding exchanges by text, newest first
+func TestEngine_HandleSearch(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	adapter := newHistoryCLIAdapter(0)
+	engine.RegisterCLIAdapter("claude", adapter)
+	fileBot := &mockFileBot{}
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	adapter.turns = []cli.HistoryTurn{
+		{Prompt: "add a migration", Response: "Created 001_init.sql"},
+		{Prompt: "run the tests", Response: "All green"},
+		{Prompt: "rename the table", Response: strings.Repeat("a", 400) + " Updated the Migration file " + strings.Repeat("b", 400)},
+	}
+
+	engine.HandleSpecialCommandWithArgs("ssearch", []string{"migration"}, historyMessage("ssearch migration"))
+	require.Len(t, fileBot.messages, 1)
+	reply := fileBot.messages[0]
+	assert.Contains(t, reply, `🔎 [main] 2 exchanges matching "migration"`)
+	assert.Less(t, strings.Index(reply, "#3 "), strings.Index(reply, "#1 "), "newest first")
+	assert.NotContains(t, reply, "#2 ")
+	assert.Contains(t, reply, "…"+strings.Repeat("a", 10))
+	assert.Contains(t, reply, "Updated the Migration file")
+	assert.Less(t, len(reply), 800)
+
+	fileBot.messages = nil
+	engine.HandleSpecialCommandWithArgs("ssearch", []string{"nothing", "here"}, historyMessage("ssearch nothing here"))
+	assert.Equal(t, []string{`🔎 [main] No exchanges matching "nothing here"`}, fileBot.messages)
+
+	fileBot.messages = nil
+	engine.HandleSpecialCommandWithArgs("ssearch", nil, historyMessage("ssearch"))
+	assert.Equal(t, []string{"❌ Usage: ssearch <text>"}, fileBot.messages)
+}
+
+func TestPaginate(t *testing.T) {
+	assert.Equal(t, []string{"a\n\nb"}, paginate([]string{"a", "b"}, 100))
+
+	pages := paginate([]string{strings.Repeat("a", 30), strings.Repeat("b", 30)}, 50)
+	assert.Equal(t, []string{strings.Repeat("a", 30), strings.Repeat("b", 30)}, pages)
+
+	// Oversized blocks are split at rune boundaries
+	pages = paginate([]string{strings.Repeat("é", 40)}, 50)
+	require.Len(t, pages, 2)
+	assert.Equal(t, strings.Repeat("é", 40), pages[0]+pages[1])
+}
+
+func TestIsSpecialCommand_History(t *testing.T) {
+	cmd, ok, args := isSpecialCommand("ssearch failing test")
+	assert.True(t, ok)
+	assert.Equal(t, "ssearch", cmd)
+	assert.Equal(t, []string{"failing", "test"}, args)
+
+	cmd, ok, _ = isSpecialCommand("shistory")
+	assert.True(t, ok)
+	assert.Equal(t, "shistory", cmd)
+}
@@ -1,46 +1,315 @@
 package core
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/keepmind9/clibot/internal/cli"
 	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/watchdog"
+	"github.com/keepmind9/clibot/pkg/constants"
 	"github.com/sirupsen/logrus"
 )
 
+// HookEndpoint tells "clibot hook" where the running hook server listens and
+// which secret to send. It is written at startup and removed on shutdown.
+type HookEndpoint struct {
+	URL    string `json:"url"`              // Base URL, e.g. http://127.0.0.1:8080
+	Socket string `json:"socket,omitempty"` // Unix socket path (URL host is ignored)
+	Secret string `json:"secret"`           // Value for the constants.HookSecretHeader header
+}
+
+// DefaultHookEndpointPath returns the file the hook endpoint is published to
+func DefaultHookEndpointPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".clibot", "hook.json")
+}
+
+// LoadHookEndpoint reads the endpoint published by a running clibot service
+func LoadHookEndpoint(path string) (HookEndpoint, error) {
+	var endpoint HookEndpoint
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return endpoint, fmt.Errorf("read hook endpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, &endpoint); err != nil {
+		return endpoint, fmt.Errorf("parse hook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// writeHookEndpoint publishes the endpoint with owner-only permissions
+func writeHookEndpoint(path string, endpoint HookEndpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create hook endpoint directory: %w", err)
+	}
+	data, err := json.MarshalIndent(endpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write hook endpoint: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// generateHookSecret returns a random hex secret for hook requests
+func generateHookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate hook secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// listenHook opens the hook listener: a unix socket when configured,
+// otherwise TCP on host:port (127.0.0.1 by default)
+func listenHook(cfg HookServerConfig) (net.Listener, HookEndpoint, error) {
+	if cfg.Socket != "" {
+		socket, err := expandHome(cfg.Socket)
+		if err != nil {
+			return nil, HookEndpoint{}, err
+		}
+		// Remove a stale socket left by a previous run
+		os.Remove(socket)
+		listener, err := net.Listen("unix", socket)
+		if err != nil {
+			return nil, HookEndpoint{}, err
+		}
+		os.Chmod(socket, 0600)
+		return listener, HookEndpoint{URL: "http://unix", Socket: socket}, nil
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(cfg.Host, fmt.Sprintf("%d", cfg.Port)))
+	if err != nil {
+		return nil, HookEndpoint{}, err
+	}
+	host := cfg.Host
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = DefaultHookHost
+	}
+	url := "http://" + net.JoinHostPort(host, fmt.Sprintf("%d", listener.Addr().(*net.TCPAddr).Port))
+	return listener, HookEndpoint{URL: url}, nil
+}
+
 // startHookServer starts the HTTP hook server in a separate goroutine
 // This server listens for completion notifications from CLI tools
+//
+// Requests must carry the secret generated here, which is published together
+// with the listen address in the hook endpoint file read by "clibot hook"
 func (e *Engine) startHookServer() {
-	addr := fmt.Sprintf(":%d", e.config.HookServer.Port)
+	secret, err := generateHookSecret()
+	if err != nil {
+		logger.Errorf("hook-server-error: %v", err)
+		return
+	}
+
+	listener, endpoint, err := listenHook(e.config.HookServer)
+	if err != nil {
+		logger.Errorf("hook-server-error: %v", err)
+		return
+	}
+	endpoint.Secret = secret
 
 	// Create HTTP server instance
 	mux := http.NewServeMux()
 	mux.HandleFunc("/hook", e.handleHookRequest)
 
-	e.hookServer = &http.Server{
-		Addr:    addr,
-		Handler: mux,
+	e.sessionMu.Lock()
+	e.hookSecret = secret
+	e.hookServer = &http.Server{Handler: mux}
+	server := e.hookServer
+	e.sessionMu.Unlock()
+
+	if err := writeHookEndpoint(e.hookEndpointPath, endpoint); err != nil {
+		logger.WithField("error", err).Error("failed-to-publish-hook-endpoint")
 	}
+	defer os.Remove(e.hookEndpointPath)
+	unpublishRemote := e.publishRemoteHookEndpoints(secret)
+	defer unpublishRemote()
 
-	logger.WithField("address", addr).Info("hook-server-listening")
+	logger.WithFields(logrus.Fields{
+		"address":  listener.Addr().String(),
+		"endpoint": e.hookEndpointPath,
+	}).Info("hook-server-listening")
 
 	// Start server (blocking)
-	// When Shutdown() is called, ListenAndServe will return ErrServerClosed
-	if err := e.hookServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	// When Shutdown() is called, Serve will return ErrServerClosed
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 		logger.Errorf("hook-server-error: %v", err)
 	}
 
 	logger.Info("hook-server-stopped")
 }
 
+// authorizeHook checks the shared secret header of a hook request
+func (e *Engine) authorizeHook(r *http.Request) bool {
+	e.sessionMu.RLock()
+	secret := e.hookSecret
+	e.sessionMu.RUnlock()
+
+	provided := r.Header.Get(constants.HookSecretHeader)
+	return secret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(secret)) == 1
+}
+
+// matchHookSession finds the session a hook belongs to. In order of preference:
+//  1. the tmux session name reported by "clibot hook" (tmux sessions are named after sessions)
+//  2. a CLI session ID previously matched to a session
+//  3. the session whose resolved work_dir is the longest prefix of the reported cwd,
+//     preferring sessions of the hook's CLI type
+//
+// Successful matches remember the CLI session ID for later hooks.
+func (e *Engine) matchHookSession(cliType, tmuxSession, cliSessionID, cwd string) (*Session, string, error) {
+	e.sessionMu.Lock()
+	defer e.sessionMu.Unlock()
+
+	remember := func(session *Session) {
+		if cliSessionID != "" {
+			e.hookSessionIDs[cliSessionID] = session.Name
+		}
+	}
+
+	if session, ok := e.sessions[tmuxSession]; ok && tmuxSession != "" {
+		remember(session)
+		return session, "tmux_session", nil
+	}
+	if name, ok := e.hookSessionIDs[cliSessionID]; ok && cliSessionID != "" {
+		if session, ok := e.sessions[name]; ok {
+			return session, "session_id", nil
+		}
+	}
+
+	if cwd == "" {
+		return nil, "", fmt.Errorf("no tmux session or cwd reported")
+	}
+	target := resolvePath(cwd)
+
+	var candidates []*Session
+	longest := -1
+	for _, s := range e.sessions {
+		if s.WorkDir == "" {
+			continue
+		}
+		dir := resolvePath(s.WorkDir)
+		if target != dir && !strings.HasPrefix(target, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator)) {
+			continue
+		}
+		switch {
+		case len(dir) > longest:
+			candidates, longest = []*Session{s}, len(dir)
+		case len(dir) == longest:
+			candidates = append(candidates, s)
+		}
+	}
+
+	// Several sessions in the same directory: narrow down by CLI type
+	if len(candidates) > 1 {
+		var sameCLI []*Session
+		for _, s := range candidates {
+			if s.CLIType == cliType {
+				sameCLI = append(sameCLI, s)
+			}
+		}
+		if len(sameCLI) > 0 {
+			candidates = sameCLI
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, "", fmt.Errorf("no session has a work_dir containing %s", cwd)
+	case 1:
+		remember(candidates[0])
+		return candidates[0], "work_dir", nil
+	}
+	names := make([]string, 0, len(candidates))
+	for _, s := range candidates {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	return nil, "", fmt.Errorf("%s matches several sessions (%s); run the CLI inside its clibot tmux session",
+		cwd, strings.Join(names, ", "))
+}
+
+// shouldAlertUnmatchedHook reports whether a hook that matches no session is worth
+// an admin alert. Hooks naming a tmux session or CLI session ID point at a broken
+// session and always alert; bare hooks, e.g. from a CLI started outside clibot,
+// alert once per cli_type and cwd every UnmatchedHookAlertCooldown
+func (e *Engine) shouldAlertUnmatchedHook(cliType, tmuxSession, cliSessionID, cwd string) bool {
+	if tmuxSession != "" || cliSessionID != "" {
+		return true
+	}
+	if cwd != "" {
+		cwd = resolvePath(cwd)
+	}
+	key := cliType + ":" + cwd
+	now := time.Now()
+
+	e.sessionMu.Lock()
+	defer e.sessionMu.Unlock()
+	if last, ok := e.unmatchedAlerts[key]; ok && now.Sub(last) < constants.UnmatchedHookAlertCooldown {
+		return false
+	}
+	for k, last := range e.unmatchedAlerts {
+		if now.Sub(last) >= constants.UnmatchedHookAlertCooldown {
+			delete(e.unmatchedAlerts, k)
+		}
+	}
+	e.unmatchedAlerts[key] = now
+	return true
+}
+
+// formatUnmatchedHookAlert builds the admin alert for a hook that matches no
+// session, with what the hook reported so the session config can be fixed
+func formatUnmatchedHookAlert(cliType, tmuxSession, cliSessionID, cwd string, err error) string {
+	orNone := func(value string) string {
+		if value == "" {
+			return "(none)"
+		}
+		return value
+	}
+	resolved := ""
+	if cwd != "" {
+		resolved = resolvePath(cwd)
+	}
+	return fmt.Sprintf("⚠️ Ignored %s hook: %v\n\ncli_type: %s\ntmux_session: %s\nsession_id: %s\ncwd: %s",
+		orNone(cliType), err, orNone(cliType), orNone(tmuxSession), orNone(cliSessionID), orNone(resolved))
+}
+
+// resolvePath makes a path absolute and resolves symlinks where possible,
+// so equivalent directories compare equal
+func resolvePath(path string) string {
+	if expanded, err := expandPath(path); err == nil {
+		path = expanded
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	return filepath.Clean(path)
+}
+
 // handleHookRequest handles HTTP hook requests from CLI tools
 //
 // This function:
-// 1. Validates the request (POST method, cli_type parameter)
+// 1. Validates the request (POST method, shared secret, cli_type parameter)
 // 2. Reads raw data from request body
 // 3. Delegates parsing to CLI adapter (protocol-agnostic)
-// 4. Matches session by identifier (e.g., working directory)
+// 4. Matches session by tmux session name, CLI session ID or working directory
 // 5. Captures response from tmux (with retry mechanism)
 // 6. Sends response to user via bot
 //
@@ -55,6 +324,12 @@ func (e *Engine) handleHookRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !e.authorizeHook(r) {
+		logger.WithField("remote", r.RemoteAddr).Warn("rejected-hook-request-invalid-secret")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	// Get cli_type from query parameter (used for routing to correct adapter)
 	cliType := r.URL.Query().Get("cli_type")
 	if cliType == "" {
@@ -92,6 +367,19 @@ func (e *Engine) handleHookRequest(w http.ResponseWriter, r *http.Request) {
 		"hook_data": string(data),
 	}).Debug("hook-data-received")
 
+	// Transcripts of sessions on hosts are read through a local copy
+	query := r.URL.Query()
+	data, err = e.mirrorRemoteTranscript(query.Get("tmux_session"), data)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"cli_type":     cliType,
+			"tmux_session": query.Get("tmux_session"),
+			"error":        err,
+		}).Error("failed-to-mirror-remote-transcript")
+		http.Error(w, "Failed to read remote transcript", http.StatusBadGateway)
+		return
+	}
+
 	// Delegate to CLI adapter (protocol-agnostic)
 	// The adapter parses the data and returns: (identifier, lastUserPrompt, response, error)
 	// identifier is used to match the session (e.g., cwd, session name, etc.)
@@ -105,33 +393,30 @@ func (e *Engine) handleHookRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Match identifier (cwd) to actual tmux session
-	// The adapter returns an identifier (e.g., cwd), and we find the matching session
-	e.sessionMu.RLock()
-	var session *Session
-	for _, s := range e.sessions {
-		// Normalize paths for comparison
-		normalizedWorkDir := normalizePath(s.WorkDir)
-		normalizedIdentifier := normalizePath(identifier)
-
-		if normalizedWorkDir == normalizedIdentifier {
-			session = s
-			break
+	// Match the hook to a session: tmux session name, then a CLI session ID seen
+	// before, then the longest work_dir containing the reported cwd
+	session, matchedBy, err := e.matchHookSession(cliType, query.Get("tmux_session"), query.Get("session_id"), identifier)
+	if err != nil {
+		fields := logrus.Fields{
+			"cli_type":     cliType,
+			"identifier":   identifier,
+			"tmux_session": query.Get("tmux_session"),
+			"error":        err,
+		}
+		if e.shouldAlertUnmatchedHook(cliType, query.Get("tmux_session"), query.Get("session_id"), identifier) {
+			logger.WithFields(fields).Warn("no-session-found-matching-hook")
+			e.notifyAdmins("", formatUnmatchedHookAlert(cliType, query.Get("tmux_session"), query.Get("session_id"), identifier, err))
+		} else {
+			logger.WithFields(fields).Debug("no-session-found-matching-hook")
 		}
-	}
-	e.sessionMu.RUnlock()
-
-	if session == nil {
-		logger.WithFields(logrus.Fields{
-			"identifier": identifier,
-		}).Warn("no-session-found-matching-identifier")
 		http.Error(w, "Session not found", http.StatusNotFound)
 		return
 	}
 
 	logger.WithFields(logrus.Fields{
-		"session":  session.Name,
-		"work_dir": session.WorkDir,
+		"session":    session.Name,
+		"work_dir":   session.WorkDir,
+		"matched_by": matchedBy,
 	}).Debug("hook-matched-to-session")
 
 	// Ignore hook requests for ACP sessions (they use ACP protocol, not hooks)
@@ -145,6 +430,40 @@ func (e *Engine) handleHookRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Subagent, tool and session lifecycle events, each opted into per session
+	if parser, ok := adapter.(cli.HookEventParser); ok {
+		if event, isEvent := parser.ParseHookEvent(data); isEvent {
+			e.handleHookEvent(w, r, session, event, response)
+			return
+		}
+	}
+
+	// Notification events (e.g. a permission prompt) become chat alerts
+	if parser, ok := adapter.(cli.NotificationParser); ok {
+		if message, isNotification := parser.ParseNotification(data); isNotification {
+			e.handleHookNotification(session, message)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, "Notification received")
+			return
+		}
+	}
+
+	// Polling sessions read the response from the pane instead
+	if session.Mode == SessionModePoll {
+		logger.WithField("session", session.Name).Debug("ignoring-hook-response-for-poll-mode-session")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Hook received (poll mode - response ignored)")
+		return
+	}
+
+	// Watched sessions read the response from the transcript instead
+	if session.Completion == CompletionWatch {
+		logger.WithField("session", session.Name).Debug("ignoring-hook-response-for-watched-session")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Hook received (completion watch - response ignored)")
+		return
+	}
+
 	// If adapter returned empty response, return error to user
 	if response == "" {
 		logger.WithFields(logrus.Fields{
@@ -180,8 +499,11 @@ func (e *Engine) handleHookRequest(w http.ResponseWriter, r *http.Request) {
 		"session":  session.Name,
 	}).Info("sending-hook-response-to-bot")
 
-	// Send the message
-	e.SendToBot(botChannel.Platform, botChannel.Channel, response)
+	// Send the message, replacing the tool feed if one was shown
+	e.clearToolFeed(session.Name)
+	if !e.finishProgress(session.Name, botChannel, response) {
+		e.SendToBot(botChannel.Platform, botChannel.Channel, response)
+	}
 
 	// Remove typing indicator after a short delay if supported
 	if botChannel.MessageID != "" {
@@ -192,6 +514,69 @@ func (e *Engine) handleHookRequest(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Hook received")
 }
 
+// handleHookNotification alerts the session's chat that the CLI is waiting,
+// with the notification message and the tail of the tmux pane, and marks the
+// session as waiting for input. Replies such as "1", "enter" or "esc" are
+// delivered to the prompt like any other input.
+func (e *Engine) handleHookNotification(session *Session, message string) {
+	e.updateSessionState(session.Name, StateWaitingInput)
+
+	e.sessionMu.RLock()
+	botChannel, exists := e.sessionChannels[session.Name]
+	e.sessionMu.RUnlock()
+	if !exists {
+		logger.WithField("session", session.Name).Debug("no-active-channel-found-skipping-notification-alert")
+		return
+	}
+
+	pane, err := e.capturePane(session.Name, constants.NotificationCaptureLines)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"session": session.Name,
+			"error":   err,
+		}).Warn("failed-to-capture-pane-for-notification")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"session":  session.Name,
+		"platform": botChannel.Platform,
+		"message":  message,
+	}).Info("sending-notification-alert-to-bot")
+	e.SendToBot(botChannel.Platform, botChannel.Channel, formatNotificationAlert(session.Name, message, pane))
+
+	if botChannel.MessageID != "" {
+		e.removeTypingIndicatorAsync(botChannel.Platform, botChannel.MessageID)
+	}
+}
+
+// formatNotificationAlert builds the chat alert for a CLI notification, listing
+// the quick replies for numbered prompt options found in the pane
+func formatNotificationAlert(sessionName, message, pane string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔔 [%s] %s\n", sessionName, message)
+
+	pane = strings.Trim(pane, "\n")
+	if strings.TrimSpace(pane) != "" {
+		lines := strings.Split(pane, "\n")
+		if len(lines) > constants.NotificationAlertLines {
+			lines = lines[len(lines)-constants.NotificationAlertLines:]
+		}
+		fmt.Fprintf(&b, "\n```\n%s\n```\n", strings.Join(lines, "\n"))
+	}
+
+	options := watchdog.ParsePromptOptions(pane)
+	if len(options) > 0 {
+		b.WriteString("\nReply with:\n")
+		for _, option := range options {
+			fmt.Fprintf(&b, "  %s → %s\n", option.Key, option.Label)
+		}
+		b.WriteString("  esc → cancel")
+	} else {
+		b.WriteString("\nReply 'enter' to confirm, 'esc' to cancel, or type an answer")
+	}
+	return b.String()
+}
+
 // truncateString truncates a string to a maximum length
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -0,0 +1,285 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/internal/cli"
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// Permission decisions returned to Claude Code for PreToolUse hooks
+const (
+	decisionAllow = "allow"
+	decisionDeny  = "deny"
+	decisionAsk   = "ask" // Fall back to the prompt in the terminal
+)
+
+// pendingApproval is a PreToolUse hook blocked until the user allows or denies the tool call
+type pendingApproval struct {
+	toolName string
+	decision chan toolDecision
+}
+
+// toolDecision is the user's answer to a pending approval
+type toolDecision struct {
+	decision string
+	reason   string
+}
+
+// preToolUseResponse is the hook output Claude Code expects from a PreToolUse hook
+type preToolUseResponse struct {
+	HookSpecificOutput struct {
+		HookEventName            string `json:"hookEventName"`
+		PermissionDecision       string `json:"permissionDecision"`
+		PermissionDecisionReason string `json:"permissionDecisionReason,omitempty"`
+	} `json:"hookSpecificOutput"`
+}
+
+// handleHookEvent handles the subagent, tool and session lifecycle hook events
+// a session opted into via hook_events. Events that are not enabled are
+// acknowledged without output, which leaves the CLI's default behavior intact.
+func (e *Engine) handleHookEvent(w http.ResponseWriter, r *http.Request, session *Session, event cli.HookEvent, response string) {
+	logger.WithFields(logrus.Fields{
+		"session": session.Name,
+		"event":   event.Name,
+		"tool":    event.ToolName,
+	}).Debug("hook-event-received")
+
+	events := session.HookEvents
+	switch event.Name {
+	case "SubagentStop":
+		if events.SubagentStop && strings.TrimSpace(response) != "" {
+			e.sendToSessionChannel(session.Name, fmt.Sprintf("🤖 [%s] Subagent finished\n\n%s", session.Name, response))
+		}
+	case "SessionStart":
+		if events.SessionLifecycle {
+			// Compaction restarts the session in the middle of a running request
+			if event.Detail != "compact" {
+				e.updateSessionState(session.Name, StateIdle)
+			}
+			e.sendToSessionChannel(session.Name, fmt.Sprintf("▶️ [%s] %s session started%s", session.Name, session.CLIType, formatEventDetail(event.Detail)))
+		}
+	case "SessionEnd":
+		if events.SessionLifecycle {
+			e.updateSessionState(session.Name, StateIdle)
+			e.clearToolFeed(session.Name)
+			e.sendToSessionChannel(session.Name, fmt.Sprintf("⏹️ [%s] %s session ended%s", session.Name, session.CLIType, formatEventDetail(event.Detail)))
+		}
+	case "PostToolUse":
+		if events.PostToolUse {
+			e.appendToolFeed(session.Name, formatToolCall(event))
+		}
+	case "PreToolUse":
+		if events.PreToolUse == PreToolUseOff || events.PreToolUse == "" || !isRiskyTool(events.RiskyTools, event.ToolName) {
+			break
+		}
+		if events.PreToolUse == PreToolUseNotify {
+			e.sendToSessionChannel(session.Name, fmt.Sprintf("⚠️ [%s] Running %s", session.Name, formatToolCall(event)))
+			break
+		}
+		decision := e.awaitToolApproval(r, session, event)
+		writePreToolUseDecision(w, decision)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// awaitToolApproval asks the session's chat to allow or deny a tool call and
+// blocks until the answer, the approval timeout or the hook client giving up.
+// Without a reply the decision is "ask", handing the call back to the terminal.
+func (e *Engine) awaitToolApproval(r *http.Request, session *Session, event cli.HookEvent) toolDecision {
+	timeout, err := time.ParseDuration(session.HookEvents.ApprovalTimeout)
+	if err != nil || timeout <= 0 {
+		timeout, _ = time.ParseDuration(DefaultApprovalTimeout)
+	}
+
+	approval := &pendingApproval{toolName: event.ToolName, decision: make(chan toolDecision, 1)}
+	if !e.sendToSessionChannel(session.Name, fmt.Sprintf(
+		"🛂 [%s] %s\n\nReply 'allow' or 'deny' (optionally with a reason) within %s",
+		session.Name, formatToolCall(event), timeout)) {
+		return toolDecision{decision: decisionAsk, reason: "No chat channel to ask for approval"}
+	}
+
+	e.sessionMu.Lock()
+	e.pendingApprovals[session.Name] = append(e.pendingApprovals[session.Name], approval)
+	e.sessionMu.Unlock()
+	defer e.removeApproval(session.Name, approval)
+
+	logger.WithFields(logrus.Fields{
+		"session": session.Name,
+		"tool":    event.ToolName,
+		"timeout": timeout,
+	}).Info("waiting-for-tool-approval")
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case decision := <-approval.decision:
+		return decision
+	case <-timer.C:
+		e.sendToSessionChannel(session.Name, fmt.Sprintf("⌛ [%s] No answer for %s, asking in the terminal instead", session.Name, event.ToolName))
+		return toolDecision{decision: decisionAsk, reason: "No answer from chat before the approval timeout"}
+	case <-r.Context().Done():
+		logger.WithFields(logrus.Fields{
+			"session": session.Name,
+			"tool":    event.ToolName,
+			"error":   r.Context().Err(),
+		}).Warn("tool-approval-cancelled-by-hook-client")
+		return toolDecision{decision: decisionAsk}
+	}
+}
+
+// answerToolApproval resolves the oldest pending approval of a session when the
+// message is an allow or deny reply. It returns true if the message was consumed.
+func (e *Engine) answerToolApproval(sessionName string, msg bot.BotMessage) bool {
+	fields := strings.Fields(msg.Content)
+	if len(fields) == 0 {
+		return false
+	}
+
+	var decision toolDecision
+	// Only the words the prompt asks for, so replies such as "no" meant for the CLI pass through
+	switch strings.ToLower(fields[0]) {
+	case "allow":
+		decision.decision = decisionAllow
+	case "deny":
+		decision.decision = decisionDeny
+	default:
+		return false
+	}
+	decision.reason = strings.TrimSpace(strings.Join(fields[1:], " "))
+
+	e.sessionMu.Lock()
+	pending := e.pendingApprovals[sessionName]
+	if len(pending) == 0 {
+		e.sessionMu.Unlock()
+		return false
+	}
+	approval := pending[0]
+	e.pendingApprovals[sessionName] = pending[1:]
+	e.sessionMu.Unlock()
+
+	verb := "Allowed"
+	if decision.decision == decisionDeny {
+		verb = "Denied"
+	}
+	if decision.reason == "" {
+		decision.reason = fmt.Sprintf("%s from chat by %s", verb, getUserKey(msg.Platform, msg.UserID))
+	}
+	approval.decision <- decision
+
+	logger.WithFields(logrus.Fields{
+		"session":  sessionName,
+		"tool":     approval.toolName,
+		"decision": decision.decision,
+		"user":     getUserKey(msg.Platform, msg.UserID),
+	}).Info("tool-approval-answered")
+
+	e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("%s %s", verb, approval.toolName))
+	return true
+}
+
+// removeApproval drops an approval that timed out or was abandoned
+func (e *Engine) removeApproval(sessionName string, approval *pendingApproval) {
+	e.sessionMu.Lock()
+	defer e.sessionMu.Unlock()
+
+	pending := e.pendingApprovals[sessionName]
+	for i, p := range pending {
+		if p == approval {
+			e.pendingApprovals[sessionName] = append(pending[:i:i], pending[i+1:]...)
+			break
+		}
+	}
+	if len(e.pendingApprovals[sessionName]) == 0 {
+		delete(e.pendingApprovals, sessionName)
+	}
+}
+
+// writePreToolUseDecision writes the decision JSON Claude Code reads from a PreToolUse hook
+func writePreToolUseDecision(w http.ResponseWriter, decision toolDecision) {
+	var out preToolUseResponse
+	out.HookSpecificOutput.HookEventName = "PreToolUse"
+	out.HookSpecificOutput.PermissionDecision = decision.decision
+	out.HookSpecificOutput.PermissionDecisionReason = decision.reason
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		logger.WithField("error", err).Warn("failed-to-write-pre-tool-use-decision")
+	}
+}
+
+// appendToolFeed adds a completed tool call to the session's progress feed,
+// shown as a single message edited in place on platforms that support it
+func (e *Engine) appendToolFeed(sessionName, line string) {
+	e.sessionMu.Lock()
+	feed := append(e.toolFeeds[sessionName], line)
+	if len(feed) > constants.ToolFeedLines {
+		feed = feed[len(feed)-constants.ToolFeedLines:]
+	}
+	e.toolFeeds[sessionName] = feed
+	message := "🔧 Tools:\n" + strings.Join(feed, "\n")
+	e.sessionMu.Unlock()
+
+	e.SendProgressToSession(sessionName, message)
+}
+
+// clearToolFeed forgets the progress feed once the response is delivered
+func (e *Engine) clearToolFeed(sessionName string) {
+	e.sessionMu.Lock()
+	delete(e.toolFeeds, sessionName)
+	e.sessionMu.Unlock()
+}
+
+// sendToSessionChannel sends a message to the session's active chat channel.
+// Returns false when no channel is bound to the session.
+func (e *Engine) sendToSessionChannel(sessionName, message string) bool {
+	e.sessionMu.RLock()
+	botChannel, exists := e.sessionChannels[sessionName]
+	e.sessionMu.RUnlock()
+	if !exists {
+		logger.WithField("session", sessionName).Debug("no-active-channel-found-skipping-hook-event")
+		return false
+	}
+	e.SendToBot(botChannel.Platform, botChannel.Channel, message)
+	return true
+}
+
+// isRiskyTool reports whether PreToolUse handling applies to the tool
+func isRiskyTool(riskyTools []string, toolName string) bool {
+	if len(riskyTools) == 0 {
+		riskyTools = DefaultRiskyTools
+	}
+	for _, tool := range riskyTools {
+		if tool == "*" || strings.EqualFold(tool, toolName) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatToolCall renders a tool call as "Bash: go test ./..."
+func formatToolCall(event cli.HookEvent) string {
+	if event.ToolInput == "" {
+		return event.ToolName
+	}
+	return fmt.Sprintf("%s: %s", event.ToolName, event.ToolInput)
+}
+
+// formatEventDetail renders a SessionStart source or SessionEnd reason
+func formatEventDetail(detail string) string {
+	if detail == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", detail)
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/internal/cli"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hookEventCLIAdapter is a mock CLI adapter reporting a fixed hook event
+type hookEventCLIAdapter struct {
+	*mockCLIAdapter
+	event    cli.HookEvent
+	response string
+}
+
+func (h *hookEventCLIAdapter) HandleHookData(data []byte) (string, string, string, error) {
+	return "/nonexistent", "", h.response, nil
+}
+
+func (h *hookEventCLIAdapter) ParseHookEvent(data []byte) (cli.HookEvent, bool) {
+	return h.event, h.event.Name != ""
+}
+
+func postHookEvent(engine *Engine) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/hook?cli_type=claude&tmux_session=main", strings.NewReader(`{}`))
+	req.Header.Set(constants.HookSecretHeader, "s3cret")
+	rec := httptest.NewRecorder()
+	engine.handleHookRequest(rec, req)
+	return rec
+}
+
+// TestEngine_HookEvent_Disabled tests that events a session did not opt into are acknowledged silently
+func TestEngine_HookEvent_Disabled(t *testing.T) {
+	engine, _, botAdapter := newMessageTestEngine()
+	engine.hookSecret = "s3cret"
+	engine.RegisterCLIAdapter("claude", &hookEventCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), event: cli.HookEvent{Name: "SubagentStop"}, response: "subagent done"})
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+	engine.sessions["main"].State = StateProcessing
+
+	rec := postHookEvent(engine)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, 0, botAdapter.messageCount)
+	// A subagent finishing does not end the main request
+	assert.Equal(t, StateProcessing, engine.sessions["main"].State)
+}
+
+// TestEngine_HookEvent_SubagentStop tests posting subagent summaries
+func TestEngine_HookEvent_SubagentStop(t *testing.T) {
+	engine, _, botAdapter := newMessageTestEngine()
+	engine.hookSecret = "s3cret"
+	engine.RegisterCLIAdapter("claude", &hookEventCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), event: cli.HookEvent{Name: "SubagentStop"}, response: "Found 3 call sites"})
+	engine.sessions["main"].HookEvents = HookEventsConfig{SubagentStop: true}
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+
+	rec := postHookEvent(engine)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "🤖 [main] Subagent finished\n\nFound 3 call sites", botAdapter.lastMessage)
+}
+
+// TestEngine_HookEvent_SessionLifecycle tests that SessionStart/SessionEnd update state and notify
+func TestEngine_HookEvent_SessionLifecycle(t *testing.T) {
+	engine, _, botAdapter := newMessageTestEngine()
+	engine.hookSecret = "s3cret"
+	engine.RegisterCLIAdapter("claude", &hookEventCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), event: cli.HookEvent{Name: "SessionEnd", Detail: "logout"}})
+	engine.sessions["main"].HookEvents = HookEventsConfig{SessionLifecycle: true}
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+	engine.sessions["main"].State = StateProcessing
+
+	postHookEvent(engine)
+
+	assert.Equal(t, StateIdle, engine.sessions["main"].State)
+	assert.Equal(t, "⏹️ [main] claude session ended (logout)", botAdapter.lastMessage)
+}
+
+// TestEngine_HookEvent_PreToolUseNotify tests notify mode and the risky tool filter
+func TestEngine_HookEvent_PreToolUseNotify(t *testing.T) {
+	engine, _, botAdapter := newMessageTestEngine()
+	engine.hookSecret = "s3cret"
+	engine.RegisterCLIAdapter("claude", &hookEventCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), event: cli.HookEvent{Name: "PreToolUse", ToolName: "Read", ToolInput: "main.go"}})
+	engine.sessions["main"].HookEvents = HookEventsConfig{PreToolUse: PreToolUseNotify, RiskyTools: []string{"Bash"}}
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+
+	rec := postHookEvent(engine)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, 0, botAdapter.messageCount)
+
+	engine.RegisterCLIAdapter("claude", &hookEventCLIAdapter{mockCLIAdapter: newMockCLIAdapter(),
+		event: cli.HookEvent{Name: "PreToolUse", ToolName: "Bash", ToolInput: "rm -rf build"}})
+	rec = postHookEvent(engine)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, "⚠️ [main] Running Bash: rm -rf build", botAdapter.lastMessage)
+}
+
+// TestEngine_HookEvent_PreToolUseApprove tests vetoing a tool call from chat
+func TestEngine_HookEvent_PreToolUseApprove(t *testing.T) {
+	engine, _, botAdapter := newMessageTestEngine()
+	engine.hookSecret = "s3cret"
+	engine.RegisterCLIAdapter("claude", &hookEventCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), event: cli.HookEvent{Name: "PreToolUse", ToolName: "Bash", ToolInput: "git push -f"}})
+	engine.sessions["main"].HookEvents = HookEventsConfig{PreToolUse: PreToolUseApprove, ApprovalTimeout: "1m"}
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postHookEvent(engine) }()
+
+	require.Eventually(t, func() bool {
+		engine.sessionMu.RLock()
+		defer engine.sessionMu.RUnlock()
+		return len(engine.pendingApprovals["main"]) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Contains(t, botAdapter.lastMessage, "🛂 [main] Bash: git push -f")
+
+	engine.HandleUserMessage(bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1", Content: "deny not on main"})
+
+	rec := <-done
+	var out preToolUseResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Equal(t, "PreToolUse", out.HookSpecificOutput.HookEventName)
+	assert.Equal(t, "deny", out.HookSpecificOutput.PermissionDecision)
+	assert.Equal(t, "not on main", out.HookSpecificOutput.PermissionDecisionReason)
+	assert.Equal(t, "Denied Bash", botAdapter.lastMessage)
+	assert.Empty(t, engine.pendingApprovals)
+}
+
+// TestEngine_HookEvent_PreToolUseTimeout tests that an unanswered approval defers to the terminal
+func TestEngine_HookEvent_PreToolUseTimeout(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	engine.hookSecret = "s3cret"
+	engine.RegisterCLIAdapter("claude", &hookEventCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), event: cli.HookEvent{Name: "PreToolUse", ToolName: "Write", ToolInput: "/etc/hosts"}})
+	engine.sessions["main"].HookEvents = HookEventsConfig{PreToolUse: PreToolUseApprove, ApprovalTimeout: "20ms"}
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+
+	rec := postHookEvent(engine)
+
+	var out preToolUseResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Equal(t, "ask", out.HookSpecificOutput.PermissionDecision)
+	assert.Empty(t, engine.pendingApprovals)
+}
+
+// TestEngine_AnswerToolApproval_NoPending tests that allow/deny are ordinary input without a pending approval
+func TestEngine_AnswerToolApproval_NoPending(t *testing.T) {
+	engine, cliAdapter, _ := newMessageTestEngine()
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleUserMessage(bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1", Content: "deny"})
+
+	assert.Equal(t, []string{"deny"}, cliAdapter.inputs["main"])
+}
+
+// TestEngine_AnswerToolApproval_OnlyAllowDeny tests that other yes/no words do not answer an approval
+func TestEngine_AnswerToolApproval_OnlyAllowDeny(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	approval := &pendingApproval{toolName: "Bash", decision: make(chan toolDecision, 1)}
+	engine.pendingApprovals["main"] = []*pendingApproval{approval}
+	msg := bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1"}
+
+	for _, content := range []string{"y", "yes", "n", "no", "allowed", "denying it"} {
+		msg.Content = content
+		assert.False(t, engine.answerToolApproval("main", msg), content)
+	}
+	assert.Len(t, engine.pendingApprovals["main"], 1)
+
+	msg.Content = "Allow"
+	assert.True(t, engine.answerToolApproval("main", msg))
+	assert.Equal(t, decisionAllow, (<-approval.decision).decision)
+}
@@ -0,0 +1,275 @@
+package core
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEngine_HandleHookRequest_RequiresSecret tests that hook requests need the shared secret
+func TestEngine_HandleHookRequest_RequiresSecret(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	engine.hookSecret = "s3cret"
+
+	post := func(secret string) int {
+		req := httptest.NewRequest(http.MethodPost, "/hook?cli_type=claude", strings.NewReader(`{"cwd":"/tmp"}`))
+		if secret != "" {
+			req.Header.Set(constants.HookSecretHeader, secret)
+		}
+		rec := httptest.NewRecorder()
+		engine.handleHookRequest(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post(""))
+	assert.Equal(t, http.StatusUnauthorized, post("wrong"))
+	// Authorized requests are passed on to the CLI adapter
+	assert.NotEqual(t, http.StatusUnauthorized, post("s3cret"))
+
+	// Without a generated secret every request is rejected
+	engine.hookSecret = ""
+	assert.Equal(t, http.StatusUnauthorized, post(""))
+}
+
+func TestListenHook_DefaultsToLoopback(t *testing.T) {
+	listener, endpoint, err := listenHook(HookServerConfig{Host: DefaultHookHost, Port: 0})
+	require.NoError(t, err)
+	defer listener.Close()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	assert.True(t, addr.IP.IsLoopback())
+	assert.Equal(t, "http://"+listener.Addr().String(), endpoint.URL)
+	assert.Empty(t, endpoint.Socket)
+}
+
+func TestHookEndpoint_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "hook.json")
+	want := HookEndpoint{URL: "http://127.0.0.1:8080", Secret: "abc"}
+	require.NoError(t, writeHookEndpoint(path, want))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	got, err := LoadHookEndpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	_, err = LoadHookEndpoint(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+// TestEngine_StartHookServer_UnixSocket tests serving hooks on a unix socket with a published endpoint
+func TestEngine_StartHookServer_UnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not used on windows")
+	}
+	dir, err := os.MkdirTemp("", "clibot-hook")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	engine, _, _ := newMessageTestEngine()
+	engine.config.HookServer.Socket = filepath.Join(dir, "hook.sock")
+	engine.hookEndpointPath = filepath.Join(dir, "hook.json")
+
+	stopped := make(chan struct{})
+	go func() {
+		engine.startHookServer()
+		close(stopped)
+	}()
+
+	var endpoint HookEndpoint
+	require.Eventually(t, func() bool {
+		endpoint, err = LoadHookEndpoint(engine.hookEndpointPath)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, engine.config.HookServer.Socket, endpoint.Socket)
+	assert.Len(t, endpoint.Secret, 64)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", endpoint.Socket)
+		},
+	}}
+	req, _ := http.NewRequest(http.MethodPost, endpoint.URL+"/hook?cli_type=claude", strings.NewReader(`{"cwd":"/tmp"}`))
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	require.NoError(t, engine.hookServer.Shutdown(context.Background()))
+	<-stopped
+	_, err = os.Stat(engine.hookEndpointPath)
+	assert.True(t, os.IsNotExist(err), "endpoint file is removed on shutdown")
+}
+
+func TestEngine_MatchHookSession(t *testing.T) {
+	repo := t.TempDir()
+	sub := filepath.Join(repo, "pkg", "api")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	other := t.TempDir()
+
+	engine := NewEngine(&Config{})
+	engine.sessions["claude-a"] = &Session{Name: "claude-a", CLIType: "claude", WorkDir: repo}
+	engine.sessions["claude-b"] = &Session{Name: "claude-b", CLIType: "claude", WorkDir: repo + "/"}
+	engine.sessions["gemini"] = &Session{Name: "gemini", CLIType: "gemini", WorkDir: repo}
+	engine.sessions["api"] = &Session{Name: "api", CLIType: "claude", WorkDir: filepath.Join(repo, "pkg")}
+
+	// The tmux session name wins over the working directory
+	session, by, err := engine.matchHookSession("claude", "claude-b", "sid-1", other)
+	require.NoError(t, err)
+	assert.Equal(t, "claude-b", session.Name)
+	assert.Equal(t, "tmux_session", by)
+
+	// The CLI session ID is remembered for hooks without tmux information
+	session, by, err = engine.matchHookSession("claude", "", "sid-1", repo)
+	require.NoError(t, err)
+	assert.Equal(t, "claude-b", session.Name)
+	assert.Equal(t, "session_id", by)
+
+	// Subdirectories match the longest containing work_dir
+	session, by, err = engine.matchHookSession("claude", "", "", sub)
+	require.NoError(t, err)
+	assert.Equal(t, "api", session.Name)
+	assert.Equal(t, "work_dir", by)
+
+	// The CLI type disambiguates sessions sharing a directory
+	session, _, err = engine.matchHookSession("gemini", "", "", repo)
+	require.NoError(t, err)
+	assert.Equal(t, "gemini", session.Name)
+
+	_, _, err = engine.matchHookSession("claude", "", "", repo)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "claude-a, claude-b")
+
+	_, _, err = engine.matchHookSession("claude", "", "", other)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no session")
+}
+
+func TestEngine_MatchHookSession_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on windows")
+	}
+	repo := t.TempDir()
+	link := filepath.Join(t.TempDir(), "repo-link")
+	require.NoError(t, os.Symlink(repo, link))
+
+	engine := NewEngine(&Config{})
+	engine.sessions["main"] = &Session{Name: "main", CLIType: "claude", WorkDir: link}
+
+	session, _, err := engine.matchHookSession("claude", "", "", repo)
+	require.NoError(t, err)
+	assert.Equal(t, "main", session.Name)
+}
+
+// notificationCLIAdapter is a mock CLI adapter whose hooks are notifications
+type notificationCLIAdapter struct {
+	*mockCLIAdapter
+	cwd string
+}
+
+func (n *notificationCLIAdapter) HandleHookData(data []byte) (string, string, string, error) {
+	return n.cwd, "", "", nil
+}
+
+func (n *notificationCLIAdapter) ParseNotification(data []byte) (string, bool) {
+	return "Claude needs your permission to use Bash", strings.Contains(string(data), "Notification")
+}
+
+// TestEngine_HandleHookRequest_Notification tests that Notification hooks become chat alerts
+func TestEngine_HandleHookRequest_Notification(t *testing.T) {
+	repo := t.TempDir()
+	engine, _, botAdapter := newMessageTestEngine()
+	engine.hookSecret = "s3cret"
+	engine.RegisterCLIAdapter("claude", &notificationCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), cwd: repo})
+	engine.sessions["main"].WorkDir = repo
+	engine.sessions["main"].State = StateProcessing
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+	engine.capturePane = func(session string, lines int) (string, error) {
+		return "Do you want to proceed?\n❯ 1. Yes\n  2. No\n", nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/hook?cli_type=claude&tmux_session=main",
+		strings.NewReader(`{"hook_event_name":"Notification"}`))
+	req.Header.Set(constants.HookSecretHeader, "s3cret")
+	rec := httptest.NewRecorder()
+	engine.handleHookRequest(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, StateWaitingInput, engine.sessions["main"].State)
+	assert.Equal(t, "c1", botAdapter.lastChannel)
+	assert.Contains(t, botAdapter.lastMessage, "🔔 [main] Claude needs your permission to use Bash")
+	assert.Contains(t, botAdapter.lastMessage, "Do you want to proceed?")
+	assert.Contains(t, botAdapter.lastMessage, "1 → Yes")
+	assert.Contains(t, botAdapter.lastMessage, "2 → No")
+}
+
+func TestFormatNotificationAlert_NoOptions(t *testing.T) {
+	alert := formatNotificationAlert("main", "Claude is waiting for your input", "")
+	assert.Equal(t, "🔔 [main] Claude is waiting for your input\n\nReply 'enter' to confirm, 'esc' to cancel, or type an answer", alert)
+}
+
+// TestEngine_HandleHookRequest_Unmatched tests that hooks matching no session alert the admins
+func TestEngine_HandleHookRequest_Unmatched(t *testing.T) {
+	repo := t.TempDir()
+	engine, _, _ := newMessageTestEngine()
+	defer engine.cancel()
+	engine.hookSecret = "s3cret"
+	engine.config.Security.Admins = map[string][]string{"discord": {"admin1"}}
+	engine.RegisterCLIAdapter("claude", &notificationCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), cwd: repo})
+	botAdapter := &mockDMBot{}
+	engine.RegisterBotAdapter("discord", botAdapter)
+	engine.supervisor.Supervise(engine.ctx, "discord", botAdapter, engine.HandleBotMessage)
+	require.Eventually(t, func() bool {
+		statuses := engine.BotStatuses()
+		return len(statuses) == 1 && statuses[0].State == bot.ConnectionConnected
+	}, time.Second, 5*time.Millisecond)
+
+	req := httptest.NewRequest(http.MethodPost, "/hook?cli_type=claude&tmux_session=gone&session_id=abc",
+		strings.NewReader(`{"hook_event_name":"Stop"}`))
+	req.Header.Set(constants.HookSecretHeader, "s3cret")
+	rec := httptest.NewRecorder()
+	engine.handleHookRequest(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, 1, botAdapter.dmCount)
+	assert.Equal(t, "admin1", botAdapter.lastDMUser)
+	assert.Contains(t, botAdapter.lastDM, "⚠️ Ignored claude hook: no session has a work_dir containing "+repo)
+	assert.Contains(t, botAdapter.lastDM, "\ncli_type: claude\ntmux_session: gone\nsession_id: abc\ncwd: "+resolvePath(repo))
+}
+
+// TestEngine_ShouldAlertUnmatchedHook tests that only bare hooks are deduplicated per cli_type and cwd
+func TestEngine_ShouldAlertUnmatchedHook(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	defer engine.cancel()
+	repo, other := t.TempDir(), t.TempDir()
+
+	assert.True(t, engine.shouldAlertUnmatchedHook("claude", "", "", repo))
+	assert.False(t, engine.shouldAlertUnmatchedHook("claude", "", "", repo))
+	assert.True(t, engine.shouldAlertUnmatchedHook("gemini", "", "", repo))
+	assert.True(t, engine.shouldAlertUnmatchedHook("claude", "", "", other))
+
+	// Hooks naming a session always alert
+	assert.True(t, engine.shouldAlertUnmatchedHook("claude", "gone", "", repo))
+	assert.True(t, engine.shouldAlertUnmatchedHook("claude", "", "abc", repo))
+
+	// Bare hooks alert again once the cooldown has passed
+	engine.unmatchedAlerts["claude:"+resolvePath(repo)] = time.Now().Add(-constants.UnmatchedHookAlertCooldown)
+	assert.True(t, engine.shouldAlertUnmatchedHook("claude", "", "", repo))
+	assert.False(t, engine.shouldAlertUnmatchedHook("claude", "", "", repo))
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/watchdog"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+const keysUsage = "❌ Usage: keys <keys>, e.g. keys Down Down Enter or keys C-r \"search\" Enter"
+
+// handleKeys sends a key sequence to the current session's terminal and shows
+// the pane afterwards
+// Usage: keys <keys>
+func (e *Engine) handleKeys(args []string, msg bot.BotMessage) {
+	input := commandArgText("keys", args, msg)
+	if input == "" {
+		e.SendToBot(msg.Platform, msg.Channel, keysUsage)
+		return
+	}
+	steps, err := watchdog.ParseKeys(input)
+	if err != nil {
+		e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("❌ %v\n%s", err, strings.TrimPrefix(keysUsage, "❌ ")))
+		return
+	}
+
+	session := e.currentSession(msg)
+	if session == nil {
+		e.SendToBot(msg.Platform, msg.Channel,
+			"❌ You don't have an active session\nUse 'suse <name>' to select one")
+		return
+	}
+	e.sendKeysAndShow(session, steps, msg)
+}
+
+// handleRawMode turns raw mode on or off for the user, or toggles it. In raw
+// mode messages are typed into the session without pressing Enter.
+// Usage: raw [on|off]
+func (e *Engine) handleRawMode(args []string, msg bot.BotMessage) {
+	userKey := getUserKey(msg.Platform, msg.UserID)
+
+	e.sessionMu.Lock()
+	enabled := !e.rawModes[userKey]
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+			enabled = true
+		case "off":
+			enabled = false
+		default:
+			e.sessionMu.Unlock()
+			e.SendToBot(msg.Platform, msg.Channel, "❌ Usage: raw [on|off]")
+			return
+		}
+	}
+	if enabled {
+		e.rawModes[userKey] = true
+	} else {
+		delete(e.rawModes, userKey)
+	}
+	e.sessionMu.Unlock()
+
+	logger.WithFields(logrus.Fields{
+		"user":    userKey,
+		"enabled": enabled,
+	}).Info("raw-mode-changed")
+
+	if enabled {
+		e.SendToBot(msg.Platform, msg.Channel,
+			"⌨️ Raw mode on: messages are typed into the session without pressing Enter\n"+
+				"Send 'enter' to submit, or 'raw off' to leave raw mode")
+	} else {
+		e.SendToBot(msg.Platform, msg.Channel, "⌨️ Raw mode off")
+	}
+}
+
+// isRawMode reports whether the user has raw mode on
+func (e *Engine) isRawMode(userKey string) bool {
+	e.sessionMu.RLock()
+	defer e.sessionMu.RUnlock()
+	return e.rawModes[userKey]
+}
+
+// sendKeysAndShow sends steps to a session's terminal, then replies with the
+// bottom of the pane so the user sees their effect
+func (e *Engine) sendKeysAndShow(session *Session, steps []watchdog.KeyStep, msg bot.BotMessage) {
+	if e.sendSessionKeys(session, steps, msg) {
+		e.showPaneAfterKeys(session, steps, msg)
+	}
+}
+
+// sendSessionKeys sends steps to a session's terminal, replying with the
+// reason when it cannot
+func (e *Engine) sendSessionKeys(session *Session, steps []watchdog.KeyStep, msg bot.BotMessage) bool {
+	if session.CLIType == "acp" {
+		e.SendToBot(msg.Platform, msg.Channel,
+			fmt.Sprintf("❌ Session '%s' runs over ACP and has no terminal to send keys to", session.Name))
+		return false
+	}
+
+	if err := e.sendKeys(session.Name, steps); err != nil {
+		logger.WithFields(logrus.Fields{
+			"session": session.Name,
+			"error":   err,
+		}).Warn("failed-to-send-keys")
+		e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("❌ Failed to send keys: %v", err))
+		return false
+	}
+	return true
+}
+
+// showPaneAfterKeys waits for the CLI to redraw, then replies with the bottom
+// of the pane
+func (e *Engine) showPaneAfterKeys(session *Session, steps []watchdog.KeyStep, msg bot.BotMessage) {
+	time.Sleep(e.keysCaptureDelay)
+	sent := watchdog.FormatKeys(steps)
+	pane, err := e.capturePane(session.Name, constants.KeysCaptureLines)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"session": session.Name,
+			"error":   err,
+		}).Warn("failed-to-capture-pane-after-keys")
+		e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("⌨️ [%s] Sent %s", session.Name, sent))
+		return
+	}
+
+	lines := strings.Split(strings.TrimRight(pane, " \n"), "\n")
+	if len(lines) > constants.KeysCaptureLines {
+		lines = lines[len(lines)-constants.KeysCaptureLines:]
+	}
+	e.SendToBot(msg.Platform, msg.Channel,
+		fmt.Sprintf("⌨️ [%s] Sent %s\n```\n%s\n```", session.Name, sent, strings.Join(lines, "\n")))
+}
+
+// commandArgText returns the raw text after the command word, keeping quotes
+// and spacing that splitting into args loses
+func commandArgText(command string, args []string, msg bot.BotMessage) string {
+	content := strings.TrimSpace(msg.Content)
+	if rest, ok := strings.CutPrefix(content, command); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n') {
+		return strings.TrimSpace(rest)
+	}
+	return strings.Join(args, " ")
+}
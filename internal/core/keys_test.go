@@ -0,0 +1,162 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/internal/watchdog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keysCall is a key sequence sent to a session
+type keysCall struct {
+	session string
+	steps   []watchdog.KeyStep
+}
+
+// fakeTerminal records the keys sent to sessions and shows a fixed pane
+type fakeTerminal struct {
+	calls []keysCall
+}
+
+func (f *fakeTerminal) sendKeys(sessionName string, steps []watchdog.KeyStep) error {
+	f.calls = append(f.calls, keysCall{session: sessionName, steps: steps})
+	return nil
+}
+
+func (f *fakeTerminal) capture(sessionName string, lines int) (string, error) {
+	return strings.Repeat("history\n", 40) + "❯ 1. Yes\n  2. No\n\n\n", nil
+}
+
+func keysMessage(content string) bot.BotMessage {
+	return bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1", Content: content}
+}
+
+// TestEngine_HandleKeys tests sending a key sequence and showing the pane afterwards
+func TestEngine_HandleKeys(t *testing.T) {
+	term := &fakeTerminal{}
+	fileBot := &mockFileBot{}
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	engine.sendKeys = term.sendKeys
+	engine.capturePane = term.capture
+	engine.keysCaptureDelay = 0
+
+	engine.HandleUserMessage(keysMessage(`keys C-r "git  log" Enter`))
+	require.Len(t, term.calls, 1)
+	assert.Equal(t, "main", term.calls[0].session)
+	assert.Equal(t, []watchdog.KeyStep{{Key: "C-r"}, {Text: "git  log"}, {Key: "Enter"}}, term.calls[0].steps)
+
+	require.Len(t, fileBot.messages, 1)
+	reply := fileBot.messages[0]
+	assert.True(t, strings.HasPrefix(reply, "⌨️ [main] Sent C-r \"git  log\" Enter\n```\n"), reply)
+	assert.True(t, strings.HasSuffix(reply, "❯ 1. Yes\n  2. No\n```"), reply)
+	assert.Equal(t, 23, strings.Count(reply, "history"), "only the last lines of the pane are shown")
+}
+
+// TestEngine_HandleKeys_Errors tests invalid sequences and sessions without a terminal
+func TestEngine_HandleKeys_Errors(t *testing.T) {
+	term := &fakeTerminal{}
+	fileBot := &mockFileBot{}
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	engine.sendKeys = term.sendKeys
+	engine.capturePane = term.capture
+	engine.keysCaptureDelay = 0
+
+	engine.HandleSpecialCommandWithArgs("keys", []string{"Dwn"}, keysMessage("keys Dwn"))
+	require.Len(t, fileBot.messages, 1)
+	assert.Contains(t, fileBot.messages[0], `❌ unknown key "Dwn"`)
+
+	engine.HandleSpecialCommandWithArgs("keys", nil, keysMessage("keys"))
+	assert.Contains(t, fileBot.messages[1], "❌ Usage: keys <keys>")
+
+	engine.sessions["agent"] = &Session{Name: "agent", CLIType: "acp"}
+	engine.userSessions[getUserKey("discord", "u1")] = "agent"
+	engine.HandleUserMessage(keysMessage("keys Enter"))
+	assert.Contains(t, fileBot.messages[2], "runs over ACP")
+
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	engine.sendKeys = func(string, []watchdog.KeyStep) error { return fmt.Errorf("no server running") }
+	engine.HandleUserMessage(keysMessage("keys Enter"))
+	assert.Equal(t, "❌ Failed to send keys: no server running", fileBot.messages[3])
+	assert.Empty(t, term.calls)
+}
+
+// TestEngine_RawMode tests typing messages without Enter while raw mode is on
+func TestEngine_RawMode(t *testing.T) {
+	term := &fakeTerminal{}
+	fileBot := &mockFileBot{}
+	engine, cliAdapter, _ := newMessageTestEngine()
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	engine.sendKeys = term.sendKeys
+	engine.capturePane = term.capture
+	engine.keysCaptureDelay = 0
+
+	engine.HandleUserMessage(keysMessage("raw"))
+	assert.Contains(t, fileBot.messages[0], "Raw mode on")
+
+	// The pane is captured off the message loop, so a slow capture does not block it
+	release := make(chan struct{})
+	engine.capturePane = func(sessionName string, lines int) (string, error) {
+		<-release
+		return term.capture(sessionName, lines)
+	}
+	engine.HandleUserMessage(keysMessage("git status"))
+	engine.HandleUserMessage(keysMessage("enter"))
+	require.Len(t, term.calls, 2)
+	assert.Equal(t, []watchdog.KeyStep{{Text: "git status"}}, term.calls[0].steps)
+	assert.Equal(t, []watchdog.KeyStep{{Key: "C-m"}}, term.calls[1].steps)
+	assert.Empty(t, cliAdapter.inputs["main"])
+	assert.Len(t, fileBot.sent(), 1)
+	close(release)
+	require.Eventually(t, func() bool { return len(fileBot.sent()) == 3 }, time.Second, 10*time.Millisecond)
+	assert.Contains(t, strings.Join(fileBot.sent()[1:], "\n"), "⌨️ [main] Sent \"git status\"")
+
+	// Raw mode is per user
+	engine.userSessions[getUserKey("discord", "u2")] = "main"
+	engine.HandleUserMessage(bot.BotMessage{Platform: "discord", UserID: "u2", Channel: "c1", Content: "hello"})
+	assert.Equal(t, []string{"hello"}, cliAdapter.inputs["main"])
+
+	engine.HandleUserMessage(keysMessage("raw off"))
+	assert.Equal(t, "⌨️ Raw mode off", fileBot.messages[len(fileBot.messages)-1])
+	engine.HandleUserMessage(keysMessage("done"))
+	assert.Equal(t, []string{"hello", "done"}, cliAdapter.inputs["main"])
+	assert.Len(t, term.calls, 2)
+
+	engine.HandleSpecialCommandWithArgs("raw", []string{"maybe"}, keysMessage("raw maybe"))
+	assert.Equal(t, "❌ Usage: raw [on|off]", fileBot.messages[len(fileBot.messages)-1])
+}
+
+// TestEngine_RawAndKeysPrompts tests that prompts starting with "raw" or "keys" reach the CLI
+func TestEngine_RawAndKeysPrompts(t *testing.T) {
+	term := &fakeTerminal{}
+	fileBot := &mockFileBot{}
+	engine, cliAdapter, _ := newMessageTestEngine()
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	engine.sendKeys = term.sendKeys
+	engine.capturePane = term.capture
+	engine.keysCaptureDelay = 0
+
+	engine.HandleUserMessage(keysMessage("raw SQL is slow here, why?"))
+	engine.HandleUserMessage(keysMessage("keys in this map are never sorted"))
+
+	assert.Equal(t, []string{"raw SQL is slow here, why?", "keys in this map are never sorted"}, cliAdapter.inputs["main"])
+	assert.False(t, engine.isRawMode(getUserKey("discord", "u1")))
+	assert.Empty(t, term.calls)
+	assert.Empty(t, fileBot.messages)
+}
+
+func TestCommandArgText(t *testing.T) {
+	assert.Equal(t, `"a  b" Enter`, commandArgText("keys", []string{`"a`, `b"`, "Enter"}, keysMessage(` keys "a  b" Enter `)))
+	assert.Equal(t, "x y", commandArgText("keys", []string{"x", "y"}, keysMessage("")))
+	assert.Equal(t, "", commandArgText("keys", nil, keysMessage("keys")))
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/watchdog"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// pollSchedule is the timing of a polling watchdog, parsed from WatchdogConfig
+type pollSchedule struct {
+	initialDelay time.Duration
+	intervals    []time.Duration // Delays between checks, the last one repeating
+	timeout      time.Duration
+	maxRetries   int
+}
+
+// newPollSchedule parses the watchdog settings, falling back to the defaults
+// for values that are missing or invalid
+func newPollSchedule(cfg WatchdogConfig) pollSchedule {
+	schedule := pollSchedule{
+		initialDelay: parseDurationOr(cfg.InitialDelay, DefaultWatchdogInitialDelay),
+		timeout:      parseDurationOr(cfg.Timeout, DefaultWatchdogTimeout),
+		maxRetries:   cfg.MaxRetries,
+	}
+	for _, interval := range cfg.CheckIntervals {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			schedule.intervals = append(schedule.intervals, d)
+		}
+	}
+	if len(schedule.intervals) == 0 {
+		schedule.intervals = []time.Duration{parseDurationOr(cfg.RetryDelay, DefaultWatchdogRetryDelay)}
+	}
+	if schedule.maxRetries <= 0 {
+		schedule.maxRetries = DefaultWatchdogMaxRetries
+	}
+	return schedule
+}
+
+// interval returns the delay before the given check (0-based)
+func (s pollSchedule) interval(check int) time.Duration {
+	if check < len(s.intervals) {
+		return s.intervals[check]
+	}
+	return s.intervals[len(s.intervals)-1]
+}
+
+// pollForResponse watches a polling session's pane until the output stops
+// changing and the CLI no longer looks busy, then delivers what appeared since
+// beforeCapture. Partial output is streamed as progress while it changes.
+// Returns nil without delivering when ctx is cancelled by a newer message.
+func (e *Engine) pollForResponse(ctx context.Context, sessionName, prompt, beforeCapture string) error {
+	schedule := newPollSchedule(e.config.Watchdog)
+	deadline := time.Now().Add(schedule.timeout)
+
+	logger.WithFields(logrus.Fields{
+		"session": sessionName,
+		"timeout": schedule.timeout,
+	}).Debug("poll-mode-watchdog-started")
+
+	wait := schedule.initialDelay
+	var last, progress string
+	stable, failures := 0, 0
+	for check := 0; ; check++ {
+		select {
+		case <-ctx.Done():
+			logger.WithField("session", sessionName).Debug("poll-mode-watchdog-cancelled")
+			return nil
+		case <-time.After(wait):
+		}
+		wait = schedule.interval(check)
+
+		capture, err := e.capturePane(sessionName, constants.PollCaptureLines)
+		if err != nil {
+			failures++
+			if failures >= schedule.maxRetries {
+				e.updateSessionState(sessionName, StateError)
+				return fmt.Errorf("capture pane of %s failed %d times: %w", sessionName, failures, err)
+			}
+			continue
+		}
+		failures = 0
+
+		// Wait for the CLI to react to the input, then for the output to settle
+		if capture != beforeCapture && capture == last && !watchdog.IsThinking(capture) {
+			stable++
+		} else {
+			stable = 0
+		}
+		last = capture
+
+		response := watchdog.ExtractResponse(beforeCapture, capture, prompt)
+		if stable >= constants.PollStableChecks {
+			logger.WithFields(logrus.Fields{
+				"session": sessionName,
+				"checks":  check + 1,
+				"length":  len(response),
+			}).Info("poll-mode-response-settled")
+			e.updateSessionState(sessionName, StateIdle)
+			e.SendResponseToSession(sessionName, response)
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			logger.WithFields(logrus.Fields{
+				"session": sessionName,
+				"timeout": schedule.timeout,
+			}).Warn("poll-mode-response-timeout")
+			// No longer watched: let the user send more input or take over with keys
+			e.updateSessionState(sessionName, StateIdle)
+			e.SendResponseToSession(sessionName, fmt.Sprintf(
+				"⏱️ [%s] Output has not settled after %s, showing what is there so far:\n\n%s",
+				sessionName, schedule.timeout, response))
+			return nil
+		}
+
+		if response != progress {
+			progress = response
+			e.SendProgressToSession(sessionName, response)
+		}
+	}
+}
+
+// parseDurationOr parses value, or fallback when value is empty or invalid
+func parseDurationOr(value, fallback string) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d
+	}
+	d, _ := time.ParseDuration(fallback)
+	return d
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePane replays pane captures, repeating the last one
+type fakePane struct {
+	mu       sync.Mutex
+	captures []string
+	calls    int
+}
+
+func (f *fakePane) capture(session string, lines int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.calls
+	if i >= len(f.captures) {
+		i = len(f.captures) - 1
+	}
+	f.calls++
+	return f.captures[i], nil
+}
+
+// TestEngine_PollForResponse tests delivering pane output once it settles
+func TestEngine_PollForResponse(t *testing.T) {
+	before := "welcome\n│ > │"
+	pane := &fakePane{captures: []string{
+		before,
+		"welcome\n> list files\n✻ Thinking… (esc to interrupt)",
+		"welcome\n> list files\nREADME.md\n│ > │",
+	}}
+	engine, _, botAdapter := newMessageTestEngine()
+	engine.config.Watchdog = WatchdogConfig{InitialDelay: "1ms", RetryDelay: "1ms", Timeout: "1s"}
+	engine.capturePane = pane.capture
+	engine.sessions["main"].Mode = SessionModePoll
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+	engine.sessions["main"].State = StateProcessing
+
+	err := engine.pollForResponse(context.Background(), "main", "list files", before)
+
+	require.NoError(t, err)
+	assert.Equal(t, "README.md", botAdapter.lastMessage)
+	assert.Equal(t, StateIdle, engine.sessions["main"].State)
+}
+
+// TestEngine_PollForResponse_Timeout tests delivering partial output when the pane never settles
+func TestEngine_PollForResponse_Timeout(t *testing.T) {
+	pane := &fakePane{captures: []string{"> build\n⠋ Working..."}}
+	engine, _, botAdapter := newMessageTestEngine()
+	engine.config.Watchdog = WatchdogConfig{InitialDelay: "1ms", RetryDelay: "1ms", Timeout: "20ms"}
+	engine.capturePane = pane.capture
+	engine.sessions["main"].Mode = SessionModePoll
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+	engine.sessions["main"].State = StateProcessing
+
+	err := engine.pollForResponse(context.Background(), "main", "build", "")
+
+	require.NoError(t, err)
+	assert.Contains(t, botAdapter.lastMessage, "⏱️ [main] Output has not settled after 20ms")
+	assert.Equal(t, StateIdle, engine.sessions["main"].State)
+}
+
+// TestEngine_PollForResponse_Cancelled tests that a newer message cancels polling silently
+func TestEngine_PollForResponse_Cancelled(t *testing.T) {
+	pane := &fakePane{captures: []string{"unchanged"}}
+	engine, _, botAdapter := newMessageTestEngine()
+	engine.config.Watchdog = WatchdogConfig{InitialDelay: "1ms", RetryDelay: "1ms", Timeout: "1s"}
+	engine.capturePane = pane.capture
+	engine.sessions["main"].Mode = SessionModePoll
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.NoError(t, engine.pollForResponse(ctx, "main", "hi", "unchanged"))
+	assert.Equal(t, 0, botAdapter.messageCount)
+}
+
+// TestEngine_PollForResponse_CaptureFails tests giving up after max_retries failed captures
+func TestEngine_PollForResponse_CaptureFails(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	engine.config.Watchdog = WatchdogConfig{InitialDelay: "1ms", RetryDelay: "1ms", Timeout: "1s", MaxRetries: 2}
+	engine.sessions["main"].Mode = SessionModePoll
+	engine.capturePane = func(string, int) (string, error) { return "", errors.New("no server running") }
+
+	err := engine.pollForResponse(context.Background(), "main", "hi", "")
+
+	assert.Error(t, err)
+	assert.Equal(t, StateError, engine.sessions["main"].State)
+}
+
+// TestEngine_HandleUserMessage_PollMode tests capturing the pane before sending input
+func TestEngine_HandleUserMessage_PollMode(t *testing.T) {
+	pane := &fakePane{captures: []string{"prompt", "prompt\n> hello there\nhi!", "prompt\n> hello there\nhi!"}}
+	engine, _, botAdapter := newMessageTestEngine()
+	engine.config.Watchdog = WatchdogConfig{InitialDelay: "1ms", RetryDelay: "1ms", Timeout: "1s"}
+	engine.capturePane = pane.capture
+	engine.sessions["main"].Mode = SessionModePoll
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleUserMessage(bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1", Content: "hello there"})
+
+	require.Eventually(t, func() bool {
+		_, _, message := botAdapter.lastSent()
+		return message == "hi!"
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, StateIdle, engine.sessions["main"].State)
+}
+
+func TestNewPollSchedule(t *testing.T) {
+	schedule := newPollSchedule(WatchdogConfig{CheckIntervals: []string{"1s", "bad", "3s"}})
+	assert.Equal(t, 500*time.Millisecond, schedule.initialDelay)
+	assert.Equal(t, 10*time.Minute, schedule.timeout)
+	assert.Equal(t, DefaultWatchdogMaxRetries, schedule.maxRetries)
+	assert.Equal(t, time.Second, schedule.interval(0))
+	assert.Equal(t, 3*time.Second, schedule.interval(1))
+	assert.Equal(t, 3*time.Second, schedule.interval(5))
+
+	schedule = newPollSchedule(WatchdogConfig{})
+	assert.Equal(t, 800*time.Millisecond, schedule.interval(0))
+}
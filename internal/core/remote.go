@@ -0,0 +1,287 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/cli"
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/watchdog"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// remoteHookEndpointFile is where "clibot hook" on a session host finds the
+// tunnelled hook server, relative to the remote home directory
+const remoteHookEndpointFile = ".clibot/hook.json"
+
+// hookHosts returns the distinct hosts of sessions that receive hooks
+func (e *Engine) hookHosts() []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, session := range e.config.Sessions {
+		if session.Host != "" && session.Mode == SessionModeHook && !seen[session.Host] {
+			seen[session.Host] = true
+			hosts = append(hosts, session.Host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// remoteHookForward returns the ssh -R spec exposing the hook server on
+// 127.0.0.1:<remote_port> of session hosts
+func remoteHookForward(cfg HookServerConfig) (string, error) {
+	remote := fmt.Sprintf("127.0.0.1:%d", cfg.RemotePort)
+	if cfg.Socket != "" {
+		socket, err := expandHome(cfg.Socket)
+		if err != nil {
+			return "", err
+		}
+		return remote + ":" + socket, nil
+	}
+	host := cfg.Host
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = DefaultHookHost
+	}
+	return remote + ":" + net.JoinHostPort(host, fmt.Sprintf("%d", cfg.Port)), nil
+}
+
+// forwardHooks opens the hook server tunnel to each session host and keeps it
+// open until the engine stops
+func (e *Engine) forwardHooks() {
+	hosts := e.hookHosts()
+	if len(hosts) == 0 {
+		return
+	}
+	forward, err := remoteHookForward(e.config.HookServer)
+	if err != nil {
+		logger.WithField("error", err).Error("failed-to-forward-hooks-to-hosts")
+		return
+	}
+	for _, host := range hosts {
+		go e.superviseHookTunnel(host, forward)
+	}
+}
+
+// superviseHookTunnel reopens the hook tunnel to host whenever it closes,
+// backing off while it keeps failing
+func (e *Engine) superviseHookTunnel(host, forward string) {
+	backoff := constants.HookTunnelInitialBackoff
+	for {
+		opened := time.Now()
+		err := e.hookTunnel(e.ctx, host, forward)
+		if e.ctx.Err() != nil {
+			return
+		}
+		if time.Since(opened) >= constants.HookTunnelStableAfter {
+			backoff = constants.HookTunnelInitialBackoff
+		}
+		logger.WithFields(logrus.Fields{
+			"host":     host,
+			"forward":  forward,
+			"error":    err,
+			"retry_in": backoff,
+		}).Error("hook-tunnel-closed")
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, constants.HookTunnelMaxBackoff)
+	}
+}
+
+// openHookTunnel holds the hook tunnel to host open on its own SSH
+// connection, returning why it closed
+func openHookTunnel(ctx context.Context, host, forward string) error {
+	cmd := watchdog.ForwardCommand(ctx, host, forward)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err == nil {
+		err = errors.New("ssh exited")
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
+
+// publishRemoteHookEndpoints writes the tunnelled hook endpoint to each
+// session host, for "clibot hook" running there. It returns a function that
+// removes them again.
+func (e *Engine) publishRemoteHookEndpoints(secret string) func() {
+	endpoint := HookEndpoint{
+		URL:    fmt.Sprintf("http://127.0.0.1:%d", e.config.HookServer.RemotePort),
+		Secret: secret,
+	}
+	data, err := json.MarshalIndent(endpoint, "", "  ")
+	if err != nil {
+		return func() {}
+	}
+
+	var published []string
+	for _, host := range e.hookHosts() {
+		if err := watchdog.WriteRemoteFile(host, remoteHookEndpointFile, data); err != nil {
+			logger.WithFields(logrus.Fields{
+				"host":  host,
+				"error": err,
+			}).Error("failed-to-publish-hook-endpoint-on-host")
+			continue
+		}
+		logger.WithFields(logrus.Fields{
+			"host": host,
+			"url":  endpoint.URL,
+		}).Info("hook-endpoint-published-on-host")
+		published = append(published, host)
+	}
+
+	return func() {
+		for _, host := range published {
+			if err := watchdog.RemoveRemoteFile(host, remoteHookEndpointFile); err != nil {
+				logger.WithFields(logrus.Fields{
+					"host":  host,
+					"error": err,
+				}).Warn("failed-to-remove-hook-endpoint-on-host")
+			}
+		}
+	}
+}
+
+// remoteTranscriptDirs are the CLI data directories under the remote home
+// that transcripts are mirrored from
+var remoteTranscriptDirs = []string{".claude", ".gemini"}
+
+// inRemoteTranscriptDir reports whether transcript, once cleaned, lies in one
+// of remoteTranscriptDirs under home. The remote side checks the path with
+// symlinks resolved as well.
+func inRemoteTranscriptDir(home, transcript string) bool {
+	transcript = path.Clean(transcript)
+	for _, dir := range remoteTranscriptDirs {
+		if strings.HasPrefix(transcript, path.Join(home, dir)+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteHomeDir returns the home directory on host, resolving it over SSH
+// the first time
+func (e *Engine) remoteHomeDir(host string) (string, error) {
+	e.sessionMu.RLock()
+	home, ok := e.remoteHomes[host]
+	e.sessionMu.RUnlock()
+	if ok {
+		return home, nil
+	}
+
+	home, err := e.resolveRemoteHome(host)
+	if err != nil {
+		return "", err
+	}
+	e.sessionMu.Lock()
+	e.remoteHomes[host] = home
+	e.sessionMu.Unlock()
+	return home, nil
+}
+
+// resolveRemoteHome resolves the home directory on host
+func resolveRemoteHome(host string) (string, error) {
+	return watchdog.RemoteDir(host, "~")
+}
+
+// readRemoteTranscript reads a transcript on host, refusing files that do not
+// resolve into remoteTranscriptDirs under the remote home
+func readRemoteTranscript(host, path string) ([]byte, error) {
+	return watchdog.ReadRemoteFileUnder(host, path, remoteTranscriptDirs)
+}
+
+// mirrorRemoteTranscript copies the transcript named by a hook from a
+// session on a host to RemoteTranscriptDir and points the hook data at the
+// copy, so adapters read it like a local transcript. Hooks of local sessions,
+// and hooks without a transcript, are returned unchanged.
+func (e *Engine) mirrorRemoteTranscript(tmuxSession string, data []byte) ([]byte, error) {
+	e.sessionMu.RLock()
+	session, ok := e.sessions[tmuxSession]
+	e.sessionMu.RUnlock()
+	if !ok || session.Host == "" {
+		return data, nil
+	}
+
+	var payload map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		// Not JSON: let the adapter report it
+		return data, nil
+	}
+	remotePath, _ := payload["transcript_path"].(string)
+	if remotePath == "" {
+		return data, nil
+	}
+	home, err := e.remoteHomeDir(session.Host)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(remotePath, "/") || strings.Contains(remotePath, "..") ||
+		(filepath.Ext(remotePath) != ".jsonl" && filepath.Ext(remotePath) != ".json") ||
+		!inRemoteTranscriptDir(home, remotePath) {
+		return nil, fmt.Errorf("transcript path on %s is not a transcript: %s", session.Host, remotePath)
+	}
+	remotePath = path.Clean(remotePath)
+
+	content, err := e.readRemoteFile(session.Host, remotePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := expandHome(cli.RemoteTranscriptDir)
+	if err != nil {
+		return nil, err
+	}
+	dir = filepath.Join(dir, sanitizeHostDir(session.Host))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create transcript mirror directory: %w", err)
+	}
+	// Name copies after the remote path so each transcript keeps one file
+	sum := sha256.Sum256([]byte(remotePath))
+	localPath := filepath.Join(dir, hex.EncodeToString(sum[:6])+"-"+filepath.Base(remotePath))
+	if err := os.WriteFile(localPath, content, 0600); err != nil {
+		return nil, fmt.Errorf("write transcript mirror: %w", err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"session": session.Name,
+		"host":    session.Host,
+		"remote":  remotePath,
+		"local":   localPath,
+		"bytes":   len(content),
+	}).Debug("remote-transcript-mirrored")
+
+	payload["transcript_path"] = localPath
+	return json.Marshal(payload)
+}
+
+// sanitizeHostDir turns an SSH target into a directory name
+func sanitizeHostDir(host string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, host)
+}
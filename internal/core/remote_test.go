@@ -0,0 +1,222 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHookAdapter is a mock CLI adapter that records the hook data it gets
+type recordingHookAdapter struct {
+	*mockCLIAdapter
+	data []byte
+}
+
+func (r *recordingHookAdapter) HandleHookData(data []byte) (string, string, string, error) {
+	r.data = data
+	return "", "", "done", nil
+}
+
+// fakeRemoteFiles holds the contents of remote files by "host:path"
+type fakeRemoteFiles map[string]string
+
+func (f fakeRemoteFiles) read(host, path string) ([]byte, error) {
+	content, ok := f[host+":"+path]
+	if !ok {
+		return nil, fmt.Errorf("failed to read %s on %s", path, host)
+	}
+	return []byte(content), nil
+}
+
+// devHome resolves the home directory of every host to /home/dev
+func devHome(host string) (string, error) {
+	return "/home/dev", nil
+}
+
+func postHook(engine *Engine, tmuxSession, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/hook?cli_type=claude&tmux_session="+tmuxSession, strings.NewReader(body))
+	req.Header.Set(constants.HookSecretHeader, "s3cret")
+	rec := httptest.NewRecorder()
+	engine.handleHookRequest(rec, req)
+	return rec
+}
+
+// TestEngine_HandleHookRequest_RemoteTranscript tests that hooks of sessions on
+// hosts get a local copy of their transcript
+func TestEngine_HandleHookRequest_RemoteTranscript(t *testing.T) {
+	remote := "/home/dev/.claude/projects/-repo/abc.jsonl"
+	t.Setenv("HOME", t.TempDir())
+	adapter := &recordingHookAdapter{mockCLIAdapter: newMockCLIAdapter()}
+	engine, _, _ := newMessageTestEngine()
+	engine.hookSecret = "s3cret"
+	engine.RegisterCLIAdapter("claude", adapter)
+	engine.sessions["main"].Host = "dev@devbox"
+	engine.resolveRemoteHome = devHome
+	engine.readRemoteFile = fakeRemoteFiles{"dev@devbox:" + remote: `{"type":"assistant"}`}.read
+
+	rec := postHook(engine, "main", `{"cwd":"/repo","session_id":"abc","transcript_path":"`+remote+`","n":12345678901234567}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var payload map[string]any
+	decoder := json.NewDecoder(strings.NewReader(string(adapter.data)))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&payload))
+	local := payload["transcript_path"].(string)
+	home, _ := os.UserHomeDir()
+	assert.True(t, strings.HasPrefix(local, filepath.Join(home, ".clibot", "remote", "dev_devbox")+string(filepath.Separator)), local)
+	assert.True(t, strings.HasSuffix(local, "-abc.jsonl"), local)
+	assert.Equal(t, "12345678901234567", payload["n"].(json.Number).String(), "numbers survive the rewrite")
+
+	content, err := os.ReadFile(local)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"assistant"}`, string(content))
+
+	// The same transcript is mirrored to the same file
+	postHook(engine, "main", `{"transcript_path":"`+remote+`"}`)
+	assert.Contains(t, string(adapter.data), local)
+}
+
+// TestEngine_HandleHookRequest_RemoteTranscriptErrors tests rejected and unreadable remote transcripts
+func TestEngine_HandleHookRequest_RemoteTranscriptErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	adapter := &recordingHookAdapter{mockCLIAdapter: newMockCLIAdapter()}
+	engine, _, _ := newMessageTestEngine()
+	engine.hookSecret = "s3cret"
+	engine.RegisterCLIAdapter("claude", adapter)
+	engine.sessions["main"].Host = "dev@devbox"
+	engine.resolveRemoteHome = devHome
+	engine.readRemoteFile = fakeRemoteFiles{}.read
+
+	for _, path := range []string{"/home/dev/.ssh/id_ed25519", "/home/dev/.claude/../x.jsonl", "relative.jsonl", "/home/dev/notes.jsonl", "/home/dev/.claude.jsonl", "/tmp/x/.claude/projects/abc.jsonl"} {
+		rec := postHook(engine, "main", `{"transcript_path":"`+path+`"}`)
+		assert.Equal(t, http.StatusBadGateway, rec.Code, path)
+	}
+	rec := postHook(engine, "main", `{"transcript_path":"/home/dev/.claude/gone.jsonl"}`)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Nil(t, adapter.data)
+
+	// Local sessions and hooks without a transcript pass through unchanged
+	postHook(engine, "side", `{"transcript_path":"/home/dev/.claude/gone.jsonl"}`)
+	assert.Equal(t, `{"transcript_path":"/home/dev/.claude/gone.jsonl"}`, string(adapter.data))
+	postHook(engine, "main", `{"cwd":"/repo"}`)
+	assert.Equal(t, `{"cwd":"/repo"}`, string(adapter.data))
+}
+
+func TestInRemoteTranscriptDir(t *testing.T) {
+	assert.True(t, inRemoteTranscriptDir("/home/dev", "/home/dev/.claude/projects/-repo/abc.jsonl"))
+	assert.True(t, inRemoteTranscriptDir("/home/dev", "/home/dev/.gemini/tmp/x/chats/session.json"))
+	assert.True(t, inRemoteTranscriptDir("/home/dev", "/home/dev/.claude/projects/../projects/abc.jsonl"))
+
+	assert.False(t, inRemoteTranscriptDir("/home/dev", "/tmp/x/.claude/projects/../../../etc/passwd"))
+	assert.False(t, inRemoteTranscriptDir("/home/dev", "/home/dev/.claude/projects/../../.ssh/id_ed25519"))
+	assert.False(t, inRemoteTranscriptDir("/home/dev", "/tmp/x/.claude/projects/abc.jsonl"), "only under the remote home")
+	assert.False(t, inRemoteTranscriptDir("/home/dev", "/home/dev/.claude"))
+	assert.False(t, inRemoteTranscriptDir("/home/dev", "/home/dev/.claude.jsonl"))
+}
+
+// TestEngine_RemoteHomeDir tests that the home directory of a host is resolved once
+func TestEngine_RemoteHomeDir(t *testing.T) {
+	engine := NewEngine(&Config{})
+	resolved := 0
+	engine.resolveRemoteHome = func(host string) (string, error) {
+		if host == "down" {
+			return "", errors.New("connection refused")
+		}
+		resolved++
+		return "/home/dev", nil
+	}
+
+	for range 2 {
+		home, err := engine.remoteHomeDir("dev@devbox")
+		require.NoError(t, err)
+		assert.Equal(t, "/home/dev", home)
+	}
+	assert.Equal(t, 1, resolved)
+
+	_, err := engine.remoteHomeDir("down")
+	assert.Error(t, err)
+}
+
+func TestRemoteHookForward(t *testing.T) {
+	forward, err := remoteHookForward(HookServerConfig{Host: "0.0.0.0", Port: 8080, RemotePort: 9090})
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:9090:127.0.0.1:8080", forward)
+
+	forward, err = remoteHookForward(HookServerConfig{Socket: "/run/clibot.sock", RemotePort: 8080})
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8080:/run/clibot.sock", forward)
+}
+
+func TestEngine_SuperviseHookTunnel(t *testing.T) {
+	engine := NewEngine(&Config{})
+	opened := make(chan string, 4)
+	attempts := 0
+	engine.hookTunnel = func(ctx context.Context, host, forward string) error {
+		opened <- host + " " + forward
+		if attempts++; attempts == 1 {
+			return errors.New("remote port forwarding failed")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		engine.superviseHookTunnel("devbox", "127.0.0.1:8080:127.0.0.1:8080")
+		close(done)
+	}()
+
+	// A tunnel that fails is reopened after the backoff
+	for i := 0; i < 2; i++ {
+		select {
+		case got := <-opened:
+			assert.Equal(t, "devbox 127.0.0.1:8080:127.0.0.1:8080", got)
+		case <-time.After(3 * constants.HookTunnelInitialBackoff):
+			t.Fatal("hook tunnel was not reopened")
+		}
+	}
+
+	engine.cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("supervisor did not stop with the engine")
+	}
+}
+
+func TestEngine_HookHosts(t *testing.T) {
+	engine := NewEngine(&Config{Sessions: []SessionConfig{
+		{Name: "a", Host: "devbox", Mode: SessionModeHook},
+		{Name: "b", Host: "devbox", Mode: SessionModeHook},
+		{Name: "c", Host: "build", Mode: SessionModePoll},
+		{Name: "d", Host: "arm", Mode: SessionModeHook},
+		{Name: "e", Mode: SessionModeHook},
+	}})
+	assert.Equal(t, []string{"arm", "devbox"}, engine.hookHosts())
+}
+
+// TestEngine_HandleHistory_RemoteSession tests that history is not read for sessions on hosts
+func TestEngine_HandleHistory_RemoteSession(t *testing.T) {
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterCLIAdapter("claude", newHistoryCLIAdapter(2))
+	fileBot := &mockFileBot{}
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	engine.sessions["main"].Host = "devbox"
+
+	engine.HandleSpecialCommandWithArgs("shistory", nil, historyMessage("shistory"))
+	require.Len(t, fileBot.messages, 1)
+	assert.Contains(t, fileBot.messages[0], "runs on devbox")
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/sandbox"
+	"github.com/sirupsen/logrus"
+)
+
+// SandboxStatus is the container of a sandboxed session as shown by sstatus
+type SandboxStatus struct {
+	Container string
+	Image     string
+	Network   string
+	Stats     *sandbox.Stats // Resource usage (nil when unavailable)
+}
+
+// sandboxStartCmd returns the command starting startCmd in the session's
+// container. The container gets the CLI adapter's env merged with the
+// session's env (session values win). Any container left over from an
+// earlier run is removed first, so the name is free.
+func (e *Engine) sandboxStartCmd(session *Session, workDir, startCmd string, env map[string]string) (string, error) {
+	sb := session.Sandbox
+	opts := sandbox.Options{
+		Runtime: sb.Runtime,
+		Image:   sb.Image,
+		Network: sb.Network,
+		CPUs:    sb.CPUs,
+		Memory:  sb.Memory,
+		TTY:     session.CLIType != "acp",
+	}
+
+	if workDir != "" {
+		expanded, err := expandHome(workDir)
+		if err != nil {
+			return "", fmt.Errorf("invalid work_dir: %w", err)
+		}
+		opts.WorkDir = expanded
+	}
+	for _, mount := range sb.Mounts {
+		source, rest, _ := strings.Cut(mount, ":")
+		expanded, err := expandHome(source)
+		if err != nil {
+			return "", fmt.Errorf("invalid sandbox mount %q: %w", mount, err)
+		}
+		opts.Mounts = append(opts.Mounts, expanded+":"+rest)
+	}
+	// Values go through a file so they stay off the command line tmux shows
+	merged := make(map[string]string)
+	for name, value := range e.config.CLIAdapters[session.CLIType].Env {
+		merged[name] = value
+	}
+	for name, value := range env {
+		merged[name] = value
+	}
+	if len(merged) > 0 {
+		opts.EnvFile = sandboxEnvFile(session.Name)
+		if err := sandbox.WriteEnvFile(opts.EnvFile, merged); err != nil {
+			return "", err
+		}
+	}
+
+	container := sandbox.ContainerName(session.Name)
+	if err := e.removeSandbox(sb.Runtime, container); err != nil {
+		return "", err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"session":   session.Name,
+		"container": container,
+		"runtime":   sb.Runtime,
+		"image":     sb.Image,
+		"network":   opts.Network,
+	}).Info("starting-session-in-sandbox")
+	return sandbox.Command(container, opts, startCmd), nil
+}
+
+// stopSandbox removes the container of a sandboxed session, which outlives
+// the tmux pane or ACP process that started it
+func (e *Engine) stopSandbox(session *Session) {
+	if session.Sandbox == nil {
+		return
+	}
+	os.Remove(sandboxEnvFile(session.Name))
+	container := sandbox.ContainerName(session.Name)
+	if err := e.removeSandbox(session.Sandbox.Runtime, container); err != nil {
+		logger.WithFields(logrus.Fields{
+			"session":   session.Name,
+			"container": container,
+			"error":     err,
+		}).Warn("failed-to-remove-sandbox-container")
+		return
+	}
+	logger.WithFields(logrus.Fields{
+		"session":   session.Name,
+		"container": container,
+	}).Info("sandbox-container-removed")
+}
+
+// sandboxEnvFile returns the env file of a sandboxed session
+func sandboxEnvFile(sessionName string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".clibot", "sandbox", sessionName+".env")
+}
+
+// sandboxStatus reports the container of a sandboxed session, or nil
+func (e *Engine) sandboxStatus(session *Session) *SandboxStatus {
+	if session.Sandbox == nil {
+		return nil
+	}
+	status := &SandboxStatus{
+		Container: sandbox.ContainerName(session.Name),
+		Image:     session.Sandbox.Image,
+		Network:   session.Sandbox.Network,
+	}
+	if stats, err := e.sandboxStats(session.Sandbox.Runtime, status.Container); err == nil {
+		status.Stats = &stats
+	}
+	return status
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/internal/sandbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startingCLIAdapter is a mock CLI adapter that records how sessions are started
+type startingCLIAdapter struct {
+	*mockCLIAdapter
+	alive    bool
+	startCmd string
+	env      map[string]string
+}
+
+func (s *startingCLIAdapter) IsSessionAlive(sessionName string) bool {
+	return s.alive
+}
+
+func (s *startingCLIAdapter) CreateSession(sessionName, workDir, startCmd, transportURL string, env map[string]string) error {
+	s.startCmd, s.env, s.alive = startCmd, env, true
+	return nil
+}
+
+// fakeSandbox records the containers removed and reports fixed stats
+type fakeSandbox struct {
+	removed []string
+}
+
+func (f *fakeSandbox) remove(runtime, container string) error {
+	f.removed = append(f.removed, runtime+" "+container)
+	return nil
+}
+
+func (f *fakeSandbox) stats(runtime, container string) (sandbox.Stats, error) {
+	return sandbox.Stats{CPU: "3.20%", Memory: "812MiB / 4GiB", PIDs: "9"}, nil
+}
+
+// podmanSandbox returns the sandbox the tests run "main" in
+func podmanSandbox() *SandboxConfig {
+	return &SandboxConfig{
+		Runtime: sandbox.RuntimePodman,
+		Image:   "ghcr.io/acme/claude:latest",
+		Network: "bridge",
+		Memory:  "4g",
+		Mounts:  []string{"~/.claude:/home/dev/.claude"},
+	}
+}
+
+// TestEngine_EnsureSessionStarted_Sandbox tests starting the CLI in the session's container
+func TestEngine_EnsureSessionStarted_Sandbox(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	box := &fakeSandbox{}
+	adapter := &startingCLIAdapter{mockCLIAdapter: newMockCLIAdapter()}
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterCLIAdapter("claude", adapter)
+	engine.sessions["main"].Sandbox = podmanSandbox()
+	engine.removeSandbox = box.remove
+	repo := t.TempDir()
+	engine.sessions["main"].WorkDir = repo
+
+	_, err := engine.ensureSessionStarted(engine.sessions["main"], SessionConfig{
+		StartCmd: "claude --resume",
+		Env:      map[string]string{"ANTHROPIC_API_KEY": "sk-secret"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"podman clibot-main"}, box.removed, "a leftover container is removed first")
+	assert.True(t, strings.HasPrefix(adapter.startCmd, "podman 'run' '--rm' '--name' 'clibot-main' "), adapter.startCmd)
+	assert.Contains(t, adapter.startCmd, "'-it'")
+	assert.Contains(t, adapter.startCmd, "'--network' 'bridge' '--memory' '4g'")
+	assert.Contains(t, adapter.startCmd, fmt.Sprintf("'-v' '%s:%s' '-w' '%s'", repo, repo, repo))
+	home, _ := os.UserHomeDir()
+	assert.Contains(t, adapter.startCmd, fmt.Sprintf("'-v' '%s/.claude:/home/dev/.claude'", home))
+	assert.True(t, strings.HasSuffix(adapter.startCmd, "'ghcr.io/acme/claude:latest' 'sh' '-c' 'claude --resume'"), adapter.startCmd)
+
+	// Environment values reach the container through a private file, not the command line
+	assert.Nil(t, adapter.env)
+	assert.NotContains(t, adapter.startCmd, "sk-secret")
+	envFile := sandboxEnvFile("main")
+	assert.Contains(t, adapter.startCmd, "'--env-file' '"+envFile+"'")
+	data, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, "ANTHROPIC_API_KEY=sk-secret\n", string(data))
+
+	// Stopping the session removes the container and the env file
+	engine.stopSandbox(engine.sessions["main"])
+	assert.Equal(t, []string{"podman clibot-main", "podman clibot-main"}, box.removed)
+	assert.NoFileExists(t, envFile)
+}
+
+// TestEngine_SandboxStartCmd_AdapterEnv tests that adapter env reaches the container with session env on top
+func TestEngine_SandboxStartCmd_AdapterEnv(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	engine, _, _ := newMessageTestEngine()
+	engine.removeSandbox = (&fakeSandbox{}).remove
+	engine.config.CLIAdapters = map[string]CLIAdapterConfig{
+		"claude": {Env: map[string]string{"ANTHROPIC_BASE_URL": "https://proxy.example.com", "ANTHROPIC_API_KEY": "sk-adapter"}},
+		"acp":    {Env: map[string]string{"ACP_TOKEN": "acp-secret"}},
+	}
+	box := podmanSandbox()
+	session := &Session{Name: "main", CLIType: "claude", Sandbox: box}
+
+	// Sessions started from config: session env overrides the adapter's
+	startCmd, err := engine.sandboxStartCmd(session, "", "claude", map[string]string{"ANTHROPIC_API_KEY": "sk-session"})
+	require.NoError(t, err)
+	assert.NotContains(t, startCmd, "sk-")
+	data, err := os.ReadFile(sandboxEnvFile("main"))
+	require.NoError(t, err)
+	assert.Equal(t, "ANTHROPIC_API_KEY=sk-session\nANTHROPIC_BASE_URL=https://proxy.example.com\n", string(data))
+
+	// Dynamic sessions have no session env, and ACP sessions use the acp adapter's
+	for _, session := range []*Session{
+		{Name: "dyn", CLIType: "claude", Sandbox: box},
+		{Name: "agent", CLIType: "acp", Sandbox: box},
+	} {
+		startCmd, err := engine.sandboxStartCmd(session, "", "start", nil)
+		require.NoError(t, err)
+		assert.Contains(t, startCmd, "'--env-file' '"+sandboxEnvFile(session.Name)+"'", session.Name)
+	}
+	data, err = os.ReadFile(sandboxEnvFile("dyn"))
+	require.NoError(t, err)
+	assert.Equal(t, "ANTHROPIC_API_KEY=sk-adapter\nANTHROPIC_BASE_URL=https://proxy.example.com\n", string(data))
+	data, err = os.ReadFile(sandboxEnvFile("agent"))
+	require.NoError(t, err)
+	assert.Equal(t, "ACP_TOKEN=acp-secret\n", string(data))
+}
+
+// TestEngine_HandleSessionStatus_Sandbox tests that sstatus reports the container's resource usage
+func TestEngine_HandleSessionStatus_Sandbox(t *testing.T) {
+	fileBot := &mockFileBot{}
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterCLIAdapter("claude", &startingCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), alive: true})
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.sessions["main"].Sandbox = podmanSandbox()
+	engine.sandboxStats = (&fakeSandbox{}).stats
+	msg := bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1"}
+
+	engine.HandleSpecialCommandWithArgs("sstatus", []string{"main"}, msg)
+	require.Len(t, fileBot.messages, 1)
+	reply := fileBot.messages[0]
+	assert.Contains(t, reply, "📦 **Sandbox**")
+	assert.Contains(t, reply, "Container: clibot-main")
+	assert.Contains(t, reply, "Image: ghcr.io/acme/claude:latest")
+	assert.Contains(t, reply, "CPU: 3.20%")
+	assert.Contains(t, reply, "Memory: 812MiB / 4GiB")
+	assert.NotContains(t, reply, "Process Info", "the pane process is only the container client")
+
+	engine.sandboxStats = func(string, string) (sandbox.Stats, error) { return sandbox.Stats{}, fmt.Errorf("no such container") }
+	engine.HandleSpecialCommandWithArgs("sstatus", nil, msg)
+	assert.Contains(t, fileBot.messages[1], "CLI: claude")
+	assert.NotContains(t, fileBot.messages[1], "📦")
+}
+
+// TestEngine_ListSessions_Placement tests that slist shows where sessions run
+func TestEngine_ListSessions_Placement(t *testing.T) {
+	fileBot := &mockFileBot{}
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterBotAdapter("discord", fileBot)
+	engine.sessions["main"].Sandbox = podmanSandbox()
+	engine.sessions["side"].Host = "devbox"
+
+	engine.HandleSpecialCommandWithArgs("slist", nil, bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1"})
+	require.Len(t, fileBot.messages, 1)
+	assert.Contains(t, fileBot.messages[0], "main (claude, sandboxed)")
+	assert.Contains(t, fileBot.messages[0], "side (claude @ devbox)")
+}
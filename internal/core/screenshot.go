@@ -0,0 +1,82 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/screenshot"
+	"github.com/keepmind9/clibot/internal/watchdog"
+	"github.com/sirupsen/logrus"
+)
+
+// handleScreenshot sends an image of a session's tmux pane, keeping the
+// colors and menus a plain text capture loses. Platforms that cannot receive
+// images get the pane as text.
+// Usage: sshot [session]
+func (e *Engine) handleScreenshot(args []string, msg bot.BotMessage) {
+	var session *Session
+	if len(args) > 0 {
+		e.sessionMu.RLock()
+		session = e.sessions[args[0]]
+		e.sessionMu.RUnlock()
+		if session == nil {
+			e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("❌ Session '%s' not found", args[0]))
+			return
+		}
+	} else {
+		session = e.currentSession(msg)
+		if session == nil {
+			e.SendToBot(msg.Platform, msg.Channel,
+				"❌ You don't have an active session\nUsage: sshot <name>")
+			return
+		}
+	}
+
+	if session.CLIType == "acp" {
+		e.SendToBot(msg.Platform, msg.Channel,
+			fmt.Sprintf("❌ Session '%s' runs over ACP and has no terminal to capture", session.Name))
+		return
+	}
+
+	screen, err := e.captureScreen(session.Name)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"session": session.Name,
+			"error":   err,
+		}).Warn("failed-to-capture-screen")
+		e.SendToBot(msg.Platform, msg.Channel,
+			fmt.Sprintf("❌ Failed to capture session '%s': %v", session.Name, err))
+		return
+	}
+
+	if sender, ok := e.activeBots[msg.Platform].(bot.ImageSender); ok {
+		err := e.sendScreenshot(sender, session, screen, msg)
+		if err == nil {
+			return
+		}
+		logger.WithFields(logrus.Fields{
+			"platform": msg.Platform,
+			"session":  session.Name,
+			"error":    err,
+		}).Warn("failed-to-send-screenshot")
+	}
+
+	text := strings.TrimRight(watchdog.StripANSI(screen), " \n")
+	e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf("🖥️ [%s]\n```\n%s\n```", session.Name, text))
+}
+
+// sendScreenshot renders the captured screen to a temporary PNG and sends it
+func (e *Engine) sendScreenshot(sender bot.ImageSender, session *Session, screen string, msg bot.BotMessage) error {
+	name := fmt.Sprintf("clibot-sshot-%s-%s.png", session.Name, time.Now().Format("20060102-150405"))
+	path := filepath.Join(os.TempDir(), name)
+	if err := screenshot.WritePNG(path, screen); err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	return sender.SendImage(msg.Channel, path)
+}
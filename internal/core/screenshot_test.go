@@ -0,0 +1,106 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockImageBot is a mock bot adapter that records the images sent
+type mockImageBot struct {
+	mockBotAdapter
+	images  [][]byte // Contents of the images sent
+	sendErr error
+}
+
+func (m *mockImageBot) SendImage(channel, imagePath string) error {
+	if m.sendErr != nil {
+		return m.sendErr
+	}
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return err
+	}
+	m.images = append(m.images, data)
+	return nil
+}
+
+// fakeScreen records the sessions captured and shows a fixed screen
+type fakeScreen struct {
+	captured []string
+}
+
+func (f *fakeScreen) capture(sessionName string) (string, error) {
+	f.captured = append(f.captured, sessionName)
+	return "\x1b[32m❯\x1b[0m Do you want to proceed?\n\x1b[7m 1. Yes \x1b[0m\n\n", nil
+}
+
+// TestEngine_HandleScreenshot tests sending the current or named session's screen as a PNG
+func TestEngine_HandleScreenshot(t *testing.T) {
+	screen := &fakeScreen{}
+	imageBot := &mockImageBot{}
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterBotAdapter("discord", imageBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	engine.captureScreen = screen.capture
+
+	engine.HandleSpecialCommandWithArgs("sshot", nil, historyMessage("sshot"))
+	require.Len(t, imageBot.images, 1)
+	assert.True(t, bytes.HasPrefix(imageBot.images[0], []byte("\x89PNG")))
+	assert.Equal(t, 0, imageBot.messageCount)
+
+	engine.HandleSpecialCommandWithArgs("sshot", []string{"side"}, historyMessage("sshot side"))
+	assert.Len(t, imageBot.images, 2)
+	assert.Equal(t, []string{"main", "side"}, screen.captured)
+}
+
+// TestEngine_HandleScreenshot_TextFallback tests platforms without image upload
+func TestEngine_HandleScreenshot_TextFallback(t *testing.T) {
+	engine, _, plain := newMessageTestEngine()
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+	engine.captureScreen = (&fakeScreen{}).capture
+
+	engine.HandleSpecialCommandWithArgs("sshot", nil, historyMessage("sshot"))
+	assert.Equal(t, "🖥️ [main]\n```\n❯ Do you want to proceed?\n 1. Yes\n```", plain.lastMessage)
+
+	// Images that fail to send also fall back to text
+	imageBot := &mockImageBot{sendErr: fmt.Errorf("upload failed")}
+	engine.RegisterBotAdapter("discord", imageBot)
+	engine.HandleSpecialCommandWithArgs("sshot", nil, historyMessage("sshot"))
+	assert.Contains(t, imageBot.lastMessage, "❯ Do you want to proceed?")
+}
+
+// TestEngine_HandleScreenshot_Errors tests sessions that cannot be captured
+func TestEngine_HandleScreenshot_Errors(t *testing.T) {
+	imageBot := &mockImageBot{}
+	engine, _, _ := newMessageTestEngine()
+	engine.RegisterBotAdapter("discord", imageBot)
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleSpecialCommandWithArgs("sshot", []string{"nope"}, historyMessage("sshot nope"))
+	assert.Equal(t, "❌ Session 'nope' not found", imageBot.lastMessage)
+
+	engine.sessions["agent"] = &Session{Name: "agent", CLIType: "acp"}
+	engine.HandleSpecialCommandWithArgs("sshot", []string{"agent"}, historyMessage("sshot agent"))
+	assert.Contains(t, imageBot.lastMessage, "runs over ACP")
+
+	engine.captureScreen = func(string) (string, error) { return "", fmt.Errorf("no server running") }
+	engine.HandleSpecialCommandWithArgs("sshot", nil, historyMessage("sshot"))
+	assert.Equal(t, "❌ Failed to capture session 'main': no server running", imageBot.lastMessage)
+
+	delete(engine.userSessions, getUserKey("discord", "u1"))
+	engine.HandleSpecialCommandWithArgs("sshot", nil, historyMessage("sshot"))
+	assert.Contains(t, imageBot.lastMessage, "You don't have an active session")
+	assert.Empty(t, imageBot.images)
+}
+
+func TestIsSpecialCommand_Screenshot(t *testing.T) {
+	cmd, ok, args := isSpecialCommand("sshot backend")
+	assert.True(t, ok)
+	assert.Equal(t, "sshot", cmd)
+	assert.Equal(t, []string{"backend"}, args)
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/internal/proxy"
+	"github.com/keepmind9/clibot/internal/transcribe"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// pendingTranscript is a voice transcript waiting for the user's confirmation
+type pendingTranscript struct {
+	text      string
+	createdAt time.Time
+}
+
+// newTranscriber builds the configured transcription backend, or nil when disabled
+func newTranscriber(cfg TranscriptionConfig, proxyMgr *proxy.ProxyManager) bot.Transcriber {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	switch cfg.Backend {
+	case TranscriptionBackendWhisperCPP:
+		modelPath, err := expandHome(cfg.ModelPath)
+		if err != nil {
+			modelPath = cfg.ModelPath
+		}
+		whisper := transcribe.NewWhisperCPP(cfg.BinaryPath, modelPath, cfg.Language)
+		if cfg.FFmpegPath != "" {
+			whisper.FFmpegPath = cfg.FFmpegPath
+		}
+		return whisper
+	case TranscriptionBackendOpenAI:
+		client, err := proxyMgr.GetHTTPClient("transcription")
+		if err != nil {
+			logger.WithField("error", err).Warn("transcription-proxy-client-failed-using-default")
+			client = nil
+		}
+		return transcribe.NewOpenAI(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.Language, client)
+	default:
+		logger.WithField("backend", cfg.Backend).Warn("unknown-transcription-backend")
+		return nil
+	}
+}
+
+// interceptTranscript echoes voice transcripts back to the user and handles the
+// confirmation reply. It returns the message to process, which is the confirmed
+// transcript when the user replies "ok", and false when processing should stop.
+func (e *Engine) interceptTranscript(msg bot.BotMessage) (bot.BotMessage, bool) {
+	userKey := getUserKey(msg.Platform, msg.UserID)
+
+	if msg.Transcribed {
+		msg.Transcribed = false
+		if e.config.Transcription.AutoSend {
+			e.SendToBot(msg.Platform, msg.Channel, "🎤 "+msg.Content)
+			return msg, true
+		}
+
+		e.sessionMu.Lock()
+		e.pendingTranscripts[userKey] = pendingTranscript{text: msg.Content, createdAt: time.Now()}
+		e.sessionMu.Unlock()
+
+		logger.WithFields(logrus.Fields{
+			"user":   userKey,
+			"length": len(msg.Content),
+		}).Info("voice-transcript-awaiting-confirmation")
+		e.SendToBot(msg.Platform, msg.Channel, fmt.Sprintf(
+			"🎤 Heard: %s\n\nReply 'ok' to send it, 'cancel' to discard it, or type a correction", msg.Content))
+		return msg, false
+	}
+
+	e.sessionMu.Lock()
+	pending, exists := e.pendingTranscripts[userKey]
+	delete(e.pendingTranscripts, userKey)
+	e.sessionMu.Unlock()
+
+	if !exists || time.Since(pending.createdAt) > constants.TranscriptConfirmTimeout {
+		return msg, true
+	}
+
+	switch strings.ToLower(strings.TrimSpace(msg.Content)) {
+	case "ok", "yes", "send":
+		msg.Content = pending.text
+		return msg, true
+	case "cancel", "no":
+		e.SendToBot(msg.Platform, msg.Channel, "🗑️ Voice message discarded")
+		return msg, false
+	}
+	// Any other input replaces the pending transcript
+	return msg, true
+}
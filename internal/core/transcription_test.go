@@ -0,0 +1,106 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/bot"
+	"github.com/keepmind9/clibot/internal/transcribe"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func voiceMessage(content string) bot.BotMessage {
+	return bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1", Content: content, Transcribed: true}
+}
+
+func textMessage(content string) bot.BotMessage {
+	return bot.BotMessage{Platform: "discord", UserID: "u1", Channel: "c1", Content: content}
+}
+
+// TestEngine_Transcript_ConfirmAndCancel tests that transcripts wait for confirmation
+func TestEngine_Transcript_ConfirmAndCancel(t *testing.T) {
+	engine, cliAdapter, botAdapter := newMessageTestEngine()
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleUserMessage(voiceMessage("run the tests"))
+	assert.Empty(t, cliAdapter.inputs["main"])
+	assert.Contains(t, botAdapter.lastMessage, "Heard: run the tests")
+
+	engine.HandleUserMessage(textMessage("OK"))
+	assert.Equal(t, []string{"run the tests"}, cliAdapter.inputs["main"])
+
+	// A second "ok" without a pending transcript is sent as-is
+	engine.HandleUserMessage(textMessage("ok"))
+	assert.Equal(t, []string{"run the tests", "ok"}, cliAdapter.inputs["main"])
+
+	engine.HandleUserMessage(voiceMessage("delete everything"))
+	engine.HandleUserMessage(textMessage("cancel"))
+	assert.Len(t, cliAdapter.inputs["main"], 2)
+	assert.Contains(t, botAdapter.lastMessage, "discarded")
+
+	// Typing a correction replaces the transcript
+	engine.HandleUserMessage(voiceMessage("show the dif"))
+	engine.HandleUserMessage(textMessage("show the diff"))
+	assert.Equal(t, "show the diff", cliAdapter.inputs["main"][2])
+}
+
+// TestEngine_Transcript_Expired tests that stale transcripts are not confirmed
+func TestEngine_Transcript_Expired(t *testing.T) {
+	engine, cliAdapter, _ := newMessageTestEngine()
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleUserMessage(voiceMessage("run the tests"))
+	key := getUserKey("discord", "u1")
+	pending := engine.pendingTranscripts[key]
+	pending.createdAt = time.Now().Add(-constants.TranscriptConfirmTimeout - time.Second)
+	engine.pendingTranscripts[key] = pending
+
+	engine.HandleUserMessage(textMessage("ok"))
+	assert.Equal(t, []string{"ok"}, cliAdapter.inputs["main"])
+}
+
+// TestEngine_Transcript_AutoSend tests that auto_send echoes and forwards immediately
+func TestEngine_Transcript_AutoSend(t *testing.T) {
+	engine, cliAdapter, botAdapter := newMessageTestEngine()
+	engine.config.Transcription.AutoSend = true
+	engine.userSessions[getUserKey("discord", "u1")] = "main"
+
+	engine.HandleUserMessage(voiceMessage("run the tests"))
+	assert.Equal(t, []string{"run the tests"}, cliAdapter.inputs["main"])
+	assert.Equal(t, 1, botAdapter.messageCount)
+	assert.Equal(t, "🎤 run the tests", botAdapter.lastMessage)
+}
+
+// TestEngine_Transcript_CommandNeedsConfirmation tests that a spoken command is not run directly
+func TestEngine_Transcript_CommandNeedsConfirmation(t *testing.T) {
+	engine, _, botAdapter := newMessageTestEngine()
+
+	engine.HandleBotMessage(voiceMessage("slist"))
+	msg := <-engine.messageChan
+	assert.True(t, msg.Transcribed, "transcribed commands are queued, not fast-tracked")
+
+	engine.HandleUserMessage(msg)
+	assert.Contains(t, botAdapter.lastMessage, "Heard: slist")
+	engine.HandleUserMessage(textMessage("ok"))
+	assert.Contains(t, botAdapter.lastMessage, "main")
+}
+
+func TestNewTranscriber(t *testing.T) {
+	engine := NewEngine(&Config{})
+	assert.Nil(t, newTranscriber(TranscriptionConfig{}, engine.proxyMgr))
+
+	whisper, ok := newTranscriber(TranscriptionConfig{
+		Enabled: true, Backend: TranscriptionBackendWhisperCPP, ModelPath: "model.bin", FFmpegPath: "/opt/ffmpeg",
+	}, engine.proxyMgr).(*transcribe.WhisperCPP)
+	require.True(t, ok)
+	assert.Equal(t, "model.bin", whisper.ModelPath)
+	assert.Equal(t, "/opt/ffmpeg", whisper.FFmpegPath)
+
+	openai, ok := newTranscriber(TranscriptionConfig{
+		Enabled: true, Backend: TranscriptionBackendOpenAI, APIKey: "sk-test",
+	}, engine.proxyMgr).(*transcribe.OpenAI)
+	require.True(t, ok)
+	assert.Equal(t, "sk-test", openai.APIKey)
+}
@@ -16,15 +16,20 @@ const (
 
 // Session represents a tmux session with its metadata
 type Session struct {
-	Name      string             // tmux session name
-	CLIType   string             // claude/gemini/opencode
-	WorkDir   string             // Working directory
-	StartCmd  string             // Command to start the CLI (default: same as CLIType)
-	State     SessionState       // Current state
-	CreatedAt string             // Creation timestamp
-	IsDynamic bool               // true if session was created dynamically via IM
-	CreatedBy string             // creator identity (format: "platform:userID")
-	cancelCtx context.CancelFunc // Cancel function for active watchdog goroutine
+	Name       string             // tmux session name
+	CLIType    string             // claude/gemini/opencode
+	WorkDir    string             // Working directory
+	StartCmd   string             // Command to start the CLI (default: same as CLIType)
+	Mode       string             // "hook" or "poll" (empty means hook)
+	Completion string             // "hook" or "watch" (empty means hook)
+	Host       string             // SSH target running the session's tmux (empty means local)
+	Sandbox    *SandboxConfig     // Container the CLI runs in (nil means none)
+	State      SessionState       // Current state
+	CreatedAt  string             // Creation timestamp
+	IsDynamic  bool               // true if session was created dynamically via IM
+	CreatedBy  string             // creator identity (format: "platform:userID")
+	HookEvents HookEventsConfig   // Extra hook events forwarded to chat
+	cancelCtx  context.CancelFunc // Cancel function for active watchdog goroutine
 }
 
 // NeedsWatchdog returns true if session requires watchdog monitoring
@@ -45,20 +50,27 @@ type ResponseEvent struct {
 
 // Config represents the complete clibot configuration structure
 type Config struct {
-	HookServer  HookServerConfig            `yaml:"hook_server"`
-	Security    SecurityConfig              `yaml:"security"`
-	Watchdog    WatchdogConfig              `yaml:"watchdog"`
-	Session     SessionGlobalConfig         `yaml:"session"`
-	Sessions    []SessionConfig             `yaml:"sessions"`
-	Bots        map[string]BotConfig        `yaml:"bots"`
-	CLIAdapters map[string]CLIAdapterConfig `yaml:"cli_adapters"`
-	Logging     LoggingConfig               `yaml:"logging"`
-	Proxy       ProxyConfig                 `yaml:"proxy"`
+	HookServer    HookServerConfig            `yaml:"hook_server"`
+	Security      SecurityConfig              `yaml:"security"`
+	Watchdog      WatchdogConfig              `yaml:"watchdog"`
+	Session       SessionGlobalConfig         `yaml:"session"`
+	Sessions      []SessionConfig             `yaml:"sessions"`
+	Bots          map[string]BotConfig        `yaml:"bots"`
+	CLIAdapters   map[string]CLIAdapterConfig `yaml:"cli_adapters"`
+	Logging       LoggingConfig               `yaml:"logging"`
+	Proxy         ProxyConfig                 `yaml:"proxy"`
+	Transcription TranscriptionConfig         `yaml:"transcription"`
 }
 
 // HookServerConfig represents HTTP Hook server configuration
 type HookServerConfig struct {
-	Port int `yaml:"port"`
+	Port   int    `yaml:"port"`
+	Host   string `yaml:"host"`   // Listen address (default: 127.0.0.1)
+	Socket string `yaml:"socket"` // Unix socket path, replaces host/port when set
+
+	// RemotePort is the port the hook server is forwarded to on session hosts,
+	// where "clibot hook" posts to (default: port)
+	RemotePort int `yaml:"remote_port"`
 }
 
 // SecurityConfig represents security and access control configuration
@@ -69,18 +81,20 @@ type SecurityConfig struct {
 }
 
 // WatchdogConfig represents watchdog monitoring configuration
+// Polling sessions (mode: poll) use these settings to watch the tmux pane
 type WatchdogConfig struct {
 	Enabled        bool     `yaml:"enabled"`
-	CheckIntervals []string `yaml:"check_intervals"`
-	Timeout        string   `yaml:"timeout"`
-	MaxRetries     int      `yaml:"max_retries"`
-	InitialDelay   string   `yaml:"initial_delay"`
-	RetryDelay     string   `yaml:"retry_delay"`
+	CheckIntervals []string `yaml:"check_intervals"` // Delays between pane checks, the last one repeating (default: retry_delay)
+	Timeout        string   `yaml:"timeout"`         // Longest wait for output to settle (default: 10m)
+	MaxRetries     int      `yaml:"max_retries"`     // Consecutive failed pane captures before giving up (default: 10)
+	InitialDelay   string   `yaml:"initial_delay"`   // Wait before the first check (default: 500ms)
+	RetryDelay     string   `yaml:"retry_delay"`     // Delay between checks without check_intervals (default: 800ms)
 }
 
 // SessionGlobalConfig represents global session configuration
 type SessionGlobalConfig struct {
-	MaxDynamicSessions int `yaml:"max_dynamic_sessions"` // Maximum number of dynamic sessions allowed (default: 50)
+	MaxDynamicSessions int            `yaml:"max_dynamic_sessions"` // Maximum number of dynamic sessions allowed (default: 50)
+	Sandbox            *SandboxConfig `yaml:"sandbox"`              // Container for sessions created with snew (default: none)
 }
 
 // SessionConfig represents a session configuration
@@ -92,6 +106,40 @@ type SessionConfig struct {
 	StartCmd  string            `yaml:"start_cmd"` // Command to start the CLI (default: same as CLIType)
 	Transport string            `yaml:"transport"` // Connection URL for ACP: stdio://, tcp://host:port, unix:///path (for acp cli_type only)
 	Env       map[string]string `yaml:"env"`       // Session-level environment variables (merged with adapter-level env)
+	Mode      string            `yaml:"mode"`      // "hook" (default) or "poll" to watch the tmux pane instead of waiting for hooks
+	Host      string            `yaml:"host"`      // SSH target running the session's tmux, e.g. "dev@devbox" (default: this machine)
+
+	// Completion selects what ends a hook mode turn: "hook" (default) waits for
+	// the Stop hook, "watch" tails the CLI's transcript so no hook is needed
+	Completion string `yaml:"completion"`
+
+	HookEvents HookEventsConfig `yaml:"hook_events"` // Extra Claude Code hook events forwarded to chat (all off by default)
+
+	// Sandbox runs the CLI inside a Docker or Podman container (default: on this machine)
+	Sandbox *SandboxConfig `yaml:"sandbox"`
+}
+
+// SandboxConfig describes the container a session's CLI runs in. work_dir is
+// bind-mounted at the same path; nothing else of the machine is visible
+// unless listed in mounts.
+type SandboxConfig struct {
+	Runtime string   `yaml:"runtime"` // "docker" (default) or "podman"
+	Image   string   `yaml:"image"`   // Container image with the CLI installed
+	Network string   `yaml:"network"` // "none" (default), "bridge", "host" or a named network
+	CPUs    string   `yaml:"cpus"`    // CPU limit, e.g. "2" (default: unlimited)
+	Memory  string   `yaml:"memory"`  // Memory limit, e.g. "4g" (default: unlimited)
+	Mounts  []string `yaml:"mounts"`  // Extra bind mounts "source:target[:ro]", e.g. the CLI's config directory
+}
+
+// HookEventsConfig selects which hook events besides Stop and Notification a
+// session forwards to chat
+type HookEventsConfig struct {
+	SubagentStop     bool     `yaml:"subagent_stop"`     // Post subagent summaries
+	SessionLifecycle bool     `yaml:"session_lifecycle"` // Report SessionStart/SessionEnd and update session state
+	PostToolUse      bool     `yaml:"post_tool_use"`     // Progress feed of completed tool calls
+	PreToolUse       string   `yaml:"pre_tool_use"`      // "off" (default), "notify" or "approve"
+	RiskyTools       []string `yaml:"risky_tools"`       // Tools that pre_tool_use applies to (default: Bash, Write, Edit, MultiEdit, NotebookEdit)
+	ApprovalTimeout  string   `yaml:"approval_timeout"`  // How long "approve" waits for a reply before deferring to the terminal (default: 5m)
 }
 
 // BotConfig represents bot configuration
@@ -101,11 +149,54 @@ type BotConfig struct {
 	AppSecret         string       `yaml:"app_secret"`
 	Token             string       `yaml:"token"`
 	ChannelID         string       `yaml:"channel_id"`         // For Discord: server channel ID
+	ChannelIDs        []string     `yaml:"channel_ids"`        // Discord: additional channels accepting messages (optional)
+	ThreadPerSession  bool         `yaml:"thread_per_session"` // Discord: open a thread per session on /suse (optional)
 	EncryptKey        string       `yaml:"encrypt_key"`        // Feishu: event encryption key (optional)
 	VerificationToken string       `yaml:"verification_token"` // Feishu: verification token (optional)
 	BaseURL           string       `yaml:"base_url"`           // WeChat iLink: API base URL (optional)
 	CredentialsPath   string       `yaml:"credentials_path"`   // WeChat iLink: credentials file path (optional)
 	Proxy             *ProxyConfig `yaml:"proxy"`              // Optional bot-level proxy override
+	Markdown          bool         `yaml:"markdown"`           // QQ/DingTalk: send markdown messages (QQ needs permission)
+	KeyboardID        string       `yaml:"keyboard_id"`        // QQ: approved keyboard template for markdown messages
+	ActionCard        bool         `yaml:"action_card"`        // DingTalk: send action cards with control-key buttons
+	StatePath         string       `yaml:"state_path"`         // DingTalk: conversation state file (optional)
+
+	// Generic webhook bot settings
+	Listen       string                     `yaml:"listen"`       // Webhook/Web: listen address (default: 127.0.0.1:8090 / 127.0.0.1:8091)
+	Integrations []WebhookIntegrationConfig `yaml:"integrations"` // Webhook: outgoing-webhook integrations
+
+	// Web chat UI settings (listen is shared with the webhook bot)
+	Tokens map[string]string `yaml:"tokens"` // Web: user ID -> login token
+
+	// Email bot settings
+	IMAPServer   string `yaml:"imap_server"`   // Email: IMAP host:port (993 TLS, 143 STARTTLS)
+	SMTPServer   string `yaml:"smtp_server"`   // Email: SMTP host:port (465 TLS, 587 STARTTLS)
+	Username     string `yaml:"username"`      // Email: IMAP/SMTP login
+	Password     string `yaml:"password"`      // Email: IMAP/SMTP password or app password
+	FromAddress  string `yaml:"from_address"`  // Email: reply sender address (default: username)
+	Mailbox      string `yaml:"mailbox"`       // Email: watched mailbox (default: INBOX)
+	SubjectToken string `yaml:"subject_token"` // Email: shared secret required in subject (default: require DKIM/SPF)
+}
+
+// WebhookIntegrationConfig represents one generic outgoing-webhook integration
+// (e.g. Microsoft Teams outgoing webhook, Mattermost, Rocket.Chat)
+type WebhookIntegrationConfig struct {
+	Name              string            `yaml:"name"`               // Integration name, prefixes user IDs and channels
+	Path              string            `yaml:"path"`               // Inbound path (default: /webhook/<name>)
+	Secret            string            `yaml:"secret"`             // HMAC-SHA256 secret
+	SecretEncoding    string            `yaml:"secret_encoding"`    // "raw" (default) or "base64"
+	SignatureHeader   string            `yaml:"signature_header"`   // Signature header (default: X-Signature)
+	SignaturePrefix   string            `yaml:"signature_prefix"`   // Prefix before the signature (e.g. "HMAC ")
+	SignatureEncoding string            `yaml:"signature_encoding"` // "hex" (default) or "base64"
+	CallbackURL       string            `yaml:"callback_url"`       // URL receiving replies
+	CallbackTemplate  string            `yaml:"callback_template"`  // Go text/template for the reply body
+	CallbackHeaders   map[string]string `yaml:"callback_headers"`   // Extra headers for callback requests
+	UserField         string            `yaml:"user_field"`         // Dotted JSON path of sender ID
+	TextField         string            `yaml:"text_field"`         // Dotted JSON path of message text (default: text)
+	ChannelField      string            `yaml:"channel_field"`      // Dotted JSON path of conversation ID (optional)
+	MessageIDField    string            `yaml:"message_id_field"`   // Dotted JSON path of message ID (optional)
+	AckBody           string            `yaml:"ack_body"`           // Synchronous response body (optional)
+	UserMap           map[string]string `yaml:"user_map"`           // Platform user ID -> whitelist user ID
 }
 
 // CLIAdapterConfig represents CLI adapter configuration
@@ -118,6 +209,42 @@ type CLIAdapterConfig struct {
 
 	// Environment variables to set for the CLI process
 	Env map[string]string `yaml:"env"`
+
+	// Type "custom" defines a CLI entirely in YAML; built-in adapters leave it empty
+	Type     string               `yaml:"type"`
+	StartCmd string               `yaml:"start_cmd"` // Custom: command starting the CLI
+	Input    CustomInputConfig    `yaml:"input"`     // Custom: how input is sent
+	Response CustomResponseConfig `yaml:"response"`  // Custom: where responses come from
+	Keys     map[string]string    `yaml:"keys"`      // Custom: chat keyword -> space-separated tmux key names
+
+	// Aider: chat history file, relative to the repo root or absolute
+	// (default: .aider.chat.history.md, Aider's --chat-history-file default)
+	HistoryFile string `yaml:"history_file"`
+}
+
+// CustomInputConfig selects how a custom CLI receives input
+type CustomInputConfig struct {
+	Method       string `yaml:"method"`         // "send_keys" (default), "paste" or "stdin"
+	EnterDelayMs int    `yaml:"enter_delay_ms"` // Delay before Enter
+}
+
+// CustomResponseConfig locates the responses of a custom CLI.
+// Fields and paths use a JSONPath subset such as "$.message.content" or "items[-1].text".
+type CustomResponseConfig struct {
+	Source string `yaml:"source"` // "poll" (default), "hook" or "transcript"
+
+	CWDField      string `yaml:"cwd_field"`      // Hook payload: working directory (default: cwd)
+	PromptField   string `yaml:"prompt_field"`   // Hook payload: last user prompt (hook source)
+	ResponseField string `yaml:"response_field"` // Hook payload: assistant response (hook source)
+
+	TranscriptGlob  string `yaml:"transcript_glob"`  // Transcript files, newest match is read
+	TranscriptField string `yaml:"transcript_field"` // Hook payload: transcript path (must lie under transcript_glob)
+	MessagesPath    string `yaml:"messages_path"`    // Messages array in a JSON transcript (empty: JSONL)
+	RolePath        string `yaml:"role_path"`        // Message role (default: role)
+	TextPath        string `yaml:"text_path"`        // Message text (default: content)
+	AssistantRole   string `yaml:"assistant_role"`   // Default: assistant
+	UserRole        string `yaml:"user_role"`        // Default: user
+	Regex           string `yaml:"regex"`            // Alternative: last match in the transcript (group 1 if present)
 }
 
 // LoggingConfig represents logging configuration
@@ -131,6 +258,24 @@ type LoggingConfig struct {
 	EnableStdout bool   `yaml:"enable_stdout"` // Also output to stdout (default: true)
 }
 
+// TranscriptionConfig represents voice message transcription configuration
+type TranscriptionConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Backend  string `yaml:"backend"`   // "whisper_cpp" or "openai"
+	AutoSend bool   `yaml:"auto_send"` // Send transcripts without waiting for confirmation
+	Language string `yaml:"language"`  // Spoken language code (default: auto-detect)
+
+	// whisper.cpp backend
+	BinaryPath string `yaml:"binary_path"` // whisper.cpp CLI (default: whisper-cli)
+	ModelPath  string `yaml:"model_path"`  // ggml model file (required)
+	FFmpegPath string `yaml:"ffmpeg_path"` // Converts voice notes to WAV (default: ffmpeg)
+
+	// OpenAI-compatible backend
+	BaseURL string `yaml:"base_url"` // API base (default: https://api.openai.com/v1)
+	APIKey  string `yaml:"api_key"`  // Bearer token
+	Model   string `yaml:"model"`    // Model name (default: whisper-1)
+}
+
 // ProxyConfig represents network proxy configuration
 type ProxyConfig struct {
 	Enabled  bool   `yaml:"enabled"`  // Whether proxy is enabled
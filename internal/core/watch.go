@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/keepmind9/clibot/internal/cli"
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/keepmind9/clibot/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// latestTurnID returns the ID of the latest turn in a watched session's
+// transcript, taken before a prompt is sent so the old turn is not delivered
+func (e *Engine) latestTurnID(session *Session) string {
+	watcher, ok := e.cliAdapters[session.CLIType].(cli.TranscriptWatcher)
+	if !ok {
+		return ""
+	}
+	workDir, err := expandHome(session.WorkDir)
+	if err != nil {
+		return ""
+	}
+	turn, err := watcher.LatestTurn(workDir)
+	if err != nil {
+		return ""
+	}
+	return turn.ID
+}
+
+// watchForCompletion watches a session's transcripts until a turn other than
+// baselineID is complete, then delivers its response. Partial replies are
+// streamed as progress. Returns nil without delivering when ctx is cancelled
+// by a newer message.
+func (e *Engine) watchForCompletion(ctx context.Context, session *Session, baselineID string) error {
+	adapter, ok := e.cliAdapters[session.CLIType].(cli.TranscriptWatcher)
+	if !ok {
+		return fmt.Errorf("CLI adapter %s cannot watch transcripts", session.CLIType)
+	}
+	workDir, err := expandHome(session.WorkDir)
+	if err != nil {
+		return err
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create transcript watcher: %w", err)
+	}
+	defer fsWatcher.Close()
+
+	// Directories that do not exist yet are covered by the periodic rescan
+	for _, dir := range adapter.TranscriptDirs(workDir) {
+		if err := fsWatcher.Add(dir); err != nil {
+			logger.WithFields(logrus.Fields{
+				"session": session.Name,
+				"dir":     dir,
+				"error":   err,
+			}).Debug("transcript-dir-not-watched")
+		}
+	}
+
+	timeout := newPollSchedule(e.config.Watchdog).timeout
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	rescan := time.NewTicker(constants.WatchRescanInterval)
+	defer rescan.Stop()
+
+	logger.WithFields(logrus.Fields{
+		"session": session.Name,
+		"timeout": timeout,
+	}).Debug("transcript-watch-started")
+
+	var settle <-chan time.Time
+	var progress string
+	for {
+		select {
+		case <-ctx.Done():
+			logger.WithField("session", session.Name).Debug("transcript-watch-cancelled")
+			return nil
+		case _, ok := <-fsWatcher.Events:
+			if !ok {
+				return nil
+			}
+			settle = time.After(constants.WatchSettleDelay)
+			continue
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.WithFields(logrus.Fields{
+				"session": session.Name,
+				"error":   err,
+			}).Warn("transcript-watch-error")
+			continue
+		case <-deadline.C:
+			logger.WithFields(logrus.Fields{
+				"session": session.Name,
+				"timeout": timeout,
+			}).Warn("transcript-watch-timeout")
+			e.SendResponseToSession(session.Name, fmt.Sprintf(
+				"⏱️ [%s] No finished reply in the transcript after %s, showing what is there so far:\n\n%s",
+				session.Name, timeout, progress))
+			return nil
+		case <-settle:
+			settle = nil
+		case <-rescan.C:
+		}
+
+		turn, err := adapter.LatestTurn(workDir)
+		if err != nil || turn.ID == baselineID {
+			continue
+		}
+		if turn.Complete {
+			logger.WithFields(logrus.Fields{
+				"session": session.Name,
+				"turn":    turn.ID,
+				"length":  len(turn.Response),
+			}).Info("transcript-turn-complete")
+			e.updateSessionState(session.Name, StateIdle)
+			e.SendResponseToSession(session.Name, turn.Response)
+			return nil
+		}
+		if turn.Response != progress {
+			progress = turn.Response
+			e.SendProgressToSession(session.Name, progress)
+		}
+	}
+}
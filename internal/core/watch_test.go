@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keepmind9/clibot/internal/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// transcriptCLIAdapter is a mock CLI adapter whose transcript turn is set by the test
+type transcriptCLIAdapter struct {
+	*mockCLIAdapter
+	dir  string
+	mu   sync.Mutex
+	turn cli.TurnStatus
+}
+
+func (a *transcriptCLIAdapter) TranscriptDirs(workDir string) []string { return []string{a.dir} }
+
+func (a *transcriptCLIAdapter) LatestTurn(workDir string) (cli.TurnStatus, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.turn, nil
+}
+
+// setTurn updates the turn and touches the transcript directory
+func (a *transcriptCLIAdapter) setTurn(t *testing.T, turn cli.TurnStatus) {
+	a.mu.Lock()
+	a.turn = turn
+	a.mu.Unlock()
+	require.NoError(t, os.WriteFile(filepath.Join(a.dir, "session.jsonl"), []byte(turn.Response), 0o644))
+}
+
+// TestEngine_WatchForCompletion tests delivering a reply once the transcript shows the turn finished
+func TestEngine_WatchForCompletion(t *testing.T) {
+	engine, _, botAdapter := newMessageTestEngine()
+	adapter := &transcriptCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), dir: t.TempDir(),
+		turn: cli.TurnStatus{ID: "t#1", Prompt: "old", Response: "old reply", Complete: true}}
+	engine.RegisterCLIAdapter("claude", adapter)
+	engine.config.Watchdog = WatchdogConfig{Timeout: "5s"}
+	engine.sessions["main"].Completion = CompletionWatch
+	engine.sessions["main"].WorkDir = adapter.dir
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+	engine.sessions["main"].State = StateProcessing
+	baseline := engine.latestTurnID(engine.sessions["main"])
+	assert.Equal(t, "t#1", baseline)
+
+	done := make(chan error)
+	go func() { done <- engine.watchForCompletion(context.Background(), engine.sessions["main"], baseline) }()
+
+	// The previous turn and an unfinished new turn are not delivered
+	time.Sleep(50 * time.Millisecond)
+	adapter.setTurn(t, cli.TurnStatus{ID: "t#3", Prompt: "list files", Response: "Let me look."})
+	time.Sleep(1500 * time.Millisecond)
+	assert.Equal(t, 0, botAdapter.messageCount)
+
+	adapter.setTurn(t, cli.TurnStatus{ID: "t#3", Prompt: "list files", Response: "Let me look.\n\nREADME.md", Complete: true})
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not finish")
+	}
+	assert.Equal(t, "Let me look.\n\nREADME.md", botAdapter.lastMessage)
+	assert.Equal(t, StateIdle, engine.sessions["main"].State)
+}
+
+// TestEngine_WatchForCompletion_Cancelled tests that a newer message stops the watch silently
+func TestEngine_WatchForCompletion_Cancelled(t *testing.T) {
+	engine, _, botAdapter := newMessageTestEngine()
+	adapter := &transcriptCLIAdapter{mockCLIAdapter: newMockCLIAdapter(), dir: t.TempDir(),
+		turn: cli.TurnStatus{ID: "t#1", Prompt: "old", Response: "old reply", Complete: true}}
+	engine.RegisterCLIAdapter("claude", adapter)
+	engine.sessions["main"].Completion = CompletionWatch
+	engine.sessions["main"].WorkDir = adapter.dir
+	engine.sessionChannels["main"] = BotChannel{Platform: "discord", Channel: "c1"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := engine.watchForCompletion(ctx, engine.sessions["main"], "t#1")
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, botAdapter.messageCount)
+}
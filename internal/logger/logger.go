@@ -42,13 +42,15 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 var (
-	globalLogger *logrus.Logger
+	globalLogger      *logrus.Logger
+	defaultLoggerOnce sync.Once // Guards lazy initialization when InitLogger was not called
 )
 
 // Config represents the configuration for the logger
@@ -129,15 +131,17 @@ func InitLogger(config Config) error {
 
 // GetLogger returns the global logger instance
 func GetLogger() *logrus.Logger {
-	if globalLogger == nil {
-		// Initialize with default config if not initialized
-		globalLogger = logrus.New()
-		globalLogger.SetLevel(logrus.InfoLevel)
-		globalLogger.SetFormatter(&logrus.TextFormatter{
-			FullTimestamp:   true,
-			TimestampFormat: "2006-01-02 15:04:05",
-		})
-	}
+	defaultLoggerOnce.Do(func() {
+		if globalLogger == nil {
+			// Initialize with default config if not initialized
+			globalLogger = logrus.New()
+			globalLogger.SetLevel(logrus.InfoLevel)
+			globalLogger.SetFormatter(&logrus.TextFormatter{
+				FullTimestamp:   true,
+				TimestampFormat: "2006-01-02 15:04:05",
+			})
+		}
+	})
 	return globalLogger
 }
 
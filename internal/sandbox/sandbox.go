@@ -0,0 +1,183 @@
+// Package sandbox runs CLI processes inside Docker or Podman containers.
+//
+// A sandboxed session's start command is wrapped in a "docker run" (or
+// "podman run") command line, so the adapters start it like any other command:
+// typed into the tmux pane, or run as the ACP stdio server. The container is
+// named after the session, which lets the engine remove it when the session is
+// closed and read its resource usage.
+//
+// Containers drop all capabilities, cannot gain privileges, run as the
+// invoking user and only see the bind-mounted work directory plus any extra
+// mounts.
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Container runtimes
+const (
+	RuntimeDocker = "docker"
+	RuntimePodman = "podman"
+)
+
+// NetworkNone disables networking in the container
+const NetworkNone = "none"
+
+// Options describe the container a command runs in
+type Options struct {
+	Runtime string   // "docker" or "podman"
+	Image   string   // Container image
+	Network string   // Network mode, e.g. "none", "bridge" or a named network
+	CPUs    string   // CPU limit, e.g. "2" or "0.5" (empty: unlimited)
+	Memory  string   // Memory limit, e.g. "4g" (empty: unlimited)
+	Mounts  []string // Extra bind mounts "source:target[:ro]"
+	WorkDir string   // Bind-mounted at the same path and used as the working directory
+	EnvFile string   // File of KEY=value lines set in the container (optional)
+	TTY     bool     // Allocate a terminal (tmux sessions); stdin only otherwise (ACP stdio)
+}
+
+// WriteEnvFile writes env as an --env-file with owner-only permissions, so
+// values stay off the command line
+func WriteEnvFile(path string, env map[string]string) error {
+	names := make([]string, 0, len(env))
+	for name, value := range env {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("environment variable %s: multi-line values are not supported in containers", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + "=" + env[name] + "\n")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create env file directory: %w", err)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// Stats is a container's current resource usage as reported by the runtime
+type Stats struct {
+	CPU    string // CPU usage, e.g. "12.5%"
+	Memory string // Memory usage and limit, e.g. "512MiB / 4GiB"
+	PIDs   string // Number of processes
+}
+
+// ContainerName returns the container name of a session
+func ContainerName(sessionName string) string {
+	return "clibot-" + sessionName
+}
+
+// RunArgs returns the runtime arguments (without the runtime itself) running
+// command in a new container named name
+func RunArgs(name string, opts Options, command string) []string {
+	args := []string{"run", "--rm", "--name", name, "--init",
+		"--cap-drop", "ALL", "--security-opt", "no-new-privileges"}
+	if opts.TTY {
+		args = append(args, "-it")
+	} else {
+		args = append(args, "-i")
+	}
+
+	// Files written in the work directory belong to the invoking user
+	if opts.Runtime == RuntimePodman {
+		args = append(args, "--userns", "keep-id")
+	} else {
+		args = append(args, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
+	}
+
+	network := opts.Network
+	if network == "" {
+		network = NetworkNone
+	}
+	args = append(args, "--network", network)
+	if opts.CPUs != "" {
+		args = append(args, "--cpus", opts.CPUs)
+	}
+	if opts.Memory != "" {
+		args = append(args, "--memory", opts.Memory)
+	}
+
+	if opts.WorkDir != "" {
+		args = append(args, "-v", opts.WorkDir+":"+opts.WorkDir, "-w", opts.WorkDir)
+	}
+	for _, mount := range opts.Mounts {
+		args = append(args, "-v", mount)
+	}
+	if opts.EnvFile != "" {
+		args = append(args, "--env-file", opts.EnvFile)
+	}
+
+	return append(args, opts.Image, "sh", "-c", command)
+}
+
+// Command returns the shell command line running command in a new container
+// named name
+func Command(name string, opts Options, command string) string {
+	parts := []string{runtimeOrDefault(opts.Runtime)}
+	for _, arg := range RunArgs(name, opts, command) {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Remove force-removes a container. A container that does not exist is not
+// an error, so it is safe to call before starting and after stopping.
+func Remove(runtime, name string) error {
+	output, err := exec.Command(runtimeOrDefault(runtime), "rm", "-f", name).CombinedOutput()
+	if err != nil && !strings.Contains(strings.ToLower(string(output)), "no such container") {
+		return fmt.Errorf("failed to remove container %s: %w (output: %s)", name, err, strings.TrimSpace(string(output)))
+	}
+	logger.WithField("container", name).Debug("sandbox-container-removed")
+	return nil
+}
+
+// ContainerStats returns the resource usage of a running container
+func ContainerStats(runtime, name string) (Stats, error) {
+	output, err := exec.Command(runtimeOrDefault(runtime), "stats", "--no-stream",
+		"--format", "{{.CPUPerc}}\t{{.MemUsage}}\t{{.PIDs}}", name).Output()
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"container": name,
+			"error":     err,
+		}).Debug("failed-to-read-sandbox-stats")
+		return Stats{}, fmt.Errorf("failed to read stats of container %s: %w", name, err)
+	}
+	return parseStats(string(output))
+}
+
+// parseStats parses one line of "stats --format" output
+func parseStats(output string) (Stats, error) {
+	fields := strings.Split(strings.TrimSpace(output), "\t")
+	if len(fields) != 3 {
+		return Stats{}, fmt.Errorf("unexpected container stats: %q", output)
+	}
+	return Stats{
+		CPU:    strings.TrimSpace(fields[0]),
+		Memory: strings.TrimSpace(fields[1]),
+		PIDs:   strings.TrimSpace(fields[2]),
+	}, nil
+}
+
+func runtimeOrDefault(runtime string) string {
+	if runtime == "" {
+		return RuntimeDocker
+	}
+	return runtime
+}
+
+// shellQuote quotes s as a single word for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,75 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunArgs(t *testing.T) {
+	args := RunArgs("clibot-main", Options{
+		Image:   "node:22",
+		CPUs:    "2",
+		Memory:  "4g",
+		Mounts:  []string{"/home/u/.claude:/home/u/.claude"},
+		WorkDir: "/home/u/repo",
+		EnvFile: "/home/u/.clibot/sandbox/main.env",
+		TTY:     true,
+	}, "claude --resume")
+
+	joined := strings.Join(args, " ")
+	assert.True(t, strings.HasPrefix(joined, "run --rm --name clibot-main --init --cap-drop ALL"), joined)
+	assert.Contains(t, joined, " -it ")
+	assert.Contains(t, joined, fmt.Sprintf(" --user %d:%d ", os.Getuid(), os.Getgid()))
+	assert.Contains(t, joined, " --network none --cpus 2 --memory 4g ")
+	assert.Contains(t, joined, " -v /home/u/repo:/home/u/repo -w /home/u/repo -v /home/u/.claude:/home/u/.claude ")
+	assert.Contains(t, joined, " --env-file /home/u/.clibot/sandbox/main.env ")
+	assert.Equal(t, []string{"node:22", "sh", "-c", "claude --resume"}, args[len(args)-4:])
+}
+
+func TestRunArgs_PodmanStdio(t *testing.T) {
+	args := RunArgs("clibot-agent", Options{Runtime: RuntimePodman, Image: "agent", Network: "bridge"}, "agent --acp")
+	assert.Contains(t, args, "-i")
+	assert.NotContains(t, args, "-it")
+	assert.NotContains(t, args, "--user")
+	assert.Contains(t, strings.Join(args, " "), "--userns keep-id --network bridge agent")
+	assert.NotContains(t, args, "--cpus")
+	assert.NotContains(t, args, "--memory")
+}
+
+func TestCommand(t *testing.T) {
+	cmd := Command("clibot-main", Options{Image: "img"}, "echo 'hi'")
+	assert.True(t, strings.HasPrefix(cmd, "docker 'run' '--rm' "), cmd)
+	assert.True(t, strings.HasSuffix(cmd, ` 'img' 'sh' '-c' 'echo '\''hi'\'''`), cmd)
+
+	cmd = Command("clibot-main", Options{Runtime: RuntimePodman, Image: "img"}, "x")
+	assert.True(t, strings.HasPrefix(cmd, "podman "), cmd)
+}
+
+func TestWriteEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sandbox", "main.env")
+	require.NoError(t, WriteEnvFile(path, map[string]string{"B": "two words", "A": "x=1"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "A=x=1\nB=two words\n", string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	assert.ErrorContains(t, WriteEnvFile(path, map[string]string{"KEY": "a\nb"}), "multi-line")
+}
+
+func TestParseStats(t *testing.T) {
+	stats, err := parseStats("12.50%\t512MiB / 4GiB\t7\n")
+	require.NoError(t, err)
+	assert.Equal(t, Stats{CPU: "12.50%", Memory: "512MiB / 4GiB", PIDs: "7"}, stats)
+
+	_, err = parseStats("Error: no such container\n")
+	assert.Error(t, err)
+}
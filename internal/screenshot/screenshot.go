@@ -0,0 +1,439 @@
+// Package screenshot renders terminal output to PNG images.
+//
+// Input is text with ANSI escape sequences, as captured by
+// "tmux capture-pane -e". SGR sequences set the colors (16, 256 and
+// truecolor), bold, faint, underline and inverse attributes; other control
+// sequences are skipped. Text is drawn with the Go Mono font embedded in
+// golang.org/x/image, so rendering needs no system fonts or external tools.
+//
+// # Example Usage
+//
+//	pane, _ := watchdog.CaptureScreen("my-session")
+//	if err := screenshot.WritePNG("/tmp/pane.png", pane); err != nil {
+//	    log.Fatal(err)
+//	}
+package screenshot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/width"
+)
+
+const (
+	fontSize = 14 // Points at 72 DPI, i.e. pixels
+	padding  = 12 // Border around the text in pixels
+	tabWidth = 8  // Columns per tab stop
+
+	// maxCols and maxRows bound the image size; wider lines are cut and
+	// only the last rows are kept
+	maxCols = 400
+	maxRows = 200
+)
+
+var (
+	defaultFg = color.RGBA{0xd4, 0xd4, 0xd4, 0xff}
+	defaultBg = color.RGBA{0x1e, 0x1e, 0x1e, 0xff}
+)
+
+// palette16 is the xterm palette for the 8 standard and 8 bright colors
+var palette16 = [16]color.RGBA{
+	{0x00, 0x00, 0x00, 0xff}, {0xcd, 0x00, 0x00, 0xff}, {0x00, 0xcd, 0x00, 0xff}, {0xcd, 0xcd, 0x00, 0xff},
+	{0x00, 0x00, 0xee, 0xff}, {0xcd, 0x00, 0xcd, 0xff}, {0x00, 0xcd, 0xcd, 0xff}, {0xe5, 0xe5, 0xe5, 0xff},
+	{0x7f, 0x7f, 0x7f, 0xff}, {0xff, 0x00, 0x00, 0xff}, {0x00, 0xff, 0x00, 0xff}, {0xff, 0xff, 0x00, 0xff},
+	{0x5c, 0x5c, 0xff, 0xff}, {0xff, 0x00, 0xff, 0xff}, {0x00, 0xff, 0xff, 0xff}, {0xff, 0xff, 0xff, 0xff},
+}
+
+// style is the set of SGR attributes applied to a cell
+type style struct {
+	fg, bg    color.RGBA
+	bold      bool
+	faint     bool
+	underline bool
+	inverse   bool
+}
+
+var defaultStyle = style{fg: defaultFg, bg: defaultBg}
+
+// cell is one terminal column. Double-width runes occupy two cells; the
+// second one is marked as a continuation and not drawn.
+type cell struct {
+	r            rune
+	style        style
+	continuation bool
+}
+
+// faces holds the regular and bold font faces and the cell geometry
+type faces struct {
+	regular, bold font.Face
+	cellW, cellH  int
+	ascent        int
+}
+
+var (
+	loadOnce    sync.Once
+	loadedFaces *faces
+	loadErr     error
+)
+
+// loadFaces parses the embedded Go Mono fonts once
+func loadFaces() (*faces, error) {
+	loadOnce.Do(func() {
+		newFace := func(ttf []byte) (font.Face, error) {
+			f, err := opentype.Parse(ttf)
+			if err != nil {
+				return nil, err
+			}
+			return opentype.NewFace(f, &opentype.FaceOptions{Size: fontSize, DPI: 72, Hinting: font.HintingFull})
+		}
+		regular, err := newFace(gomono.TTF)
+		if err != nil {
+			loadErr = fmt.Errorf("failed to load monospace font: %w", err)
+			return
+		}
+		bold, err := newFace(gomonobold.TTF)
+		if err != nil {
+			loadErr = fmt.Errorf("failed to load monospace bold font: %w", err)
+			return
+		}
+		advance, _ := regular.GlyphAdvance('M')
+		metrics := regular.Metrics()
+		loadedFaces = &faces{
+			regular: regular,
+			bold:    bold,
+			cellW:   advance.Ceil(),
+			cellH:   metrics.Height.Ceil(),
+			ascent:  metrics.Ascent.Ceil(),
+		}
+	})
+	return loadedFaces, loadErr
+}
+
+// Render draws terminal output with ANSI escape sequences as an image
+func Render(text string) (*image.RGBA, error) {
+	f, err := loadFaces()
+	if err != nil {
+		return nil, err
+	}
+
+	lines := parse(text)
+	if len(lines) > maxRows {
+		lines = lines[len(lines)-maxRows:]
+	}
+	cols := 1
+	for i, line := range lines {
+		if len(line) > maxCols {
+			lines[i] = line[:maxCols]
+		}
+		cols = max(cols, len(lines[i]))
+	}
+	rows := max(len(lines), 1)
+
+	img := image.NewRGBA(image.Rect(0, 0, cols*f.cellW+2*padding, rows*f.cellH+2*padding))
+	draw.Draw(img, img.Bounds(), image.NewUniform(defaultBg), image.Point{}, draw.Src)
+
+	for row, line := range lines {
+		y := padding + row*f.cellH
+		for col, c := range line {
+			if c.continuation {
+				continue
+			}
+			x := padding + col*f.cellW
+			fg, bg := c.style.colors()
+
+			cells := 1
+			if col+1 < len(line) && line[col+1].continuation {
+				cells = 2
+			}
+			if bg != defaultBg {
+				draw.Draw(img, image.Rect(x, y, x+cells*f.cellW, y+f.cellH), image.NewUniform(bg), image.Point{}, draw.Src)
+			}
+			if c.r != ' ' {
+				face := f.regular
+				if c.style.bold {
+					face = f.bold
+				}
+				d := font.Drawer{Dst: img, Src: image.NewUniform(fg), Face: face, Dot: fixed.P(x, y+f.ascent)}
+				d.DrawString(string(c.r))
+			}
+			if c.style.underline {
+				underline := y + f.ascent + 2
+				draw.Draw(img, image.Rect(x, underline, x+cells*f.cellW, underline+1), image.NewUniform(fg), image.Point{}, draw.Src)
+			}
+		}
+	}
+	return img, nil
+}
+
+// EncodePNG renders terminal output and writes it to w as PNG
+func EncodePNG(w io.Writer, text string) error {
+	img, err := Render(text)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// WritePNG renders terminal output to a PNG file at path
+func WritePNG(path, text string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := EncodePNG(file, text); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// colors returns the cell's foreground and background after inverse and faint
+func (s style) colors() (color.RGBA, color.RGBA) {
+	fg, bg := s.fg, s.bg
+	if s.inverse {
+		fg, bg = bg, fg
+	}
+	if s.faint {
+		fg = color.RGBA{uint8((int(fg.R) + int(bg.R)) / 2), uint8((int(fg.G) + int(bg.G)) / 2), uint8((int(fg.B) + int(bg.B)) / 2), 0xff}
+	}
+	return fg, bg
+}
+
+// parse splits terminal output into lines of styled cells
+func parse(text string) [][]cell {
+	var lines [][]cell
+	var line []cell
+	st := defaultStyle
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\x1b':
+			i = parseEscape(runes, i, &st)
+		case r == '\n':
+			lines = append(lines, line)
+			line = nil
+		case r == '\t':
+			for n := tabWidth - len(line)%tabWidth; n > 0; n-- {
+				line = append(line, cell{r: ' ', style: st})
+			}
+		case r < 0x20 || r == 0x7f:
+			// Other control characters (\r, bell, ...) do not draw
+		default:
+			line = append(line, cell{r: r, style: st})
+			if isWide(r) {
+				line = append(line, cell{r: ' ', style: st, continuation: true})
+			}
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+
+	// Drop the blank rows below the last output
+	for len(lines) > 0 && isBlank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// parseEscape handles the escape sequence starting at runes[i] and returns
+// the index of its last rune. Only SGR sequences change the style.
+func parseEscape(runes []rune, i int, st *style) int {
+	if i+1 >= len(runes) {
+		return i
+	}
+	switch runes[i+1] {
+	case '[':
+		// CSI: parameters up to a final byte in 0x40-0x7e
+		start := i + 2
+		for j := start; j < len(runes); j++ {
+			if runes[j] >= 0x40 && runes[j] <= 0x7e {
+				if runes[j] == 'm' {
+					st.apply(string(runes[start:j]))
+				}
+				return j
+			}
+		}
+		return len(runes) - 1
+	case ']':
+		// OSC: up to BEL or ST (ESC \)
+		for j := i + 2; j < len(runes); j++ {
+			if runes[j] == '\a' {
+				return j
+			}
+			if runes[j] == '\x1b' && j+1 < len(runes) && runes[j+1] == '\\' {
+				return j + 1
+			}
+		}
+		return len(runes) - 1
+	case '(', ')', '*', '+':
+		// Character set designation: one more rune
+		return min(i+2, len(runes)-1)
+	default:
+		return i + 1
+	}
+}
+
+// apply updates the style from the parameters of an SGR sequence
+func (s *style) apply(params string) {
+	if params == "" {
+		*s = defaultStyle
+		return
+	}
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code := codes[i]
+		// Colon form: 38:5:n, 38:2:r:g:b or 38:2::r:g:b
+		if strings.Contains(code, ":") {
+			sub := strings.Split(code, ":")
+			if c, ok := extendedColor(sub[1:]); ok {
+				s.setExtended(sub[0], c)
+			}
+			continue
+		}
+
+		n, err := strconv.Atoi(code)
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			*s = defaultStyle
+		case n == 1:
+			s.bold = true
+		case n == 2:
+			s.faint = true
+		case n == 4:
+			s.underline = true
+		case n == 7:
+			s.inverse = true
+		case n == 22:
+			s.bold, s.faint = false, false
+		case n == 24:
+			s.underline = false
+		case n == 27:
+			s.inverse = false
+		case n >= 30 && n <= 37:
+			s.fg = palette16[n-30]
+		case n == 39:
+			s.fg = defaultFg
+		case n >= 40 && n <= 47:
+			s.bg = palette16[n-40]
+		case n == 49:
+			s.bg = defaultBg
+		case n >= 90 && n <= 97:
+			s.fg = palette16[n-90+8]
+		case n >= 100 && n <= 107:
+			s.bg = palette16[n-100+8]
+		case n == 38 || n == 48:
+			// Semicolon form: 38;5;n or 38;2;r;g;b
+			rest := codes[i+1:]
+			if c, ok := extendedColor(rest); ok {
+				s.setExtended(code, c)
+			}
+			if len(rest) > 0 && rest[0] == "5" {
+				i += 2
+			} else if len(rest) > 0 && rest[0] == "2" {
+				i += 4
+			}
+		}
+	}
+}
+
+// setExtended sets the foreground (38) or background (48) color
+func (s *style) setExtended(code string, c color.RGBA) {
+	switch code {
+	case "38":
+		s.fg = c
+	case "48":
+		s.bg = c
+	}
+}
+
+// extendedColor parses the arguments of a 38/48 code: "5", n for the 256
+// color palette or "2", r, g, b for truecolor (an empty color space ID before
+// r is allowed)
+func extendedColor(args []string) (color.RGBA, bool) {
+	if len(args) == 0 {
+		return color.RGBA{}, false
+	}
+	switch args[0] {
+	case "5":
+		if len(args) < 2 {
+			return color.RGBA{}, false
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 || n > 255 {
+			return color.RGBA{}, false
+		}
+		return palette256(n), true
+	case "2":
+		rgb := args[1:]
+		if len(rgb) == 4 && rgb[0] == "" {
+			rgb = rgb[1:]
+		}
+		if len(rgb) < 3 {
+			return color.RGBA{}, false
+		}
+		var c [3]uint8
+		for k := range c {
+			v, err := strconv.Atoi(rgb[k])
+			if err != nil || v < 0 || v > 255 {
+				return color.RGBA{}, false
+			}
+			c[k] = uint8(v)
+		}
+		return color.RGBA{c[0], c[1], c[2], 0xff}, true
+	}
+	return color.RGBA{}, false
+}
+
+// palette256 returns color n of the xterm 256 color palette
+func palette256(n int) color.RGBA {
+	switch {
+	case n < 16:
+		return palette16[n]
+	case n < 232:
+		levels := [6]uint8{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return color.RGBA{levels[n/36], levels[n/6%6], levels[n%6], 0xff}
+	default:
+		gray := uint8(8 + 10*(n-232))
+		return color.RGBA{gray, gray, gray, 0xff}
+	}
+}
+
+// isWide reports whether r takes two terminal columns (CJK, fullwidth forms)
+func isWide(r rune) bool {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return true
+	}
+	return false
+}
+
+// isBlank reports whether a line has no visible content
+func isBlank(line []cell) bool {
+	for _, c := range line {
+		if c.r != ' ' || c.style.bg != defaultBg || c.style.inverse {
+			return false
+		}
+	}
+	return true
+}
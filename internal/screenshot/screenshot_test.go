@@ -0,0 +1,132 @@
+package screenshot
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Colors(t *testing.T) {
+	lines := parse("\x1b[31mred\x1b[0m \x1b[1;38;5;82mg\x1b[38;2;10;20;30mt\x1b[48:2::1:2:3mb\x1b[m")
+	require.Len(t, lines, 1)
+	line := lines[0]
+	require.Len(t, line, 7)
+
+	assert.Equal(t, palette16[1], line[0].style.fg)
+	assert.Equal(t, defaultStyle, line[3].style)
+	assert.True(t, line[4].style.bold)
+	assert.Equal(t, color.RGBA{0x5f, 0xff, 0x00, 0xff}, line[4].style.fg)
+	assert.Equal(t, color.RGBA{10, 20, 30, 0xff}, line[5].style.fg)
+	assert.True(t, line[5].style.bold)
+	assert.Equal(t, color.RGBA{1, 2, 3, 0xff}, line[6].style.bg)
+}
+
+func TestParse_Attributes(t *testing.T) {
+	lines := parse("\x1b[4;7ma\x1b[24mb\x1b[27;92;104mc\x1b[39;49md")
+	require.Len(t, lines, 1)
+	line := lines[0]
+
+	assert.True(t, line[0].style.underline)
+	assert.True(t, line[0].style.inverse)
+	assert.False(t, line[1].style.underline)
+	assert.False(t, line[2].style.inverse)
+	assert.Equal(t, palette16[10], line[2].style.fg)
+	assert.Equal(t, palette16[12], line[2].style.bg)
+	assert.Equal(t, defaultStyle, line[3].style)
+
+	fg, bg := line[0].style.colors()
+	assert.Equal(t, defaultBg, fg)
+	assert.Equal(t, defaultFg, bg)
+}
+
+func TestParse_SkipsControlSequences(t *testing.T) {
+	lines := parse("\x1b]0;title\x07a\x1b[2Kb\x1b(Bc\x1b]8;;http://x\x1b\\d\r\n\tx\n\n\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "abcd", text(lines[0]))
+	assert.Equal(t, "        x", text(lines[1]))
+}
+
+func TestParse_WideRunes(t *testing.T) {
+	lines := parse("中a")
+	require.Len(t, lines, 1)
+	require.Len(t, lines[0], 3)
+	assert.True(t, lines[0][1].continuation)
+	assert.Equal(t, 'a', lines[0][2].r)
+}
+
+func TestPalette256(t *testing.T) {
+	assert.Equal(t, palette16[9], palette256(9))
+	assert.Equal(t, color.RGBA{0, 0, 0, 0xff}, palette256(16))
+	assert.Equal(t, color.RGBA{0xff, 0xff, 0xff, 0xff}, palette256(231))
+	assert.Equal(t, color.RGBA{8, 8, 8, 0xff}, palette256(232))
+	assert.Equal(t, color.RGBA{238, 238, 238, 0xff}, palette256(255))
+}
+
+func TestRender(t *testing.T) {
+	img, err := Render("ab\n\x1b[41m  \x1b[0m")
+	require.NoError(t, err)
+
+	f, err := loadFaces()
+	require.NoError(t, err)
+	bounds := img.Bounds()
+	assert.Equal(t, 2*f.cellW+2*padding, bounds.Dx())
+	assert.Equal(t, 2*f.cellH+2*padding, bounds.Dy())
+
+	// Padding keeps the default background, the second row the red one
+	assert.Equal(t, defaultBg, img.RGBAAt(0, 0))
+	assert.Equal(t, palette16[1], img.RGBAAt(padding+1, padding+f.cellH+f.cellH/2))
+
+	// The text is drawn in the foreground color
+	drawn := false
+	for y := padding; y < padding+f.cellH; y++ {
+		for x := padding; x < padding+2*f.cellW; x++ {
+			if img.RGBAAt(x, y) != defaultBg {
+				drawn = true
+			}
+		}
+	}
+	assert.True(t, drawn)
+}
+
+func TestRender_LimitsSize(t *testing.T) {
+	var b bytes.Buffer
+	for i := 0; i < maxRows+50; i++ {
+		b.WriteString("x\n")
+	}
+	b.Write(bytes.Repeat([]byte("y"), maxCols+100))
+
+	img, err := Render(b.String())
+	require.NoError(t, err)
+	f, _ := loadFaces()
+	assert.Equal(t, maxCols*f.cellW+2*padding, img.Bounds().Dx())
+	assert.Equal(t, maxRows*f.cellH+2*padding, img.Bounds().Dy())
+}
+
+func TestWritePNG(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "screen.png")
+	require.NoError(t, WritePNG(path, "\x1b[32m$\x1b[0m ls"))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	img, err := png.Decode(file)
+	require.NoError(t, err)
+	assert.Greater(t, img.Bounds().Dx(), 2*padding)
+}
+
+// text returns the runes of a line, skipping wide rune continuations
+func text(line []cell) string {
+	var runes []rune
+	for _, c := range line {
+		if !c.continuation {
+			runes = append(runes, c.r)
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,202 @@
+// Package transcribe converts voice messages to text for bot adapters.
+//
+// Two backends are provided, both implementing bot.Transcriber:
+//
+//   - WhisperCPP: runs a local whisper.cpp executable (audio is converted to
+//     16 kHz mono WAV with ffmpeg first when needed)
+//   - OpenAI: posts the audio to an OpenAI-compatible /audio/transcriptions endpoint
+//     (OpenAI, Groq, a local faster-whisper server, ...)
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DefaultWhisperBinary = "whisper-cli"
+	DefaultFFmpegBinary  = "ffmpeg"
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOpenAIModel   = "whisper-1"
+
+	maxErrorBodyLength = 512 // Bytes of an error response kept in the error message
+)
+
+// WhisperCPP transcribes audio with a local whisper.cpp executable
+type WhisperCPP struct {
+	BinaryPath string // whisper.cpp CLI (default: whisper-cli)
+	ModelPath  string // ggml model file
+	Language   string // Spoken language code, empty for auto-detection
+	FFmpegPath string // Used to convert non-WAV audio (default: ffmpeg)
+}
+
+// NewWhisperCPP creates a whisper.cpp transcriber, filling empty binaries with defaults
+func NewWhisperCPP(binaryPath, modelPath, language string) *WhisperCPP {
+	if binaryPath == "" {
+		binaryPath = DefaultWhisperBinary
+	}
+	return &WhisperCPP{
+		BinaryPath: binaryPath,
+		ModelPath:  modelPath,
+		Language:   language,
+		FFmpegPath: DefaultFFmpegBinary,
+	}
+}
+
+// Transcribe runs whisper.cpp on the audio file and returns the recognized text
+func (w *WhisperCPP) Transcribe(ctx context.Context, audioPath string) (string, error) {
+	input := audioPath
+	if !strings.EqualFold(filepath.Ext(audioPath), ".wav") {
+		wavPath := strings.TrimSuffix(audioPath, filepath.Ext(audioPath)) + ".16k.wav"
+		if err := run(ctx, w.FFmpegPath, "-y", "-loglevel", "error", "-i", audioPath,
+			"-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", wavPath); err != nil {
+			return "", fmt.Errorf("convert audio: %w", err)
+		}
+		defer os.Remove(wavPath)
+		input = wavPath
+	}
+
+	args := []string{"-m", w.ModelPath, "-f", input, "-nt", "-np"}
+	if w.Language != "" {
+		args = append(args, "-l", w.Language)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, w.BinaryPath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("whisper.cpp failed: %w: %s", err, truncate(stderr.String()))
+	}
+	return cleanTranscript(stdout.String()), nil
+}
+
+// run executes a helper command, including its stderr in the error
+func run(ctx context.Context, name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, truncate(stderr.String()))
+	}
+	return nil
+}
+
+// cleanTranscript joins whisper.cpp output segments into one line,
+// dropping markers such as [BLANK_AUDIO]
+func cleanTranscript(output string) string {
+	var parts []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || (strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")) {
+			continue
+		}
+		parts = append(parts, line)
+	}
+	return strings.Join(parts, " ")
+}
+
+// OpenAI transcribes audio through an OpenAI-compatible HTTP endpoint
+type OpenAI struct {
+	BaseURL  string // API base including version (default: https://api.openai.com/v1)
+	APIKey   string // Bearer token, optional for local servers
+	Model    string // Model name (default: whisper-1)
+	Language string // Spoken language code, empty for auto-detection
+	Client   *http.Client
+}
+
+// NewOpenAI creates an OpenAI-compatible transcriber, filling empty values with defaults
+func NewOpenAI(baseURL, apiKey, model, language string, client *http.Client) *OpenAI {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OpenAI{
+		BaseURL:  strings.TrimSuffix(baseURL, "/"),
+		APIKey:   apiKey,
+		Model:    model,
+		Language: language,
+		Client:   client,
+	}
+}
+
+// Transcribe uploads the audio file to /audio/transcriptions and returns the text
+func (o *OpenAI) Transcribe(ctx context.Context, audioPath string) (string, error) {
+	f, err := os.Open(audioPath)
+	if err != nil {
+		return "", fmt.Errorf("open audio: %w", err)
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filepath.Base(audioPath))
+	if err != nil {
+		return "", fmt.Errorf("create form file: %w", err)
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return "", fmt.Errorf("read audio: %w", err)
+	}
+	writer.WriteField("model", o.Model)
+	writer.WriteField("response_format", "json")
+	if o.Language != "" {
+		writer.WriteField("language", o.Language)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("close form: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.BaseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if o.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	}
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("transcription failed: %s: %s", resp.Status, truncate(string(respBody)))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("parse response: %w", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
+
+// truncate shortens command or server output for error messages
+func truncate(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxErrorBodyLength {
+		return s[:maxErrorBodyLength] + "..."
+	}
+	return s
+}
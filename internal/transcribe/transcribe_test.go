@@ -0,0 +1,111 @@
+package transcribe
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAudio(t *testing.T, name string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte("audio"), 0600))
+	return path
+}
+
+func TestOpenAI_Transcribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/audio/transcriptions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		assert.Equal(t, "whisper-1", r.FormValue("model"))
+		assert.Equal(t, "zh", r.FormValue("language"))
+
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(file)
+		assert.Equal(t, "voice.ogg", header.Filename)
+		assert.Equal(t, "audio", string(data))
+
+		json.NewEncoder(w).Encode(map[string]string{"text": " run the tests \n"})
+	}))
+	defer server.Close()
+
+	o := NewOpenAI(server.URL+"/v1/", "sk-test", "", "zh", nil)
+	text, err := o.Transcribe(context.Background(), writeAudio(t, "voice.ogg"))
+	require.NoError(t, err)
+	assert.Equal(t, "run the tests", text)
+}
+
+func TestOpenAI_TranscribeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAI(server.URL, "", "", "", nil).Transcribe(context.Background(), writeAudio(t, "voice.ogg"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid api key")
+}
+
+func TestNewOpenAI_Defaults(t *testing.T) {
+	o := NewOpenAI("", "", "", "", nil)
+	assert.Equal(t, DefaultOpenAIBaseURL, o.BaseURL)
+	assert.Equal(t, DefaultOpenAIModel, o.Model)
+	assert.NotNil(t, o.Client)
+}
+
+func TestWhisperCPP_Transcribe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as fake executables")
+	}
+	dir := t.TempDir()
+
+	// Fake whisper.cpp requires a WAV input and prints a marker and two segments
+	whisper := filepath.Join(dir, "whisper-cli")
+	require.NoError(t, os.WriteFile(whisper, []byte(`#!/bin/sh
+while [ $# -gt 0 ]; do
+  if [ "$1" = "-f" ]; then case "$2" in *.wav) ;; *) echo "not wav" >&2; exit 1;; esac; fi
+  shift
+done
+echo "[BLANK_AUDIO]"
+echo " hello"
+echo "world "
+`), 0700))
+	// Fake ffmpeg creates the output file (last argument)
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	require.NoError(t, os.WriteFile(ffmpeg, []byte(`#!/bin/sh
+for last; do :; done
+touch "$last"
+`), 0700))
+
+	w := NewWhisperCPP(whisper, "model.bin", "en")
+	w.FFmpegPath = ffmpeg
+	audio := writeAudio(t, "voice.ogg")
+
+	text, err := w.Transcribe(context.Background(), audio)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", text)
+
+	// The intermediate WAV file is removed
+	_, err = os.Stat(filepath.Join(filepath.Dir(audio), "voice.16k.wav"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWhisperCPP_TranscribeError(t *testing.T) {
+	w := NewWhisperCPP(filepath.Join(t.TempDir(), "missing-whisper"), "model.bin", "")
+	_, err := w.Transcribe(context.Background(), writeAudio(t, "voice.wav"))
+	assert.Error(t, err)
+}
+
+func TestCleanTranscript(t *testing.T) {
+	assert.Equal(t, "", cleanTranscript("\n[BLANK_AUDIO]\n"))
+	assert.Equal(t, "a b", cleanTranscript(" a \n\n b\n"))
+}
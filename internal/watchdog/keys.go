@@ -0,0 +1,250 @@
+// Package watchdog provides utilities for tmux session monitoring and output parsing.
+//
+// This file parses the key sequence DSL of the "keys" command and sends the
+// resulting keys to tmux in batches.
+package watchdog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxKeySteps limits how many keys one sequence may send (after repetition)
+const MaxKeySteps = 100
+
+// tmuxKeyNames maps lowercase key names and aliases to tmux key names
+var tmuxKeyNames = map[string]string{
+	"up": "Up", "down": "Down", "left": "Left", "right": "Right",
+	"enter": "Enter", "return": "Enter",
+	"escape": "Escape", "esc": "Escape",
+	"tab": "Tab", "btab": "BTab", "stab": "BTab",
+	"space": "Space", "bspace": "BSpace", "backspace": "BSpace",
+	"home": "Home", "end": "End",
+	"ic": "IC", "insert": "IC", "dc": "DC", "delete": "DC", "del": "DC",
+	"ppage": "PPage", "pageup": "PPage", "pgup": "PPage",
+	"npage": "NPage", "pagedown": "NPage", "pgdn": "NPage",
+	"f1": "F1", "f2": "F2", "f3": "F3", "f4": "F4", "f5": "F5", "f6": "F6",
+	"f7": "F7", "f8": "F8", "f9": "F9", "f10": "F10", "f11": "F11", "f12": "F12",
+}
+
+// KeyStep is one step of a key sequence: a tmux key name or literal text
+type KeyStep struct {
+	Key  string // tmux key name, e.g. "Down" or "C-r"
+	Text string // Literal text, typed as is (used when Key is empty)
+}
+
+// ParseKeys parses a key sequence such as `Down Down Enter` or
+// `C-r "search" Enter`.
+//
+// Tokens are separated by whitespace:
+//   - Key names: Up, Down, Left, Right, Enter, Escape (Esc), Tab, BTab (STab),
+//     Space, BSpace, Home, End, PageUp, PageDown, Insert, Delete, F1-F12
+//     (case-insensitive), or a single character
+//   - Modifiers: C- (Ctrl), M- (Alt) and S- (Shift) before a key, e.g. C-r, M-Enter
+//   - Quoted text: "..." or '...' is typed literally (\" and \\ escape)
+//   - Repetition: Down*3 sends Down three times
+//
+// Examples:
+//
+//	ParseKeys("Down Down Enter")      → [Down Down Enter]
+//	ParseKeys(`C-r "search" Enter`)   → [C-r "search" Enter]
+//	ParseKeys("down*2 esc")           → [Down Down Escape]
+//	ParseKeys("Dwn")                  → error: unknown key "Dwn"
+func ParseKeys(input string) ([]KeyStep, error) {
+	var steps []KeyStep
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if r == ' ' || r == '\t' || r == '\n' {
+			i++
+			continue
+		}
+
+		var step KeyStep
+		if r == '"' || r == '\'' {
+			text, next, err := parseQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			step, i = KeyStep{Text: text}, next
+		} else {
+			start := i
+			for i < len(runes) && runes[i] != ' ' && runes[i] != '\t' && runes[i] != '\n' {
+				i++
+			}
+			token := string(runes[start:i])
+			name, count, err := parseKeyToken(token)
+			if err != nil {
+				return nil, err
+			}
+			if len(steps)+count > MaxKeySteps {
+				return nil, fmt.Errorf("too many keys (max %d)", MaxKeySteps)
+			}
+			for n := 0; n < count; n++ {
+				steps = append(steps, KeyStep{Key: name})
+			}
+			continue
+		}
+
+		if len(steps) >= MaxKeySteps {
+			return nil, fmt.Errorf("too many keys (max %d)", MaxKeySteps)
+		}
+		steps = append(steps, step)
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no keys given")
+	}
+	return steps, nil
+}
+
+// parseQuoted reads the quoted text starting at runes[i] and returns it with
+// the index after the closing quote
+func parseQuoted(runes []rune, i int) (string, int, error) {
+	quote := runes[i]
+	var b strings.Builder
+	for j := i + 1; j < len(runes); j++ {
+		switch {
+		case runes[j] == '\\' && j+1 < len(runes) && (runes[j+1] == quote || runes[j+1] == '\\'):
+			j++
+			b.WriteRune(runes[j])
+		case runes[j] == quote:
+			if b.Len() == 0 {
+				return "", 0, fmt.Errorf("empty text at position %d", i+1)
+			}
+			return b.String(), j + 1, nil
+		default:
+			b.WriteRune(runes[j])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated %c quote", quote)
+}
+
+// parseKeyToken resolves a key token with optional modifiers and repetition
+// to its tmux key name and repeat count
+func parseKeyToken(token string) (string, int, error) {
+	count := 1
+	if at := strings.LastIndex(token, "*"); at > 0 && at < len(token)-1 {
+		n, err := strconv.Atoi(token[at+1:])
+		if err != nil || n < 1 || n > MaxKeySteps {
+			return "", 0, fmt.Errorf("invalid repeat count in %q", token)
+		}
+		token, count = token[:at], n
+	}
+
+	// Modifier prefixes: C-, M-, S- in any order
+	var mods strings.Builder
+	key := token
+	for len(key) > 2 && key[1] == '-' {
+		switch key[0] {
+		case 'C', 'c':
+			mods.WriteString("C-")
+		case 'M', 'm':
+			mods.WriteString("M-")
+		case 'S', 's':
+			mods.WriteString("S-")
+		default:
+			return "", 0, fmt.Errorf("unknown key %q", token)
+		}
+		key = key[2:]
+	}
+
+	if name, ok := tmuxKeyNames[strings.ToLower(key)]; ok {
+		// tmux has no S-Tab key name; Shift+Tab is BTab
+		if name == "Tab" && mods.String() == "S-" {
+			return "BTab", count, nil
+		}
+		return mods.String() + name, count, nil
+	}
+	if utf8.RuneCountInString(key) == 1 {
+		return mods.String() + key, count, nil
+	}
+	return "", 0, fmt.Errorf("unknown key %q", token)
+}
+
+// RawInputSteps returns the steps that type input without pressing Enter: a
+// key word (see ProcessKeyWords) becomes its key, anything else is literal text
+func RawInputSteps(input string) []KeyStep {
+	key := ProcessKeyWords(input)
+	if key == input || isLiteralKeySequence(key) {
+		return []KeyStep{{Text: key}}
+	}
+	return []KeyStep{{Key: key}}
+}
+
+// FormatKeys formats steps back into the key sequence DSL
+func FormatKeys(steps []KeyStep) string {
+	parts := make([]string, 0, len(steps))
+	for _, step := range steps {
+		if step.Key != "" {
+			parts = append(parts, step.Key)
+		} else {
+			parts = append(parts, strconv.Quote(step.Text))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// SendKeySequence sends steps to a session without a trailing Enter.
+// Consecutive key names go out in one send-keys call, and each text step in
+// one literal (-l) call.
+func SendKeySequence(sessionName string, steps []KeyStep) error {
+	tmuxSemaphore <- struct{}{}
+	defer func() { <-tmuxSemaphore }()
+
+	for _, batch := range batchKeySteps(steps) {
+		args := append([]string{"send-keys", "-t", sessionName}, batch...)
+		if output, err := TmuxCommand(sessionName, args...).CombinedOutput(); err != nil {
+			logger.WithFields(logrus.Fields{
+				"session": sessionName,
+				"error":   err,
+				"output":  string(output),
+			}).Error("failed-to-send-key-sequence-to-tmux-session")
+			return fmt.Errorf("failed to send keys to session %s: %w (output: %s)", sessionName, err, string(output))
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"session": sessionName,
+		"steps":   len(steps),
+	}).Debug("key-sequence-sent-to-tmux-session")
+	return nil
+}
+
+// batchKeySteps groups steps into send-keys arguments: runs of key names, and
+// "-l --" with the text of each text step ("--" keeps text like "-v" from
+// being read as a flag)
+func batchKeySteps(steps []KeyStep) [][]string {
+	var batches [][]string
+	var keys []string
+	for _, step := range steps {
+		if step.Key != "" {
+			keys = append(keys, escapeTmuxSemicolon(step.Key))
+			continue
+		}
+		if len(keys) > 0 {
+			batches = append(batches, keys)
+			keys = nil
+		}
+		batches = append(batches, []string{"-l", "--", escapeTmuxSemicolon(step.Text)})
+	}
+	if len(keys) > 0 {
+		batches = append(batches, keys)
+	}
+	return batches
+}
+
+// escapeTmuxSemicolon escapes a trailing ";", which tmux would otherwise take
+// as a command separator: "ls;" would lose the semicolon and a lone ";" would
+// run the next argument as a tmux command
+func escapeTmuxSemicolon(arg string) string {
+	if strings.HasSuffix(arg, ";") {
+		return arg[:len(arg)-1] + `\;`
+	}
+	return arg
+}
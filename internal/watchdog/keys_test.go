@@ -0,0 +1,83 @@
+package watchdog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []KeyStep
+	}{
+		{"Down Down Enter", []KeyStep{{Key: "Down"}, {Key: "Down"}, {Key: "Enter"}}},
+		{"down esc pgup", []KeyStep{{Key: "Down"}, {Key: "Escape"}, {Key: "PPage"}}},
+		{`C-r "search term" Enter`, []KeyStep{{Key: "C-r"}, {Text: "search term"}, {Key: "Enter"}}},
+		{"c-m-x M-Enter S-Tab stab", []KeyStep{{Key: "C-M-x"}, {Key: "M-Enter"}, {Key: "BTab"}, {Key: "BTab"}}},
+		{"Up*3 y", []KeyStep{{Key: "Up"}, {Key: "Up"}, {Key: "Up"}, {Key: "y"}}},
+		{`'it''s' "say \"hi\""`, []KeyStep{{Text: "it"}, {Text: "s"}, {Text: `say "hi"`}}},
+		{"F5 * M--", []KeyStep{{Key: "F5"}, {Key: "*"}, {Key: "M--"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			steps, err := ParseKeys(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, steps)
+		})
+	}
+}
+
+func TestParseKeys_Errors(t *testing.T) {
+	tests := map[string]string{
+		"Dwn Enter":                        `unknown key "Dwn"`,
+		"X-a":                              `unknown key "X-a"`,
+		`"open`:                            "unterminated \" quote",
+		`""`:                               "empty text",
+		"Down*0":                           "invalid repeat count",
+		"   ":                              "no keys given",
+		strings.Repeat("a ", 60) + "Up*50": "too many keys",
+	}
+	for input, expected := range tests {
+		_, err := ParseKeys(input)
+		require.Error(t, err, input)
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+func TestFormatKeys(t *testing.T) {
+	steps, err := ParseKeys(`C-r "a b" enter`)
+	require.NoError(t, err)
+	assert.Equal(t, `C-r "a b" Enter`, FormatKeys(steps))
+}
+
+func TestRawInputSteps(t *testing.T) {
+	assert.Equal(t, []KeyStep{{Text: "ls -la"}}, RawInputSteps("ls -la"))
+	assert.Equal(t, []KeyStep{{Key: "C-m"}}, RawInputSteps("enter"))
+	assert.Equal(t, []KeyStep{{Key: "C-c"}}, RawInputSteps("Ctrl-C"))
+	assert.Equal(t, []KeyStep{{Text: "\x1b[Z"}}, RawInputSteps("stab"))
+}
+
+func TestBatchKeySteps(t *testing.T) {
+	batches := batchKeySteps([]KeyStep{{Key: "C-r"}, {Key: "C-a"}, {Text: "-v"}, {Key: "Enter"}})
+	assert.Equal(t, [][]string{{"C-r", "C-a"}, {"-l", "--", "-v"}, {"Enter"}}, batches)
+}
+
+func TestBatchKeySteps_Semicolon(t *testing.T) {
+	// A lone semicolon key, e.g. "keys ; x"
+	steps, err := ParseKeys("; x")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{`\;`, "x"}}, batchKeySteps(steps))
+
+	// Text ending in a semicolon, e.g. `keys "ls;"`
+	steps, err = ParseKeys(`"ls;" Enter`)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"-l", "--", `ls\;`}, {"Enter"}}, batchKeySteps(steps))
+
+	// Raw mode input
+	assert.Equal(t, [][]string{{"-l", "--", `cd /tmp\;`}}, batchKeySteps(RawInputSteps("cd /tmp;")))
+	// Semicolons elsewhere are not separators
+	assert.Equal(t, [][]string{{"-l", "--", "a;b"}}, batchKeySteps([]KeyStep{{Text: "a;b"}}))
+}
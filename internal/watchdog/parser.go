@@ -0,0 +1,207 @@
+// Package watchdog provides utilities for tmux session monitoring and output parsing.
+//
+// This file extracts a CLI's response from pane captures taken before and after
+// a prompt was sent, for sessions in polling mode (no hooks installed).
+package watchdog
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// uiStatusPhrases mark status and hint lines drawn by TUI CLIs around their output
+var uiStatusPhrases = []string{
+	"esc to interrupt",
+	"esc to cancel",
+	"? for shortcuts",
+	"ctrl+c to exit",
+	"ctrl+c again to exit",
+	"shift+tab to cycle",
+	"accept edits on",
+	"bypass permissions on",
+	"context left until auto-compact",
+}
+
+// thinkingPhrases mark a CLI that is still working on a response
+var thinkingPhrases = []string{
+	"esc to interrupt",
+	"esc to cancel",
+	"thinking…",
+	"thinking...",
+	"working…",
+	"working...",
+	"generating…",
+	"generating...",
+}
+
+// spinnerRunes are drawn at the start of a status line ending in an ellipsis
+// ("✻ Pondering…") while a CLI is busy
+const spinnerRunes = "⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏✻✽✶✳✢"
+
+// borderRunes are box-drawing characters framing TUI panels and input boxes
+const borderRunes = "─━═│┃╭╮╰╯┌┐└┘├┤┬┴┼ "
+
+// promptRunes are input cursors shown by an empty input box
+const promptRunes = ">❯›"
+
+// thinkingTailLines is how many trailing non-empty lines IsThinking inspects
+const thinkingTailLines = 10
+
+// minPromptKeyLen is the shortest prompt that is matched against the pane;
+// shorter input (like "y" or "1") appears too often to locate the echo
+const minPromptKeyLen = 3
+
+// maxPromptKeyLen limits the prompt prefix matched against the pane, since
+// long prompts are wrapped by the CLI
+const maxPromptKeyLen = 40
+
+// anchorLines is how many trailing lines of the before capture locate the
+// start of new output in the after capture
+const anchorLines = 3
+
+// RemoveUIStatusLines removes TUI chrome from a pane capture: panel borders,
+// empty input boxes and status hints such as "esc to interrupt". Borders
+// around content lines are trimmed.
+func RemoveUIStatusLines(output string) string {
+	var kept []string
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		content := strings.TrimSpace(strings.Trim(trimmed, "│┃"))
+		switch {
+		case trimmed == "":
+			kept = append(kept, "")
+		case strings.Trim(trimmed, borderRunes) == "":
+			// Panel border
+		case strings.Trim(content, promptRunes+" ") == "":
+			// Empty input box or blank line inside a panel
+		case containsAny(strings.ToLower(content), uiStatusPhrases):
+			// Status hint
+		case content != trimmed:
+			kept = append(kept, content)
+		default:
+			kept = append(kept, strings.TrimRight(line, " "))
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// IsThinking reports whether the bottom of a pane capture shows the CLI still
+// working, e.g. a spinner or an "esc to interrupt" hint
+func IsThinking(output string) bool {
+	lines := strings.Split(output, "\n")
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < thinkingTailLines; i-- {
+		line := strings.TrimSpace(strings.Trim(strings.TrimSpace(lines[i]), "│┃"))
+		if line == "" {
+			continue
+		}
+		checked++
+		if containsAny(strings.ToLower(line), thinkingPhrases) {
+			return true
+		}
+		r, _ := utf8.DecodeRuneInString(line)
+		if strings.ContainsRune(spinnerRunes, r) && (strings.Contains(line, "…") || strings.Contains(line, "...")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractContentAfterPrompt returns the output following the first line that
+// echoes the prompt, or "" when the prompt cannot be located. output should
+// start where the prompt was sent (see ExtractResponse), so that the echo is
+// found rather than an earlier identical prompt or a reply quoting it.
+func ExtractContentAfterPrompt(output, prompt string) string {
+	key := promptKey(prompt)
+	if key == "" {
+		return ""
+	}
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if strings.Contains(line, key) {
+			return strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
+		}
+	}
+	return ""
+}
+
+// ExtractNewOutput returns the lines of after that follow the content of
+// before, locating the last lines of before in after so that scrolling is
+// handled. UI chrome is removed from both captures first. When before cannot
+// be located the whole of after is returned.
+func ExtractNewOutput(before, after string) string {
+	beforeLines := nonEmptyLines(RemoveUIStatusLines(before))
+	afterLines := strings.Split(RemoveUIStatusLines(after), "\n")
+
+	var afterIdx []int // Indexes of the non-empty lines of after
+	for i, line := range afterLines {
+		if strings.TrimSpace(line) != "" {
+			afterIdx = append(afterIdx, i)
+		}
+	}
+
+	for n := min(anchorLines, len(beforeLines)); n > 0; n-- {
+		anchor := beforeLines[len(beforeLines)-n:]
+		for start := len(afterIdx) - n; start >= 0; start-- {
+			if matchesAnchor(afterLines, afterIdx[start:start+n], anchor) {
+				end := afterIdx[start+n-1]
+				return strings.TrimSpace(strings.Join(afterLines[end+1:], "\n"))
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(afterLines, "\n"))
+}
+
+// ExtractResponse extracts a CLI response from pane captures taken before the
+// prompt was sent and after the output settled. The captures are diffed, and
+// the new output starts after the echo of the prompt when it can be found.
+func ExtractResponse(before, after, prompt string) string {
+	output := ExtractNewOutput(before, after)
+	if content := ExtractContentAfterPrompt(output, prompt); content != "" {
+		return content
+	}
+	return output
+}
+
+// promptKey returns the prefix of the prompt's first line used to find its echo
+func promptKey(prompt string) string {
+	line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(prompt), "\n", 2)[0])
+	if utf8.RuneCountInString(line) < minPromptKeyLen {
+		return ""
+	}
+	if runes := []rune(line); len(runes) > maxPromptKeyLen {
+		line = strings.TrimSpace(string(runes[:maxPromptKeyLen]))
+	}
+	return line
+}
+
+// matchesAnchor compares the lines of after at the given indexes with anchor
+func matchesAnchor(afterLines []string, indexes []int, anchor []string) bool {
+	for i, idx := range indexes {
+		if strings.TrimSpace(afterLines[idx]) != anchor[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// nonEmptyLines returns the trimmed non-empty lines of s
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func containsAny(s string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(s, phrase) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package watchdog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveUIStatusLines(t *testing.T) {
+	pane := `● Done. All tests pass.
+
+╭──────────────────────────────────────╮
+│ >                                    │
+╰──────────────────────────────────────╯
+  ? for shortcuts`
+
+	assert.Equal(t, "● Done. All tests pass.\n", RemoveUIStatusLines(pane))
+	assert.Equal(t, "hello world", RemoveUIStatusLines("│ hello world   │"))
+}
+
+func TestIsThinking(t *testing.T) {
+	assert.True(t, IsThinking("> fix it\n\n✻ Pondering… (esc to interrupt)\n\n│ > │"))
+	assert.True(t, IsThinking("⠋ Thinking...\n"))
+	assert.False(t, IsThinking("> fix it\n\n● Fixed.\n\n✻ Worked for 12s\n│ > │"))
+	assert.False(t, IsThinking(""))
+}
+
+func TestExtractContentAfterPrompt(t *testing.T) {
+	output := "> old question\nold answer\n> what is 2+2?\n4\n"
+	assert.Equal(t, "4", ExtractContentAfterPrompt(output, "what is 2+2?"))
+	assert.Empty(t, ExtractContentAfterPrompt(output, "not sent"))
+	// Short input is too ambiguous to locate
+	assert.Empty(t, ExtractContentAfterPrompt("1\nanswer", "1"))
+}
+
+func TestExtractNewOutput(t *testing.T) {
+	before := "$ make\nbuild ok\n$ \n╭────╮\n│ >  │\n╰────╯"
+	after := "build ok\n$ \n$ make test\nPASS\nok\n╭────╮\n│ >  │\n╰────╯"
+	assert.Equal(t, "$ make test\nPASS\nok", ExtractNewOutput(before, after))
+
+	// The before content scrolled out entirely
+	assert.Equal(t, "fresh", ExtractNewOutput("gone", "fresh"))
+}
+
+func TestExtractResponse(t *testing.T) {
+	before := "welcome\n│ > │"
+	after := "welcome\n> summarize README\n● The README explains setup.\n│ > │\n  ? for shortcuts"
+	assert.Equal(t, "● The README explains setup.", ExtractResponse(before, after, "summarize README"))
+	assert.Equal(t, "> y\nok", ExtractResponse("welcome", "welcome\n> y\nok", "y"))
+
+	// Anchored on the prompt that was sent, not on earlier prompts or a reply quoting it
+	before = "> explain the parser\nold reply\n│ > │"
+	after = "> explain the parser\nold reply\n> explain the parser\n● You asked: explain the parser\nIt splits lines.\n│ > │"
+	assert.Equal(t, "● You asked: explain the parser\nIt splits lines.", ExtractResponse(before, after, "explain the parser"))
+}
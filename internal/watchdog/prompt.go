@@ -0,0 +1,46 @@
+// Package watchdog provides utilities for tmux session monitoring and output parsing.
+//
+// This file extracts the numbered options of interactive CLI prompts, such as
+// Claude Code's permission confirmation, from a pane capture.
+package watchdog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PromptOption is a numbered choice of an interactive prompt
+type PromptOption struct {
+	Key   string // Key that selects the option ("1", "2", ...)
+	Label string // Option text
+}
+
+// promptOptionPattern matches "1. Yes", "❯ 2. No" and "3) Cancel" lines
+var promptOptionPattern = regexp.MustCompile(`^(?:[❯›>●○◯]\s*)?([1-9])[.)]\s+(.+)$`)
+
+// ParsePromptOptions returns the last sequence of numbered options (1, 2, 3, ...)
+// in a pane capture. Box-drawing borders and the selection cursor are ignored.
+func ParsePromptOptions(pane string) []PromptOption {
+	var current, last []PromptOption
+	for _, line := range strings.Split(pane, "\n") {
+		line = strings.TrimSpace(strings.Trim(strings.TrimSpace(line), "│┃|"))
+		match := promptOptionPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(match[1])
+		switch {
+		case n == 1:
+			current = []PromptOption{{Key: match[1], Label: strings.TrimSpace(match[2])}}
+		case current != nil && n == len(current)+1:
+			current = append(current, PromptOption{Key: match[1], Label: strings.TrimSpace(match[2])})
+		default:
+			continue
+		}
+		if len(current) > 1 {
+			last = current
+		}
+	}
+	return last
+}
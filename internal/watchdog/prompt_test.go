@@ -0,0 +1,37 @@
+package watchdog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePromptOptions(t *testing.T) {
+	pane := `╭──────────────────────────────────────────────╮
+│ Bash command                                 │
+│                                              │
+│   rm -rf build                               │
+│                                              │
+│ Do you want to proceed?                      │
+│ ❯ 1. Yes                                     │
+│   2. Yes, and don't ask again for rm commands │
+│   3. No, and tell Claude what to do (esc)    │
+╰──────────────────────────────────────────────╯`
+
+	assert.Equal(t, []PromptOption{
+		{Key: "1", Label: "Yes"},
+		{Key: "2", Label: "Yes, and don't ask again for rm commands"},
+		{Key: "3", Label: "No, and tell Claude what to do (esc)"},
+	}, ParsePromptOptions(pane))
+}
+
+func TestParsePromptOptions_LastListWins(t *testing.T) {
+	pane := "Steps:\n1. build\n2. test\n\nApply changes?\n● 1) Apply\n○ 2) Skip\n"
+	assert.Equal(t, []PromptOption{{Key: "1", Label: "Apply"}, {Key: "2", Label: "Skip"}}, ParsePromptOptions(pane))
+}
+
+func TestParsePromptOptions_None(t *testing.T) {
+	assert.Nil(t, ParsePromptOptions("Waiting for input\n> "))
+	// A single numbered line is not a prompt
+	assert.Nil(t, ParsePromptOptions("1. only one"))
+}
@@ -0,0 +1,181 @@
+// Package watchdog provides utilities for tmux session monitoring and output parsing.
+//
+// This file runs tmux on remote hosts: sessions registered with a host have
+// their tmux commands executed over SSH. Connections are pooled with OpenSSH
+// connection sharing (ControlMaster), so each host keeps one persistent
+// connection that all commands are multiplexed over. Remote port forwards run
+// on connections of their own (see ForwardCommand).
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/keepmind9/clibot/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// sshControlPersist is how long an idle master connection stays open
+	sshControlPersist = "10m"
+	// sshConnectTimeout is the SSH connection timeout in seconds
+	sshConnectTimeout = "10"
+	// sshServerAliveInterval is how often, in seconds, an unresponsive host is probed
+	sshServerAliveInterval = "15"
+)
+
+var (
+	hostsMu      sync.RWMutex
+	sessionHosts = make(map[string]string) // Session name -> SSH target
+
+	controlDirOnce sync.Once
+)
+
+// SetSessionHost registers the SSH target (e.g. "dev@devbox", or an alias from
+// ~/.ssh/config) a session's tmux runs on. An empty host means local tmux.
+func SetSessionHost(sessionName, host string) {
+	hostsMu.Lock()
+	defer hostsMu.Unlock()
+	if host == "" {
+		delete(sessionHosts, sessionName)
+		return
+	}
+	sessionHosts[sessionName] = host
+}
+
+// SessionHost returns the SSH target of a session, or "" for local sessions
+func SessionHost(sessionName string) string {
+	hostsMu.RLock()
+	defer hostsMu.RUnlock()
+	return sessionHosts[sessionName]
+}
+
+// TmuxCommand returns the command running tmux with args for a session:
+// locally, or over SSH on the session's host
+func TmuxCommand(sessionName string, args ...string) *exec.Cmd {
+	host := SessionHost(sessionName)
+	if host == "" {
+		return exec.Command("tmux", args...)
+	}
+	return SSHCommand(host, append([]string{"tmux"}, args...)...)
+}
+
+// SSHCommand returns the command running args on host over its shared SSH
+// connection. Arguments are quoted for the remote shell. Key authentication is
+// required: BatchMode disables password prompts.
+func SSHCommand(host string, args ...string) *exec.Cmd {
+	sshArgs := []string{
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout=" + sshConnectTimeout,
+		"-o", "ServerAliveInterval=" + sshServerAliveInterval,
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=" + sshControlPath(),
+		"-o", "ControlPersist=" + sshControlPersist,
+		"--", host,
+	}
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	sshArgs = append(sshArgs, strings.Join(quoted, " "))
+	return exec.Command("ssh", sshArgs...)
+}
+
+// ForwardCommand returns the command holding the remote port forward spec
+// ("port:target" as for ssh -R) open to host until ctx is done. It runs on a
+// dedicated connection: the shared one closes when idle, and an open forward
+// does not count as activity. ssh exits when the forward cannot be set up or
+// the host stops answering keepalives, so callers restart it.
+func ForwardCommand(ctx context.Context, host, spec string) *exec.Cmd {
+	return exec.CommandContext(ctx, "ssh",
+		"-N",
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout="+sshConnectTimeout,
+		"-o", "ServerAliveInterval="+sshServerAliveInterval,
+		"-o", "ServerAliveCountMax=3",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "ControlMaster=no",
+		"-o", "ControlPath=none",
+		"-R", spec,
+		"--", host,
+	)
+}
+
+// sshControlPath returns the socket path pattern for shared connections,
+// creating its directory on first use
+func sshControlPath() string {
+	home, _ := os.UserHomeDir()
+	dir := filepath.Join(home, ".clibot", "ssh")
+	controlDirOnce.Do(func() {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			logger.WithFields(logrus.Fields{
+				"dir":   dir,
+				"error": err,
+			}).Warn("failed-to-create-ssh-control-dir")
+		}
+	})
+	// %C is a hash of the connection, short enough for the socket path limit
+	return filepath.Join(dir, "%C")
+}
+
+// shellQuote quotes s as a single word for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// RemoteDir resolves dir on host ("~" is the remote home directory) and
+// checks that it exists, returning the absolute path
+func RemoteDir(host, dir string) (string, error) {
+	const script = `case "$1" in "~") d=$HOME ;; "~/"*) d=$HOME/${1#"~/"} ;; *) d=$1 ;; esac; cd "$d" && pwd`
+	output, err := SSHCommand(host, "sh", "-c", script, "sh", dir).Output()
+	if err != nil {
+		return "", fmt.Errorf("work_dir %s does not exist on %s: %w", dir, host, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// readUnderScript prints the file $1 if, with symlinks resolved, it lies
+// within one of the remaining arguments (directories relative to $HOME)
+const readUnderScript = `f=$(readlink -f -- "$1") || exit 1; shift
+for d; do
+	r=$(cd "$HOME/$d" 2>/dev/null && pwd -P) || continue
+	case "$f" in "$r"/*) exec cat -- "$f" ;; esac
+done
+echo "not in an allowed directory" >&2; exit 1`
+
+// ReadRemoteFileUnder reads a file on host after resolving symlinks, failing
+// unless it lies within one of dirs (relative to the remote home directory)
+func ReadRemoteFileUnder(host, path string, dirs []string) ([]byte, error) {
+	args := append([]string{"sh", "-c", readUnderScript, "sh", path}, dirs...)
+	output, err := SSHCommand(host, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s on %s: %w", path, host, err)
+	}
+	return output, nil
+}
+
+// WriteRemoteFile writes data to a file in the home directory of host with
+// owner-only permissions, creating its directory. path is relative to home.
+func WriteRemoteFile(host, path string, data []byte) error {
+	const script = `umask 077 && mkdir -p "$(dirname "$HOME/$1")" && cat > "$HOME/$1"`
+	cmd := SSHCommand(host, "sh", "-c", script, "sh", path)
+	cmd.Stdin = strings.NewReader(string(data))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write %s on %s: %w (output: %s)", path, host, err, string(output))
+	}
+	return nil
+}
+
+// RemoveRemoteFile removes a file in the home directory of host
+func RemoveRemoteFile(host, path string) error {
+	if output, err := SSHCommand(host, "sh", "-c", `rm -f "$HOME/$1"`, "sh", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to remove %s on %s: %w (output: %s)", path, host, err, string(output))
+	}
+	return nil
+}
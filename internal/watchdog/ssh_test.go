@@ -0,0 +1,73 @@
+package watchdog
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTmuxCommand_Host(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	SetSessionHost("remote", "dev@devbox")
+	defer SetSessionHost("remote", "")
+
+	local := TmuxCommand("local", "has-session", "-t", "local")
+	assert.Equal(t, []string{"tmux", "has-session", "-t", "local"}, local.Args)
+
+	remote := TmuxCommand("remote", "send-keys", "-t", "remote", "-l", "--", "it's $HOME")
+	assert.Equal(t, "ssh", remote.Args[0])
+	assert.Contains(t, remote.Args, "BatchMode=yes")
+	assert.Contains(t, remote.Args, "ControlMaster=auto")
+	assert.Equal(t, []string{"--", "dev@devbox", `'tmux' 'send-keys' '-t' 'remote' '-l' '--' 'it'\''s $HOME'`},
+		remote.Args[len(remote.Args)-3:])
+	assert.NotContains(t, remote.Args, "-R")
+}
+
+func TestForwardCommand(t *testing.T) {
+	args := ForwardCommand(context.Background(), "devbox", "127.0.0.1:8080:127.0.0.1:8080").Args
+	assert.Equal(t, "ssh", args[0])
+	assert.Contains(t, args, "-N")
+	assert.Contains(t, args, "ExitOnForwardFailure=yes")
+	assert.Contains(t, args, "ControlPath=none", "the forward must not ride on the shared connection")
+	assert.Equal(t, []string{"-R", "127.0.0.1:8080:127.0.0.1:8080", "--", "devbox"}, args[len(args)-4:])
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `''`, shellQuote(""))
+	assert.Equal(t, `'a b'`, shellQuote("a b"))
+	assert.Equal(t, `'don'\''t'`, shellQuote("don't"))
+}
+
+func TestReadUnderScript(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".claude", "projects")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	transcript := filepath.Join(dir, "abc.jsonl")
+	secret := filepath.Join(home, "secret.jsonl")
+	for path, content := range map[string]string{transcript: "transcript", secret: "secret"} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+	link := filepath.Join(dir, "link.jsonl")
+	require.NoError(t, os.Symlink(secret, link))
+
+	read := func(path string) (string, error) {
+		output, err := exec.Command("sh", "-c", readUnderScript, "sh", path, ".gemini", ".claude").Output()
+		return string(output), err
+	}
+
+	content, err := read(transcript)
+	assert.NoError(t, err)
+	assert.Equal(t, "transcript", content)
+
+	for _, path := range []string{secret, link, filepath.Join(dir, "gone.jsonl"), filepath.Join(home, ".claude")} {
+		content, err := read(path)
+		assert.Error(t, err, path)
+		assert.Empty(t, content, path)
+	}
+}
@@ -10,17 +10,25 @@
 // This package wraps tmux commands for session management:
 //
 //   - CapturePane: Capture output from a tmux session
+//   - CaptureScreen: Capture the visible screen of a session with its colors
 //   - SendKeys: Send keystrokes to a tmux session
+//   - SendKeySequence: Send keys parsed by ParseKeys, batched into few send-keys calls
+//   - PasteText: Paste multi-line text into a tmux session
 //   - IsSessionAlive: Check if a session exists
+//   - PaneWorkDir: Get the working directory of a session's pane
 //   - ListSessions: List all active sessions
 //
+// Sessions registered with SetSessionHost run their tmux commands on that
+// host over SSH (see ssh.go); all functions taking a session name follow it.
+//
 // # Content Parsing
 //
 // The parser provides utilities for extracting relevant content from tmux output:
 //
-//   - ExtractContentAfterPrompt: Filters tmux output to show only the latest AI response
+//   - ExtractContentAfterPrompt: Returns the output following the echo of a prompt
 //   - IsThinking: Detects if the AI is still processing (shows "thinking" indicators)
 //   - RemoveUIStatusLines: Removes UI artifacts like "ESC to interrupt"
+//   - ExtractResponse: Diffs pane captures taken before and after a prompt (poll mode)
 //   - StripANSI: Removes ANSI escape codes for clean text
 //
 // # Example Usage
@@ -60,10 +68,10 @@ func CapturePane(sessionName string, lines int) (string, error) {
 	// Format: -S -N captures N lines from the end
 	var cmd *exec.Cmd
 	if lines > 0 {
-		cmd = exec.Command("tmux", "capture-pane", "-t", sessionName, "-p", "-e", "-S", fmt.Sprintf("-%d", lines))
+		cmd = TmuxCommand(sessionName, "capture-pane", "-t", sessionName, "-p", "-e", "-S", fmt.Sprintf("-%d", lines))
 	} else {
 		// Capture all lines if lines is 0 or negative
-		cmd = exec.Command("tmux", "capture-pane", "-t", sessionName, "-p", "-e", "-S", "-")
+		cmd = TmuxCommand(sessionName, "capture-pane", "-t", sessionName, "-p", "-e", "-S", "-")
 	}
 
 	output, err := cmd.Output()
@@ -74,6 +82,19 @@ func CapturePane(sessionName string, lines int) (string, error) {
 	return string(output), nil
 }
 
+// CaptureScreen captures the visible area of a session's pane, keeping the
+// escape sequences for colors and attributes
+func CaptureScreen(sessionName string) (string, error) {
+	tmuxSemaphore <- struct{}{}
+	defer func() { <-tmuxSemaphore }()
+
+	output, err := TmuxCommand(sessionName, "capture-pane", "-t", sessionName, "-p", "-e").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to capture screen of session %s: %w", sessionName, err)
+	}
+	return string(output), nil
+}
+
 // StripANSI removes ANSI escape codes from a string
 func StripANSI(input string) string {
 	// Comprehensive ANSI escape code regex pattern
@@ -85,7 +106,7 @@ func StripANSI(input string) string {
 
 // IsSessionAlive checks if a tmux session exists and is running
 func IsSessionAlive(sessionName string) bool {
-	cmd := exec.Command("tmux", "has-session", "-t", sessionName)
+	cmd := TmuxCommand(sessionName, "has-session", "-t", sessionName)
 	err := cmd.Run()
 	// tmux has-session returns 0 if session exists, non-zero otherwise
 	return err == nil
@@ -152,7 +173,7 @@ func SendKeys(sessionName, input string, delayMs ...int) error {
 		args1 = []string{"send-keys", "-t", sessionName, "-l", input}
 	}
 
-	cmd1 := exec.Command("tmux", args1...)
+	cmd1 := TmuxCommand(sessionName, args1...)
 	if output, err := cmd1.CombinedOutput(); err != nil {
 		logger.WithFields(logrus.Fields{
 			"session": sessionName,
@@ -171,7 +192,7 @@ func SendKeys(sessionName, input string, delayMs ...int) error {
 		}
 
 		args2 := []string{"send-keys", "-t", sessionName, "C-m"}
-		cmd2 := exec.Command("tmux", args2...)
+		cmd2 := TmuxCommand(sessionName, args2...)
 		if output, err := cmd2.CombinedOutput(); err != nil {
 			logger.WithFields(logrus.Fields{
 				"session": sessionName,
@@ -185,6 +206,52 @@ func SendKeys(sessionName, input string, delayMs ...int) error {
 	return nil
 }
 
+// SendKeyNames sends tmux key names (e.g. "Down", "C-c", "F1") to a session
+// as keys rather than literal text, without a trailing Enter
+func SendKeyNames(sessionName string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := append([]string{"send-keys", "-t", sessionName}, keys...)
+	if output, err := TmuxCommand(sessionName, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to send keys to session %s: %w (output: %s)", sessionName, err, string(output))
+	}
+	return nil
+}
+
+// PasteText pipes text into a tmux buffer on stdin and pastes it into the
+// session with bracketed paste, so multi-line input arrives as one message.
+// Enter is sent after delayMs milliseconds.
+func PasteText(sessionName, text string, delayMs int) error {
+	buffer := "clibot-" + sessionName
+
+	load := TmuxCommand(sessionName, "load-buffer", "-b", buffer, "-")
+	load.Stdin = strings.NewReader(text)
+	if output, err := load.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to load paste buffer for session %s: %w (output: %s)", sessionName, err, string(output))
+	}
+
+	paste := TmuxCommand(sessionName, "paste-buffer", "-d", "-p", "-b", buffer, "-t", sessionName)
+	if output, err := paste.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to paste into session %s: %w (output: %s)", sessionName, err, string(output))
+	}
+
+	if delayMs > 0 {
+		time.Sleep(time.Duration(delayMs) * time.Millisecond)
+	}
+	return SendKeyNames(sessionName, []string{"C-m"})
+}
+
+// PaneWorkDir returns the current working directory of a session's active pane
+func PaneWorkDir(sessionName string) (string, error) {
+	output, err := TmuxCommand(sessionName, "display-message", "-p", "-t", sessionName, "#{pane_current_path}").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get work dir of session %s: %w", sessionName, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // CapturePaneClean captures and strips ANSI codes from tmux output
 func CapturePaneClean(sessionName string, lines int) (string, error) {
 	output, err := CapturePane(sessionName, lines)
@@ -58,6 +58,15 @@ func TestCapturePane_InvalidSession_ReturnsError(t *testing.T) {
 	assert.Empty(t, output)
 }
 
+func TestCaptureScreen_InvalidSession_ReturnsError(t *testing.T) {
+	if testing.Short() {
+		t.Skip("requires actual tmux session")
+	}
+	output, err := CaptureScreen("non-existent-session-12345")
+	assert.Error(t, err)
+	assert.Empty(t, output)
+}
+
 func TestIsSessionAlive_NonExistentSession_ReturnsFalse(t *testing.T) {
 	if testing.Short() {
 		t.Skip("requires actual tmux")
@@ -14,6 +14,10 @@ const (
 	MaxDingTalkMessageLength = 20000
 	// MaxWeixinMessageLength is WeChat iLink's message character limit
 	MaxWeixinMessageLength = 2000
+	// MaxWebhookMessageLength is the default character limit for generic webhook callbacks
+	MaxWebhookMessageLength = 20000
+	// MaxEmailInlineLength is the response length above which email replies carry an attachment
+	MaxEmailInlineLength = 8000
 )
 
 // Timeouts and delays
@@ -38,6 +42,92 @@ const (
 	DingTalkMessageSendTimeout = 10 * time.Second
 )
 
+// Bot reconnect supervision
+const (
+	// BotReconnectInitialBackoff is the first delay before restarting a failed bot adapter
+	BotReconnectInitialBackoff = 1 * time.Second
+	// BotReconnectMaxBackoff caps the exponential reconnect delay
+	BotReconnectMaxBackoff = 5 * time.Minute
+	// BotDownAlertThreshold is how long a bot may stay down before admins are notified
+	BotDownAlertThreshold = 2 * time.Minute
+)
+
+// Hook tunnels to session hosts
+const (
+	// HookTunnelInitialBackoff is the first delay before reopening a closed hook tunnel
+	HookTunnelInitialBackoff = 1 * time.Second
+	// HookTunnelMaxBackoff caps the exponential reopen delay
+	HookTunnelMaxBackoff = 1 * time.Minute
+	// HookTunnelStableAfter is how long a tunnel must stay open to reset the backoff
+	HookTunnelStableAfter = 1 * time.Minute
+)
+
+// Voice transcription
+const (
+	// TranscriptConfirmTimeout is how long a voice transcript waits for the user's confirmation
+	TranscriptConfirmTimeout = 10 * time.Minute
+	// TranscriptionTimeout bounds a single transcription request or whisper.cpp run
+	TranscriptionTimeout = 2 * time.Minute
+)
+
+// Hook server
+const (
+	// HookSecretHeader carries the shared secret on hook requests
+	HookSecretHeader = "X-Clibot-Hook-Secret"
+	// NotificationCaptureLines is how many pane lines are captured for a notification alert
+	NotificationCaptureLines = 60
+	// NotificationAlertLines is how many trailing pane lines are shown in a notification alert
+	NotificationAlertLines = 20
+	// ToolFeedLines is how many recent tool calls the PostToolUse progress feed shows
+	ToolFeedLines = 10
+	// PreToolUseHookTimeout bounds how long "clibot hook" waits for a PreToolUse decision;
+	// it must exceed the longest approval_timeout in use
+	PreToolUseHookTimeout = 30 * time.Minute
+	// UnmatchedHookAlertCooldown is how long unmatched hooks without a tmux_session or
+	// session_id stay quiet after alerting admins, per cli_type and cwd
+	UnmatchedHookAlertCooldown = 1 * time.Hour
+)
+
+// Tmux polling
+const (
+	// PollCaptureLines is how many pane lines polling sessions capture
+	PollCaptureLines = 200
+	// PollStableChecks is how many consecutive identical captures mark a polled response as complete
+	PollStableChecks = 2
+)
+
+// Raw key input (keys, raw mode)
+const (
+	// KeysCaptureDelay is how long after sending keys the pane is captured to show their effect
+	KeysCaptureDelay = 500 * time.Millisecond
+	// KeysCaptureLines is how many trailing pane lines are shown after sending keys
+	KeysCaptureLines = 25
+)
+
+// Transcript watching
+const (
+	// WatchSettleDelay is how long a transcript must stay unchanged before a
+	// completed turn is delivered, since CLIs write a turn in several records
+	WatchSettleDelay = 1 * time.Second
+	// WatchRescanInterval is how often transcripts are re-read without file
+	// events, covering directories created after the watch started
+	WatchRescanInterval = 5 * time.Second
+)
+
+// Conversation history (shistory, ssearch)
+const (
+	// HistoryDefaultTurns is how many exchanges shistory shows without a count
+	HistoryDefaultTurns = 5
+	// HistoryMaxTurns caps the exchanges shistory shows in chat (exports are not capped)
+	HistoryMaxTurns = 50
+	// HistorySearchMaxResults caps the turns ssearch returns
+	HistorySearchMaxResults = 10
+	// HistoryTurnPreviewLength is where prompts and replies are cut in chat
+	HistoryTurnPreviewLength = 1500
+	// HistoryDefaultPageLength is the page size on platforms without a known message limit
+	HistoryDefaultPageLength = 4000
+)
+
 // Message buffer sizes
 const (
 	// MessageChannelBufferSize is the buffer size for the message channel